@@ -90,7 +90,7 @@ func TestClientApp_RequestNil(t *testing.T) {
 			defer response.Body.Close()
 		}
 
-		modelURL, _ := memoryStorage.FindByURL("https://ya.ru/map1")
+		modelURL, _ := memoryStorage.FindByURL(context.Background(), "https://ya.ru/map1")
 		if modelURL == nil {
 			t.Error("Expected modelURL")
 		}
@@ -17,8 +17,12 @@ import (
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/handlers"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	"github.com/northmule/shorturl/internal/app/services/certificate"
 	"github.com/northmule/shorturl/internal/app/services/certificate/signers"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/policy"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	appStorage "github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/workers"
@@ -59,9 +63,33 @@ func run(ctx context.Context) error {
 		return err
 	}
 	sessionStorage := appStorage.NewSessionStorage()
+	tokenManager, err := auntificator.NewTokenManagerFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	rateLimiter, err := ratelimit.NewLimiterFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	codeGenerator, err := url.NewCodeGeneratorFromConfig(cfg)
+	if err != nil {
+		return err
+	}
 	shortURLService := url.NewShortURLService(storage, storage)
+	shortURLService.SetNormalizer(url.NewNormalizerFromConfig(cfg))
+	shortURLService.SetCodeGenerator(codeGenerator)
 	stop := make(chan struct{})
+	if cfg.DestinationPolicyFile != "" {
+		destinationPolicy, err := policy.NewFilePolicy(cfg.DestinationPolicyFile, policy.ReloadInterval, stop)
+		if err != nil {
+			return err
+		}
+		shortURLService.SetPolicy(destinationPolicy)
+	}
 	worker := workers.NewWorker(storage, stop)
+	workers.NewExpiredSweeper(storage, workers.ExpiredSweepInterval, stop)
+	purgeWorker := workers.NewPurgeWorker(storage, workers.NewPurgeOptionsFromConfig(cfg), stop)
+	clickPipeline := workers.NewClickPipeline(storage, workers.DefaultClickPipelineOptions(), stop)
 
 	// Собираем роутер
 	handlerBuilder := handlers.GetBuilder()
@@ -70,7 +98,12 @@ func run(ctx context.Context) error {
 	handlerBuilder.SetSessionStorage(sessionStorage)
 	handlerBuilder.SetWorker(worker)
 	handlerBuilder.SetFinderStats(storage)
+	handlerBuilder.SetPurgeCounter(purgeWorker)
 	handlerBuilder.SetConfigApp(cfg)
+	handlerBuilder.SetClickRecorder(clickPipeline)
+	handlerBuilder.SetTokenManager(tokenManager)
+	handlerBuilder.SetRateLimiter(rateLimiter)
+	handlerBuilder.SetImporter(importer.NewImporter(storage, shortURLService, cfg.ImportChunkSize))
 	routes := handlerBuilder.GetAppRoutes().Init()
 
 	if cfg.PprofEnabled {
@@ -80,22 +113,25 @@ func run(ctx context.Context) error {
 	httpServer := http.Server{
 		Addr:    cfg.ServerURL,
 		Handler: routes,
+		// Запросы, начатые до сигнала, дорабатывают при остановке сервера
 		BaseContext: func(net.Listener) context.Context {
-			return ctx
+			return context.WithoutCancel(ctx)
 		},
 	}
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		// Отправка сигнала о завершении в канал воркерам
-		stop <- struct{}{}
 		logger.LogSugar.Info("Получин сигнал. Останавливаю сервер...")
 
-		shutdownCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		// ctx уже отменён, с ним Shutdown не стал бы ждать завершения обработчиков
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
-		err = httpServer.Shutdown(shutdownCtx)
-		if err != nil {
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			logger.LogSugar.Error(err)
 		}
+		// Воркеры останавливаются после обработчиков, чтобы переходы из последних запросов попали в статистику
+		close(stop)
 	}()
 
 	if cfg.EnableHTTPS {
@@ -119,7 +155,18 @@ func run(ctx context.Context) error {
 
 	if err != nil {
 		if errors.Is(err, http.ErrServerClosed) {
+			// ListenAndServe возвращается в начале Shutdown, дожидаемся его окончания
+			<-shutdownDone
 			logger.LogSugar.Info("Сервер остановлен")
+			// Дожидаемся записи накопленной статистики переходов
+			clickPipeline.Wait()
+			logger.LogSugar.Infof("Статистика переходов записана, отброшено событий: %d", clickPipeline.Dropped())
+			// Последний снимок хранилища в памяти, чтобы не потерять изменения после периодического
+			if snapshotter, ok := storage.(appStorage.Snapshotter); ok {
+				if err = snapshotter.Snapshot(); err != nil {
+					logger.LogSugar.Errorf("Не удалось сохранить снимок хранилища: %s", err)
+				}
+			}
 			return nil
 		}
 		return err
@@ -11,6 +11,10 @@ import (
 
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/policy"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	appStorage "github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/workers"
@@ -53,9 +57,33 @@ func run(ctx context.Context) error {
 		return err
 	}
 	sessionStorage := appStorage.NewSessionStorage()
+	tokenManager, err := auntificator.NewTokenManagerFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	rateLimiter, err := ratelimit.NewLimiterFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	codeGenerator, err := url.NewCodeGeneratorFromConfig(cfg)
+	if err != nil {
+		return err
+	}
 	shortURLService := url.NewShortURLService(storage, storage)
+	shortURLService.SetNormalizer(url.NewNormalizerFromConfig(cfg))
+	shortURLService.SetCodeGenerator(codeGenerator)
 	stop := make(chan struct{})
+	if cfg.DestinationPolicyFile != "" {
+		destinationPolicy, err := policy.NewFilePolicy(cfg.DestinationPolicyFile, policy.ReloadInterval, stop)
+		if err != nil {
+			return err
+		}
+		shortURLService.SetPolicy(destinationPolicy)
+	}
 	worker := workers.NewWorker(storage, stop)
+	workers.NewExpiredSweeper(storage, workers.ExpiredSweepInterval, stop)
+	purgeWorker := workers.NewPurgeWorker(storage, workers.NewPurgeOptionsFromConfig(cfg), stop)
+	clickPipeline := workers.NewClickPipeline(storage, workers.DefaultClickPipelineOptions(), stop)
 
 	lc := net.ListenConfig{}
 	listen, err := lc.Listen(ctx, "tcp", cfg.ServerURL)
@@ -64,35 +92,54 @@ func run(ctx context.Context) error {
 	}
 
 	logger.LogSugar.Info("создаём gRPC-сервер")
-	authInterceptor := interceptors.NewCheckAuth(storage, sessionStorage)
+	authInterceptor := interceptors.NewCheckAuth(storage, sessionStorage, tokenManager, auntificator.NewAPIKeys(storage))
 	trustedInterceptor := interceptors.NewCheckTrustedSubnet(cfg)
+	// Адрес клиента из метаданных принимается только от прокси из доверенной сети
+	trustedProxies := auntificator.NewTrustedSubnet(cfg)
 	loggerInterceptor := interceptors.NewLogger(logger.LogSugar)
+	rateLimitInterceptor := interceptors.NewRateLimit(rateLimiter, trustedProxies)
 
 	s := grpc.NewServer(grpc.ChainUnaryInterceptor([]grpc.UnaryServerInterceptor{
 		loggerInterceptor.LogStart,
 		authInterceptor.AuthEveryone,
 		authInterceptor.AccessVerificationUserUrls,
 		trustedInterceptor.GrantAccess,
+		rateLimitInterceptor.Limit,
 		loggerInterceptor.LogEnd,
-	}...))
+	}...), grpc.ChainStreamInterceptor(
+		authInterceptor.AuthEveryoneStream,
+		authInterceptor.AccessVerificationUserUrlsStream,
+		rateLimitInterceptor.LimitStream,
+	))
 
 	logger.LogSugar.Info("Подготовка сервисов")
 	contract.RegisterPingHandlerServer(s, grpcHandlers.NewPingHandler(storage))
-	contract.RegisterRedirectHandlerServer(s, grpcHandlers.NewRedirectHandler(shortURLService))
-	contract.RegisterShortenerHandlerServer(s, grpcHandlers.NewShortenerHandler(shortURLService, storage, storage))
-	contract.RegisterStatsHandlerServer(s, grpcHandlers.NewStatsHandler(storage))
-	contract.RegisterUserUrlsHandlerServer(s, grpcHandlers.NewUserURLsHandler(storage, sessionStorage, worker))
+	contract.RegisterRedirectHandlerServer(s, grpcHandlers.NewRedirectHandler(shortURLService, clickPipeline, trustedProxies))
+	contract.RegisterShortenerHandlerServer(s, grpcHandlers.NewShortenerHandler(shortURLService))
+	contract.RegisterStatsHandlerServer(s, grpcHandlers.NewStatsHandler(storage, purgeWorker))
+	contract.RegisterUserUrlsHandlerServer(s, grpcHandlers.NewUserURLsHandler(storage, sessionStorage, worker, storage, shortURLService, storage))
+	contract.RegisterAnalyticsHandlerServer(s, grpcHandlers.NewAnalyticsHandler(storage, storage))
+	contract.RegisterAccountHandlerServer(s, grpcHandlers.NewAccountHandler(auntificator.NewAccount(storage, tokenManager)))
+	contract.RegisterImportHandlerServer(s, grpcHandlers.NewImportHandler(importer.NewImporter(storage, shortURLService, cfg.ImportChunkSize)))
 
 	logger.LogSugar.Infof("Running server on - %s", cfg.ServerURL)
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		stop <- struct{}{}
 		logger.LogSugar.Info("Получин сигнал. Останавливаю сервер...")
 		s.GracefulStop()
+		// Воркеры останавливаются после обработчиков, чтобы переходы из последних запросов попали в статистику
+		close(stop)
 	}()
 	if err = s.Serve(listen); err != nil {
 		return err
 	}
+	// Serve возвращается, как только закрыт приём соединений, дожидаемся завершения обработчиков
+	<-shutdownDone
+	// Дожидаемся записи накопленной статистики переходов
+	clickPipeline.Wait()
+	logger.LogSugar.Infof("Статистика переходов записана, отброшено событий: %d", clickPipeline.Dropped())
 
 	return nil
 }
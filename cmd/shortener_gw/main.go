@@ -16,14 +16,19 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	"github.com/northmule/shorturl/internal/app/services/certificate"
 	"github.com/northmule/shorturl/internal/app/services/certificate/signers"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/policy"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	appStorage "github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/workers"
 	"github.com/northmule/shorturl/internal/grpc/contract"
 	grpcHandlers "github.com/northmule/shorturl/internal/grpc/handlers"
 	"github.com/northmule/shorturl/internal/grpc/handlers/interceptors"
+	"github.com/northmule/shorturl/internal/grpc/handlers/metadata"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -67,31 +72,72 @@ func run(ctx context.Context) error {
 		return err
 	}
 	sessionStorage := appStorage.NewSessionStorage()
+	tokenManager, err := auntificator.NewTokenManagerFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	rateLimiter, err := ratelimit.NewLimiterFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	codeGenerator, err := url.NewCodeGeneratorFromConfig(cfg)
+	if err != nil {
+		return err
+	}
 	shortURLService := url.NewShortURLService(storage, storage)
+	shortURLService.SetNormalizer(url.NewNormalizerFromConfig(cfg))
+	shortURLService.SetCodeGenerator(codeGenerator)
 	stop := make(chan struct{})
+	if cfg.DestinationPolicyFile != "" {
+		destinationPolicy, err := policy.NewFilePolicy(cfg.DestinationPolicyFile, policy.ReloadInterval, stop)
+		if err != nil {
+			return err
+		}
+		shortURLService.SetPolicy(destinationPolicy)
+	}
 	worker := workers.NewWorker(storage, stop)
+	workers.NewExpiredSweeper(storage, workers.ExpiredSweepInterval, stop)
+	purgeWorker := workers.NewPurgeWorker(storage, workers.NewPurgeOptionsFromConfig(cfg), stop)
+	clickPipeline := workers.NewClickPipeline(storage, workers.DefaultClickPipelineOptions(), stop)
 
 	logger.LogSugar.Info("создаём gRPC-сервер")
-	authInterceptor := interceptors.NewCheckAuth(storage, sessionStorage)
+	authInterceptor := interceptors.NewCheckAuth(storage, sessionStorage, tokenManager, auntificator.NewAPIKeys(storage))
 	trustedInterceptor := interceptors.NewCheckTrustedSubnet(cfg)
+	// Адрес клиента из метаданных принимается только от прокси из доверенной сети
+	trustedProxies := auntificator.NewTrustedSubnet(cfg)
 	loggerInterceptor := interceptors.NewLogger(logger.LogSugar)
+	rateLimitInterceptor := interceptors.NewRateLimit(rateLimiter, trustedProxies)
 
-	mux := runtime.NewServeMux()
+	// Заголовок X-API-Key передаётся в gRPC как метаданные x-api-key
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+		if http.CanonicalHeaderKey(key) == auntificator.HeaderAPIKey {
+			return metadata.APIKey, true
+		}
+		return runtime.DefaultHeaderMatcher(key)
+	}))
 
 	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor([]grpc.UnaryServerInterceptor{
 		loggerInterceptor.LogStart,
 		authInterceptor.AuthEveryone,
 		authInterceptor.AccessVerificationUserUrls,
 		trustedInterceptor.GrantAccess,
+		rateLimitInterceptor.Limit,
 		loggerInterceptor.LogEnd,
-	}...))
+	}...), grpc.ChainStreamInterceptor(
+		authInterceptor.AuthEveryoneStream,
+		authInterceptor.AccessVerificationUserUrlsStream,
+		rateLimitInterceptor.LimitStream,
+	))
 
 	logger.LogSugar.Info("Подготовка сервисов")
 	contract.RegisterPingHandlerServer(grpcServer, grpcHandlers.NewPingHandler(storage))
-	contract.RegisterRedirectHandlerServer(grpcServer, grpcHandlers.NewRedirectHandler(shortURLService))
-	contract.RegisterShortenerHandlerServer(grpcServer, grpcHandlers.NewShortenerHandler(shortURLService, storage, storage))
-	contract.RegisterStatsHandlerServer(grpcServer, grpcHandlers.NewStatsHandler(storage))
-	contract.RegisterUserUrlsHandlerServer(grpcServer, grpcHandlers.NewUserURLsHandler(storage, sessionStorage, worker))
+	contract.RegisterRedirectHandlerServer(grpcServer, grpcHandlers.NewRedirectHandler(shortURLService, clickPipeline, trustedProxies))
+	contract.RegisterShortenerHandlerServer(grpcServer, grpcHandlers.NewShortenerHandler(shortURLService))
+	contract.RegisterStatsHandlerServer(grpcServer, grpcHandlers.NewStatsHandler(storage, purgeWorker))
+	contract.RegisterUserUrlsHandlerServer(grpcServer, grpcHandlers.NewUserURLsHandler(storage, sessionStorage, worker, storage, shortURLService, storage))
+	contract.RegisterAnalyticsHandlerServer(grpcServer, grpcHandlers.NewAnalyticsHandler(storage, storage))
+	contract.RegisterAccountHandlerServer(grpcServer, grpcHandlers.NewAccountHandler(auntificator.NewAccount(storage, tokenManager)))
+	contract.RegisterImportHandlerServer(grpcServer, grpcHandlers.NewImportHandler(importer.NewImporter(storage, shortURLService, cfg.ImportChunkSize)))
 
 	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 	err = errors.Join(contract.RegisterPingHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
@@ -99,6 +145,9 @@ func run(ctx context.Context) error {
 	err = errors.Join(err, contract.RegisterShortenerHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
 	err = errors.Join(err, contract.RegisterStatsHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
 	err = errors.Join(err, contract.RegisterUserUrlsHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
+	err = errors.Join(err, contract.RegisterAnalyticsHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
+	err = errors.Join(err, contract.RegisterAccountHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
+	err = errors.Join(err, contract.RegisterImportHandlerHandlerFromEndpoint(ctx, mux, gRPCGatewayServerAddress, opts))
 
 	if err != nil {
 		return err
@@ -120,27 +169,29 @@ func run(ctx context.Context) error {
 	httpServer := http.Server{
 		Addr:    cfg.ServerURL,
 		Handler: mux,
+		// Запросы, начатые до сигнала, дорабатывают при остановке сервера
 		BaseContext: func(net.Listener) context.Context {
-			return ctx
+			return context.WithoutCancel(ctx)
 		},
 	}
 
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		// Отправка сигнала о завершении в канал воркерам
-		stop <- struct{}{}
 		logger.LogSugar.Info("Получин сигнал. Останавливаю HTTP сервер...")
 
-		shutdownCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		// ctx уже отменён, с ним Shutdown не стал бы ждать завершения обработчиков
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.LogSugar.Error(err)
+		}
 
 		logger.LogSugar.Info("Получин сигнал. Останавливаю gRPC сервер...")
 		grpcServer.GracefulStop()
-
-		err = httpServer.Shutdown(shutdownCtx)
-		if err != nil {
-			logger.LogSugar.Error(err)
-		}
+		// Воркеры останавливаются после обработчиков, чтобы переходы из последних запросов попали в статистику
+		close(stop)
 	}()
 
 	if cfg.EnableHTTPS {
@@ -164,7 +215,12 @@ func run(ctx context.Context) error {
 
 	if err != nil {
 		if errors.Is(err, http.ErrServerClosed) {
+			// ListenAndServe возвращается в начале Shutdown, дожидаемся его окончания
+			<-shutdownDone
 			logger.LogSugar.Info("Сервер HTTP остановлен")
+			// Дожидаемся записи накопленной статистики переходов
+			clickPipeline.Wait()
+			logger.LogSugar.Infof("Статистика переходов записана, отброшено событий: %d", clickPipeline.Dropped())
 			return nil
 		}
 		return err
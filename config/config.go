@@ -5,6 +5,7 @@ import (
 	"flag"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env"
 )
@@ -16,12 +17,28 @@ import (
 
 // Параметры по умолчанию.
 const (
-	addressAndPortDefault     = ":8080"
-	baseAddressDefault        = "http://localhost:8080"
-	pathFileStorage           = "/tmp/short-url-db.json"
-	DataBaseConnectionTimeOut = 10000
-	pprofEnabledDefault       = true
-	enableHTTPSDefault        = false
+	addressAndPortDefault      = ":8080"
+	baseAddressDefault         = "http://localhost:8080"
+	pathFileStorage            = "/tmp/short-url-db.json"
+	DataBaseConnectionTimeOut  = 10 * time.Second
+	pprofEnabledDefault        = true
+	enableHTTPSDefault         = false
+	authKeyIDDefault           = "default"
+	authTokenTTLDefault        = time.Hour * 600
+	rateLimitShortenDefault    = "20:40"
+	rateLimitBatchDefault      = "2:5"
+	rateLimitRedirectDefault   = "100:200"
+	shortURLGeneratorDefault   = "random"
+	shortURLLengthDefault      = 10
+	importChunkSizeDefault     = 1000
+	restoreWindowDefault       = time.Hour * 24 * 30
+	purgeRetentionDefault      = time.Hour * 24 * 90
+	purgeBatchSizeDefault      = 500
+	purgeBatchPauseDefault     = 100 * time.Millisecond
+	fileStorageSyncDefault     = "interval"
+	fileSyncIntervalDefault    = time.Second
+	fileCompactIntervalDefault = time.Hour
+	memorySnapshotIntervalDef  = 5 * time.Minute
 )
 
 // Config Конфигурация приложения.
@@ -43,6 +60,47 @@ type Config struct {
 	Config string `env:"CONFIG"`
 	// Доверенная сеть
 	TrustedSubnet string `env:"TRUSTED_SUBNET"`
+	// Идентификатор текущего ключа подписи токенов
+	AuthKeyID string `env:"AUTH_KEY_ID"`
+	// Текущий ключ подписи токенов, значения по умолчанию нет: без ключа сервер не запускается
+	AuthSecretKey string `env:"AUTH_SECRET_KEY"`
+	// Предыдущие ключи, токены которых ещё принимаются, в формате kid1:secret1,kid2:secret2
+	AuthPreviousKeys string `env:"AUTH_PREVIOUS_KEYS"`
+	// Время жизни токена
+	AuthTokenTTL time.Duration `env:"AUTH_TOKEN_TTL"`
+	// Лимиты запросов в формате rate:burst (запросов в секунду:всплеск), "0" отключает лимит
+	RateLimitShorten  string `env:"RATE_LIMIT_SHORTEN"`
+	RateLimitBatch    string `env:"RATE_LIMIT_BATCH"`
+	RateLimitRedirect string `env:"RATE_LIMIT_REDIRECT"`
+	// Запрет сокращения ссылок на localhost и адреса частных сетей
+	URLRejectPrivateHosts bool `env:"URL_REJECT_PRIVATE_HOSTS"`
+	// Путь к JSON файлу политики разрешённых и запрещённых ссылок
+	DestinationPolicyFile string `env:"DESTINATION_POLICY_FILE"`
+	// Стратегия генерации кода короткой ссылки: random или sequence
+	ShortURLGenerator string `env:"SHORT_URL_GENERATOR"`
+	// Длина кода короткой ссылки
+	ShortURLLength int `env:"SHORT_URL_LENGTH"`
+	// Количество ссылок, записываемых одной транзакцией при импорте
+	ImportChunkSize int `env:"IMPORT_CHUNK_SIZE"`
+	// Сколько времени после удаления ссылку можно восстановить
+	RestoreWindow time.Duration `env:"RESTORE_WINDOW"`
+	// Сколько времени после удаления ссылка хранится, прежде чем будет удалена окончательно
+	PurgeRetention time.Duration `env:"PURGE_RETENTION"`
+	// Количество ссылок, удаляемых окончательно за один запрос
+	PurgeBatchSize int `env:"PURGE_BATCH_SIZE"`
+	// Пауза между пачками окончательного удаления
+	PurgeBatchPause time.Duration `env:"PURGE_BATCH_PAUSE"`
+	// Сброс журнала файлового хранилища на диск: always, interval или never
+	FileStorageSync string `env:"FILE_STORAGE_SYNC"`
+	// Период сброса журнала на диск для политики interval
+	FileStorageSyncInterval time.Duration `env:"FILE_STORAGE_SYNC_INTERVAL"`
+	// Период сжатия журнала файлового хранилища
+	FileStorageCompactInterval time.Duration `env:"FILE_STORAGE_COMPACT_INTERVAL"`
+	// Файл снимка хранилища в памяти, пустое значение отключает снимки.
+	// Если путь файлового хранилища не задан, вместо файла по умолчанию используется хранилище в памяти
+	MemorySnapshotPath string `env:"MEMORY_SNAPSHOT_PATH"`
+	// Период сохранения снимка хранилища в памяти
+	MemorySnapshotInterval time.Duration `env:"MEMORY_SNAPSHOT_INTERVAL"`
 }
 
 // ConfigurationFile Структура файла конфигурацииы
@@ -59,6 +117,48 @@ type ConfigurationFile struct {
 	EnableHTTPS bool `json:"enable_https"`
 	// Доверенная сеть
 	TrustedSubnet string `json:"trusted_subnet"`
+	// AuthKeyID аналог переменной окружения AUTH_KEY_ID
+	AuthKeyID string `json:"auth_key_id"`
+	// AuthSecretKey аналог переменной окружения AUTH_SECRET_KEY
+	AuthSecretKey string `json:"auth_secret_key"`
+	// AuthPreviousKeys аналог переменной окружения AUTH_PREVIOUS_KEYS
+	AuthPreviousKeys string `json:"auth_previous_keys"`
+	// AuthTokenTTL аналог переменной окружения AUTH_TOKEN_TTL, например "24h"
+	AuthTokenTTL string `json:"auth_token_ttl"`
+	// RateLimitShorten аналог переменной окружения RATE_LIMIT_SHORTEN
+	RateLimitShorten string `json:"rate_limit_shorten"`
+	// RateLimitBatch аналог переменной окружения RATE_LIMIT_BATCH
+	RateLimitBatch string `json:"rate_limit_batch"`
+	// RateLimitRedirect аналог переменной окружения RATE_LIMIT_REDIRECT
+	RateLimitRedirect string `json:"rate_limit_redirect"`
+	// URLRejectPrivateHosts аналог переменной окружения URL_REJECT_PRIVATE_HOSTS
+	URLRejectPrivateHosts bool `json:"url_reject_private_hosts"`
+	// DestinationPolicyFile аналог переменной окружения DESTINATION_POLICY_FILE
+	DestinationPolicyFile string `json:"destination_policy_file"`
+	// ShortURLGenerator аналог переменной окружения SHORT_URL_GENERATOR
+	ShortURLGenerator string `json:"short_url_generator"`
+	// ShortURLLength аналог переменной окружения SHORT_URL_LENGTH
+	ShortURLLength int `json:"short_url_length"`
+	// ImportChunkSize аналог переменной окружения IMPORT_CHUNK_SIZE
+	ImportChunkSize int `json:"import_chunk_size"`
+	// RestoreWindow аналог переменной окружения RESTORE_WINDOW, например "720h"
+	RestoreWindow string `json:"restore_window"`
+	// PurgeRetention аналог переменной окружения PURGE_RETENTION, например "2160h"
+	PurgeRetention string `json:"purge_retention"`
+	// PurgeBatchSize аналог переменной окружения PURGE_BATCH_SIZE
+	PurgeBatchSize int `json:"purge_batch_size"`
+	// PurgeBatchPause аналог переменной окружения PURGE_BATCH_PAUSE, например "100ms"
+	PurgeBatchPause string `json:"purge_batch_pause"`
+	// FileStorageSync аналог переменной окружения FILE_STORAGE_SYNC
+	FileStorageSync string `json:"file_storage_sync"`
+	// FileStorageSyncInterval аналог переменной окружения FILE_STORAGE_SYNC_INTERVAL, например "1s"
+	FileStorageSyncInterval string `json:"file_storage_sync_interval"`
+	// FileStorageCompactInterval аналог переменной окружения FILE_STORAGE_COMPACT_INTERVAL, например "1h"
+	FileStorageCompactInterval string `json:"file_storage_compact_interval"`
+	// MemorySnapshotPath аналог переменной окружения MEMORY_SNAPSHOT_PATH
+	MemorySnapshotPath string `json:"memory_snapshot_path"`
+	// MemorySnapshotInterval аналог переменной окружения MEMORY_SNAPSHOT_INTERVAL, например "5m"
+	MemorySnapshotInterval string `json:"memory_snapshot_interval"`
 }
 
 // InitConfig инициализация настроек приложения.
@@ -186,7 +286,8 @@ func (c *Config) initDefaultConfig() {
 		c.BaseShortURL = baseAddressDefault
 	}
 
-	if c.FileStoragePath == "" {
+	// С файлом снимка без явного пути файлового хранилища выбирается хранилище в памяти
+	if c.FileStoragePath == "" && c.MemorySnapshotPath == "" {
 		c.FileStoragePath = pathFileStorage
 	}
 
@@ -197,4 +298,72 @@ func (c *Config) initDefaultConfig() {
 	if !c.EnableHTTPS {
 		c.EnableHTTPS = enableHTTPSDefault
 	}
+
+	if c.AuthKeyID == "" {
+		c.AuthKeyID = authKeyIDDefault
+	}
+
+	if c.AuthTokenTTL <= 0 {
+		c.AuthTokenTTL = authTokenTTLDefault
+	}
+
+	if c.RateLimitShorten == "" {
+		c.RateLimitShorten = rateLimitShortenDefault
+	}
+
+	if c.RateLimitBatch == "" {
+		c.RateLimitBatch = rateLimitBatchDefault
+	}
+
+	if c.RateLimitRedirect == "" {
+		c.RateLimitRedirect = rateLimitRedirectDefault
+	}
+
+	if c.ShortURLGenerator == "" {
+		c.ShortURLGenerator = shortURLGeneratorDefault
+	}
+
+	if c.ShortURLLength == 0 {
+		c.ShortURLLength = shortURLLengthDefault
+	}
+
+	if c.ImportChunkSize <= 0 {
+		c.ImportChunkSize = importChunkSizeDefault
+	}
+
+	if c.RestoreWindow <= 0 {
+		c.RestoreWindow = restoreWindowDefault
+	}
+
+	if c.PurgeRetention <= 0 {
+		c.PurgeRetention = purgeRetentionDefault
+	}
+	// Ссылки, которые ещё можно восстановить, окончательно не удаляются
+	if c.PurgeRetention < c.RestoreWindow {
+		c.PurgeRetention = c.RestoreWindow
+	}
+
+	if c.PurgeBatchSize <= 0 {
+		c.PurgeBatchSize = purgeBatchSizeDefault
+	}
+
+	if c.PurgeBatchPause <= 0 {
+		c.PurgeBatchPause = purgeBatchPauseDefault
+	}
+
+	if c.FileStorageSync == "" {
+		c.FileStorageSync = fileStorageSyncDefault
+	}
+
+	if c.FileStorageSyncInterval <= 0 {
+		c.FileStorageSyncInterval = fileSyncIntervalDefault
+	}
+
+	if c.FileStorageCompactInterval <= 0 {
+		c.FileStorageCompactInterval = fileCompactIntervalDefault
+	}
+
+	if c.MemorySnapshotInterval <= 0 {
+		c.MemorySnapshotInterval = memorySnapshotIntervalDef
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
@@ -108,7 +109,11 @@ func TestInitJSONConfig(t *testing.T) {
 		"file_storage_path": "/tmp/storage",
 		"database_dsn": "/dbname",
 		"enable_https": true,
-		"trusted_subnet": "192.168.0.1/24"
+		"trusted_subnet": "192.168.0.1/24",
+		"auth_key_id": "kid2",
+		"auth_secret_key": "secret2",
+		"auth_previous_keys": "kid1:secret1",
+		"auth_token_ttl": "24h"
 	}`
 	jsonFile, err := os.CreateTemp("", "config.json")
 	assert.NoError(t, err)
@@ -127,13 +132,17 @@ func TestInitJSONConfig(t *testing.T) {
 	assert.NoError(t, err)
 
 	wantConfig := Config{
-		ServerURL:       "localhost:8080",
-		BaseShortURL:    "http://localhost:8080",
-		FileStoragePath: "/tmp/storage",
-		DataBaseDsn:     "/dbname",
-		EnableHTTPS:     true,
-		Config:          jsonFile.Name(),
-		TrustedSubnet:   "192.168.0.1/24",
+		ServerURL:        "localhost:8080",
+		BaseShortURL:     "http://localhost:8080",
+		FileStoragePath:  "/tmp/storage",
+		DataBaseDsn:      "/dbname",
+		EnableHTTPS:      true,
+		Config:           jsonFile.Name(),
+		TrustedSubnet:    "192.168.0.1/24",
+		AuthKeyID:        "kid2",
+		AuthSecretKey:    "secret2",
+		AuthPreviousKeys: "kid1:secret1",
+		AuthTokenTTL:     time.Hour * 24,
 	}
 	if diff := cmp.Diff(wantConfig, actualConfig); diff != "" {
 		t.Errorf("Config mismatch (-expected +got):\n%s", diff)
@@ -149,6 +158,10 @@ func TestNewConfig(t *testing.T) {
 	_ = os.Setenv("PPROF_ENABLED", "true")
 	_ = os.Setenv("ENABLE_HTTPS", "true")
 	_ = os.Setenv("TRUSTED_SUBNET", "mocket_subnet")
+	_ = os.Setenv("AUTH_KEY_ID", "mocked_kid")
+	_ = os.Setenv("AUTH_PREVIOUS_KEYS", "old_kid:old_secret")
+	_ = os.Setenv("RATE_LIMIT_SHORTEN", "5:10")
+	_ = os.Setenv("SHORT_URL_GENERATOR", "sequence")
 
 	jsonFile, err := os.CreateTemp("", "config.json")
 	assert.NoError(t, err)
@@ -164,7 +177,19 @@ func TestNewConfig(t *testing.T) {
 		"file_storage_path": "/tmp/storage",
 		"database_dsn": "/dbname",
 		"enable_https": true,
-		"trusted_subnet": "192.168.0.1/24"
+		"trusted_subnet": "192.168.0.1/24",
+		"auth_secret_key": "json_secret",
+		"rate_limit_redirect": "0",
+		"url_reject_private_hosts": true,
+		"destination_policy_file": "/etc/shorturl/policy.json",
+		"short_url_length": 8,
+		"import_chunk_size": 500,
+		"restore_window": "48h",
+		"purge_retention": "24h",
+		"purge_batch_size": 50,
+		"file_storage_sync": "always",
+		"file_storage_compact_interval": "30m",
+		"memory_snapshot_path": "/var/lib/shorturl/snapshot.json"
 	}`
 
 	_, err = jsonFile.WriteString(jsonConfig)
@@ -176,16 +201,74 @@ func TestNewConfig(t *testing.T) {
 	assert.NoError(t, err)
 
 	wantConfig := &Config{
-		ServerURL:       "mocked_address",
-		BaseShortURL:    "mocked_base_url",
-		FileStoragePath: "mocked_file_path",
-		DataBaseDsn:     "mocked_db_dsn",
-		PprofEnabled:    true,
-		EnableHTTPS:     true,
-		Config:          jsonFile.Name(),
-		TrustedSubnet:   "mocket_subnet",
+		ServerURL:                  "mocked_address",
+		BaseShortURL:               "mocked_base_url",
+		FileStoragePath:            "mocked_file_path",
+		DataBaseDsn:                "mocked_db_dsn",
+		PprofEnabled:               true,
+		EnableHTTPS:                true,
+		Config:                     jsonFile.Name(),
+		TrustedSubnet:              "mocket_subnet",
+		AuthKeyID:                  "mocked_kid",
+		AuthSecretKey:              "json_secret",
+		AuthPreviousKeys:           "old_kid:old_secret",
+		AuthTokenTTL:               time.Hour * 600,
+		RateLimitShorten:           "5:10",
+		RateLimitBatch:             "2:5",
+		RateLimitRedirect:          "0",
+		URLRejectPrivateHosts:      true,
+		DestinationPolicyFile:      "/etc/shorturl/policy.json",
+		ShortURLGenerator:          "sequence",
+		ShortURLLength:             8,
+		ImportChunkSize:            500,
+		RestoreWindow:              time.Hour * 48,
+		PurgeRetention:             time.Hour * 48,
+		PurgeBatchSize:             50,
+		PurgeBatchPause:            100 * time.Millisecond,
+		FileStorageSync:            "always",
+		FileStorageSyncInterval:    time.Second,
+		FileStorageCompactInterval: time.Minute * 30,
+		MemorySnapshotPath:         "/var/lib/shorturl/snapshot.json",
+		MemorySnapshotInterval:     time.Minute * 5,
 	}
 	if diff := cmp.Diff(wantConfig, actualConfig); diff != "" {
 		t.Errorf("Config mismatch (-expected +got):\n%s", diff)
 	}
 }
+
+func TestInitDefaultConfig_AuthSecretKey(t *testing.T) {
+	cfg := Config{}
+	cfg.initDefaultConfig()
+	assert.Empty(t, cfg.AuthSecretKey, "ключ подписи токенов не подставляется по умолчанию")
+	assert.Equal(t, authKeyIDDefault, cfg.AuthKeyID)
+}
+
+func TestInitDefaultConfig_StorageChoice(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		wantPath string
+	}{
+		{
+			name:     "#1_файловое_хранилище_по_умолчанию",
+			config:   Config{},
+			wantPath: pathFileStorage,
+		},
+		{
+			name:     "#2_снимок_выбирает_хранилище_в_памяти",
+			config:   Config{MemorySnapshotPath: "/tmp/snapshot.json"},
+			wantPath: "",
+		},
+		{
+			name:     "#3_явный_путь_файлового_хранилища_важнее_снимка",
+			config:   Config{FileStoragePath: "/tmp/storage", MemorySnapshotPath: "/tmp/snapshot.json"},
+			wantPath: "/tmp/storage",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.initDefaultConfig()
+			assert.Equal(t, tt.wantPath, tt.config.FileStoragePath)
+		})
+	}
+}
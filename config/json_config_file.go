@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"time"
 )
 
 // JSONConfig Конфигурация приложения через JSON
@@ -61,5 +62,110 @@ func (cfg *JSONConfig) Init(appConfig *Config) error {
 		appConfig.TrustedSubnet = JSONCfg.TrustedSubnet
 	}
 
+	if appConfig.AuthKeyID == "" {
+		appConfig.AuthKeyID = JSONCfg.AuthKeyID
+	}
+
+	if appConfig.AuthSecretKey == "" {
+		appConfig.AuthSecretKey = JSONCfg.AuthSecretKey
+	}
+
+	if appConfig.AuthPreviousKeys == "" {
+		appConfig.AuthPreviousKeys = JSONCfg.AuthPreviousKeys
+	}
+
+	if appConfig.RateLimitShorten == "" {
+		appConfig.RateLimitShorten = JSONCfg.RateLimitShorten
+	}
+
+	if appConfig.RateLimitBatch == "" {
+		appConfig.RateLimitBatch = JSONCfg.RateLimitBatch
+	}
+
+	if appConfig.RateLimitRedirect == "" {
+		appConfig.RateLimitRedirect = JSONCfg.RateLimitRedirect
+	}
+
+	if !appConfig.URLRejectPrivateHosts {
+		appConfig.URLRejectPrivateHosts = JSONCfg.URLRejectPrivateHosts
+	}
+
+	if appConfig.DestinationPolicyFile == "" {
+		appConfig.DestinationPolicyFile = JSONCfg.DestinationPolicyFile
+	}
+
+	if appConfig.ShortURLGenerator == "" {
+		appConfig.ShortURLGenerator = JSONCfg.ShortURLGenerator
+	}
+
+	if appConfig.ShortURLLength == 0 {
+		appConfig.ShortURLLength = JSONCfg.ShortURLLength
+	}
+
+	if appConfig.ImportChunkSize == 0 {
+		appConfig.ImportChunkSize = JSONCfg.ImportChunkSize
+	}
+
+	if appConfig.AuthTokenTTL == 0 && JSONCfg.AuthTokenTTL != "" {
+		appConfig.AuthTokenTTL, err = time.ParseDuration(JSONCfg.AuthTokenTTL)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse auth_token_ttl"), err)
+		}
+	}
+
+	if appConfig.RestoreWindow == 0 && JSONCfg.RestoreWindow != "" {
+		appConfig.RestoreWindow, err = time.ParseDuration(JSONCfg.RestoreWindow)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse restore_window"), err)
+		}
+	}
+
+	if appConfig.PurgeRetention == 0 && JSONCfg.PurgeRetention != "" {
+		appConfig.PurgeRetention, err = time.ParseDuration(JSONCfg.PurgeRetention)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse purge_retention"), err)
+		}
+	}
+
+	if appConfig.PurgeBatchSize == 0 {
+		appConfig.PurgeBatchSize = JSONCfg.PurgeBatchSize
+	}
+
+	if appConfig.PurgeBatchPause == 0 && JSONCfg.PurgeBatchPause != "" {
+		appConfig.PurgeBatchPause, err = time.ParseDuration(JSONCfg.PurgeBatchPause)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse purge_batch_pause"), err)
+		}
+	}
+
+	if appConfig.FileStorageSync == "" {
+		appConfig.FileStorageSync = JSONCfg.FileStorageSync
+	}
+
+	if appConfig.FileStorageSyncInterval == 0 && JSONCfg.FileStorageSyncInterval != "" {
+		appConfig.FileStorageSyncInterval, err = time.ParseDuration(JSONCfg.FileStorageSyncInterval)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse file_storage_sync_interval"), err)
+		}
+	}
+
+	if appConfig.FileStorageCompactInterval == 0 && JSONCfg.FileStorageCompactInterval != "" {
+		appConfig.FileStorageCompactInterval, err = time.ParseDuration(JSONCfg.FileStorageCompactInterval)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse file_storage_compact_interval"), err)
+		}
+	}
+
+	if appConfig.MemorySnapshotPath == "" {
+		appConfig.MemorySnapshotPath = JSONCfg.MemorySnapshotPath
+	}
+
+	if appConfig.MemorySnapshotInterval == 0 && JSONCfg.MemorySnapshotInterval != "" {
+		appConfig.MemorySnapshotInterval, err = time.ParseDuration(JSONCfg.MemorySnapshotInterval)
+		if err != nil {
+			return errors.Join(errors.New("failed to parse memory_snapshot_interval"), err)
+		}
+	}
+
 	return nil
 }
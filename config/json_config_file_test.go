@@ -33,6 +33,14 @@ func TestInit(t *testing.T) {
 		"trusted_subnet": "192.168.0.1/24"
 	}`,
 		},
+		{
+			name: "не_валидный_срок_жизни_токена",
+			want: Config{},
+			actual: `{
+		"auth_token_ttl": "month"
+	}`,
+			expectedError: true,
+		},
 		{
 			name: "не_валидный_конфиг",
 			want: Config{},
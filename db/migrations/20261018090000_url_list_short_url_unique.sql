@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Прежний неуникальный индекс допускал повторяющиеся коды. Самая ранняя ссылка сохраняет код,
+-- более поздние получают код с суффиксом "-<id>": дефиса нет в алфавите генератора кодов, а id уникален.
+UPDATE public.url_list AS ul
+SET short_url = left(ul.short_url, 80) || '-' || ul.id
+WHERE EXISTS (
+    SELECT 1 FROM public.url_list AS earlier
+    WHERE earlier.short_url = ul.short_url AND earlier.id < ul.id
+);
+DROP INDEX IF EXISTS public.short_url_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS url_list_short_url_idx ON public.url_list USING btree (short_url);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Переименованные при подъёме коды не возвращаются
+DROP INDEX IF EXISTS public.url_list_short_url_idx;
+CREATE INDEX IF NOT EXISTS short_url_idx ON public.url_list USING btree (short_url);
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.url_list ADD COLUMN IF NOT EXISTS expires_at timestamp NULL;
+CREATE INDEX IF NOT EXISTS url_list_expires_at_idx ON public.url_list (expires_at) WHERE expires_at IS NOT NULL AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.url_list_expires_at_idx;
+ALTER TABLE public.url_list DROP COLUMN IF EXISTS expires_at;
+-- +goose StatementEnd
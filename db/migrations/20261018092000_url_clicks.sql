@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.url_clicks (
+    id int8 GENERATED ALWAYS AS IDENTITY NOT NULL,
+    short_url varchar(100) NOT NULL,
+    created_at timestamp DEFAULT now() NOT NULL,
+    referer varchar(2000) NULL,
+    user_agent varchar(1000) NULL,
+    remote_ip varchar(100) NULL,
+    CONSTRAINT url_clicks_pk PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS url_clicks_short_url_created_at_idx ON public.url_clicks USING btree (short_url, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.url_clicks;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.users ALTER COLUMN login DROP NOT NULL;
+ALTER TABLE public.users ALTER COLUMN password DROP NOT NULL;
+UPDATE public.users SET login = NULL, password = NULL WHERE login = 'test_user' || uuid::text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE public.users SET login = 'test_user' || uuid::text, password = 'password' WHERE login IS NULL;
+ALTER TABLE public.users ALTER COLUMN password SET NOT NULL;
+ALTER TABLE public.users ALTER COLUMN login SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.api_keys (
+    id int8 GENERATED ALWAYS AS IDENTITY NOT NULL,
+    user_uuid uuid NOT NULL,
+    name varchar(100) NOT NULL DEFAULT '',
+    key_hash varchar(64) NOT NULL,
+    created_at timestamp DEFAULT now() NOT NULL,
+    revoked_at timestamp NULL,
+    CONSTRAINT api_keys_pk PRIMARY KEY (id),
+    CONSTRAINT api_keys_users_fk FOREIGN KEY (user_uuid) REFERENCES public.users(uuid) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_key_hash_idx ON public.api_keys (key_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.api_keys;
+-- +goose StatementEnd
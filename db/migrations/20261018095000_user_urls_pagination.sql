@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS user_short_url_user_id_url_id_idx ON public.user_short_url (user_id, url_id);
+CREATE INDEX IF NOT EXISTS url_list_created_at_id_idx ON public.url_list (created_at, id);
+CREATE INDEX IF NOT EXISTS url_list_host_idx ON public.url_list (lower(substring(url from '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/:?#]+)')));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS public.url_list_host_idx;
+DROP INDEX IF EXISTS public.url_list_created_at_id_idx;
+DROP INDEX IF EXISTS public.user_short_url_user_id_url_id_idx;
+-- +goose StatementEnd
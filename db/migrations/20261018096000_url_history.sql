@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.url_history (
+    id int8 GENERATED ALWAYS AS IDENTITY NOT NULL,
+    url_id int8 NOT NULL,
+    user_id int8 NOT NULL,
+    previous_url varchar(2000) NOT NULL,
+    url varchar(2000) NOT NULL,
+    changed_at timestamp DEFAULT now() NOT NULL,
+    CONSTRAINT url_history_pk PRIMARY KEY (id),
+    CONSTRAINT url_history_url_list_fk FOREIGN KEY (url_id) REFERENCES public.url_list(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT url_history_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS url_history_url_id_changed_at_idx ON public.url_history (url_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.url_history;
+-- +goose StatementEnd
@@ -5,6 +5,8 @@ type key int
 // KeyContext Ключи контекста, для передачи в запросах.
 const (
 	KeyContext key = iota
+	// KeyAuthenticatedUser uuid пользователя, подтвердившего авторизацию токеном или ключом доступа
+	KeyAuthenticatedUser
 )
 
 // UserUUID UUID пользователя
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+)
+
+// AccountHandler хэндлер регистрации и входа пользователей.
+type AccountHandler struct {
+	account *auntificator.Account
+}
+
+// NewAccountHandler конструктор.
+func NewAccountHandler(account *auntificator.Account) *AccountHandler {
+	return &AccountHandler{
+		account: account,
+	}
+}
+
+// RequestRegister запрос на регистрацию.
+type RequestRegister struct {
+	Name     string `json:"name,omitempty"`
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// RequestLogin запрос на вход.
+type RequestLogin struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// ResponseAccount ответ с данными пользователя.
+type ResponseAccount struct {
+	UUID  string `json:"uuid"`
+	Login string `json:"login"`
+}
+
+// Register регистрация текущего пользователя, ссылки созданные до регистрации сохраняются.
+// @Summary Регистрация пользователя
+// @Failure 400
+// @Failure 409
+// @Failure 500
+// @Success 201 {object} ResponseAccount
+// @Param Register body RequestRegister true "логин и пароль"
+// @Router /api/user/register [post]
+func (a *AccountHandler) Register(res http.ResponseWriter, req *http.Request) {
+	bodyValue, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(res, "error read bodyValue", http.StatusBadRequest)
+		return
+	}
+	defer req.Body.Close()
+
+	var request RequestRegister
+	if err = json.Unmarshal(bodyValue, &request); err != nil {
+		http.Error(res, "error unmarshal json request", http.StatusBadRequest)
+		return
+	}
+
+	var userUUID string
+	if id, ok := req.Context().Value(context.KeyContext).(string); ok {
+		userUUID = id
+	}
+
+	user, err := a.account.Register(req.Context(), userUUID, request.Name, request.Login, request.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, auntificator.ErrCredentialsInvalid):
+			http.Error(res, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, auntificator.ErrLoginExists), errors.Is(err, auntificator.ErrAlreadyRegistered):
+			http.Error(res, err.Error(), http.StatusConflict)
+		default:
+			logger.LogSugar.Error(err)
+			http.Error(res, "error register user", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	a.writeResponse(res, http.StatusCreated, ResponseAccount{UUID: user.UUID, Login: user.Login})
+}
+
+// Login вход по логину и паролю, токен передаётся в куке и заголовке Authorization.
+// @Summary Вход пользователя
+// @Failure 400
+// @Failure 401
+// @Failure 500
+// @Success 200 {object} ResponseAccount
+// @Param Login body RequestLogin true "логин и пароль"
+// @Router /api/user/login [post]
+func (a *AccountHandler) Login(res http.ResponseWriter, req *http.Request) {
+	bodyValue, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(res, "error read bodyValue", http.StatusBadRequest)
+		return
+	}
+	defer req.Body.Close()
+
+	var request RequestLogin
+	if err = json.Unmarshal(bodyValue, &request); err != nil {
+		http.Error(res, "error unmarshal json request", http.StatusBadRequest)
+		return
+	}
+
+	authResult, err := a.account.Login(req.Context(), request.Login, request.Password)
+	if err != nil {
+		if errors.Is(err, auntificator.ErrWrongCredentials) {
+			http.Error(res, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		logger.LogSugar.Error(err)
+		http.Error(res, "error login user", http.StatusInternalServerError)
+		return
+	}
+
+	auntificator.SetAuthorization(res, authResult.AuthString, authResult.TokenExp)
+	a.writeResponse(res, http.StatusOK, ResponseAccount{UUID: authResult.UserUUID, Login: request.Login})
+}
+
+func (a *AccountHandler) writeResponse(res http.ResponseWriter, status int, response ResponseAccount) {
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		http.Error(res, "error json marshal response", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(status)
+	_, err = res.Write(responseBytes)
+	if err != nil {
+		logger.LogSugar.Error("error write data")
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/workers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountHandler_RegisterAndLogin(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, memoryStorage, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
+	defer ts.Close()
+
+	post := func(path string, body string, authorization string) *http.Response {
+		request, err := http.NewRequest(http.MethodPost, ts.URL+path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response, err := ts.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		return response
+	}
+
+	// Анонимный пользователь создаёт ссылку
+	response := post("/api/shorten", `{"url":"https://ya.ru/account"}`, "")
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	anonymousToken := response.Header.Get("Authorization")
+	assert.NotEmpty(t, anonymousToken)
+
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		authorization string
+		code          int
+	}{
+		{name: "#1_короткий_пароль", path: "/api/user/register", body: `{"login":"cat","password":"1"}`, authorization: anonymousToken, code: http.StatusBadRequest},
+		{name: "#2_не_json", path: "/api/user/register", body: `login`, authorization: anonymousToken, code: http.StatusBadRequest},
+		{name: "#3_регистрация", path: "/api/user/register", body: `{"login":"cat","password":"password"}`, authorization: anonymousToken, code: http.StatusCreated},
+		{name: "#4_повторная_регистрация", path: "/api/user/register", body: `{"login":"dog","password":"password"}`, authorization: anonymousToken, code: http.StatusConflict},
+		{name: "#5_логин_занят", path: "/api/user/register", body: `{"login":"cat","password":"password"}`, code: http.StatusConflict},
+		{name: "#6_неверный_пароль", path: "/api/user/login", body: `{"login":"cat","password":"wrong_password"}`, code: http.StatusUnauthorized},
+		{name: "#7_вход", path: "/api/user/login", body: `{"login":"cat","password":"password"}`, code: http.StatusOK},
+	}
+	var loginToken string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := post(tt.path, tt.body, tt.authorization)
+			assert.Equal(t, tt.code, response.StatusCode)
+			if tt.path == "/api/user/login" && response.StatusCode == http.StatusOK {
+				loginToken = response.Header.Get("Authorization")
+			}
+		})
+	}
+
+	// После входа пользователю доступны ссылки, созданные до регистрации
+	assert.NotEmpty(t, loginToken)
+	request, err := http.NewRequest(http.MethodGet, ts.URL+"/api/user/urls", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Authorization", loginToken)
+	response, err = ts.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	var userURLs ResponseViewPage
+	_ = json.NewDecoder(response.Body).Decode(&userURLs)
+	originalURLs := make([]string, 0, len(userURLs.URLs))
+	for _, item := range userURLs.URLs {
+		originalURLs = append(originalURLs, item.OriginalURL)
+	}
+	assert.Contains(t, originalURLs, "https://ya.ru/account")
+}
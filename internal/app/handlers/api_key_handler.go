@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/storage"
+)
+
+// APIKeyHandler хэндлер выпуска и отзыва ключей доступа межсервисных клиентов.
+type APIKeyHandler struct {
+	apiKeys *auntificator.APIKeys
+}
+
+// NewAPIKeyHandler конструктор.
+func NewAPIKeyHandler(apiKeys *auntificator.APIKeys) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+	}
+}
+
+// RequestMintAPIKey запрос на выпуск ключа доступа.
+type RequestMintAPIKey struct {
+	UserUUID string `json:"user_uuid"`
+	Name     string `json:"name,omitempty"`
+}
+
+// ResponseAPIKey выпущенный ключ доступа, значение ключа показывается один раз.
+type ResponseAPIKey struct {
+	ID        int64     `json:"id"`
+	Key       string    `json:"key"`
+	UserUUID  string    `json:"user_uuid"`
+	Name      string    `json:"name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Mint выпуск ключа доступа для пользователя.
+// @Summary Выпуск ключа доступа
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Success 201 {object} ResponseAPIKey
+// @Param APIKey body RequestMintAPIKey true "владелец ключа"
+// @Router /api/internal/api-keys [post]
+func (h *APIKeyHandler) Mint(res http.ResponseWriter, req *http.Request) {
+	bodyValue, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(res, "error read bodyValue", http.StatusBadRequest)
+		return
+	}
+	defer req.Body.Close()
+
+	var request RequestMintAPIKey
+	if err = json.Unmarshal(bodyValue, &request); err != nil || request.UserUUID == "" {
+		http.Error(res, "expected user_uuid", http.StatusBadRequest)
+		return
+	}
+
+	key, model, err := h.apiKeys.Mint(req.Context(), request.UserUUID, request.Name)
+	if err != nil {
+		if errors.Is(err, auntificator.ErrAPIKeyUserNotFound) {
+			http.Error(res, err.Error(), http.StatusNotFound)
+			return
+		}
+		logger.LogSugar.Error(err)
+		http.Error(res, "error mint api key", http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(ResponseAPIKey{
+		ID:        model.ID,
+		Key:       key,
+		UserUUID:  model.UserUUID,
+		Name:      model.Name,
+		CreatedAt: model.CreatedAt,
+	})
+	if err != nil {
+		http.Error(res, "error json marshal response", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(http.StatusCreated)
+	_, err = res.Write(responseBytes)
+	if err != nil {
+		logger.LogSugar.Error("error write data")
+	}
+}
+
+// Revoke отзыв ключа доступа.
+// @Summary Отзыв ключа доступа
+// @Failure 400
+// @Failure 403
+// @Failure 404
+// @Failure 500
+// @Success 204
+// @Param id path int true "id ключа"
+// @Router /api/internal/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(res http.ResponseWriter, req *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(req, "id"), 10, 64)
+	if err != nil {
+		http.Error(res, "expected key id", http.StatusBadRequest)
+		return
+	}
+	err = h.apiKeys.Revoke(req.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			http.Error(res, err.Error(), http.StatusNotFound)
+			return
+		}
+		logger.LogSugar.Error(err)
+		http.Error(res, "error revoke api key", http.StatusInternalServerError)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
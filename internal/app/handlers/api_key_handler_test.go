@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/workers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyHandler_MintAndRevoke(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	builder := NewRoutesBuilder()
+	builder.SetService(url.NewShortURLService(memoryStorage, memoryStorage))
+	builder.SetStorage(memoryStorage)
+	builder.SetSessionStorage(storage.NewSessionStorage())
+	builder.SetWorker(workers.NewWorker(memoryStorage, stop))
+	builder.SetConfigApp(&config.Config{TrustedSubnet: "192.168.1.0/24"})
+	ts := httptest.NewServer(builder.GetAppRoutes().Init())
+	defer ts.Close()
+
+	do := func(method string, path string, body string, headers map[string]string) *http.Response {
+		request, err := http.NewRequest(method, ts.URL+path, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		response, err := ts.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+	trusted := map[string]string{"X-Real-IP": "192.168.1.10"}
+
+	// Анонимный пользователь, для которого выпускается ключ
+	response := do(http.MethodPost, "/api/shorten", `{"url":"https://ya.ru/api-key"}`, nil)
+	response.Body.Close()
+	claims, err := auntificator.NewDefaultTokenManager().Parse(response.Header.Get("Authorization"))
+	assert.NoError(t, err)
+	userUUID := claims.UserUUID
+
+	tests := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		code    int
+	}{
+		{name: "#1_не_доверенная_сеть", body: fmt.Sprintf(`{"user_uuid":"%s"}`, userUUID), headers: map[string]string{"X-Real-IP": "10.0.0.1"}, code: http.StatusForbidden},
+		{name: "#2_без_пользователя", body: `{"name":"billing"}`, headers: trusted, code: http.StatusBadRequest},
+		{name: "#3_неизвестный_пользователь", body: `{"user_uuid":"unknown"}`, headers: trusted, code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := do(http.MethodPost, "/api/internal/api-keys", tt.body, tt.headers)
+			response.Body.Close()
+			assert.Equal(t, tt.code, response.StatusCode)
+		})
+	}
+
+	response = do(http.MethodPost, "/api/internal/api-keys", fmt.Sprintf(`{"user_uuid":"%s","name":"billing"}`, userUUID), trusted)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	var minted ResponseAPIKey
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&minted))
+	response.Body.Close()
+	assert.NotEmpty(t, minted.Key)
+	assert.Equal(t, userUUID, minted.UserUUID)
+
+	// Ключ в заголовке X-API-Key и в Authorization открывает ссылки владельца, токен не выдаётся
+	for _, headers := range []map[string]string{
+		{auntificator.HeaderAPIKey: minted.Key},
+		{"Authorization": auntificator.APIKeyScheme + " " + minted.Key},
+	} {
+		response = do(http.MethodGet, "/api/user/urls", "", headers)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Empty(t, response.Header.Get("Authorization"))
+		var userURLs ResponseViewPage
+		_ = json.NewDecoder(response.Body).Decode(&userURLs)
+		response.Body.Close()
+		originalURLs := make([]string, 0, len(userURLs.URLs))
+		for _, item := range userURLs.URLs {
+			originalURLs = append(originalURLs, item.OriginalURL)
+		}
+		assert.Contains(t, originalURLs, "https://ya.ru/api-key")
+	}
+
+	revokePath := fmt.Sprintf("/api/internal/api-keys/%d", minted.ID)
+	response = do(http.MethodDelete, revokePath, "", trusted)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	response = do(http.MethodDelete, revokePath, "", trusted)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response = do(http.MethodDelete, "/api/internal/api-keys/abc", "", trusted)
+	response.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	// Отозванный ключ больше не принимается
+	response = do(http.MethodGet, "/api/user/urls", "", map[string]string{auntificator.HeaderAPIKey: minted.Key})
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/workers"
@@ -14,7 +17,12 @@ type RoutesBuilder struct {
 	worker          *workers.Worker
 	storage         storage.StorageQuery
 	finderStats     StatsFinder
+	purgeCounter    PurgeCounter
 	configApp       *config.Config
+	clickRecorder   ClickRecorder
+	tokens          *auntificator.TokenManager
+	rateLimiter     *ratelimit.Limiter
+	importer        *importer.Importer
 }
 
 // Builder строитель.
@@ -25,7 +33,12 @@ type Builder interface {
 	SetStorage(storage.StorageQuery)
 	GetAppRoutes() *Routes
 	SetFinderStats(finderStats StatsFinder)
+	SetPurgeCounter(purgeCounter PurgeCounter)
 	SetConfigApp(configApp *config.Config)
+	SetClickRecorder(clickRecorder ClickRecorder)
+	SetTokenManager(tokens *auntificator.TokenManager)
+	SetRateLimiter(rateLimiter *ratelimit.Limiter)
+	SetImporter(importer *importer.Importer)
 }
 
 // NewRoutesBuilder конструктор.
@@ -66,7 +79,12 @@ func (r *RoutesBuilder) GetAppRoutes() *Routes {
 		worker:          r.worker,
 		storage:         r.storage,
 		finderStats:     r.finderStats,
+		purgeCounter:    r.purgeCounter,
 		configApp:       r.configApp,
+		clickRecorder:   r.clickRecorder,
+		tokens:          r.tokens,
+		rateLimiter:     r.rateLimiter,
+		importer:        r.importer,
 	}
 }
 
@@ -75,7 +93,32 @@ func (r *RoutesBuilder) SetFinderStats(finderStats StatsFinder) {
 	r.finderStats = finderStats
 }
 
+// SetPurgeCounter счётчик окончательно удалённых ссылок
+func (r *RoutesBuilder) SetPurgeCounter(purgeCounter PurgeCounter) {
+	r.purgeCounter = purgeCounter
+}
+
 // SetConfigApp конфигурация приложения
 func (r *RoutesBuilder) SetConfigApp(configApp *config.Config) {
 	r.configApp = configApp
 }
+
+// SetClickRecorder запись переходов по ссылкам
+func (r *RoutesBuilder) SetClickRecorder(clickRecorder ClickRecorder) {
+	r.clickRecorder = clickRecorder
+}
+
+// SetTokenManager выпуск и проверка токенов авторизации
+func (r *RoutesBuilder) SetTokenManager(tokens *auntificator.TokenManager) {
+	r.tokens = tokens
+}
+
+// SetRateLimiter ограничитель частоты запросов
+func (r *RoutesBuilder) SetRateLimiter(rateLimiter *ratelimit.Limiter) {
+	r.rateLimiter = rateLimiter
+}
+
+// SetImporter импорт ссылок из потока
+func (r *RoutesBuilder) SetImporter(importer *importer.Importer) {
+	r.importer = importer
+}
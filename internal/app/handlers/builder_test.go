@@ -5,6 +5,8 @@ import (
 
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/workers"
@@ -64,6 +66,24 @@ func TestRoutesBuilder_SetConfigApp(t *testing.T) {
 	}
 }
 
+func TestRoutesBuilder_SetTokenManager(t *testing.T) {
+	builder := NewRoutesBuilder()
+	tokens := auntificator.NewDefaultTokenManager()
+	builder.SetTokenManager(tokens)
+	if builder.tokens != tokens {
+		t.Errorf("Expected tokens to be %v, but got %v", tokens, builder.tokens)
+	}
+}
+
+func TestRoutesBuilder_SetRateLimiter(t *testing.T) {
+	builder := NewRoutesBuilder()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), nil)
+	builder.SetRateLimiter(limiter)
+	if builder.rateLimiter != limiter {
+		t.Errorf("Expected rateLimiter to be %v, but got %v", limiter, builder.rateLimiter)
+	}
+}
+
 func TestRoutesBuilder_GetAppRoutes(t *testing.T) {
 	logger.InitLogger("fatal")
 	cfg := new(config.Config)
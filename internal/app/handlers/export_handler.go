@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/exporter"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// ExportHandler хэндлер выгрузки ссылок пользователя.
+type ExportHandler struct {
+	exporter URLExporter
+}
+
+// URLExporter выгрузка ссылок пользователя по одной.
+type URLExporter interface {
+	ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error
+}
+
+// NewExportHandler конструктор.
+func NewExportHandler(exporter URLExporter) *ExportHandler {
+	instance := &ExportHandler{
+		exporter: exporter,
+	}
+	return instance
+}
+
+// Export выгрузка всех ссылок пользователя, включая удалённые.
+// Ссылки пишутся в ответ по мере чтения из хранилища, ошибка после начала выгрузки обрывает ответ.
+// @Summary Выгрузка ссылок пользователя в CSV, JSON или NDJSON
+// @Produce text/csv
+// @Produce json
+// @Produce application/x-ndjson
+// @Failure 400
+// @Success 200
+// @Param format query string false "формат выгрузки: csv, json или ndjson, по умолчанию json"
+// @Router /api/user/urls/export [get]
+func (e *ExportHandler) Export(res http.ResponseWriter, req *http.Request) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = exporter.FormatJSON
+	}
+	writer, err := exporter.NewRowWriter(format, res)
+	if err != nil {
+		http.Error(res, "expected format csv, json or ndjson", http.StatusBadRequest)
+		return
+	}
+	var userUUID string
+	if id, ok := req.Context().Value(AppContext.KeyContext).(string); ok {
+		userUUID = id
+	}
+	logger.LogSugar.Infof("Получен запрос на выгрузку ссылок в формате %s для пользователя с uuid: %s", format, userUUID)
+
+	res.Header().Set("content-type", exporter.ContentType(format))
+	res.Header().Set("Content-Disposition", "attachment; filename=urls."+format)
+	res.WriteHeader(http.StatusOK)
+	err = e.exporter.ExportUserURLs(req.Context(), userUUID, writer.Write)
+	if err != nil {
+		logger.LogSugar.Errorf("Выгрузка ссылок пользователя %s прервана: %s", userUUID, err)
+		return
+	}
+	if err = writer.Close(); err != nil {
+		logger.LogSugar.Error(err)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/exporter"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportHandler_Export(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.ImportURLs(context.Background(), userUUID, []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru"},
+		{ShortURL: "short2", URL: "https://avito.ru"},
+	})
+	require.NoError(t, err)
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "short1"})
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), userUUID, "short2")
+
+	tests := []struct {
+		name        string
+		format      string
+		code        int
+		contentType string
+		lines       int
+	}{
+		{name: "#1_json_по_умолчанию", format: "", code: http.StatusOK, contentType: "application/json"},
+		{name: "#2_csv", format: exporter.FormatCSV, code: http.StatusOK, contentType: "text/csv", lines: 3},
+		{name: "#3_ndjson", format: exporter.FormatNDJSON, code: http.StatusOK, contentType: "application/x-ndjson", lines: 2},
+		{name: "#4_неизвестный_формат", format: "xml", code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewExportHandler(memoryStorage)
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls/export?format="+tt.format, nil)
+			ctx := context.WithValue(req.Context(), AppContext.KeyContext, userUUID)
+			res := httptest.NewRecorder()
+			handler.Export(res, req.WithContext(ctx))
+
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.contentType, res.Header().Get("content-type"))
+			if tt.lines > 0 {
+				assert.Equal(t, tt.lines, strings.Count(res.Body.String(), "\n"))
+				return
+			}
+			var rows []exporter.Row
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &rows))
+			require.Len(t, rows, 2)
+			assert.Equal(t, "short1", rows[0].ShortURL)
+			assert.Equal(t, int64(1), rows[0].Clicks)
+			assert.Nil(t, rows[0].DeletedAt)
+			assert.NotNil(t, rows[0].CreatedAt)
+			assert.NotNil(t, rows[1].DeletedAt)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+)
+
+// importFormats формат потока импорта по Content-Type.
+var importFormats = map[string]string{
+	"application/x-ndjson": importer.FormatNDJSON,
+	"text/csv":             importer.FormatCSV,
+}
+
+// ImportHandler хэндлер массового импорта ссылок.
+type ImportHandler struct {
+	importer *importer.Importer
+}
+
+// NewImportHandler конструктор.
+func NewImportHandler(importer *importer.Importer) *ImportHandler {
+	instance := &ImportHandler{
+		importer: importer,
+	}
+	return instance
+}
+
+// ResponseImportJob состояние задания импорта.
+type ResponseImportJob struct {
+	JobID  string `json:"job_id"`
+	Status string `json:"status"`
+	// Received прочитано строк
+	Received int64 `json:"received"`
+	// Imported создано ссылок
+	Imported int64 `json:"imported"`
+	// Skipped строки со ссылками, которые были сокращены ранее
+	Skipped int64 `json:"skipped"`
+	// Failed строки с ошибками
+	Failed int64 `json:"failed"`
+	// Errors отчёт о пропущенных строках и строках с ошибками
+	Errors []ResponseImportError `json:"errors"`
+	// ErrorsTruncated в отчёт попали не все строки
+	ErrorsTruncated bool `json:"errors_truncated,omitempty"`
+	// Error причина остановки импорта
+	Error string `json:"error,omitempty"`
+}
+
+// ResponseImportError строка, которая не была импортирована.
+type ResponseImportError struct {
+	Line  int64  `json:"line"`
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error"`
+}
+
+// Import приём потока ссылок для импорта.
+// Поток читается по мере поступления и ссылки записываются пачками, но ответ с идентификатором задания
+// отправляется только после получения всего потока: в HTTP/1.x тело запроса нельзя дочитывать после ответа.
+// Поэтому по идентификатору отслеживается запись последних пачек, а не ход загрузки.
+// Задания хранятся в памяти процесса и теряются при перезапуске сервиса.
+// @Summary Массовый импорт ссылок из NDJSON или CSV
+// @Description Ответ отправляется после получения всего потока, ход задания отражает запись оставшихся пачек.
+// @Description Задания хранятся в памяти процесса: после перезапуска сервиса задание не найдётся.
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Failure 400
+// @Success 202 {object} ResponseImportJob
+// @Param format query string false "формат потока: ndjson или csv, по умолчанию определяется по Content-Type"
+// @Param preserve_codes query bool false "сохранить коды ссылок из прежнего сервиса"
+// @Router /api/shorten/import [post]
+func (i *ImportHandler) Import(res http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		format = importFormats[mediaType]
+	}
+	reader, err := importer.NewRowReader(format, req.Body)
+	if err != nil {
+		http.Error(res, "expected format ndjson or csv", http.StatusBadRequest)
+		return
+	}
+	var options importer.Options
+	if value := req.URL.Query().Get("preserve_codes"); value != "" {
+		options.PreserveCodes, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(res, "expected boolean preserve_codes", http.StatusBadRequest)
+			return
+		}
+	}
+	var userUUID string
+	if id, ok := req.Context().Value(AppContext.KeyContext).(string); ok {
+		userUUID = id
+	}
+
+	job := i.importer.Import(req.Context(), userUUID, reader, options)
+	progress := job.Progress()
+	res.Header().Set("Location", "/api/shorten/import/"+progress.ID)
+	i.writeProgress(res, http.StatusAccepted, progress)
+}
+
+// View состояние задания импорта пользователя.
+// Завершённые задания хранятся сутки, до перезапуска сервиса.
+// @Summary Ход массового импорта ссылок
+// @Description Задания хранятся в памяти процесса сутки после завершения и теряются при перезапуске сервиса.
+// @Failure 404
+// @Success 200 {object} ResponseImportJob
+// @Param id path string true "идентификатор задания"
+// @Router /api/shorten/import/{id} [get]
+func (i *ImportHandler) View(res http.ResponseWriter, req *http.Request) {
+	var userUUID string
+	if id, ok := req.Context().Value(AppContext.KeyContext).(string); ok {
+		userUUID = id
+	}
+	job, err := i.importer.Job(chi.URLParam(req, "id"), userUUID)
+	if errors.Is(err, importer.ErrJobNotFound) {
+		http.Error(res, "import job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(res, "error find import job", http.StatusInternalServerError)
+		logger.LogSugar.Error(err)
+		return
+	}
+	i.writeProgress(res, http.StatusOK, job.Progress())
+}
+
+func (i *ImportHandler) writeProgress(res http.ResponseWriter, headerStatus int, progress importer.Progress) {
+	response := ResponseImportJob{
+		JobID:           progress.ID,
+		Status:          progress.Status,
+		Received:        progress.Received,
+		Imported:        progress.Imported,
+		Skipped:         progress.Skipped,
+		Failed:          progress.Failed,
+		Errors:          make([]ResponseImportError, 0, len(progress.Errors)),
+		ErrorsTruncated: progress.ErrorsTruncated,
+		Error:           progress.Error,
+	}
+	for _, rowError := range progress.Errors {
+		response.Errors = append(response.Errors, ResponseImportError{
+			Line:  rowError.Line,
+			URL:   rowError.URL,
+			Error: rowError.Error,
+		})
+	}
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		http.Error(res, "error json marshal response", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(headerStatus)
+	_, err = res.Write(responseBytes)
+	if err != nil {
+		logger.LogSugar.Error(err)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportHandler_Import(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	urlImporter := importer.NewImporter(memoryStorage, service, 10)
+	handler := NewImportHandler(urlImporter)
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		code        int
+		received    int64
+		failed      int64
+	}{
+		{
+			name:        "#1_ndjson_по_content_type",
+			target:      "/api/shorten/import",
+			contentType: "application/x-ndjson",
+			body:        "{\"original_url\":\"https://ozon.ru\"}\n{\"original_url\":\"bad url\"}\n",
+			code:        http.StatusAccepted,
+			received:    2,
+			failed:      1,
+		},
+		{
+			name:        "#2_csv_по_параметру_с_кодами",
+			target:      "/api/shorten/import?format=csv&preserve_codes=true",
+			contentType: "application/octet-stream",
+			body:        "original_url,short_url\nhttps://avito.ru,avito1\n",
+			code:        http.StatusAccepted,
+			received:    1,
+		},
+		{
+			name:        "#3_неизвестный_формат",
+			target:      "/api/shorten/import",
+			contentType: "application/json",
+			body:        "[]",
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "#4_неверный_preserve_codes",
+			target:      "/api/shorten/import?format=ndjson&preserve_codes=maybe",
+			contentType: "application/x-ndjson",
+			code:        http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			ctx := context.WithValue(req.Context(), AppContext.KeyContext, userUUID)
+			res := httptest.NewRecorder()
+			handler.Import(res, req.WithContext(ctx))
+
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusAccepted {
+				return
+			}
+			var response ResponseImportJob
+			err := json.Unmarshal(res.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Equal(t, "/api/shorten/import/"+response.JobID, res.Header().Get("Location"))
+			assert.Equal(t, tt.received, response.Received)
+			assert.Equal(t, tt.failed, response.Failed)
+			job, err := urlImporter.Job(response.JobID, userUUID)
+			require.NoError(t, err)
+			<-job.Done()
+		})
+	}
+
+	_, err := memoryStorage.FindByShortURL(context.Background(), "avito1")
+	assert.NoError(t, err)
+}
+
+func TestImportHandler_View(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	urlImporter := importer.NewImporter(memoryStorage, service, 10)
+	reader, err := importer.NewRowReader(importer.FormatNDJSON, strings.NewReader("{\"original_url\":\"https://ozon.ru\"}"))
+	require.NoError(t, err)
+	job := urlImporter.Import(context.Background(), userUUID, reader, importer.Options{})
+	<-job.Done()
+	handler := NewImportHandler(urlImporter)
+
+	tests := []struct {
+		name     string
+		jobID    string
+		userUUID string
+		code     int
+	}{
+		{name: "#1_своё_задание", jobID: job.Progress().ID, userUUID: userUUID, code: http.StatusOK},
+		{name: "#2_чужое_задание", jobID: job.Progress().ID, userUUID: "user456", code: http.StatusNotFound},
+		{name: "#3_неизвестное_задание", jobID: "unknown", userUUID: userUUID, code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/shorten/import/"+tt.jobID, nil)
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("id", tt.jobID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+			ctx = context.WithValue(ctx, AppContext.KeyContext, tt.userUUID)
+			res := httptest.NewRecorder()
+			handler.View(res, req.WithContext(ctx))
+
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var response ResponseImportJob
+			err := json.Unmarshal(res.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Equal(t, importer.StatusDone, response.Status)
+			assert.Equal(t, int64(1), response.Imported)
+		})
+	}
+}
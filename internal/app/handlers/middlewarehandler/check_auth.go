@@ -2,34 +2,34 @@ package middlewarehandler
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
 	AppContext "github.com/northmule/shorturl/internal/app/context"
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/services/auntificator"
-	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 )
 
 // CheckAuth структура.
 type CheckAuth struct {
 	userCreator UserCreator
-	session     storage.SessionAdapter
+	tokens      *auntificator.TokenManager
+	apiKeys     auntificator.APIKeyResolver
 }
 
 // NewCheckAuth конструктор структуры.
-func NewCheckAuth(userCreator UserCreator, session storage.SessionAdapter) *CheckAuth {
+func NewCheckAuth(userCreator UserCreator, tokens *auntificator.TokenManager, apiKeys auntificator.APIKeyResolver) *CheckAuth {
 	return &CheckAuth{
 		userCreator: userCreator,
-		session:     session,
+		tokens:      tokens,
+		apiKeys:     apiKeys,
 	}
 }
 
 // UserCreator интерфейс создания пользователей.
 type UserCreator interface {
-	CreateUser(user models.User) (int64, error)
+	CreateUser(ctx context.Context, user models.User) (int64, error)
 }
 
 // AccessVerificationUserUrls проверка доступа пользователя.
@@ -51,48 +51,30 @@ func (c *CheckAuth) AccessVerificationUserUrls(next http.Handler) http.Handler {
 func (c *CheckAuth) AuthEveryone(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
 
-		checkAuthService := auntificator.NewCheckAuth(c.userCreator)
+		checkAuthService := auntificator.NewCheckAuth(c.userCreator, c.tokens, c.apiKeys)
 
 		authorizationToken := auntificator.GetUserToken(req)
-		authResult, err := checkAuthService.Auth(authorizationToken)
+		authResult, err := checkAuthService.Auth(req.Context(), authorizationToken)
 		if err != nil {
 			res.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		if authResult.IsNewUser {
-			res = c.authorization(res, authResult.UserUUID, authResult.Token, authResult.TokenExp)
+		if authResult.IsNewUser || authResult.IsTokenRenewed {
+			res = c.authorization(res, authResult.AuthString, authResult.TokenExp)
 		}
 
 		res.Header().Set("content-type", "text/plain; charset=utf-8")
 		ctx := context.WithValue(req.Context(), AppContext.KeyContext, authResult.UserUUID)
+		if !authResult.IsNewUser {
+			ctx = context.WithValue(ctx, AppContext.KeyAuthenticatedUser, authResult.UserUUID)
+		}
 
 		reqWithContext := req.WithContext(ctx)
 		next.ServeHTTP(res, reqWithContext)
 	})
 }
 
-func (c *CheckAuth) authorization(res http.ResponseWriter, userUUID string, token string, tokenExp time.Time) http.ResponseWriter {
-	tokenValue := fmt.Sprintf("%s:%s", token, userUUID)
-	http.SetCookie(res, &http.Cookie{
-		Name:    auntificator.CookieAuthName,
-		Value:   tokenValue,
-		Expires: tokenExp,
-		Secure:  false,
-		Path:    "/",
-	})
-	res.Header().Set("Authorization", tokenValue)
+func (c *CheckAuth) authorization(res http.ResponseWriter, authString string, tokenExp time.Time) http.ResponseWriter {
+	auntificator.SetAuthorization(res, authString, tokenExp)
 	return res
 }
-
-func (c *CheckAuth) createUser(userUUID string) {
-	_, err := c.userCreator.CreateUser(models.User{
-		Name:     "test_user",
-		UUID:     userUUID,
-		Login:    "test_user" + userUUID,
-		Password: "password",
-	})
-	if err != nil {
-		logger.LogSugar.Errorf("Failed to create user: %v", err)
-		return
-	}
-}
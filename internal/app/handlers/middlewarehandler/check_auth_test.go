@@ -1,13 +1,15 @@
 package middlewarehandler
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	AppContext "github.com/northmule/shorturl/internal/app/context"
 	"github.com/northmule/shorturl/internal/app/logger"
-	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,7 +19,7 @@ type MockUserCreator struct {
 	user models.User
 }
 
-func (m *MockUserCreator) CreateUser(user models.User) (int64, error) {
+func (m *MockUserCreator) CreateUser(ctx context.Context, user models.User) (int64, error) {
 	m.On("CreateUser", user).Return(int64(1), nil)
 	m.Called(user)
 	return 1, nil
@@ -27,8 +29,7 @@ func TestAccessVerificationUserUrls(t *testing.T) {
 
 	_ = logger.InitLogger("fatal")
 	userCreator := new(MockUserCreator)
-	session := storage.NewSessionStorage()
-	checkAuth := NewCheckAuth(userCreator, session)
+	checkAuth := NewCheckAuth(userCreator, auntificator.NewDefaultTokenManager(), nil)
 
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -66,8 +67,7 @@ func TestAccessVerificationUserUrls(t *testing.T) {
 func TestAuthEveryone_NewUser(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	userCreator := new(MockUserCreator)
-	session := storage.NewSessionStorage()
-	handler := NewCheckAuth(userCreator, session)
+	handler := NewCheckAuth(userCreator, auntificator.NewDefaultTokenManager(), nil)
 
 	req, err := http.NewRequest("GET", "/api/user/urls", nil)
 	if err != nil {
@@ -96,8 +96,7 @@ func TestAuthEveryone_NewUser(t *testing.T) {
 func TestAuthEveryone_TokenNoValid(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	userCreator := new(MockUserCreator)
-	session := storage.NewSessionStorage()
-	handler := NewCheckAuth(userCreator, session)
+	handler := NewCheckAuth(userCreator, auntificator.NewDefaultTokenManager(), nil)
 
 	req, err := http.NewRequest("GET", "/api/user/urls", nil)
 	if err != nil {
@@ -123,10 +122,24 @@ func TestAuthEveryone_TokenNoValid(t *testing.T) {
 	}
 }
 
-func TestCreateUser(t *testing.T) {
+func TestAuthEveryone_TokenExpired(t *testing.T) {
+	_ = logger.InitLogger("fatal")
 	userCreator := new(MockUserCreator)
-	session := storage.NewSessionStorage()
-	handler := NewCheckAuth(userCreator, session)
-	handler.createUser("1111-2222-33333-44444")
-	userCreator.AssertCalled(t, "CreateUser", mock.AnythingOfType("models.User"))
+	tokens := auntificator.NewTokenManager(auntificator.SigningKey{ID: "k1", Secret: "secret"}, nil, -time.Minute)
+	handler := NewCheckAuth(userCreator, tokens, nil)
+
+	token, _ := tokens.Generate("1111111-222222-33333-444444")
+	req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+	req.Header.Add("Authorization", token)
+	res := httptest.NewRecorder()
+
+	authHandler := handler.AuthEveryone(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected request to be rejected")
+	}))
+	authHandler.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, res.Code)
+	}
+	userCreator.AssertNotCalled(t, "CreateUser", mock.AnythingOfType("models.User"))
 }
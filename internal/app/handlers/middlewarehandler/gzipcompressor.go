@@ -10,9 +10,11 @@ import (
 
 // Ожидаемые типы.
 var expectedContentTypes = map[string]bool{
-	"application/json":   true,
-	"text/html":          true,
-	"application/x-gzip": true,
+	"application/json":     true,
+	"text/html":            true,
+	"application/x-gzip":   true,
+	"application/x-ndjson": true,
+	"text/csv":             true,
 }
 
 // MiddlewareGzipCompressor промежуточно по для сжатия.
@@ -0,0 +1,47 @@
+package middlewarehandler
+
+import (
+	"net/http"
+
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
+)
+
+// RateLimit ограничение частоты запросов.
+type RateLimit struct {
+	limiter        *ratelimit.Limiter
+	trustedProxies *auntificator.CheckTrustedSubnet
+}
+
+// NewRateLimit конструктор, без ограничителя запросы не ограничиваются.
+// Адрес клиента из X-Real-IP принимается только от прокси из сети trustedProxies.
+func NewRateLimit(limiter *ratelimit.Limiter, trustedProxies *auntificator.CheckTrustedSubnet) *RateLimit {
+	return &RateLimit{
+		limiter:        limiter,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// Limit ограничивает запросы группы маршрутов по пользователю или адресу клиента.
+// Пользователь учитывается, если перед ограничителем подключена авторизация и токен прошёл проверку.
+func (r *RateLimit) Limit(group ratelimit.Group) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if r.limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			userUUID, _ := req.Context().Value(AppContext.KeyAuthenticatedUser).(string)
+			key := ratelimit.Key(userUUID, RemoteIP(req, r.trustedProxies))
+			result := r.limiter.Allow(group, key)
+			if !result.Allowed {
+				logger.LogSugar.Infof("Превышен лимит запросов %s для %s", group, key)
+				res.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(result.RetryAfter))
+				http.Error(res, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+}
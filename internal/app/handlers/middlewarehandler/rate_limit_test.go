@@ -0,0 +1,86 @@
+package middlewarehandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/northmule/shorturl/config"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_Limit(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Group]ratelimit.Limit{
+		ratelimit.GroupShorten: {Rate: 0.5, Burst: 1},
+	})
+	// Запросы httptest приходят с 192.0.2.1, адрес из X-Real-IP принимается как от прокси
+	trustedProxies := auntificator.NewTrustedSubnet(&config.Config{TrustedSubnet: "192.0.2.0/24"})
+	handler := NewRateLimit(limiter, trustedProxies).Limit(ratelimit.GroupShorten)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		ip         string
+		userUUID   string
+		code       int
+		retryAfter string
+	}{
+		{name: "#1_первый_запрос", ip: "10.0.0.1", code: http.StatusOK},
+		{name: "#2_лимит_адреса", ip: "10.0.0.1", code: http.StatusTooManyRequests, retryAfter: "2"},
+		{name: "#3_пользователь_с_того_же_адреса", ip: "10.0.0.1", userUUID: "uuid-1", code: http.StatusOK},
+		{name: "#4_лимит_пользователя", ip: "10.0.0.2", userUUID: "uuid-1", code: http.StatusTooManyRequests, retryAfter: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+			req.Header.Set("X-Real-IP", tt.ip)
+			if tt.userUUID != "" {
+				req = req.WithContext(context.WithValue(req.Context(), AppContext.KeyAuthenticatedUser, tt.userUUID))
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			assert.Equal(t, tt.code, res.Code)
+			assert.Equal(t, tt.retryAfter, res.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestRateLimit_WithoutLimiter(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := NewRateLimit(nil, nil).Limit(ratelimit.GroupRedirect)(next)
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/abc", nil))
+		assert.Equal(t, http.StatusOK, res.Code)
+	}
+}
+
+func TestRateLimit_UntrustedXRealIP(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Group]ratelimit.Limit{
+		ratelimit.GroupShorten: {Rate: 0.5, Burst: 1},
+	})
+	trustedProxies := auntificator.NewTrustedSubnet(&config.Config{TrustedSubnet: "10.10.0.0/16"})
+	handler := NewRateLimit(limiter, trustedProxies).Limit(ratelimit.GroupShorten)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Смена X-Real-IP не обходит лимит, если запрос пришёл не от доверенного прокси
+	for i, code := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		req.Header.Set("X-Real-IP", fmt.Sprintf("10.0.0.%d", i+1))
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		assert.Equal(t, code, res.Code)
+	}
+}
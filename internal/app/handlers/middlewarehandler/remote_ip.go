@@ -0,0 +1,24 @@
+package middlewarehandler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+)
+
+// RemoteIP адрес клиента. X-Real-IP учитывается только от прокси из доверенной сети,
+// иначе клиент подменил бы заголовком свой адрес, например чтобы обойти ограничение частоты запросов.
+func RemoteIP(req *http.Request, trustedProxies *auntificator.CheckTrustedSubnet) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	if trustedProxies == nil || trustedProxies.GrantAccess(host) != nil {
+		return host
+	}
+	if ip := req.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	return host
+}
@@ -0,0 +1,38 @@
+package middlewarehandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteIP(t *testing.T) {
+	trustedProxies := auntificator.NewTrustedSubnet(&config.Config{TrustedSubnet: "192.168.1.0/24"})
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		realIP         string
+		trustedProxies *auntificator.CheckTrustedSubnet
+		want           string
+	}{
+		{name: "#1_доверенный_прокси", remoteAddr: "192.168.1.10:5000", realIP: "10.0.0.1", trustedProxies: trustedProxies, want: "10.0.0.1"},
+		{name: "#2_прокси_без_заголовка", remoteAddr: "192.168.1.10:5000", trustedProxies: trustedProxies, want: "192.168.1.10"},
+		{name: "#3_клиент_не_из_доверенной_сети", remoteAddr: "10.0.0.2:5000", realIP: "10.0.0.1", trustedProxies: trustedProxies, want: "10.0.0.2"},
+		{name: "#4_доверенная_сеть_не_задана", remoteAddr: "192.168.1.10:5000", realIP: "10.0.0.1", trustedProxies: auntificator.NewTrustedSubnet(&config.Config{}), want: "192.168.1.10"},
+		{name: "#5_без_проверки_прокси", remoteAddr: "192.168.1.10:5000", realIP: "10.0.0.1", want: "192.168.1.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, RemoteIP(req, tt.trustedProxies))
+		})
+	}
+}
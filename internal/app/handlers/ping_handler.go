@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/northmule/shorturl/internal/app/logger"
@@ -21,7 +22,7 @@ func NewPingHandler(pinger Pinger) *PingHandler {
 // Pinger Интерфейс првоерки соединения.
 type Pinger interface {
 	// Ping проверка соединения с БД.
-	Ping() error
+	Ping(ctx context.Context) error
 }
 
 // CheckStorageConnect обработка запроса проверки соединения с БД /ping.
@@ -30,7 +31,7 @@ type Pinger interface {
 // @Failure 500 {string} string "Не удалось подключиться к БД"
 // @Router /ping [get]
 func (p *PingHandler) CheckStorageConnect(res http.ResponseWriter, req *http.Request) {
-	err := p.pinger.Ping()
+	err := p.pinger.Ping(req.Context())
 	if err != nil {
 		http.Error(res, "no connect db", http.StatusInternalServerError)
 		logger.LogSugar.Errorf("CheckStorageConnect Не удалось подключиться к БД %s", err)
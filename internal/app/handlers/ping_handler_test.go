@@ -12,82 +12,11 @@ import (
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
-	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/northmule/shorturl/internal/app/storage/mocks"
 	"github.com/northmule/shorturl/internal/app/workers"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 )
 
-type MockPostgresStorageOk struct {
-	mock.Mock
-}
-
-func (m *MockPostgresStorageOk) Add(url models.URL) (int64, error) {
-	return 0, nil
-}
-func (m *MockPostgresStorageOk) FindByShortURL(shortURL string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageOk) FindByURL(url string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageOk) Ping() error {
-	return nil
-}
-func (m *MockPostgresStorageOk) MultiAdd(url []models.URL) error {
-	return nil
-}
-func (m *MockPostgresStorageOk) CreateUser(user models.User) (int64, error) {
-	return 0, nil
-}
-
-func (m *MockPostgresStorageOk) LikeURLToUser(urlID int64, userUUID string) error {
-	return nil
-}
-
-func (m *MockPostgresStorageOk) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	return nil, nil
-}
-
-func (m *MockPostgresStorageOk) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
-	return nil
-}
-
-type MockPostgresStorageBad struct {
-	mock.Mock
-}
-
-func (m *MockPostgresStorageBad) Add(url models.URL) (int64, error) {
-	return 0, nil
-}
-func (m *MockPostgresStorageBad) FindByShortURL(shortURL string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageBad) FindByURL(url string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageBad) Ping() error {
-	args := m.Called()
-	return args.Error(0)
-}
-func (m *MockPostgresStorageBad) MultiAdd(url []models.URL) error {
-	return nil
-}
-func (m *MockPostgresStorageBad) CreateUser(user models.User) (int64, error) {
-	return 0, nil
-}
-
-func (m *MockPostgresStorageBad) LikeURLToUser(urlID int64, userUUID string) error {
-	return nil
-}
-
-func (m *MockPostgresStorageBad) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	return nil, nil
-}
-
-func (m *MockPostgresStorageBad) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
-	return nil
-}
-
 func TestPingHandler_CheckStorageConnect(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 
@@ -97,8 +26,9 @@ func TestPingHandler_CheckStorageConnect(t *testing.T) {
 	}
 	defer os.Remove(file.Name())
 
-	postgresStorage := new(MockPostgresStorageOk)
-	postgresStorage.On("Ping").Return("Ok")
+	ctrl := gomock.NewController(t)
+	postgresStorage := mocks.NewMockStorageQuery(ctrl)
+	postgresStorage.EXPECT().Ping(gomock.Any()).Return(nil)
 
 	tests := []struct {
 		name     string
@@ -161,8 +91,8 @@ func TestPingHandler_CheckStorageConnect(t *testing.T) {
 	}
 
 	t.Run("Возврат_ошибки_подключения", func(t *testing.T) {
-		mockStorage := new(MockPostgresStorageBad)
-		mockStorage.On("Ping").Return(errors.New("bad test request"))
+		mockStorage := mocks.NewMockStorageQuery(ctrl)
+		mockStorage.EXPECT().Ping(gomock.Any()).Return(errors.New("bad test request"))
 		sessionStorage := storage.NewSessionStorage()
 		shortURLService := url.NewShortURLService(mockStorage, mockStorage)
 		stop := make(chan struct{})
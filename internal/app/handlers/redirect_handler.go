@@ -1,28 +1,46 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/northmule/shorturl/internal/app/handlers/middlewarehandler"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage/models"
 )
 
 // RedirectHandler хэндлер для обработки коротких ссылок.
 type RedirectHandler struct {
-	service *url.ShortURLService
+	service        *url.ShortURLService
+	clickRecorder  ClickRecorder
+	trustedProxies *auntificator.CheckTrustedSubnet
+}
+
+// ClickRecorder сохранение переходов по коротким ссылкам.
+type ClickRecorder interface {
+	AddClick(ctx context.Context, click models.Click) error
 }
 
 // NewRedirectHandler конструктор хэндлера.
-func NewRedirectHandler(urlService *url.ShortURLService) RedirectHandler {
+func NewRedirectHandler(urlService *url.ShortURLService, clickRecorder ClickRecorder, trustedProxies *auntificator.CheckTrustedSubnet) RedirectHandler {
 	redirectHandler := &RedirectHandler{
-		service: urlService,
+		service:        urlService,
+		clickRecorder:  clickRecorder,
+		trustedProxies: trustedProxies,
 	}
 	return *redirectHandler
 }
 
 // RedirectHandler обработчик получения оригинальной ссылки из короткой.
 // @Summary Преобразование короткой ссылки в оригинальную с переходом по ссылке
+// @Failure 404
 // @Failure 410
+// @Failure 500
 // @Success 307 {string} Location "origin_url"
 // @Router /{id} [get]
 func (r *RedirectHandler) RedirectHandler(res http.ResponseWriter, req *http.Request) {
@@ -31,17 +49,41 @@ func (r *RedirectHandler) RedirectHandler(res http.ResponseWriter, req *http.Req
 		http.Error(res, "expected id value", http.StatusBadRequest)
 		return
 	}
-	modelURL, err := r.service.EncodeShortURL(id)
-	if err != nil {
+	modelURL, err := r.service.EncodeShortURL(req.Context(), id)
+	switch {
+	case errors.Is(err, url.ErrShortURLNotFound):
 		http.Error(res, err.Error(), http.StatusNotFound)
 		return
-	}
-	res.Header().Set("content-type", "text/plain")
-	if modelURL.DeletedAt.IsZero() {
-		res.Header().Set("Location", modelURL.URL)
-		res.WriteHeader(http.StatusTemporaryRedirect)
-	} else {
+	case errors.Is(err, url.ErrShortURLGone):
+		res.Header().Set("content-type", "text/plain")
 		res.WriteHeader(http.StatusGone)
+		return
+	case err != nil:
+		logger.LogSugar.Errorf("Не удалось получить ссылку %s: %s", id, err)
+		http.Error(res, "error get url", http.StatusInternalServerError)
+		return
 	}
+	r.recordClick(id, req)
+	res.Header().Set("content-type", "text/plain")
+	// Владелец может сменить URL ссылки, поэтому переход не должен кешироваться без проверки
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Location", modelURL.URL)
+	res.WriteHeader(http.StatusTemporaryRedirect)
+}
 
+// recordClick сохраняет переход, ошибка сохранения не мешает переходу по ссылке.
+func (r *RedirectHandler) recordClick(shortURL string, req *http.Request) {
+	if r.clickRecorder == nil {
+		return
+	}
+	err := r.clickRecorder.AddClick(req.Context(), models.Click{
+		ShortURL:  shortURL,
+		CreatedAt: time.Now().UTC(),
+		Referer:   req.Referer(),
+		UserAgent: req.UserAgent(),
+		RemoteIP:  middlewarehandler.RemoteIP(req, r.trustedProxies),
+	})
+	if err != nil {
+		logger.LogSugar.Errorf("Не удалось сохранить переход по ссылке %s: %s", shortURL, err)
+	}
 }
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/services/url"
@@ -23,6 +25,7 @@ func TestRedirectHandler(t *testing.T) {
 	defer func() {
 		stop <- struct{}{}
 	}()
+	_, _ = memoryStorage.Add(context.Background(), models.URL{ShortURL: "e98192e19505472476a49f10388428ab", URL: "https://ya.ru"})
 	ts := httptest.NewServer(NewRoutes(shortURLService, storage.NewMemoryStorage(), storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
 
 	defer ts.Close()
@@ -99,6 +102,9 @@ func TestRedirectHandler(t *testing.T) {
 			if tt.want.location != location {
 				t.Errorf("Ошибка в значение body. Ожидается %#v пришло %#v", tt.want.code, response.StatusCode)
 			}
+			if response.StatusCode == http.StatusTemporaryRedirect && response.Header.Get("Cache-Control") != "no-cache" {
+				t.Errorf("Переход не должен кешироваться, пришло Cache-Control %#v", response.Header.Get("Cache-Control"))
+			}
 		})
 	}
 }
@@ -114,15 +120,15 @@ func TestRedirectHandler_StatusGone(t *testing.T) {
 	ts := httptest.NewServer(NewRoutes(shortURLService, storage.NewMemoryStorage(), storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
 	defer ts.Close()
 	// необходимые данные
-	identy, _ := memoryStorage.Add(models.URL{
+	identy, _ := memoryStorage.Add(context.Background(), models.URL{
 		ShortURL: "ttt",
 		URL:      "https://ya.ru",
 	})
-	_, _ = memoryStorage.CreateUser(models.User{
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{
 		UUID: "1111-2222-3333",
 	})
-	_ = memoryStorage.LikeURLToUser(identy, "1111-2222-3333")
-	_ = memoryStorage.SoftDeletedShortURL("1111-2222-3333", "ttt")
+	_ = memoryStorage.LikeURLToUser(context.Background(), identy, "1111-2222-3333")
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), "1111-2222-3333", "ttt")
 
 	request, err := http.NewRequest(http.MethodGet, ts.URL+"/ttt", nil)
 
@@ -142,6 +148,95 @@ func TestRedirectHandler_StatusGone(t *testing.T) {
 
 }
 
+func TestRedirectHandler_Expired(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, memoryStorage, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
+	defer ts.Close()
+	ts.Client().CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	_, _ = memoryStorage.Add(context.Background(), models.URL{
+		ShortURL:  "expired",
+		URL:       "https://ya.ru/expired",
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+	_, _ = memoryStorage.Add(context.Background(), models.URL{
+		ShortURL:  "active",
+		URL:       "https://ya.ru/active",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	tests := []struct {
+		name string
+		id   string
+		code int
+	}{
+		{name: "срок_жизни_истёк", id: "expired", code: http.StatusGone},
+		{name: "срок_жизни_не_истёк", id: "active", code: http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := ts.Client().Get(ts.URL + "/" + tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			if tt.code != response.StatusCode {
+				t.Errorf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.code, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_RecordClick(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, memoryStorage, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
+	defer ts.Close()
+	ts.Client().CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	_, _ = memoryStorage.Add(context.Background(), models.URL{ShortURL: "clicked", URL: "https://ya.ru/clicked"})
+	_, _ = memoryStorage.Add(context.Background(), models.URL{ShortURL: "gone", URL: "https://ya.ru/gone", ExpiresAt: time.Now().Add(-time.Second)})
+
+	for _, id := range []string{"clicked", "clicked", "gone", "unknown"} {
+		request, err := http.NewRequest(http.MethodGet, ts.URL+"/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Referer", "https://example.com/page")
+		request.Header.Set("User-Agent", "test-agent")
+		request.Header.Set("X-Real-IP", "10.0.0.1")
+		response, err := ts.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+
+	stats, _ := memoryStorage.GetClickStats(context.Background(), "clicked")
+	if stats.Total != 2 {
+		t.Errorf("Не верное количество переходов. Ожидается %d пришло %d", 2, stats.Total)
+	}
+	stats, _ = memoryStorage.GetClickStats(context.Background(), "gone")
+	if stats.Total != 0 {
+		t.Errorf("Переход по удалённой ссылке не должен учитываться, пришло %d", stats.Total)
+	}
+}
+
 func TestRedirectHandler_StatusBadRequest(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	memoryStorage := storage.NewMemoryStorage()
@@ -158,7 +253,7 @@ func TestRedirectHandler_StatusBadRequest(t *testing.T) {
 		t.Error(err)
 	}
 	res := httptest.NewRecorder()
-	h := NewRedirectHandler(shortURLService)
+	h := NewRedirectHandler(shortURLService, memoryStorage, nil)
 	h.RedirectHandler(res, req)
 
 	if http.StatusBadRequest != res.Code {
@@ -199,7 +294,7 @@ func BenchmarkRedirectHandler(b *testing.B) {
 
 	b.Run("короткая_ссылка_существует", func(b *testing.B) {
 		shortURL := "e98192e19505472476a49f10388428ab"
-		memoryStorage.Add(models.URL{
+		memoryStorage.Add(context.Background(), models.URL{
 			ShortURL: shortURL,
 			URL:      "https://ya.ru/123",
 		})
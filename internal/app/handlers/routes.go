@@ -8,6 +8,9 @@ import (
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/handlers/middlewarehandler"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/workers"
@@ -20,7 +23,12 @@ type Routes struct {
 	worker          *workers.Worker
 	storage         storage.StorageQuery
 	finderStats     StatsFinder
+	purgeCounter    PurgeCounter
 	configApp       *config.Config
+	clickRecorder   ClickRecorder
+	tokens          *auntificator.TokenManager
+	rateLimiter     *ratelimit.Limiter
+	importer        *importer.Importer
 }
 
 // todo: поменять на RoutesBuilder
@@ -43,29 +51,65 @@ func (routes *Routes) Init() chi.Router {
 		w.Write([]byte("method not expect\n"))
 	})
 
-	checkAuth := middlewarehandler.NewCheckAuth(routes.storage, routes.sessionStorage)
+	tokens := routes.tokens
+	if tokens == nil {
+		tokens = auntificator.NewDefaultTokenManager()
+	}
+	apiKeys := auntificator.NewAPIKeys(routes.storage)
+	checkAuth := middlewarehandler.NewCheckAuth(routes.storage, tokens, apiKeys)
 	checkTrustedSubnet := middlewarehandler.NewCheckTrustedSubnet(routes.configApp)
+	// Адрес клиента из заголовков принимается только от прокси из доверенной сети
+	trustedProxies := auntificator.NewTrustedSubnet(routes.configApp)
+	rateLimit := middlewarehandler.NewRateLimit(routes.rateLimiter, trustedProxies)
 
 	r.Use(middleware.RequestLogger(logger.LogSugar))
 	r.Use(middlewarehandler.MiddlewareGzipCompressor)
 
-	shortenerHandler := NewShortenerHandler(routes.shortURLService, routes.storage, routes.storage)
-	redirectHandler := NewRedirectHandler(routes.shortURLService)
+	shortenerHandler := NewShortenerHandler(routes.shortURLService)
+	clickRecorder := routes.clickRecorder
+	if clickRecorder == nil {
+		clickRecorder = routes.storage
+	}
+	redirectHandler := NewRedirectHandler(routes.shortURLService, clickRecorder, trustedProxies)
 	pingHandler := NewPingHandler(routes.storage)
 
-	userUrlsHandler := NewUserUrlsHandler(routes.storage, routes.sessionStorage, routes.worker)
+	userUrlsHandler := NewUserUrlsHandler(routes.storage, routes.sessionStorage, routes.worker, routes.shortURLService, routes.storage)
 
-	statsHandler := NewStatsHandler(routes.finderStats)
+	urlStatsHandler := NewURLStatsHandler(routes.storage, routes.storage)
+	accountHandler := NewAccountHandler(auntificator.NewAccount(routes.storage, tokens))
+
+	statsHandler := NewStatsHandler(routes.finderStats, routes.purgeCounter)
+	apiKeyHandler := NewAPIKeyHandler(apiKeys)
+	urlImporter := routes.importer
+	if urlImporter == nil {
+		urlImporter = importer.NewImporter(routes.storage, routes.shortURLService, importer.DefaultChunkSize)
+	}
+	importHandler := NewImportHandler(urlImporter)
+	exportHandler := NewExportHandler(routes.storage)
 
 	r.With(
 		checkAuth.AuthEveryone,
+		rateLimit.Limit(ratelimit.GroupShorten),
 	).Post("/", shortenerHandler.ShortenerHandler)
-	r.Get("/{id}", redirectHandler.RedirectHandler)
+	r.With(
+		rateLimit.Limit(ratelimit.GroupRedirect),
+	).Get("/{id}", redirectHandler.RedirectHandler)
 	r.With(
 		checkAuth.AuthEveryone,
+		rateLimit.Limit(ratelimit.GroupShorten),
 	).Post("/api/shorten", shortenerHandler.ShortenerJSONHandler)
 	r.Get("/ping", pingHandler.CheckStorageConnect)
-	r.Post("/api/shorten/batch", shortenerHandler.ShortenerBatch)
+	r.With(
+		checkAuth.AuthEveryone,
+		rateLimit.Limit(ratelimit.GroupBatch),
+	).Post("/api/shorten/batch", shortenerHandler.ShortenerBatch)
+	r.With(
+		checkAuth.AuthEveryone,
+		rateLimit.Limit(ratelimit.GroupBatch),
+	).Post("/api/shorten/import", importHandler.Import)
+	r.With(
+		checkAuth.AuthEveryone,
+	).Get("/api/shorten/import/{id}", importHandler.View)
 
 	r.With(
 		checkAuth.AccessVerificationUserUrls,
@@ -77,9 +121,40 @@ func (routes *Routes) Init() chi.Router {
 		checkAuth.AuthEveryone,
 	).Delete("/api/user/urls", userUrlsHandler.Delete)
 
+	r.With(
+		checkAuth.AccessVerificationUserUrls,
+		checkAuth.AuthEveryone,
+	).Post("/api/user/urls/restore", userUrlsHandler.Restore)
+
+	r.With(
+		checkAuth.AccessVerificationUserUrls,
+		checkAuth.AuthEveryone,
+	).Patch("/api/user/urls/{short}", userUrlsHandler.Update)
+
+	r.With(
+		checkAuth.AccessVerificationUserUrls,
+		checkAuth.AuthEveryone,
+	).Get("/api/user/urls/{short}/stats", urlStatsHandler.View)
+
+	r.With(
+		checkAuth.AccessVerificationUserUrls,
+		checkAuth.AuthEveryone,
+	).Get("/api/user/urls/export", exportHandler.Export)
+
+	r.With(
+		checkAuth.AuthEveryone,
+	).Post("/api/user/register", accountHandler.Register)
+	r.Post("/api/user/login", accountHandler.Login)
+
 	r.With(
 		checkTrustedSubnet.GrantAccess,
 	).Get("/api/internal/stats", statsHandler.ViewStats)
+	r.With(
+		checkTrustedSubnet.GrantAccess,
+	).Post("/api/internal/api-keys", apiKeyHandler.Mint)
+	r.With(
+		checkTrustedSubnet.GrantAccess,
+	).Delete("/api/internal/api-keys/{id}", apiKeyHandler.Revoke)
 
 	return r
 }
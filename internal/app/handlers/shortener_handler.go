@@ -1,56 +1,38 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/northmule/shorturl/config"
-	"github.com/northmule/shorturl/internal/app/context"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/policy"
 	"github.com/northmule/shorturl/internal/app/services/url"
-	"github.com/northmule/shorturl/internal/app/storage"
-	"github.com/northmule/shorturl/internal/app/storage/models"
 )
 
 // ShortenerHandler хэндлер сокращения ссылок.
 type ShortenerHandler struct {
 	service *url.ShortURLService
-	finder  Finder
-	setter  LikeURLToUserSetter
-}
-
-// Finder поиск значений.
-type Finder interface {
-	// FindUrlsByUserID поиск ссылок пользователя
-	FindUrlsByUserID(userUUID string) (*[]models.URL, error)
-	// FindByShortURL поиск по короткой ссылке.
-	FindByShortURL(shortURL string) (*models.URL, error)
-	// FindByURL поиск по URL.
-	FindByURL(url string) (*models.URL, error)
 }
 
 // NewShortenerHandler конструктор.
-func NewShortenerHandler(urlService *url.ShortURLService, finder Finder, setter LikeURLToUserSetter) ShortenerHandler {
+func NewShortenerHandler(urlService *url.ShortURLService) ShortenerHandler {
 	shortenerHandler := &ShortenerHandler{
 		service: urlService,
-		finder:  finder,
-		setter:  setter,
 	}
 	return *shortenerHandler
 }
 
-// LikeURLToUserSetter интерфейс связывания URL с пользователем.
-type LikeURLToUserSetter interface {
-	LikeURLToUser(urlID int64, userUUID string) error
-}
-
 // ShortenerHandler обработчик создания короткой ссылки.
 // @Summary Получение короткой ссылки
 // @Failure 400
+// @Failure 422
 // @Success 307 {string} Location "origin_url"
 // @Param url body string true "оригинальная ссылка для сокращения" SchemaExample(https://ya.ru/1)
 // @Router / [post]
@@ -62,9 +44,9 @@ func (s *ShortenerHandler) ShortenerHandler(res http.ResponseWriter, req *http.R
 	}
 	defer req.Body.Close()
 
-	// Проверяем, содержится ли в bodyValue URL.
-	bodyString := string(bodyValue)
-	if !strings.Contains(bodyString, "http://") && !strings.Contains(bodyString, "https://") {
+	// Проверяем, что в bodyValue корректный URL.
+	originalURL, err := s.service.NormalizeURL(string(bodyValue))
+	if err != nil {
 		http.Error(res, "expected url", http.StatusBadRequest)
 		return
 	}
@@ -75,13 +57,17 @@ func (s *ShortenerHandler) ShortenerHandler(res http.ResponseWriter, req *http.R
 		headerStatus int
 		shortURL     string
 	)
-	userIDAny := req.Context().Value(context.KeyContext)
+	userIDAny := req.Context().Value(AppContext.KeyContext)
 	var userUUID string
 	if id, ok := userIDAny.(string); ok {
 		userUUID = id
 	}
-	shortURL, headerStatus, err = s.fillShortURLAndResponseStatus(userUUID, string(bodyValue))
+	shortURL, headerStatus, err = s.fillShortURLAndResponseStatus(req.Context(), userUUID, originalURL, url.DecodeOptions{})
 	if err != nil {
+		if errors.Is(err, policy.ErrDestinationDenied) {
+			http.Error(res, err.Error(), headerStatus)
+			return
+		}
 		http.Error(res, "error find model", headerStatus)
 		return
 	}
@@ -98,6 +84,12 @@ func (s *ShortenerHandler) ShortenerHandler(res http.ResponseWriter, req *http.R
 // ShortenerRequest запрос к методу ShortenerJSONHandler.
 type ShortenerRequest struct {
 	URL string `json:"URL"`
+	// Alias желаемый код короткой ссылки (необязательный)
+	Alias string `json:"alias,omitempty"`
+	// ExpiresAt время, после которого ссылка перестанет работать (необязательный)
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// TTLSeconds время жизни ссылки в секундах (необязательный)
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
 }
 
 // JSONResponse ответ от метода ShortenerJSONHandler.
@@ -108,6 +100,8 @@ type JSONResponse struct {
 // ShortenerJSONHandler принимает и отдаёт json.
 // @Summary Получение коротких ссылок
 // @Failure 400
+// @Failure 409
+// @Failure 422
 // @Success 200 {object} JSONResponse
 // @Param ShortenerJSONHandler body ShortenerRequest true "объект с сылками для сокращения"
 // @Router /api/shorten [post]
@@ -127,12 +121,26 @@ func (s *ShortenerHandler) ShortenerJSONHandler(res http.ResponseWriter, req *ht
 		return
 	}
 
-	// Проверяем, содержится ли в bodyValue URL
-	if !strings.Contains(shortenerRequest.URL, "http://") && !strings.Contains(shortenerRequest.URL, "https://") {
+	// Проверяем, что в запросе корректный URL
+	originalURL, err := s.service.NormalizeURL(shortenerRequest.URL)
+	if err != nil {
 		http.Error(res, "expected url", http.StatusBadRequest)
 		return
 	}
 
+	if shortenerRequest.Alias != "" {
+		if err = url.ValidateAlias(shortenerRequest.Alias); err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	expiresAt, err := url.ResolveExpiresAt(shortenerRequest.ExpiresAt, shortenerRequest.TTLSeconds)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	res.Header().Set("content-type", "application/json")
 
 	var (
@@ -140,13 +148,20 @@ func (s *ShortenerHandler) ShortenerJSONHandler(res http.ResponseWriter, req *ht
 		headerStatus int
 		shortURL     string
 	)
-	userIDAny := req.Context().Value(context.KeyContext)
+	userIDAny := req.Context().Value(AppContext.KeyContext)
 	var userUUID string
 	if id, ok := userIDAny.(string); ok {
 		userUUID = id
 	}
-	shortURL, headerStatus, err = s.fillShortURLAndResponseStatus(userUUID, shortenerRequest.URL)
+	shortURL, headerStatus, err = s.fillShortURLAndResponseStatus(req.Context(), userUUID, originalURL, url.DecodeOptions{
+		Alias:     shortenerRequest.Alias,
+		ExpiresAt: expiresAt,
+	})
 	if err != nil {
+		if errors.Is(err, url.ErrAliasExists) || errors.Is(err, policy.ErrDestinationDenied) {
+			http.Error(res, err.Error(), headerStatus)
+			return
+		}
 		http.Error(res, "error find model", headerStatus)
 		return
 	}
@@ -173,19 +188,30 @@ func (s *ShortenerHandler) ShortenerJSONHandler(res http.ResponseWriter, req *ht
 type BatchRequest struct {
 	CorrelationID string `json:"correlation_id"`
 	OriginalURL   string `json:"original_url"`
+	// ExpiresAt время, после которого ссылка перестанет работать (необязательный)
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// TTLSeconds время жизни ссылки в секундах (необязательный)
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
 }
 
 // BatchResponse ответ для списка адресов.
 type BatchResponse struct {
 	CorrelationID string `json:"correlation_id"`
 	ShortURL      string `json:"short_url"`
+	// Existing ссылка была сокращена ранее
+	Existing bool `json:"existing,omitempty"`
+	// Error ошибка проверки элемента, короткая ссылка для него не создаётся
+	Error string `json:"error,omitempty"`
 }
 
 // ShortenerBatch обработка списка адресов.
+// Ответ содержит элементы в порядке запроса, ошибки отдельных элементов передаются в поле error.
 // @Summary Получение коротких ссылок
 // @Failure 400
-// @Success 200 {object} BatchResponse
-// @Param ShortenerBatch body BatchRequest true "объект с сылками для сокращения"
+// @Failure 500
+// @Success 201 {array} BatchResponse "создана хотя бы одна ссылка"
+// @Success 200 {array} BatchResponse "новых ссылок не создано"
+// @Param ShortenerBatch body []BatchRequest true "объект с сылками для сокращения"
 // @Router /api/shorten/batch [post]
 func (s *ShortenerHandler) ShortenerBatch(res http.ResponseWriter, req *http.Request) {
 
@@ -202,34 +228,46 @@ func (s *ShortenerHandler) ShortenerBatch(res http.ResponseWriter, req *http.Req
 		http.Error(res, "error unmarshal json request", http.StatusBadRequest)
 		return
 	}
-	urls := make([]string, 0)
+	if len(requestItems) == 0 {
+		http.Error(res, "expected items", http.StatusBadRequest)
+		return
+	}
+	items := make([]url.BatchItem, 0, len(requestItems))
 	for _, requestItem := range requestItems {
-		if !strings.Contains(requestItem.OriginalURL, "http://") && !strings.Contains(requestItem.OriginalURL, "https://") {
-			continue
-		}
-		urls = append(urls, requestItem.OriginalURL)
+		items = append(items, url.BatchItem{
+			URL:        requestItem.OriginalURL,
+			ExpiresAt:  requestItem.ExpiresAt,
+			TTLSeconds: requestItem.TTLSeconds,
+		})
 	}
-	if len(urls) == 0 {
-		http.Error(res, "error unmarshal json request", http.StatusBadRequest)
-		return
+	var userUUID string
+	if id, ok := req.Context().Value(AppContext.KeyContext).(string); ok {
+		userUUID = id
 	}
-	modelURLs, err := s.service.DecodeURLs(urls)
+	results, err := s.service.DecodeURLs(req.Context(), userUUID, items)
 	if err != nil {
-		http.Error(res, "error decode urls", http.StatusBadRequest)
+		http.Error(res, "error decode urls", http.StatusInternalServerError)
+		logger.LogSugar.Error(err)
 		return
 	}
-	res.Header().Set("content-type", "application/json")
 
+	headerStatus := http.StatusOK
 	responseItems := make([]BatchResponse, 0, len(requestItems))
-	for _, requestItem := range requestItems {
-		for _, modelURL := range modelURLs {
-			if requestItem.OriginalURL == modelURL.URL {
-				responseItems = append(responseItems, BatchResponse{
-					CorrelationID: requestItem.CorrelationID,
-					ShortURL:      fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, modelURL.ShortURL),
-				})
-			}
+	for i, result := range results {
+		responseItem := BatchResponse{
+			CorrelationID: requestItems[i].CorrelationID,
+		}
+		if result.Err != nil {
+			responseItem.Error = result.Err.Error()
+			responseItems = append(responseItems, responseItem)
+			continue
+		}
+		responseItem.ShortURL = fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, result.ShortURL)
+		responseItem.Existing = result.Existing
+		if !result.Existing {
+			headerStatus = http.StatusCreated
 		}
+		responseItems = append(responseItems, responseItem)
 	}
 
 	responseString, err := json.Marshal(responseItems)
@@ -238,7 +276,8 @@ func (s *ShortenerHandler) ShortenerBatch(res http.ResponseWriter, req *http.Req
 		return
 	}
 
-	res.WriteHeader(http.StatusCreated)
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(headerStatus)
 
 	_, err = res.Write(responseString)
 	if err != nil {
@@ -246,36 +285,21 @@ func (s *ShortenerHandler) ShortenerBatch(res http.ResponseWriter, req *http.Req
 		return
 	}
 }
-func (s *ShortenerHandler) fillShortURLAndResponseStatus(userUUID string, url string) (string, int, error) {
-	var (
-		headerStatus int
-		shortURL     string
-		isURLExists  bool
-	)
-	shortURLData, err := s.service.DecodeURL(url)
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == storage.CodeErrorDuplicateKey {
-			isURLExists = true
-		} else {
-			return "", http.StatusInternalServerError, err
-		}
-	}
-	if isURLExists {
-		modelURL, err := s.finder.FindByURL(url)
-		if err != nil {
-			return "", http.StatusInternalServerError, err
-		}
-		headerStatus = http.StatusConflict
-		shortURL = modelURL.ShortURL
-	} else {
-		headerStatus = http.StatusCreated
-		shortURL = shortURLData.ShortURL
-		err = s.setter.LikeURLToUser(shortURLData.URLID, userUUID)
-		if err != nil {
-			return "", 0, err
-		}
-	}
 
-	return shortURL, headerStatus, nil
+func (s *ShortenerHandler) fillShortURLAndResponseStatus(ctx context.Context, userUUID string, originalURL string, options url.DecodeOptions) (string, int, error) {
+	shortURLData, err := s.service.DecodeURLWithOptions(ctx, userUUID, originalURL, options)
+	switch {
+	case err == nil:
+		return shortURLData.ShortURL, http.StatusCreated, nil
+	case errors.Is(err, url.ErrURLExists):
+		return shortURLData.ShortURL, http.StatusConflict, nil
+	case errors.Is(err, url.ErrAliasExists):
+		return "", http.StatusConflict, err
+	case errors.Is(err, url.ErrAliasInvalid), errors.Is(err, url.ErrAliasReserved), errors.Is(err, url.ErrURLInvalid):
+		return "", http.StatusBadRequest, err
+	case errors.Is(err, policy.ErrDestinationDenied):
+		return "", http.StatusUnprocessableEntity, err
+	default:
+		return "", http.StatusInternalServerError, err
+	}
 }
@@ -3,16 +3,21 @@ package handlers
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/northmule/shorturl/cmd/client"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/policy"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/storage/models"
@@ -110,9 +115,9 @@ func TestShortenerHandler(t *testing.T) {
 			if tt.want.code != response.StatusCode {
 				t.Errorf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.want.code, response.StatusCode)
 			}
-			urlModel, _ := shortURLService.Finder.FindByURL(tt.request.body)
+			_, err = shortURLService.Finder.FindByURL(context.Background(), tt.request.body)
 
-			if tt.want.isError == (urlModel.URL != "") {
+			if tt.want.isError == (err == nil) {
 				t.Error("URL не найден")
 			}
 		})
@@ -131,7 +136,7 @@ func TestShortenerHandler_StatusBadRequest_BadBody(t *testing.T) {
 	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
 	defer ts.Close()
 
-	h := NewShortenerHandler(shortURLService, memoryStorage, memoryStorage)
+	h := NewShortenerHandler(shortURLService)
 
 	errBody := io.NopCloser(&errorReader{})
 	req, err := http.NewRequest(http.MethodPost, ts.URL+"/", errBody)
@@ -256,7 +261,7 @@ func TestShortenerJsonHandler(t *testing.T) {
 				t.Errorf("Ошибка разбора json ответа: %s", respBody)
 			}
 			jsonResponse.Result = strings.Trim(jsonResponse.Result, "/")
-			urlModel, _ := shortURLService.Finder.FindByShortURL(jsonResponse.Result)
+			urlModel, _ := shortURLService.Finder.FindByShortURL(context.Background(), jsonResponse.Result)
 
 			if tt.want.isError == (urlModel != nil) {
 				t.Error("URL не найден")
@@ -265,6 +270,282 @@ func TestShortenerJsonHandler(t *testing.T) {
 	}
 }
 
+func TestShortenerJsonHandler_Alias(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	store := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(store, store)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(store, stop)).Init())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		body     string
+		code     int
+		shortURL string
+	}{
+		{
+			name:     "#1_псевдоним_свободен",
+			body:     `{"url":"https://ya.ru/spring","alias":"spring-sale"}`,
+			code:     http.StatusCreated,
+			shortURL: "spring-sale",
+		},
+		{
+			name: "#2_псевдоним_занят_другой_ссылкой",
+			body: `{"url":"https://ya.ru/autumn","alias":"spring-sale"}`,
+			code: http.StatusConflict,
+		},
+		{
+			name: "#3_зарезервированный_путь",
+			body: `{"url":"https://ya.ru/ping","alias":"ping"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "#4_недопустимые_символы",
+			body: `{"url":"https://ya.ru/bad","alias":"bad alias!"}`,
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodPost, ts.URL+"/api/shorten", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Content-Type", "application/json")
+			response, err := ts.Client().Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+
+			if tt.code != response.StatusCode {
+				t.Errorf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.code, response.StatusCode)
+			}
+			if tt.shortURL == "" {
+				return
+			}
+			var jsonResponse JSONResponse
+			err = json.NewDecoder(response.Body).Decode(&jsonResponse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(jsonResponse.Result, "/"+tt.shortURL) {
+				t.Errorf("Ожидается короткая ссылка с кодом %s, пришло %s", tt.shortURL, jsonResponse.Result)
+			}
+		})
+	}
+}
+
+func TestShortenerJsonHandler_NormalizedURL(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	store := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(store, store)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(store, stop)).Init())
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{
+			name: "#1_новая_ссылка",
+			body: `{"url":"https://ya.ru/same"}`,
+			code: http.StatusCreated,
+		},
+		{
+			name: "#2_та_же_ссылка_в_другой_записи",
+			body: `{"url":"HTTPS://YA.RU:443/same#anchor"}`,
+			code: http.StatusConflict,
+		},
+	}
+
+	var results []string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodPost, ts.URL+"/api/shorten", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Accept-Encoding", "identity")
+			response, err := ts.Client().Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+
+			if tt.code != response.StatusCode {
+				t.Errorf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.code, response.StatusCode)
+			}
+			var jsonResponse JSONResponse
+			err = json.NewDecoder(response.Body).Decode(&jsonResponse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, jsonResponse.Result)
+		})
+	}
+	if len(results) != 2 || results[0] != results[1] {
+		t.Errorf("Ожидается одна короткая ссылка для одинаковых адресов, пришло %v", results)
+	}
+}
+
+func TestShortenerHandlers_Policy(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	store := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(store, store)
+	destinationPolicy, err := policy.NewPolicy(policy.Rules{Deny: []string{"evil.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortURLService.SetPolicy(destinationPolicy)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(store, stop)).Init())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{
+			name:        "#1_текстовый_запрос",
+			path:        "/",
+			contentType: "text/plain",
+			body:        "https://evil.com/login",
+			code:        http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "#2_json_запрос",
+			path:        "/api/shorten",
+			contentType: "application/json",
+			body:        `{"url":"https://EVIL.com/login"}`,
+			code:        http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "#3_пакетный_запрос",
+			path:        "/api/shorten/batch",
+			contentType: "application/json",
+			body:        `[{"correlation_id":"1","original_url":"https://evil.com/login"}]`,
+			code:        http.StatusOK,
+		},
+		{
+			name:        "#4_разрешённая_ссылка",
+			path:        "/api/shorten",
+			contentType: "application/json",
+			body:        `{"url":"https://ya.ru/login"}`,
+			code:        http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodPost, ts.URL+tt.path, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			request.Header.Set("Content-Type", tt.contentType)
+			request.Header.Set("Accept-Encoding", "identity")
+			response, err := ts.Client().Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+
+			if tt.code != response.StatusCode {
+				t.Errorf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.code, response.StatusCode)
+			}
+			if tt.code == http.StatusCreated {
+				return
+			}
+			body, err := io.ReadAll(response.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(body), "evil.com") {
+				t.Errorf("Ожидается правило в ответе, пришло %s", body)
+			}
+		})
+	}
+}
+
+func TestShortenerJsonHandler_Expiration(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	store := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(store, store)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(store, stop)).Init())
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{
+			name: "#1_ttl",
+			body: `{"url":"https://ya.ru/ttl","ttl_seconds":60}`,
+			code: http.StatusCreated,
+		},
+		{
+			name: "#2_время_окончания_в_будущем",
+			body: `{"url":"https://ya.ru/expires","expires_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+			code: http.StatusCreated,
+		},
+		{
+			name: "#3_время_окончания_в_прошлом",
+			body: `{"url":"https://ya.ru/past","expires_at":"` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "#4_отрицательный_ttl",
+			body: `{"url":"https://ya.ru/negative","ttl_seconds":-1}`,
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "#5_ttl_и_время_окончания_одновременно",
+			body: `{"url":"https://ya.ru/both","ttl_seconds":60,"expires_at":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := ts.Client().Post(ts.URL+"/api/shorten", "application/json", bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			if tt.code != response.StatusCode {
+				t.Errorf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.code, response.StatusCode)
+			}
+		})
+	}
+
+	modelURL, _ := store.FindByURL(context.Background(), "https://ya.ru/ttl")
+	if modelURL.ExpiresAt.IsZero() {
+		t.Error("Ожидается сохранённое время окончания жизни ссылки")
+	}
+}
+
 func TestShortenerJsonHandler_StatusBadRequest(t *testing.T) {
 
 	tests := []struct {
@@ -283,6 +564,14 @@ func TestShortenerJsonHandler_StatusBadRequest(t *testing.T) {
 			name: "bad_body_url",
 			body: bytes.NewBufferString("{\"URL\":\"ftp://\"}"),
 		},
+		{
+			name: "text_before_url",
+			body: bytes.NewBufferString("{\"URL\":\"foo http://ya.ru\"}"),
+		},
+		{
+			name: "url_without_scheme",
+			body: bytes.NewBufferString("{\"URL\":\"ya.ru/http://\"}"),
+		},
 	}
 	_ = logger.InitLogger("fatal")
 	memoryStorage := storage.NewMemoryStorage()
@@ -295,7 +584,7 @@ func TestShortenerJsonHandler_StatusBadRequest(t *testing.T) {
 	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
 	defer ts.Close()
 
-	h := NewShortenerHandler(shortURLService, memoryStorage, memoryStorage)
+	h := NewShortenerHandler(shortURLService)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req, err := http.NewRequest(http.MethodPost, ts.URL+"/", tt.body)
@@ -327,8 +616,8 @@ func TestShortenerBatch_StatusBadRequest(t *testing.T) {
 			body: bytes.NewBufferString("{\"bad\":\"ftp://\"}"),
 		},
 		{
-			name: "bad_body_url",
-			body: bytes.NewBufferString(`[{"correlation_id":"1","original_url":"sftp://ya.ru"}]`),
+			name: "empty_list",
+			body: bytes.NewBufferString(`[]`),
 		},
 	}
 	_ = logger.InitLogger("fatal")
@@ -342,7 +631,7 @@ func TestShortenerBatch_StatusBadRequest(t *testing.T) {
 	ts := httptest.NewServer(NewRoutes(shortURLService, store, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
 	defer ts.Close()
 
-	h := NewShortenerHandler(shortURLService, memoryStorage, memoryStorage)
+	h := NewShortenerHandler(shortURLService)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req, err := http.NewRequest(http.MethodPost, ts.URL+"/", tt.body)
@@ -359,6 +648,71 @@ func TestShortenerBatch_StatusBadRequest(t *testing.T) {
 	}
 }
 
+func TestShortenerBatch_Items(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	store := storage.NewMemoryStorage()
+	_, _ = store.Add(context.Background(), models.URL{ShortURL: "exist1", URL: "https://ya.ru/exist"})
+	shortURLService := url.NewShortURLService(store, store)
+	h := NewShortenerHandler(shortURLService)
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		want []BatchResponse
+	}{
+		{
+			name: "#1_существующая_и_не_валидная_ссылки",
+			body: `[{"correlation_id":"1","original_url":"https://ya.ru/exist"},{"correlation_id":"2","original_url":"sftp://ya.ru"}]`,
+			code: http.StatusOK,
+			want: []BatchResponse{
+				{CorrelationID: "1", ShortURL: "/exist1", Existing: true},
+				{CorrelationID: "2", Error: url.ErrURLInvalid.Error()},
+			},
+		},
+		{
+			name: "#2_новая_ссылка_и_дубли_correlation_id",
+			body: `[{"correlation_id":"3","original_url":"https://ya.ru/new"},{"correlation_id":"3","original_url":"https://ya.ru/new"},{"correlation_id":"4","original_url":"https://ya.ru/exist"}]`,
+			code: http.StatusCreated,
+			want: []BatchResponse{
+				{CorrelationID: "3"},
+				{CorrelationID: "3"},
+				{CorrelationID: "4", ShortURL: "/exist1", Existing: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewBufferString(tt.body))
+			res := httptest.NewRecorder()
+			h.ShortenerBatch(res, req)
+
+			if tt.code != res.Code {
+				t.Fatalf("Не верный код ответа сервера. Ожидается %#v пришло %#v", tt.code, res.Code)
+			}
+			var responseItems []BatchResponse
+			if err := json.Unmarshal(res.Body.Bytes(), &responseItems); err != nil {
+				t.Fatal(err)
+			}
+			if len(responseItems) != len(tt.want) {
+				t.Fatalf("Ожидается %d элементов, пришло %d", len(tt.want), len(responseItems))
+			}
+			for i, want := range tt.want {
+				got := responseItems[i]
+				if got.CorrelationID != want.CorrelationID || got.Existing != want.Existing || !strings.HasPrefix(got.Error, want.Error) {
+					t.Errorf("Ожидается %+v пришло %+v", want, got)
+				}
+				if want.Error == "" && !strings.HasSuffix(got.ShortURL, want.ShortURL) {
+					t.Errorf("Ожидается короткая ссылка %s пришло %s", want.ShortURL, got.ShortURL)
+				}
+			}
+			if tt.code == http.StatusCreated && responseItems[0].ShortURL != responseItems[1].ShortURL {
+				t.Errorf("Ожидается одна короткая ссылка для дублей, пришло %s и %s", responseItems[0].ShortURL, responseItems[1].ShortURL)
+			}
+		})
+	}
+}
+
 func TestGzipCompression(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	memoryStorage := storage.NewMemoryStorage()
@@ -414,7 +768,7 @@ func TestGzipCompression(t *testing.T) {
 		}
 		jsonResponse.Result = strings.Trim(jsonResponse.Result, "/")
 		// Если всё ок, то должена найтись модель по короткой ссылке с сервера
-		urlModel, _ := shortURLService.Finder.FindByShortURL(jsonResponse.Result)
+		urlModel, _ := shortURLService.Finder.FindByShortURL(context.Background(), jsonResponse.Result)
 		if urlModel == nil {
 			t.Error("Закодированный URL из ответа в БД не найден")
 		}
@@ -441,7 +795,7 @@ func TestGzipCompression(t *testing.T) {
 		}
 		jsonResponse.Result = strings.Trim(jsonResponse.Result, "/")
 		// Если всё ок, то должена найтись модель по короткой ссылке с сервера
-		urlModel, _ := shortURLService.Finder.FindByShortURL(jsonResponse.Result)
+		urlModel, _ := shortURLService.Finder.FindByShortURL(context.Background(), jsonResponse.Result)
 		if urlModel == nil {
 			t.Error("Закодированный URL из ответа в БД не найден")
 		}
@@ -476,7 +830,7 @@ func TestGzipCompression(t *testing.T) {
 		}
 		jsonResponse.Result = strings.Trim(jsonResponse.Result, "/")
 		// Если всё ок, то должена найтись модель по короткой ссылке с сервера
-		urlModel, _ := shortURLService.Finder.FindByShortURL(jsonResponse.Result)
+		urlModel, _ := shortURLService.Finder.FindByShortURL(context.Background(), jsonResponse.Result)
 		if urlModel == nil {
 			t.Error("Закодированный URL из ответа в БД не найден")
 		}
@@ -574,14 +928,12 @@ func TestFillShortURLAndResponseStatus(t *testing.T) {
 
 	handler := &ShortenerHandler{
 		service: shortURLService,
-		finder:  memoryStorage,
-		setter:  memoryStorage,
 	}
 
 	t.Run("new_url", func(t *testing.T) {
 		expectedURL := "https://ya.ru/map"
 
-		_, status, err := handler.fillShortURLAndResponseStatus("", expectedURL)
+		_, status, err := handler.fillShortURLAndResponseStatus(context.Background(), "", expectedURL, url.DecodeOptions{})
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -592,9 +944,9 @@ func TestFillShortURLAndResponseStatus(t *testing.T) {
 	t.Run("url_exists", func(t *testing.T) {
 		expectedURL := "https://ya.ru/hello"
 		expectedShortURL := "short123"
-		_, _ = memoryStorage.Add(models.URL{ShortURL: expectedShortURL, URL: expectedURL})
+		_, _ = memoryStorage.Add(context.Background(), models.URL{ShortURL: expectedShortURL, URL: expectedURL})
 
-		actualShortURL, status, err := handler.fillShortURLAndResponseStatus("", expectedURL)
+		actualShortURL, status, err := handler.fillShortURLAndResponseStatus(context.Background(), "", expectedURL, url.DecodeOptions{})
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -606,3 +958,62 @@ func TestFillShortURLAndResponseStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestShortenerHandlers_Concurrent(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+	stop := make(chan struct{})
+	defer func() {
+		stop <- struct{}{}
+	}()
+	ts := httptest.NewServer(NewRoutes(shortURLService, memoryStorage, storage.NewSessionStorage(), workers.NewWorker(memoryStorage, stop)).Init())
+	defer ts.Close()
+	ts.Client().CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	const workersCount = 20
+	const requestsPerWorker = 10
+	var wg sync.WaitGroup
+	wg.Add(workersCount)
+	for worker := 0; worker < workersCount; worker++ {
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < requestsPerWorker; i++ {
+				originalURL := fmt.Sprintf("https://ya.ru/concurrent/%d/%d", worker, i)
+				request, err := http.NewRequest(http.MethodPost, ts.URL+"/api/shorten", bytes.NewBufferString(`{"url":"`+originalURL+`"}`))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				request.Header.Set("Content-Type", "application/json")
+				request.Header.Set("Accept-Encoding", "identity")
+				response, err := ts.Client().Do(request)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				var jsonResponse JSONResponse
+				err = json.NewDecoder(response.Body).Decode(&jsonResponse)
+				response.Body.Close()
+				if err != nil || response.StatusCode != http.StatusCreated {
+					t.Errorf("Не удалось сократить %s: код %d, ошибка %v", originalURL, response.StatusCode, err)
+					return
+				}
+
+				shortURL := jsonResponse.Result[strings.LastIndex(jsonResponse.Result, "/"):]
+				response, err = ts.Client().Get(ts.URL + shortURL)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				response.Body.Close()
+				if location := response.Header.Get("Location"); location != originalURL {
+					t.Errorf("Короткая ссылка %s ведёт на %s, ожидается %s", shortURL, location, originalURL)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -9,19 +10,26 @@ import (
 
 // StatsHandler обработка запросов статистики
 type StatsHandler struct {
-	finderStats StatsFinder
+	finderStats  StatsFinder
+	purgeCounter PurgeCounter
 }
 
 // StatsFinder интерфейс поиска данных
 type StatsFinder interface {
-	GetCountShortURL() (int64, error)
-	GetCountUser() (int64, error)
+	GetCountShortURL(ctx context.Context) (int64, error)
+	GetCountUser(ctx context.Context) (int64, error)
 }
 
-// NewStatsHandler конструктор
-func NewStatsHandler(finderStats StatsFinder) *StatsHandler {
+// PurgeCounter количество окончательно удалённых ссылок
+type PurgeCounter interface {
+	Purged() int64
+}
+
+// NewStatsHandler конструктор, purgeCounter может быть nil, если очистка не запущена
+func NewStatsHandler(finderStats StatsFinder, purgeCounter PurgeCounter) *StatsHandler {
 	instance := &StatsHandler{
-		finderStats: finderStats,
+		finderStats:  finderStats,
+		purgeCounter: purgeCounter,
 	}
 
 	return instance
@@ -31,24 +39,29 @@ func NewStatsHandler(finderStats StatsFinder) *StatsHandler {
 type ResponseViewStats struct {
 	Urls  int64 `json:"urls"`
 	Users int64 `json:"users"`
+	// Ссылки, окончательно удалённые с момента запуска сервиса
+	PurgedURLs int64 `json:"purged_urls"`
 }
 
 // ViewStats показывает статистику по пользователям и URL-ам
 func (s *StatsHandler) ViewStats(res http.ResponseWriter, req *http.Request) {
 	var err error
 	var responseView ResponseViewStats
-	responseView.Users, err = s.finderStats.GetCountUser()
+	responseView.Users, err = s.finderStats.GetCountUser(req.Context())
 	if err != nil {
 		logger.LogSugar.Error("error GetCountUser()")
 		http.Error(res, "error", http.StatusInternalServerError)
 		return
 	}
-	responseView.Urls, err = s.finderStats.GetCountShortURL()
+	responseView.Urls, err = s.finderStats.GetCountShortURL(req.Context())
 	if err != nil {
 		logger.LogSugar.Error("error GetCountShortURL()")
 		http.Error(res, "error", http.StatusInternalServerError)
 		return
 	}
+	if s.purgeCounter != nil {
+		responseView.PurgedURLs = s.purgeCounter.Purged()
+	}
 
 	responseBytes, err := json.Marshal(responseView)
 	if err != nil {
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -17,12 +19,12 @@ type mockBadUserFinder struct {
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (s *mockBadUserFinder) GetCountShortURL() (int64, error) {
+func (s *mockBadUserFinder) GetCountShortURL(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
 // GetCountUser кол-во пользвателей
-func (s *mockBadUserFinder) GetCountUser() (int64, error) {
+func (s *mockBadUserFinder) GetCountUser(ctx context.Context) (int64, error) {
 	return 0, errors.New("error")
 }
 
@@ -30,12 +32,12 @@ type mockBadURLsFinder struct {
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (s *mockBadURLsFinder) GetCountShortURL() (int64, error) {
+func (s *mockBadURLsFinder) GetCountShortURL(ctx context.Context) (int64, error) {
 	return 0, errors.New("error")
 }
 
 // GetCountUser кол-во пользвателей
-func (s *mockBadURLsFinder) GetCountUser() (int64, error) {
+func (s *mockBadURLsFinder) GetCountUser(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
@@ -43,15 +45,23 @@ type mockFinder struct {
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (s *mockFinder) GetCountShortURL() (int64, error) {
+func (s *mockFinder) GetCountShortURL(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
 // GetCountUser кол-во пользвателей
-func (s *mockFinder) GetCountUser() (int64, error) {
+func (s *mockFinder) GetCountUser(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
+type mockPurgeCounter struct {
+}
+
+// Purged количество окончательно удалённых ссылок
+func (m *mockPurgeCounter) Purged() int64 {
+	return 7
+}
+
 func TestStatsHandler_ViewStats(t *testing.T) {
 
 	memoryStorage := storage.NewMemoryStorage()
@@ -59,7 +69,9 @@ func TestStatsHandler_ViewStats(t *testing.T) {
 	tests := []struct {
 		name         string
 		finder       StatsFinder
+		purgeCounter PurgeCounter
 		expectedCode int
+		expectedView ResponseViewStats
 	}{
 		{
 			name:         "error_GetCountUser",
@@ -75,6 +87,14 @@ func TestStatsHandler_ViewStats(t *testing.T) {
 			name:         "ok",
 			finder:       new(mockFinder),
 			expectedCode: http.StatusOK,
+			expectedView: ResponseViewStats{Urls: 1, Users: 1},
+		},
+		{
+			name:         "ok_с_очисткой",
+			finder:       new(mockFinder),
+			purgeCounter: new(mockPurgeCounter),
+			expectedCode: http.StatusOK,
+			expectedView: ResponseViewStats{Urls: 1, Users: 1, PurgedURLs: 7},
 		},
 	}
 
@@ -91,7 +111,7 @@ func TestStatsHandler_ViewStats(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			h := NewStatsHandler(tt.finder)
+			h := NewStatsHandler(tt.finder, tt.purgeCounter)
 			req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/internal/stats", nil)
 			if err != nil {
 				t.Error(err)
@@ -99,6 +119,12 @@ func TestStatsHandler_ViewStats(t *testing.T) {
 			res := httptest.NewRecorder()
 			h.ViewStats(res, req)
 			assert.Equal(t, tt.expectedCode, res.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			var view ResponseViewStats
+			assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &view))
+			assert.Equal(t, tt.expectedView, view)
 		})
 	}
 }
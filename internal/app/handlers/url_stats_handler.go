@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// URLStatsHandler хэндлер статистики переходов по ссылке пользователя.
+type URLStatsHandler struct {
+	finder      URLFinder
+	clickFinder ClickStatsFinder
+}
+
+// ClickStatsFinder поиск статистики переходов.
+type ClickStatsFinder interface {
+	GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error)
+}
+
+// NewURLStatsHandler конструктор.
+func NewURLStatsHandler(finder URLFinder, clickFinder ClickStatsFinder) *URLStatsHandler {
+	instance := &URLStatsHandler{
+		finder:      finder,
+		clickFinder: clickFinder,
+	}
+	return instance
+}
+
+// ResponseURLStats ответ со статистикой переходов.
+type ResponseURLStats struct {
+	ShortURL string            `json:"short_url"`
+	Total    int64             `json:"total"`
+	Days     []models.ClickDay `json:"days"`
+}
+
+// View статистика переходов по короткой ссылке пользователя.
+// @Summary Статистика переходов по короткой ссылке пользователя
+// @Failure 500
+// @Failure 404
+// @Failure 400
+// @Success 200 {object} ResponseURLStats
+// @Param short path string true "короткая ссылка"
+// @Router /api/user/urls/{short}/stats [get]
+func (u *URLStatsHandler) View(res http.ResponseWriter, req *http.Request) {
+	shortURL := chi.URLParam(req, "short")
+	if shortURL == "" {
+		http.Error(res, "expected short value", http.StatusBadRequest)
+		return
+	}
+	var userUUID string
+	if id, ok := req.Context().Value(AppContext.KeyContext).(string); ok {
+		userUUID = id
+	}
+
+	owned, err := isUserURL(req.Context(), u.finder, userUUID, shortURL)
+	if err != nil {
+		http.Error(res, "Ошибка получения ссылок пользователя", http.StatusInternalServerError)
+		logger.LogSugar.Error(err)
+		return
+	}
+	if !owned {
+		http.Error(res, "url not found", http.StatusNotFound)
+		return
+	}
+
+	stats, err := u.clickFinder.GetClickStats(req.Context(), shortURL)
+	if err != nil {
+		http.Error(res, "Ошибка получения статистики переходов", http.StatusInternalServerError)
+		logger.LogSugar.Error(err)
+		return
+	}
+
+	responseBytes, err := json.Marshal(ResponseURLStats{
+		ShortURL: shortURL,
+		Total:    stats.Total,
+		Days:     stats.Days,
+	})
+	if err != nil {
+		http.Error(res, "error json marshal response", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	_, err = res.Write(responseBytes)
+	if err != nil {
+		http.Error(res, "error write data", http.StatusInternalServerError)
+		return
+	}
+}
+
+// isUserURL проверяет, что короткая ссылка принадлежит пользователю.
+func isUserURL(ctx context.Context, finder URLFinder, userUUID string, shortURL string) (bool, error) {
+	userURLs, err := finder.FindUrlsByUserID(ctx, userUUID)
+	if err != nil {
+		return false, err
+	}
+	if userURLs == nil {
+		return false, nil
+	}
+	for _, urlItem := range *userURLs {
+		if urlItem.ShortURL == shortURL {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLStatsHandler_View(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "short1", CreatedAt: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)})
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "short1", CreatedAt: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)})
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "short1", CreatedAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)})
+
+	mockFinder := new(MockFinder)
+	mockFinder.On("FindUrlsByUserID", userUUID).Return(&[]models.URL{
+		{ShortURL: "short1", URL: "http://example.com"},
+	}, nil)
+
+	tests := []struct {
+		name     string
+		finder   URLFinder
+		shortURL string
+		code     int
+	}{
+		{name: "#1_статистика_своей_ссылки", finder: mockFinder, shortURL: "short1", code: http.StatusOK},
+		{name: "#2_чужая_ссылка", finder: mockFinder, shortURL: "short2", code: http.StatusNotFound},
+		{name: "#3_ошибка_поиска_ссылок", finder: new(MockFinderBad), shortURL: "short1", code: http.StatusInternalServerError},
+		{name: "#4_ссылка_не_передана", finder: mockFinder, shortURL: "", code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewURLStatsHandler(tt.finder, memoryStorage)
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls/"+tt.shortURL+"/stats", nil)
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("short", tt.shortURL)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+			ctx = context.WithValue(ctx, AppContext.KeyContext, userUUID)
+			res := httptest.NewRecorder()
+			handler.View(res, req.WithContext(ctx))
+
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var response ResponseURLStats
+			err := json.Unmarshal(res.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, ResponseURLStats{
+				ShortURL: "short1",
+				Total:    3,
+				Days: []models.ClickDay{
+					{Date: "2026-10-17", Clicks: 1},
+					{Date: "2026-10-18", Clicks: 2},
+				},
+			}, response)
+		})
+	}
+}
@@ -1,35 +1,58 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	netURL "net/url"
+	"slices"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/northmule/shorturl/config"
-	"github.com/northmule/shorturl/internal/app/context"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/policy"
+	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 )
 
 // UserURLsHandler хэндлер отображения ссылок пользователя.
 type UserURLsHandler struct {
-	finder  URLFinder
-	session storage.SessionAdapter
-	worker  Deleter
+	finder   URLPageFinder
+	session  storage.SessionAdapter
+	worker   Deleter
+	updater  URLUpdater
+	restorer Restorer
 }
 
 // NewUserUrlsHandler Конструктор.
-func NewUserUrlsHandler(finder URLFinder, sessionStorage storage.SessionAdapter, worker Deleter) *UserURLsHandler {
+func NewUserUrlsHandler(finder URLPageFinder, sessionStorage storage.SessionAdapter, worker Deleter, updater URLUpdater, restorer Restorer) *UserURLsHandler {
 	instance := UserURLsHandler{
-		finder:  finder,
-		session: sessionStorage,
-		worker:  worker,
+		finder:   finder,
+		session:  sessionStorage,
+		worker:   worker,
+		updater:  updater,
+		restorer: restorer,
 	}
 	return &instance
 }
 
+// Restorer восстановление удалённых ссылок пользователя.
+type Restorer interface {
+	RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error)
+}
+
+// URLUpdater смена URL короткой ссылки пользователя.
+type URLUpdater interface {
+	UpdateURL(ctx context.Context, userUUID string, shortURL string, url string) (url.ShortURLData, error)
+}
+
 // Deleter Интерфейс удаления ссылок пользователя
 type Deleter interface {
 	Del(userUUID string, input []string)
@@ -37,25 +60,56 @@ type Deleter interface {
 
 // ResponseView структура ответа для просмотра.
 type ResponseView struct {
-	ShortURL    string `json:"short_Url"`
-	OriginalURL string `json:"original_url"`
+	ShortURL    string     `json:"short_Url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ResponseViewPage страница ссылок пользователя.
+type ResponseViewPage struct {
+	URLs       []ResponseView `json:"urls"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
 
 // URLFinder Поиск URL-s по пользователю.
 type URLFinder interface {
-	FindUrlsByUserID(userUUID string) (*[]models.URL, error)
+	FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error)
+}
+
+// URLPageFinder Постраничный поиск URL-s по пользователю.
+type URLPageFinder interface {
+	FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error)
 }
 
 // View коротки ссылки пользователя.
 // @Summary Просмотр коротких ссылок пользователя
 // @Failure 500
 // @Failure 400
-// @Success 200 {object} ResponseView
+// @Success 200 {object} ResponseViewPage
+// @Success 204
+// @Param limit query int false "размер страницы, от 1 до 1000, по умолчанию 100"
+// @Param cursor query string false "курсор следующей страницы из next_cursor"
+// @Param created_from query string false "созданные не раньше, RFC3339"
+// @Param created_to query string false "созданные раньше, RFC3339"
+// @Param host query string false "домен оригинальной ссылки"
+// @Param include_deleted query bool false "включать удалённые ссылки"
+// @Param search query string false "подстрока в оригинальной или короткой ссылке"
+// @Param sort query string false "порядок: created_asc или created_desc"
 // @Router /api/user/urls [get]
 func (u *UserURLsHandler) View(res http.ResponseWriter, req *http.Request) {
 	userUUID := u.getUserUUID(res, req)
 	logger.LogSugar.Infof("Получен запрос на просмотр URL для пользователя с uuid: %s", userUUID)
-	userURLs, err := u.finder.FindUrlsByUserID(userUUID)
+	filter, err := parseURLFilter(req.URL.Query())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := u.finder.FindUserURLs(req.Context(), userUUID, filter)
+	if errors.Is(err, storage.ErrFilterInvalid) {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(res, "Ошибка получения ссылок пользователя", http.StatusInternalServerError)
 		logger.LogSugar.Error(err)
@@ -63,19 +117,24 @@ func (u *UserURLsHandler) View(res http.ResponseWriter, req *http.Request) {
 	}
 	res.Header().Set("content-type", "application/json")
 
-	if len(*userURLs) == 0 {
+	if len(page.URLs) == 0 && filter.Cursor == "" {
 		logger.LogSugar.Infof("Не нашёл сокращённых ссылок для пользователя с uuid: %s", userUUID)
 		res.WriteHeader(http.StatusNoContent)
 		return
 	}
-	var responseList []ResponseView
-	for _, urlItem := range *userURLs {
-		responseList = append(responseList, ResponseView{
+	response := ResponseViewPage{
+		URLs:       make([]ResponseView, 0, len(page.URLs)),
+		NextCursor: page.NextCursor,
+	}
+	for _, urlItem := range page.URLs {
+		response.URLs = append(response.URLs, ResponseView{
 			ShortURL:    fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, urlItem.ShortURL),
 			OriginalURL: urlItem.URL,
+			CreatedAt:   optionalTime(urlItem.CreatedAt),
+			DeletedAt:   optionalTime(urlItem.DeletedAt),
 		})
 	}
-	responseURLs, err := json.Marshal(responseList)
+	responseURLs, err := json.Marshal(response)
 	if err != nil {
 		http.Error(res, "error json marshal response", http.StatusInternalServerError)
 		return
@@ -120,11 +179,215 @@ func (u *UserURLsHandler) Delete(res http.ResponseWriter, req *http.Request) {
 	res.WriteHeader(http.StatusAccepted)
 }
 
+// parseURLFilter отбор ссылок из параметров запроса.
+func parseURLFilter(query netURL.Values) (models.URLFilter, error) {
+	filter := models.URLFilter{
+		Cursor: query.Get("cursor"),
+		Host:   query.Get("host"),
+		Search: query.Get("search"),
+		Sort:   query.Get("sort"),
+	}
+	var err error
+	if value := query.Get("limit"); value != "" {
+		filter.Limit, err = strconv.Atoi(value)
+		if err != nil || filter.Limit < 1 || filter.Limit > models.URLPageMaxLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", models.URLPageMaxLimit)
+		}
+	}
+	if value := query.Get("include_deleted"); value != "" {
+		filter.IncludeDeleted, err = strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("include_deleted must be a boolean")
+		}
+	}
+	if value := query.Get("created_from"); value != "" {
+		filter.CreatedFrom, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("created_from must be in RFC3339 format")
+		}
+	}
+	if value := query.Get("created_to"); value != "" {
+		filter.CreatedTo, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, errors.New("created_to must be in RFC3339 format")
+		}
+	}
+	return filter, nil
+}
+
+// RequestRestore запрос на восстановление удалённых ссылок.
+type RequestRestore []string
+
+// ResponseRestore результат восстановления ссылок.
+type ResponseRestore struct {
+	// Restored восстановленные ссылки
+	Restored []string `json:"restored"`
+	// Skipped чужие, не удалённые, удалённые слишком давно или с URL, который уже занят действующей ссылкой
+	Skipped []string `json:"skipped"`
+}
+
+// Restore восстановление удалённых ссылок текущего пользователя.
+// @Summary Восстановление удалённых ссылок пользователем
+// @Accept json
+// @Produce json
+// @Failure 400
+// @Failure 500
+// @Success 200 {object} ResponseRestore
+// @Param Restore body RequestRestore true "ссылки для восстановления"
+// @Router /api/user/urls/restore [post]
+func (u *UserURLsHandler) Restore(res http.ResponseWriter, req *http.Request) {
+	userUUID := u.getUserUUID(res, req)
+	logger.LogSugar.Infof("Получен запрос на восстановление ссылок для пользователя с uuid: %s", userUUID)
+
+	var requestShortURLs RequestRestore
+	if err := json.NewDecoder(req.Body).Decode(&requestShortURLs); err != nil {
+		http.Error(res, "error unmarshal json request", http.StatusBadRequest)
+		return
+	}
+	if len(requestShortURLs) == 0 {
+		http.Error(res, "expected short urls", http.StatusBadRequest)
+		return
+	}
+
+	restored, err := u.restorer.RestoreShortURLs(req.Context(), userUUID, RestoreDeletedSince(time.Now()), requestShortURLs...)
+	if err != nil {
+		logger.LogSugar.Errorf("Не удалось восстановить ссылки пользователя %s: %s", userUUID, err)
+		http.Error(res, "error restore urls", http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(NewResponseRestore(requestShortURLs, restored))
+	if err != nil {
+		http.Error(res, "error json marshal response", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	_, err = res.Write(responseBytes)
+	if err != nil {
+		http.Error(res, "error write data", http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreDeletedSince самое раннее время удаления ссылки, которую ещё можно восстановить.
+// Если окно восстановления не задано, ограничения нет.
+func RestoreDeletedSince(now time.Time) time.Time {
+	if config.AppConfig.RestoreWindow <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-config.AppConfig.RestoreWindow)
+}
+
+// NewResponseRestore разделяет запрошенные ссылки на восстановленные и пропущенные.
+func NewResponseRestore(requested []string, restored []string) ResponseRestore {
+	response := ResponseRestore{
+		Restored: restored,
+		Skipped:  make([]string, 0),
+	}
+	if response.Restored == nil {
+		response.Restored = make([]string, 0)
+	}
+	for _, shortURL := range requested {
+		if !slices.Contains(restored, shortURL) && !slices.Contains(response.Skipped, shortURL) {
+			response.Skipped = append(response.Skipped, shortURL)
+		}
+	}
+	return response
+}
+
+// RequestUpdate запрос на смену URL короткой ссылки.
+type RequestUpdate struct {
+	URL string `json:"url"`
+}
+
+// Update смена URL короткой ссылки её владельцем, прежний URL сохраняется в истории.
+// @Summary Смена URL короткой ссылки пользователя
+// @Accept json
+// @Produce json
+// @Failure 400
+// @Failure 404
+// @Failure 409
+// @Failure 410
+// @Failure 422
+// @Failure 500
+// @Success 200 {object} ResponseView
+// @Param short path string true "короткая ссылка"
+// @Param Update body RequestUpdate true "новый URL"
+// @Router /api/user/urls/{short} [patch]
+func (u *UserURLsHandler) Update(res http.ResponseWriter, req *http.Request) {
+	shortURL := chi.URLParam(req, "short")
+	if shortURL == "" {
+		http.Error(res, "expected short value", http.StatusBadRequest)
+		return
+	}
+	userUUID := u.getUserUUID(res, req)
+	logger.LogSugar.Infof("Получен запрос на смену URL ссылки %s для пользователя с uuid: %s", shortURL, userUUID)
+
+	var request RequestUpdate
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		http.Error(res, "error unmarshal json request", http.StatusBadRequest)
+		return
+	}
+	if request.URL == "" {
+		http.Error(res, "expected url", http.StatusBadRequest)
+		return
+	}
+
+	shortURLData, err := u.updater.UpdateURL(req.Context(), userUUID, shortURL, request.URL)
+	switch {
+	case errors.Is(err, url.ErrURLInvalid):
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, policy.ErrDestinationDenied):
+		http.Error(res, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, url.ErrShortURLNotFound):
+		http.Error(res, "url not found", http.StatusNotFound)
+		return
+	case errors.Is(err, url.ErrShortURLGone):
+		http.Error(res, "url deleted", http.StatusGone)
+		return
+	case errors.Is(err, url.ErrURLExists):
+		http.Error(res, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		logger.LogSugar.Errorf("Не удалось сменить URL ссылки %s: %s", shortURL, err)
+		http.Error(res, "error update url", http.StatusInternalServerError)
+		return
+	}
+
+	responseBytes, err := json.Marshal(ResponseView{
+		ShortURL:    fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, shortURLData.ShortURL),
+		OriginalURL: shortURLData.URL,
+	})
+	if err != nil {
+		http.Error(res, "error json marshal response", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("content-type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	_, err = res.Write(responseBytes)
+	if err != nil {
+		http.Error(res, "error write data", http.StatusInternalServerError)
+		return
+	}
+}
+
 func (u *UserURLsHandler) getUserUUID(res http.ResponseWriter, req *http.Request) string {
-	userIDAny := req.Context().Value(context.KeyContext)
+	userIDAny := req.Context().Value(AppContext.KeyContext)
 	var userUUID string
 	if id, ok := userIDAny.(string); ok {
 		userUUID = id
 	}
 	return userUUID
 }
+
+// optionalTime nil для нулевого времени, чтобы поле не попадало в ответ.
+func optionalTime(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	value = value.UTC()
+	return &value
+}
@@ -9,20 +9,26 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/northmule/shorturl/config"
 	AppContext "github.com/northmule/shorturl/internal/app/context"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/policy"
+	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockFinder struct {
 	mock.Mock
 }
 
-func (m *MockFinder) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
+func (m *MockFinder) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
 	args := m.Called(userUUID)
 	return args.Get(0).(*[]models.URL), args.Error(1)
 }
@@ -31,7 +37,16 @@ type MockFinderBad struct {
 	mock.Mock
 }
 
-func (m *MockFinderBad) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
+func (m *MockFinder) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
+	args := m.Called(userUUID, filter)
+	return args.Get(0).(*models.URLPage), args.Error(1)
+}
+
+func (m *MockFinderBad) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
+	return nil, errors.New("error")
+}
+
+func (m *MockFinderBad) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
 	return nil, errors.New("error")
 }
 
@@ -47,17 +62,20 @@ func TestView(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	mockFinder := new(MockFinder)
 	userUUID := "user123"
-	userURLs := &[]models.URL{
-		{ShortURL: "short1", URL: "http://example.com"},
-		{ShortURL: "short2", URL: "http://example.org"},
+	userURLs := &models.URLPage{
+		URLs: []models.URL{
+			{ShortURL: "short1", URL: "http://example.com"},
+			{ShortURL: "short2", URL: "http://example.org"},
+		},
+		NextCursor: "next",
 	}
 	handler := &UserURLsHandler{
 		finder: mockFinder,
 	}
 
-	mockFinder.On("FindUrlsByUserID", userUUID).Return(userURLs, nil)
+	mockFinder.On("FindUserURLs", userUUID, models.URLFilter{Limit: 2, Host: "example.com"}).Return(userURLs, nil)
 
-	req := httptest.NewRequest("GET", "/view", nil)
+	req := httptest.NewRequest("GET", "/view?limit=2&host=example.com", nil)
 	ctx := context.WithValue(req.Context(), AppContext.KeyContext, userUUID)
 	res := httptest.NewRecorder()
 	req = req.WithContext(ctx)
@@ -65,15 +83,18 @@ func TestView(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.Code)
 
-	var responseList []ResponseView
-	err := json.Unmarshal(res.Body.Bytes(), &responseList)
+	var response ResponseViewPage
+	err := json.Unmarshal(res.Body.Bytes(), &response)
 	assert.NoError(t, err)
 
-	expectedResponse := []ResponseView{
-		{ShortURL: "/short1", OriginalURL: "http://example.com"},
-		{ShortURL: "/short2", OriginalURL: "http://example.org"},
+	expectedResponse := ResponseViewPage{
+		URLs: []ResponseView{
+			{ShortURL: "/short1", OriginalURL: "http://example.com"},
+			{ShortURL: "/short2", OriginalURL: "http://example.org"},
+		},
+		NextCursor: "next",
 	}
-	assert.Equal(t, expectedResponse, responseList)
+	assert.Equal(t, expectedResponse, response)
 
 	mockFinder.AssertExpectations(t)
 }
@@ -82,7 +103,7 @@ func BenchmarkView(b *testing.B) {
 	_ = logger.InitLogger("fatal")
 	mockFinder := new(MockFinder)
 	userUUID := "user123"
-	userURLs := &[]models.URL{
+	userURLs := &models.URLPage{URLs: []models.URL{
 		{ShortURL: "short1", URL: "http://example.com/1"},
 		{ShortURL: "short2", URL: "http://example.org/2"},
 		{ShortURL: "short3", URL: "http://example.org/3"},
@@ -91,11 +112,11 @@ func BenchmarkView(b *testing.B) {
 		{ShortURL: "short6", URL: "http://example.org/6"},
 		{ShortURL: "short7", URL: "http://example.org/7"},
 		{ShortURL: "short8", URL: "http://example.org/8"},
-	}
+	}}
 	handler := &UserURLsHandler{
 		finder: mockFinder,
 	}
-	mockFinder.On("FindUrlsByUserID", userUUID).Return(userURLs, nil)
+	mockFinder.On("FindUserURLs", userUUID, models.URLFilter{}).Return(userURLs, nil)
 	req := httptest.NewRequest("GET", "/view", nil)
 	ctx := context.WithValue(req.Context(), AppContext.KeyContext, userUUID)
 	res := httptest.NewRecorder()
@@ -221,3 +242,202 @@ func TestView_StatusNoContent(t *testing.T) {
 		t.Errorf("Expected status code %d, but got %d", http.StatusNoContent, res.Code)
 	}
 }
+
+func TestView_Pagination(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.ImportURLs(context.Background(), userUUID, []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1", CreatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{ShortURL: "short2", URL: "https://avito.ru/2", CreatedAt: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{ShortURL: "short3", URL: "https://ozon.ru/3", CreatedAt: time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), userUUID, "short3")
+	handler := &UserURLsHandler{
+		finder: memoryStorage,
+	}
+	view := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/user/urls?"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), AppContext.KeyContext, userUUID))
+		res := httptest.NewRecorder()
+		handler.View(res, req)
+		return res
+	}
+	shortURLs := func(res *httptest.ResponseRecorder) ([]string, string) {
+		var response ResponseViewPage
+		require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+		var result []string
+		for _, item := range response.URLs {
+			result = append(result, item.ShortURL)
+		}
+		return result, response.NextCursor
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		want  []string
+	}{
+		{name: "#1_без_удалённых", query: "", code: http.StatusOK, want: []string{"/short1", "/short2"}},
+		{name: "#2_с_удалёнными_по_убыванию", query: "include_deleted=true&sort=created_desc", code: http.StatusOK, want: []string{"/short3", "/short2", "/short1"}},
+		{name: "#3_по_домену", query: "host=OZON.ru&include_deleted=1", code: http.StatusOK, want: []string{"/short1", "/short3"}},
+		{name: "#4_по_подстроке", query: "search=AVITO", code: http.StatusOK, want: []string{"/short2"}},
+		{name: "#5_по_дате_создания", query: "created_from=2026-10-02T00:00:00Z&created_to=2026-10-03T00:00:00Z", code: http.StatusOK, want: []string{"/short2"}},
+		{name: "#6_ничего_не_найдено", query: "host=ya.ru", code: http.StatusNoContent},
+		{name: "#7_неверный_limit", query: "limit=0", code: http.StatusBadRequest},
+		{name: "#8_неверная_дата", query: "created_from=вчера", code: http.StatusBadRequest},
+		{name: "#9_неверный_курсор", query: "cursor=abc", code: http.StatusBadRequest},
+		{name: "#10_неверный_порядок", query: "sort=random", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := view(tt.query)
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			got, nextCursor := shortURLs(res)
+			assert.Equal(t, tt.want, got)
+			assert.Empty(t, nextCursor)
+		})
+	}
+
+	t.Run("#11_постранично", func(t *testing.T) {
+		var got []string
+		query := "limit=1&include_deleted=true"
+		for {
+			res := view(query)
+			require.Equal(t, http.StatusOK, res.Code)
+			page, nextCursor := shortURLs(res)
+			got = append(got, page...)
+			if nextCursor == "" {
+				break
+			}
+			query = "limit=1&include_deleted=true&cursor=" + nextCursor
+		}
+		assert.Equal(t, []string{"/short1", "/short2", "/short3"}, got)
+	})
+}
+
+func TestUserURLsHandler_Update(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	destinationPolicy, err := policy.NewPolicy(policy.Rules{Deny: []string{"denied.ru"}})
+	require.NoError(t, err)
+	service.SetPolicy(destinationPolicy)
+	for alias, originalURL := range map[string]string{"own-link": "https://ozon.ru/old", "taken-link": "https://avito.ru/taken", "deleted-link": "https://avito.ru/deleted"} {
+		_, err := service.DecodeURLWithOptions(context.Background(), userUUID, originalURL, url.DecodeOptions{Alias: alias})
+		require.NoError(t, err)
+	}
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), userUUID, "deleted-link")
+	handler := NewUserUrlsHandler(memoryStorage, nil, nil, service, memoryStorage)
+
+	tests := []struct {
+		name     string
+		userUUID string
+		shortURL string
+		body     string
+		code     int
+	}{
+		{name: "#1_смена_ссылки", userUUID: userUUID, shortURL: "own-link", body: `{"url":"https://ozon.ru/new"}`, code: http.StatusOK},
+		{name: "#2_не_json", userUUID: userUUID, shortURL: "own-link", body: `url`, code: http.StatusBadRequest},
+		{name: "#3_пустая_ссылка", userUUID: userUUID, shortURL: "own-link", body: `{}`, code: http.StatusBadRequest},
+		{name: "#4_некорректная_ссылка", userUUID: userUUID, shortURL: "own-link", body: `{"url":"ozon"}`, code: http.StatusBadRequest},
+		{name: "#5_запрещённая_ссылка", userUUID: userUUID, shortURL: "own-link", body: `{"url":"https://denied.ru"}`, code: http.StatusUnprocessableEntity},
+		{name: "#6_чужая_ссылка", userUUID: "user456", shortURL: "own-link", body: `{"url":"https://ozon.ru/other"}`, code: http.StatusNotFound},
+		{name: "#7_удалённая_ссылка", userUUID: userUUID, shortURL: "deleted-link", body: `{"url":"https://ozon.ru/other"}`, code: http.StatusGone},
+		{name: "#8_ссылка_уже_сокращена", userUUID: userUUID, shortURL: "own-link", body: `{"url":"https://avito.ru/taken"}`, code: http.StatusConflict},
+		{name: "#9_ссылка_не_передана", userUUID: userUUID, shortURL: "", body: `{"url":"https://ozon.ru/other"}`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/"+tt.shortURL, bytes.NewBufferString(tt.body))
+			routeCtx := chi.NewRouteContext()
+			routeCtx.URLParams.Add("short", tt.shortURL)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+			ctx = context.WithValue(ctx, AppContext.KeyContext, tt.userUUID)
+			res := httptest.NewRecorder()
+			handler.Update(res, req.WithContext(ctx))
+
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var response ResponseView
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+			assert.Equal(t, "https://ozon.ru/new", response.OriginalURL)
+		})
+	}
+
+	modelURL, err := memoryStorage.FindByShortURL(context.Background(), "own-link")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru/new", modelURL.URL)
+}
+
+func TestUserURLsHandler_Restore(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "user123"
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.ImportURLs(context.Background(), userUUID, []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1"},
+		{ShortURL: "short2", URL: "https://ozon.ru/2"},
+		{ShortURL: "short3", URL: "https://ozon.ru/3"},
+	})
+	require.NoError(t, err)
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), userUUID, "short1", "short2")
+	// URL второй ссылки после удаления сокращён заново
+	_, err = memoryStorage.Add(context.Background(), models.URL{ShortURL: "short4", URL: "https://ozon.ru/2"})
+	require.NoError(t, err)
+	handler := NewUserUrlsHandler(memoryStorage, nil, nil, nil, memoryStorage)
+
+	tests := []struct {
+		name     string
+		userUUID string
+		body     string
+		code     int
+		want     ResponseRestore
+	}{
+		{name: "#1_не_json", userUUID: userUUID, body: `short1`, code: http.StatusBadRequest},
+		{name: "#2_пустой_список", userUUID: userUUID, body: `[]`, code: http.StatusBadRequest},
+		{name: "#3_чужие_ссылки", userUUID: "user456", body: `["short1"]`, code: http.StatusOK, want: ResponseRestore{Restored: []string{}, Skipped: []string{"short1"}}},
+		{name: "#4_восстановление", userUUID: userUUID, body: `["short1","short2","short3","short1"]`, code: http.StatusOK, want: ResponseRestore{Restored: []string{"short1"}, Skipped: []string{"short2", "short3"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/urls/restore", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), AppContext.KeyContext, tt.userUUID))
+			res := httptest.NewRecorder()
+			handler.Restore(res, req)
+
+			assert.Equal(t, tt.code, res.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var response ResponseRestore
+			require.NoError(t, json.Unmarshal(res.Body.Bytes(), &response))
+			assert.Equal(t, tt.want, response)
+		})
+	}
+
+	_, err = memoryStorage.FindByShortURL(context.Background(), "short1")
+	assert.NoError(t, err)
+	_, err = memoryStorage.FindByShortURL(context.Background(), "short2")
+	assert.ErrorIs(t, err, storage.ErrGone)
+}
+
+func TestRestoreDeletedSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	window := config.AppConfig.RestoreWindow
+	defer func() {
+		config.AppConfig.RestoreWindow = window
+	}()
+
+	config.AppConfig.RestoreWindow = 0
+	assert.True(t, RestoreDeletedSince(now).IsZero())
+	config.AppConfig.RestoreWindow = time.Hour
+	assert.Equal(t, now.Add(-time.Hour), RestoreDeletedSince(now))
+}
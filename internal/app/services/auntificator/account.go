@@ -0,0 +1,111 @@
+package auntificator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/northmule/shorturl/internal/app/util/user"
+)
+
+// Ограничения учётных данных.
+const (
+	// LoginMaxSize максимальная длина логина.
+	LoginMaxSize = 100
+	// PasswordMinSize минимальная длина пароля.
+	PasswordMinSize = 6
+)
+
+// Ошибки регистрации и входа.
+var (
+	// ErrCredentialsInvalid логин или пароль не соответствуют требованиям.
+	ErrCredentialsInvalid = errors.New("invalid login or password")
+	// ErrLoginExists логин занят другим пользователем.
+	ErrLoginExists = errors.New("login already exists")
+	// ErrAlreadyRegistered текущий пользователь уже зарегистрирован.
+	ErrAlreadyRegistered = errors.New("user already registered")
+	// ErrWrongCredentials неверный логин или пароль при входе.
+	ErrWrongCredentials = errors.New("wrong login or password")
+)
+
+// AccountStorage хранилище учётных записей.
+type AccountStorage interface {
+	FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error)
+	FindUserByLoginAndPasswordHash(ctx context.Context, login string, passwordHash string) (*models.User, error)
+	RegisterUser(ctx context.Context, user models.User) error
+}
+
+// Account регистрация и вход пользователей.
+type Account struct {
+	storage AccountStorage
+	tokens  *TokenManager
+}
+
+// NewAccount конструктор.
+func NewAccount(storage AccountStorage, tokens *TokenManager) *Account {
+	return &Account{storage: storage, tokens: tokens}
+}
+
+// Register регистрирует текущего анонимного пользователя.
+// Uuid не меняется, поэтому созданные до регистрации ссылки остаются у пользователя.
+func (a *Account) Register(ctx context.Context, userUUID string, name string, login string, password string) (*models.User, error) {
+	if err := validateCredentials(login, password); err != nil {
+		return nil, err
+	}
+	current, err := a.storage.FindUserByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if current.Login != "" {
+		return nil, ErrAlreadyRegistered
+	}
+
+	registered := models.User{
+		ID:       current.ID,
+		Name:     name,
+		Login:    login,
+		Password: user.PasswordHash(password),
+		UUID:     userUUID,
+	}
+	err = a.storage.RegisterUser(ctx, registered)
+	if err != nil {
+		if errors.Is(err, storage.ErrConflict) {
+			return nil, ErrLoginExists
+		}
+		return nil, err
+	}
+	return &registered, nil
+}
+
+// Login проверяет логин и пароль и выдаёт новый токен пользователя.
+func (a *Account) Login(ctx context.Context, login string, password string) (*ResultCheckAuth, error) {
+	if login == "" || password == "" {
+		return nil, ErrWrongCredentials
+	}
+	found, err := a.storage.FindUserByLoginAndPasswordHash(ctx, login, user.PasswordHash(password))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrWrongCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found.UUID == "" {
+		return nil, ErrWrongCredentials
+	}
+
+	token, exp := a.tokens.Generate(found.UUID)
+	return &ResultCheckAuth{
+		UserUUID:   found.UUID,
+		Token:      token,
+		TokenExp:   exp,
+		AuthString: token,
+	}, nil
+}
+
+func validateCredentials(login string, password string) error {
+	if login == "" || len(login) > LoginMaxSize || len(password) < PasswordMinSize {
+		return ErrCredentialsInvalid
+	}
+	return nil
+}
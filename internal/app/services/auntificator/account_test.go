@@ -0,0 +1,72 @@
+package auntificator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/northmule/shorturl/internal/app/util/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccount_Register(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{UUID: "uuid-2"})
+	account := NewAccount(memoryStorage, NewDefaultTokenManager())
+
+	tests := []struct {
+		name     string
+		userUUID string
+		login    string
+		password string
+		err      error
+	}{
+		{name: "#1_короткий_пароль", userUUID: "uuid-1", login: "cat", password: "123", err: ErrCredentialsInvalid},
+		{name: "#2_пустой_логин", userUUID: "uuid-1", login: "", password: "password", err: ErrCredentialsInvalid},
+		{name: "#3_регистрация", userUUID: "uuid-1", login: "cat", password: "password"},
+		{name: "#4_повторная_регистрация", userUUID: "uuid-1", login: "dog", password: "password", err: ErrAlreadyRegistered},
+		{name: "#5_логин_занят", userUUID: "uuid-2", login: "cat", password: "password", err: ErrLoginExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registered, err := account.Register(context.Background(), tt.userUUID, "", tt.login, tt.password)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.userUUID, registered.UUID)
+		})
+	}
+
+	saved, _ := memoryStorage.FindUserByUUID(context.Background(), "uuid-1")
+	assert.Equal(t, "cat", saved.Login)
+	assert.Equal(t, user.PasswordHash("password"), saved.Password)
+}
+
+func TestAccount_Login(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	account := NewAccount(memoryStorage, NewDefaultTokenManager())
+	_, err := account.Register(context.Background(), "uuid-1", "Кот", "cat", "password")
+	assert.NoError(t, err)
+
+	result, err := account.Login(context.Background(), "cat", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-1", result.UserUUID)
+	assert.Equal(t, result.Token, result.AuthString)
+	claims, err := NewDefaultTokenManager().Parse(result.AuthString)
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-1", claims.UserUUID)
+
+	_, err = account.Login(context.Background(), "cat", "wrong_password")
+	assert.ErrorIs(t, err, ErrWrongCredentials)
+
+	_, err = account.Login(context.Background(), "", "")
+	assert.ErrorIs(t, err, ErrWrongCredentials)
+}
@@ -0,0 +1,121 @@
+package auntificator
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// Передача ключа доступа в запросе.
+const (
+	// HeaderAPIKey заголовок с ключом доступа.
+	HeaderAPIKey = "X-API-Key"
+	// APIKeyScheme схема заголовка Authorization, например "Authorization: ApiKey sk_...".
+	APIKeyScheme = "ApiKey"
+	// apiKeyPrefix префикс выпускаемых ключей.
+	apiKeyPrefix = "sk_"
+	// apiKeySize количество случайных байт ключа.
+	apiKeySize = 32
+)
+
+// Ошибки ключей доступа.
+var (
+	// ErrAPIKeyInvalid ключ доступа не найден или отозван.
+	ErrAPIKeyInvalid = errors.New("invalid api key")
+	// ErrAPIKeyUserNotFound пользователь, для которого выпускается ключ, не найден.
+	ErrAPIKeyUserNotFound = errors.New("api key owner not found")
+)
+
+// APIKeyStorage хранилище ключей доступа.
+type APIKeyStorage interface {
+	FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error)
+	AddAPIKey(ctx context.Context, key models.APIKey) (int64, error)
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+// APIKeyResolver определяет владельца ключа доступа.
+type APIKeyResolver interface {
+	Resolve(ctx context.Context, key string) (string, error)
+}
+
+// APIKeys выпуск, отзыв и проверка ключей доступа межсервисных клиентов.
+type APIKeys struct {
+	storage APIKeyStorage
+}
+
+// NewAPIKeys конструктор.
+func NewAPIKeys(storage APIKeyStorage) *APIKeys {
+	return &APIKeys{storage: storage}
+}
+
+// Mint выпускает ключ доступа пользователя. Ключ возвращается один раз, в хранилище остаётся только хэш.
+func (a *APIKeys) Mint(ctx context.Context, userUUID string, name string) (string, *models.APIKey, error) {
+	_, err := a.storage.FindUserByUUID(ctx, userUUID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", nil, ErrAPIKeyUserNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := make([]byte, apiKeySize)
+	if _, err = rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(raw)
+
+	model := models.APIKey{
+		UserUUID:  userUUID,
+		Name:      name,
+		KeyHash:   APIKeyHash(key),
+		CreatedAt: time.Now().UTC(),
+	}
+	model.ID, err = a.storage.AddAPIKey(ctx, model)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, &model, nil
+}
+
+// Revoke отзывает ключ доступа.
+func (a *APIKeys) Revoke(ctx context.Context, id int64) error {
+	return a.storage.RevokeAPIKey(ctx, id)
+}
+
+// Resolve вернёт uuid владельца действующего ключа доступа.
+func (a *APIKeys) Resolve(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", ErrAPIKeyInvalid
+	}
+	found, err := a.storage.FindAPIKeyByHash(ctx, APIKeyHash(key))
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+	return found.UserUUID, nil
+}
+
+// APIKeyHash хэш ключа доступа для хранения и поиска.
+func APIKeyHash(key string) string {
+	hashed := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hashed[:])
+}
+
+// ParseAPIKey достаёт ключ доступа из значения авторизации вида "ApiKey <ключ>".
+func ParseAPIKey(authorization string) (string, bool) {
+	scheme, key, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, APIKeyScheme) {
+		return "", false
+	}
+	return strings.TrimSpace(key), true
+}
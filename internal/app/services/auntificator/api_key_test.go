@@ -0,0 +1,90 @@
+package auntificator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeys_MintResolveRevoke(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	apiKeys := NewAPIKeys(memoryStorage)
+
+	_, _, err := apiKeys.Mint(context.Background(), "uuid-2", "billing")
+	assert.ErrorIs(t, err, ErrAPIKeyUserNotFound)
+
+	key, model, err := apiKeys.Mint(context.Background(), "uuid-1", "billing")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, apiKeyPrefix))
+	assert.Equal(t, APIKeyHash(key), model.KeyHash)
+	assert.NotEqual(t, key, model.KeyHash)
+
+	userUUID, err := apiKeys.Resolve(context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-1", userUUID)
+
+	_, err = apiKeys.Resolve(context.Background(), "sk_unknown")
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+	_, err = apiKeys.Resolve(context.Background(), "")
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+
+	assert.NoError(t, apiKeys.Revoke(context.Background(), model.ID))
+	_, err = apiKeys.Resolve(context.Background(), key)
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+	assert.ErrorIs(t, apiKeys.Revoke(context.Background(), model.ID), storage.ErrAPIKeyNotFound)
+}
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		key           string
+		ok            bool
+	}{
+		{name: "#1_ключ", authorization: "ApiKey sk_123", key: "sk_123", ok: true},
+		{name: "#2_регистр_схемы", authorization: "apikey sk_123", key: "sk_123", ok: true},
+		{name: "#3_токен", authorization: "payload.sign", ok: false},
+		{name: "#4_другая_схема", authorization: "Bearer sk_123", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := ParseAPIKey(tt.authorization)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.key, key)
+		})
+	}
+}
+
+func TestAuthWithAPIKey(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	apiKeys := NewAPIKeys(memoryStorage)
+	key, _, err := apiKeys.Mint(context.Background(), "uuid-1", "billing")
+	assert.NoError(t, err)
+
+	mockUserCreator := new(MockUserCreator)
+	checkAuth := NewCheckAuth(mockUserCreator, NewDefaultTokenManager(), apiKeys)
+
+	result, err := checkAuth.Auth(context.Background(), APIKeyScheme+" "+key)
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-1", result.UserUUID)
+	assert.False(t, result.IsNewUser)
+	assert.Empty(t, result.AuthString)
+
+	_, err = checkAuth.Auth(context.Background(), APIKeyScheme+" sk_unknown")
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+
+	// Без сервиса ключей авторизация по ключу отключена
+	_, err = NewCheckAuth(mockUserCreator, NewDefaultTokenManager(), nil).Auth(context.Background(), APIKeyScheme + " " + key)
+	assert.ErrorIs(t, err, ErrAPIKeyInvalid)
+	mockUserCreator.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
@@ -1,12 +1,8 @@
 package auntificator
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"net/http"
 	"testing"
-	"time"
 
 	"github.com/northmule/shorturl/internal/app/logger"
 )
@@ -34,6 +30,13 @@ func TestGetUserToken(t *testing.T) {
 			},
 			expected: "Bearer token123",
 		},
+		{
+			name: "api_key_header_present",
+			req: &http.Request{
+				Header: http.Header{"Authorization": []string{"token123"}, "X-Api-Key": []string{"sk_123"}},
+			},
+			expected: "ApiKey sk_123",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -45,82 +48,3 @@ func TestGetUserToken(t *testing.T) {
 		})
 	}
 }
-
-func TestGenerateToken(t *testing.T) {
-	_ = logger.InitLogger("fatal")
-	userUUID := "user123"
-	exp := time.Hour * 600
-	secretKey := "super_secret_key"
-
-	token, expTime := GenerateToken(userUUID, exp, secretKey)
-
-	_, err := hex.DecodeString(token)
-	if err != nil {
-		t.Errorf("Generated token %s is not a valid hex string", token)
-	}
-
-	if time.Until(expTime) > exp {
-		t.Errorf("Token expiration time is not within the expected range")
-	}
-}
-
-func TestValidateToken(t *testing.T) {
-	_ = logger.InitLogger("fatal")
-	userUUID := "user123"
-
-	// Test cases
-	testCases := []struct {
-		name     string
-		userUUID string
-		token    string
-		expected bool
-	}{
-		{
-			name:     "invalid_hex_token",
-			userUUID: userUUID,
-			token:    "invalidToken",
-			expected: false,
-		},
-		{
-			name:     "tampered_token",
-			userUUID: userUUID,
-			token:    hex.EncodeToString(hmac.New(sha256.New, []byte(HMACSecretKey)).Sum([]byte(userUUID))),
-			expected: false,
-		},
-		{
-			name:     "valid_token",
-			userUUID: userUUID,
-			token: func() string {
-				token, _ := GenerateToken(userUUID, HMACTokenExp, HMACSecretKey)
-				return token
-			}(),
-			expected: true,
-		},
-	}
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			got := ValidateToken(tc.userUUID, tc.token, HMACSecretKey)
-			if got != tc.expected {
-				t.Errorf("Expected %v, got %v", tc.expected, got)
-			}
-		})
-	}
-}
-
-func BenchmarkGenerateToken(b *testing.B) {
-	_ = logger.InitLogger("fatal")
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		GenerateToken("111111-22222-33333-44444", HMACTokenExp, HMACSecretKey)
-	}
-}
-
-func BenchmarkValidateToken(b *testing.B) {
-	_ = logger.InitLogger("fatal")
-	userUUID := "user123"
-	token, _ := GenerateToken(userUUID, HMACTokenExp, HMACSecretKey)
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		ValidateToken(userUUID, token, HMACSecretKey)
-	}
-}
@@ -1,9 +1,8 @@
 package auntificator
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,16 +13,18 @@ import (
 // CheckAuth структура.
 type CheckAuth struct {
 	userCreator UserCreator
+	tokens      *TokenManager
+	apiKeys     APIKeyResolver
 }
 
 // UserCreator интерфейс создания пользователей.
 type UserCreator interface {
-	CreateUser(user models.User) (int64, error)
+	CreateUser(ctx context.Context, user models.User) (int64, error)
 }
 
-// NewCheckAuth конструктор
-func NewCheckAuth(userCreator UserCreator) *CheckAuth {
-	return &CheckAuth{userCreator: userCreator}
+// NewCheckAuth конструктор, без apiKeys авторизация по ключам доступа отключена.
+func NewCheckAuth(userCreator UserCreator, tokens *TokenManager, apiKeys APIKeyResolver) *CheckAuth {
+	return &CheckAuth{userCreator: userCreator, tokens: tokens, apiKeys: apiKeys}
 }
 
 // ResultCheckAuth результаты работы функции
@@ -33,10 +34,16 @@ type ResultCheckAuth struct {
 	TokenExp   time.Time
 	AuthString string
 	IsNewUser  bool
+	// Токен перевыпущен текущим ключом, клиенту нужно передать новый токен
+	IsTokenRenewed bool
 }
 
 // Auth авторизация пользователя.
-func (c *CheckAuth) Auth(authorizationToken string) (*ResultCheckAuth, error) {
+func (c *CheckAuth) Auth(ctx context.Context, authorizationToken string) (*ResultCheckAuth, error) {
+
+	if apiKey, ok := ParseAPIKey(authorizationToken); ok {
+		return c.authByAPIKey(ctx, apiKey)
+	}
 
 	res := &ResultCheckAuth{}
 
@@ -44,29 +51,35 @@ func (c *CheckAuth) Auth(authorizationToken string) (*ResultCheckAuth, error) {
 	var exp time.Time
 	if authorizationToken == "" {
 		userUUID = uuid.NewString()
-		token, exp = GenerateToken(userUUID, HMACTokenExp, HMACSecretKey)
+		token, exp = c.tokens.Generate(userUUID)
 		logger.LogSugar.Infof("Cookies have not been transferred, I am creating a new user with a uuid %s", userUUID)
 		res.IsNewUser = true
 	} else {
-		cookieValues := strings.Split(authorizationToken, ":")
-		if len(cookieValues) < 2 {
-			logger.LogSugar.Infof("The user's UID was not found in the cookie %s", authorizationToken)
-			return nil, errors.New("missing user token")
-		}
-		cookieToken := cookieValues[0]
-		userUUID = cookieValues[1]
-		logger.LogSugar.Infof("I found cookies for a user with a uuid %s", userUUID)
-		if !ValidateToken(userUUID, cookieToken, HMACSecretKey) {
+		claims, err := c.tokens.Parse(authorizationToken)
+		switch {
+		case errors.Is(err, ErrTokenExpired):
+			logger.LogSugar.Infof("The token of the user with uuid %s has expired", claims.UserUUID)
+			return nil, err
+		case err != nil:
 			userUUID = uuid.NewString()
-			logger.LogSugar.Infof("The token failed validation for the user with uuid %s. Creating a new user", userUUID)
-			token, exp = GenerateToken(userUUID, HMACTokenExp, HMACSecretKey)
+			logger.LogSugar.Infof("The token failed validation (%s). Creating a new user with uuid %s", err, userUUID)
+			token, exp = c.tokens.Generate(userUUID)
 			res.IsNewUser = true
+		default:
+			userUUID = claims.UserUUID
+			token = authorizationToken
+			exp = time.Unix(claims.ExpiresAt, 0)
+			logger.LogSugar.Infof("I found cookies for a user with a uuid %s", userUUID)
+			if !c.tokens.IsCurrentKey(claims) {
+				logger.LogSugar.Infof("The token of the user with uuid %s is signed by the previous key %s, renewing", userUUID, claims.KeyID)
+				token, exp = c.tokens.Generate(userUUID)
+				res.IsTokenRenewed = true
+			}
 		}
-
 	}
-	c.createUser(userUUID)
+	c.createUser(ctx, userUUID)
 
-	res.AuthString = fmt.Sprintf("%s:%s", token, userUUID)
+	res.AuthString = token
 	res.Token = token
 	res.UserUUID = userUUID
 	res.TokenExp = exp
@@ -75,12 +88,23 @@ func (c *CheckAuth) Auth(authorizationToken string) (*ResultCheckAuth, error) {
 
 }
 
-func (c *CheckAuth) createUser(userUUID string) {
-	_, err := c.userCreator.CreateUser(models.User{
-		Name:     "test_user",
-		UUID:     userUUID,
-		Login:    "test_user" + userUUID,
-		Password: "password",
+// authByAPIKey авторизация межсервисного клиента, токен клиенту не выдаётся.
+func (c *CheckAuth) authByAPIKey(ctx context.Context, apiKey string) (*ResultCheckAuth, error) {
+	if c.apiKeys == nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	userUUID, err := c.apiKeys.Resolve(ctx, apiKey)
+	if err != nil {
+		logger.LogSugar.Infof("The api key failed validation: %s", err)
+		return nil, err
+	}
+	return &ResultCheckAuth{UserUUID: userUUID}, nil
+}
+
+func (c *CheckAuth) createUser(ctx context.Context, userUUID string) {
+	// Анонимный пользователь без логина и пароля, учётные данные появятся при регистрации
+	_, err := c.userCreator.CreateUser(ctx, models.User{
+		UUID: userUUID,
 	})
 	if err != nil {
 		logger.LogSugar.Errorf("Failed to create user: %v", err)
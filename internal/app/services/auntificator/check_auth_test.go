@@ -1,7 +1,7 @@
 package auntificator
 
 import (
-	"fmt"
+	"context"
 	"testing"
 	"time"
 
@@ -15,18 +15,18 @@ type MockUserCreator struct {
 	mock.Mock
 }
 
-func (m *MockUserCreator) CreateUser(user models.User) (int64, error) {
+func (m *MockUserCreator) CreateUser(ctx context.Context, user models.User) (int64, error) {
 	args := m.Called(user)
 	return 1, args.Error(1)
 }
 
 func TestAuthWithEmptyToken(t *testing.T) {
 	mockUserCreator := new(MockUserCreator)
-	checkAuth := NewCheckAuth(mockUserCreator)
+	checkAuth := NewCheckAuth(mockUserCreator, NewDefaultTokenManager(), nil)
 
 	mockUserCreator.On("CreateUser", mock.Anything).Return(int64(1), nil)
 
-	result, err := checkAuth.Auth("")
+	result, err := checkAuth.Auth(context.Background(), "")
 	assert.NoError(t, err)
 	assert.True(t, result.IsNewUser)
 	assert.NotEmpty(t, result.UserUUID)
@@ -34,21 +34,21 @@ func TestAuthWithEmptyToken(t *testing.T) {
 	assert.NotEqual(t, time.Time{}, result.TokenExp)
 
 	mockUserCreator.AssertCalled(t, "CreateUser", mock.MatchedBy(func(user models.User) bool {
-		return user.UUID == result.UserUUID && user.Name == "test_user" && user.Login == "test_user"+result.UserUUID && user.Password == "password"
+		return user.UUID == result.UserUUID && user.Name == "" && user.Login == "" && user.Password == ""
 	}))
 }
 
 func TestAuthWithInvalidToken(t *testing.T) {
 	mockUserCreator := new(MockUserCreator)
-	checkAuth := NewCheckAuth(mockUserCreator)
+	checkAuth := NewCheckAuth(mockUserCreator, NewDefaultTokenManager(), nil)
 
 	userUUID := uuid.NewString()
 	invalidToken := "invalid_token"
-	authString := fmt.Sprintf("%s:%s", invalidToken, userUUID)
+	authString := invalidToken + "." + userUUID
 
 	mockUserCreator.On("CreateUser", mock.Anything).Return(int64(1), nil)
 
-	result, err := checkAuth.Auth(authString)
+	result, err := checkAuth.Auth(context.Background(), authString)
 	assert.NoError(t, err)
 	assert.True(t, result.IsNewUser)
 	assert.NotEqual(t, userUUID, result.UserUUID)
@@ -56,6 +56,52 @@ func TestAuthWithInvalidToken(t *testing.T) {
 	assert.NotEqual(t, time.Time{}, result.TokenExp)
 
 	mockUserCreator.AssertCalled(t, "CreateUser", mock.MatchedBy(func(user models.User) bool {
-		return user.UUID == result.UserUUID && user.Name == "test_user" && user.Login == "test_user"+result.UserUUID && user.Password == "password"
+		return user.UUID == result.UserUUID && user.Name == "" && user.Login == "" && user.Password == ""
 	}))
 }
+
+func TestAuthWithValidToken(t *testing.T) {
+	mockUserCreator := new(MockUserCreator)
+	tokens := NewDefaultTokenManager()
+	checkAuth := NewCheckAuth(mockUserCreator, tokens, nil)
+	mockUserCreator.On("CreateUser", mock.Anything).Return(int64(1), nil)
+
+	userUUID := uuid.NewString()
+	token, _ := tokens.Generate(userUUID)
+
+	result, err := checkAuth.Auth(context.Background(), token)
+	assert.NoError(t, err)
+	assert.False(t, result.IsNewUser)
+	assert.False(t, result.IsTokenRenewed)
+	assert.Equal(t, userUUID, result.UserUUID)
+	assert.Equal(t, token, result.AuthString)
+}
+
+func TestAuthWithExpiredToken(t *testing.T) {
+	mockUserCreator := new(MockUserCreator)
+	tokens := NewTokenManager(SigningKey{ID: "k1", Secret: "secret"}, nil, -time.Minute)
+	checkAuth := NewCheckAuth(mockUserCreator, tokens, nil)
+
+	token, _ := tokens.Generate(uuid.NewString())
+
+	result, err := checkAuth.Auth(context.Background(), token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	assert.Nil(t, result)
+	mockUserCreator.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestAuthWithPreviousKeyToken(t *testing.T) {
+	mockUserCreator := new(MockUserCreator)
+	mockUserCreator.On("CreateUser", mock.Anything).Return(int64(1), nil)
+	oldKey := SigningKey{ID: "k1", Secret: "old_secret"}
+	oldToken, _ := NewTokenManager(oldKey, nil, time.Hour).Generate("uuid-1")
+
+	checkAuth := NewCheckAuth(mockUserCreator, NewTokenManager(SigningKey{ID: "k2", Secret: "new_secret"}, []SigningKey{oldKey}, time.Hour), nil)
+
+	result, err := checkAuth.Auth(context.Background(), oldToken)
+	assert.NoError(t, err)
+	assert.False(t, result.IsNewUser)
+	assert.True(t, result.IsTokenRenewed)
+	assert.Equal(t, "uuid-1", result.UserUUID)
+	assert.NotEqual(t, oldToken, result.AuthString)
+}
@@ -0,0 +1,175 @@
+package auntificator
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+)
+
+// DefaultKeyID идентификатор ключа подписи по умолчанию.
+const DefaultKeyID = "default"
+
+// Ошибки проверки токена.
+var (
+	// ErrTokenMalformed токен не удалось разобрать.
+	ErrTokenMalformed = errors.New("malformed token")
+	// ErrTokenUnknownKey токен подписан неизвестным ключом.
+	ErrTokenUnknownKey = errors.New("unknown token key")
+	// ErrTokenSignature подпись токена не совпала.
+	ErrTokenSignature = errors.New("invalid token signature")
+	// ErrTokenExpired срок действия токена истёк.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// SigningKey ключ подписи токенов.
+type SigningKey struct {
+	ID     string
+	Secret string
+}
+
+// TokenClaims данные, которые переносит токен.
+type TokenClaims struct {
+	UserUUID  string `json:"uid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	KeyID     string `json:"kid"`
+}
+
+// TokenManager выпуск и проверка подписанных токенов.
+// Токен имеет вид base64url(claims).base64url(hmac-sha256), новые токены подписываются текущим ключом,
+// предыдущие ключи принимаются только для проверки, что позволяет менять ключ без разлогинивания пользователей.
+type TokenManager struct {
+	current SigningKey
+	keys    map[string]SigningKey
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewTokenManager конструктор.
+func NewTokenManager(current SigningKey, previous []SigningKey, ttl time.Duration) *TokenManager {
+	keys := make(map[string]SigningKey, len(previous)+1)
+	for _, key := range previous {
+		keys[key.ID] = key
+	}
+	keys[current.ID] = current
+	return &TokenManager{
+		current: current,
+		keys:    keys,
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// defaultSecret ключ подписи NewDefaultTokenManager, случайный для каждого процесса:
+// известный заранее ключ позволил бы подделать токен любого пользователя.
+var defaultSecret = sync.OnceValue(func() string {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(secret)
+})
+
+// NewDefaultTokenManager менеджер токенов со случайным ключом процесса, токены не переживают перезапуск.
+func NewDefaultTokenManager() *TokenManager {
+	return NewTokenManager(SigningKey{ID: DefaultKeyID, Secret: defaultSecret()}, nil, HMACTokenExp)
+}
+
+// NewTokenManagerFromConfig менеджер токенов с ключами из конфигурации приложения.
+func NewTokenManagerFromConfig(cfg *config.Config) (*TokenManager, error) {
+	previous, err := ParseSigningKeys(cfg.AuthPreviousKeys)
+	if err != nil {
+		return nil, err
+	}
+	current := SigningKey{ID: cfg.AuthKeyID, Secret: cfg.AuthSecretKey}
+	if current.ID == "" || current.Secret == "" {
+		return nil, errors.New("auth signing key is not configured, set AUTH_SECRET_KEY or auth_secret_key")
+	}
+	return NewTokenManager(current, previous, cfg.AuthTokenTTL), nil
+}
+
+// ParseSigningKeys разбор списка ключей вида "kid1:secret1,kid2:secret2".
+func ParseSigningKeys(value string) ([]SigningKey, error) {
+	var keys []SigningKey
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, secret, found := strings.Cut(item, ":")
+		if !found || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key %q, expected kid:secret", item)
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: secret})
+	}
+	return keys, nil
+}
+
+// Generate выпускает токен пользователя, подписанный текущим ключом.
+func (m *TokenManager) Generate(userUUID string) (string, time.Time) {
+	issuedAt := m.now()
+	exp := issuedAt.Add(m.ttl)
+	claims := TokenClaims{
+		UserUUID:  userUUID,
+		IssuedAt:  issuedAt.Unix(),
+		ExpiresAt: exp.Unix(),
+		KeyID:     m.current.ID,
+	}
+	// Структура из строк и чисел всегда сериализуется
+	payload, _ := json.Marshal(claims)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	sign := base64.RawURLEncoding.EncodeToString(m.sign(m.current, encodedPayload))
+	return encodedPayload + "." + sign, exp
+}
+
+// Parse проверяет подпись и срок действия токена.
+func (m *TokenManager) Parse(token string) (*TokenClaims, error) {
+	encodedPayload, encodedSign, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrTokenMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	sign, err := base64.RawURLEncoding.DecodeString(encodedSign)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var claims TokenClaims
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.UserUUID == "" {
+		return nil, ErrTokenMalformed
+	}
+	key, ok := m.keys[claims.KeyID]
+	if !ok {
+		return nil, ErrTokenUnknownKey
+	}
+	if !hmac.Equal(sign, m.sign(key, encodedPayload)) {
+		return nil, ErrTokenSignature
+	}
+	if m.now().Unix() >= claims.ExpiresAt {
+		return &claims, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// IsCurrentKey токен подписан текущим ключом.
+func (m *TokenManager) IsCurrentKey(claims *TokenClaims) bool {
+	return claims.KeyID == m.current.ID
+}
+
+func (m *TokenManager) sign(key SigningKey, encodedPayload string) []byte {
+	hashed := hmac.New(sha256.New, []byte(key.Secret))
+	hashed.Write([]byte(encodedPayload))
+	return hashed.Sum(nil)
+}
@@ -0,0 +1,124 @@
+package auntificator
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_Parse(t *testing.T) {
+	current := SigningKey{ID: "k2", Secret: "new_secret"}
+	previous := SigningKey{ID: "k1", Secret: "old_secret"}
+	tokens := NewTokenManager(current, []SigningKey{previous}, time.Hour)
+
+	validToken, exp := tokens.Generate("uuid-1")
+	previousToken, _ := NewTokenManager(previous, nil, time.Hour).Generate("uuid-1")
+	unknownToken, _ := NewTokenManager(SigningKey{ID: "k0", Secret: "secret"}, nil, time.Hour).Generate("uuid-1")
+	expiredToken, _ := NewTokenManager(current, nil, -time.Second).Generate("uuid-1")
+	forgedToken, _ := NewTokenManager(SigningKey{ID: "k2", Secret: "other"}, nil, time.Hour).Generate("uuid-1")
+	payload, _, _ := strings.Cut(validToken, ".")
+	_, validSign, _ := strings.Cut(validToken, ".")
+	tamperedPayload := base64.RawURLEncoding.EncodeToString([]byte(`{"uid":"uuid-2","iat":1,"exp":9999999999,"kid":"k2"}`))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "#1_валидный_токен", token: validToken},
+		{name: "#2_токен_предыдущего_ключа", token: previousToken},
+		{name: "#3_неизвестный_ключ", token: unknownToken, wantErr: ErrTokenUnknownKey},
+		{name: "#4_истёкший_токен", token: expiredToken, wantErr: ErrTokenExpired},
+		{name: "#5_чужая_подпись", token: forgedToken, wantErr: ErrTokenSignature},
+		{name: "#6_подменённые_данные", token: tamperedPayload + "." + validSign, wantErr: ErrTokenSignature},
+		{name: "#7_без_подписи", token: payload, wantErr: ErrTokenMalformed},
+		{name: "#8_старый_формат", token: "2e41a78f9851029e40e85e70ac38d24c:431300ac-c58c-4dcf-941c-e47ca43511ba", wantErr: ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tokens.Parse(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "uuid-1", claims.UserUUID)
+		})
+	}
+
+	claims, err := tokens.Parse(validToken)
+	assert.NoError(t, err)
+	assert.Equal(t, exp.Unix(), claims.ExpiresAt)
+	assert.Equal(t, claims.IssuedAt+int64(time.Hour.Seconds()), claims.ExpiresAt)
+	assert.True(t, tokens.IsCurrentKey(claims))
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	keys, err := ParseSigningKeys(" k1:secret1, k2:sec:ret2 ,")
+	assert.NoError(t, err)
+	assert.Equal(t, []SigningKey{{ID: "k1", Secret: "secret1"}, {ID: "k2", Secret: "sec:ret2"}}, keys)
+
+	keys, err = ParseSigningKeys("")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = ParseSigningKeys("k1")
+	assert.Error(t, err)
+}
+
+func TestNewTokenManagerFromConfig(t *testing.T) {
+	tokens, err := NewTokenManagerFromConfig(&config.Config{
+		AuthKeyID:        "k2",
+		AuthSecretKey:    "secret2",
+		AuthPreviousKeys: "k1:secret1",
+		AuthTokenTTL:     time.Hour,
+	})
+	assert.NoError(t, err)
+	oldToken, _ := NewTokenManager(SigningKey{ID: "k1", Secret: "secret1"}, nil, time.Hour).Generate("uuid-1")
+	_, err = tokens.Parse(oldToken)
+	assert.NoError(t, err)
+
+	_, err = NewTokenManagerFromConfig(&config.Config{AuthKeyID: "k2"})
+	assert.Error(t, err)
+
+	_, err = NewTokenManagerFromConfig(&config.Config{AuthKeyID: "k2", AuthSecretKey: "secret2", AuthPreviousKeys: "broken"})
+	assert.Error(t, err)
+}
+
+func TestNewDefaultTokenManager(t *testing.T) {
+	token, _ := NewDefaultTokenManager().Generate("uuid-1")
+	// Ключ общий для процесса, токен принимает и другой менеджер по умолчанию
+	claims, err := NewDefaultTokenManager().Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, "uuid-1", claims.UserUUID)
+
+	// Прежний общеизвестный ключ токены не подписывает
+	forged, _ := NewTokenManager(SigningKey{ID: DefaultKeyID, Secret: "super_secret_key"}, nil, time.Hour).Generate("uuid-1")
+	_, err = NewDefaultTokenManager().Parse(forged)
+	assert.ErrorIs(t, err, ErrTokenSignature)
+}
+
+func BenchmarkGenerateToken(b *testing.B) {
+	_ = logger.InitLogger("fatal")
+	tokens := NewDefaultTokenManager()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tokens.Generate("111111-22222-33333-44444")
+	}
+}
+
+func BenchmarkValidateToken(b *testing.B) {
+	_ = logger.InitLogger("fatal")
+	tokens := NewDefaultTokenManager()
+	token, _ := tokens.Generate("user123")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tokens.Parse(token)
+	}
+}
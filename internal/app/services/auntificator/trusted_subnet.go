@@ -23,7 +23,7 @@ func NewTrustedSubnet(configApp *config.Config) *CheckTrustedSubnet {
 func (c *CheckTrustedSubnet) GrantAccess(ip string) error {
 
 	var err error
-	if c.configApp.TrustedSubnet == "" {
+	if c.configApp == nil || c.configApp.TrustedSubnet == "" {
 		return errors.New("trusted network is not set, access is limited")
 	}
 
@@ -0,0 +1,210 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// Форматы выгрузки.
+const (
+	// FormatCSV CSV с заголовком
+	FormatCSV = "csv"
+	// FormatJSON JSON массив
+	FormatJSON = "json"
+	// FormatNDJSON по одной JSON записи на строку
+	FormatNDJSON = "ndjson"
+)
+
+// ErrFormatUnknown не поддерживаемый формат выгрузки.
+var ErrFormatUnknown = errors.New("unknown export format")
+
+// contentTypes Content-Type ответа по формату.
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv",
+	FormatJSON:   "application/json",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// csvHeader колонки CSV, названия совпадают с колонками импорта.
+var csvHeader = []string{"short_url", "original_url", "created_at", "deleted_at", "expires_at", "clicks"}
+
+// Row строка выгрузки, поля совпадают с полями импорта.
+type Row struct {
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Clicks      int64      `json:"clicks"`
+}
+
+// NewRow строка выгрузки из ссылки, нулевое время не выгружается.
+func NewRow(url models.ExportURL) Row {
+	return Row{
+		ShortURL:    url.ShortURL,
+		OriginalURL: url.URL.URL,
+		CreatedAt:   optionalTime(url.CreatedAt),
+		DeletedAt:   optionalTime(url.DeletedAt),
+		ExpiresAt:   optionalTime(url.ExpiresAt),
+		Clicks:      url.Clicks,
+	}
+}
+
+// RowWriter запись ссылок в выгрузку.
+type RowWriter interface {
+	// Write записывает ссылку.
+	Write(url models.ExportURL) error
+	// Close дописывает окончание выгрузки и сбрасывает буфер.
+	Close() error
+}
+
+// NewRowWriter запись выгрузки в заданном формате.
+func NewRowWriter(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSON:
+		return newJSONWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	default:
+		return nil, ErrFormatUnknown
+	}
+}
+
+// ContentType Content-Type выгрузки в заданном формате.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// csvWriter выгрузка в CSV, заголовок пишется вместе с первой строкой.
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{
+		writer: csv.NewWriter(w),
+	}
+}
+
+// Write записывает ссылку.
+func (c *csvWriter) Write(url models.ExportURL) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.writer.Write([]string{
+		url.ShortURL,
+		url.URL.URL,
+		formatTime(url.CreatedAt),
+		formatTime(url.DeletedAt),
+		formatTime(url.ExpiresAt),
+		strconv.FormatInt(url.Clicks, 10),
+	})
+}
+
+// Close сбрасывает буфер, у пустой выгрузки остаётся только заголовок.
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.writer.Write(csvHeader)
+}
+
+// jsonWriter выгрузка JSON массивом, элементы пишутся по одному.
+type jsonWriter struct {
+	writer *bufio.Writer
+	count  int
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{
+		writer: bufio.NewWriter(w),
+	}
+}
+
+// Write записывает ссылку.
+func (j *jsonWriter) Write(url models.ExportURL) error {
+	data, err := json.Marshal(NewRow(url))
+	if err != nil {
+		return err
+	}
+	separator := byte(',')
+	if j.count == 0 {
+		separator = '['
+	}
+	j.count++
+	if err = j.writer.WriteByte(separator); err != nil {
+		return err
+	}
+	_, err = j.writer.Write(data)
+	return err
+}
+
+// Close закрывает массив и сбрасывает буфер.
+func (j *jsonWriter) Close() error {
+	closing := "]"
+	if j.count == 0 {
+		closing = "[]"
+	}
+	if _, err := j.writer.WriteString(closing); err != nil {
+		return err
+	}
+	return j.writer.Flush()
+}
+
+// ndjsonWriter выгрузка NDJSON.
+type ndjsonWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	writer := bufio.NewWriter(w)
+	return &ndjsonWriter{
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+// Write записывает ссылку.
+func (n *ndjsonWriter) Write(url models.ExportURL) error {
+	return n.encoder.Encode(NewRow(url))
+}
+
+// Close сбрасывает буфер.
+func (n *ndjsonWriter) Close() error {
+	return n.writer.Flush()
+}
+
+func optionalTime(value time.Time) *time.Time {
+	if value.IsZero() {
+		return nil
+	}
+	value = value.UTC()
+	return &value
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.UTC().Format(time.RFC3339)
+}
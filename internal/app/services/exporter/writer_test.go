@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportURLs() []models.ExportURL {
+	createdAt := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	deletedAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	return []models.ExportURL{
+		{URL: models.URL{ShortURL: "abc123", URL: "https://ya.ru/1", CreatedAt: createdAt}, Clicks: 3},
+		{URL: models.URL{ShortURL: "abc321", URL: "https://ya.ru/2", CreatedAt: createdAt, DeletedAt: deletedAt}},
+	}
+}
+
+func TestNewRowWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		urls   []models.ExportURL
+		want   string
+	}{
+		{
+			name:   "#1_csv",
+			format: FormatCSV,
+			urls:   exportURLs(),
+			want: "short_url,original_url,created_at,deleted_at,expires_at,clicks\n" +
+				"abc123,https://ya.ru/1,2026-10-17T10:00:00Z,,,3\n" +
+				"abc321,https://ya.ru/2,2026-10-17T10:00:00Z,2026-10-18T10:00:00Z,,0\n",
+		},
+		{
+			name:   "#2_csv_пустой",
+			format: FormatCSV,
+			want:   "short_url,original_url,created_at,deleted_at,expires_at,clicks\n",
+		},
+		{
+			name:   "#3_json",
+			format: FormatJSON,
+			urls:   exportURLs(),
+			want: `[{"short_url":"abc123","original_url":"https://ya.ru/1","created_at":"2026-10-17T10:00:00Z","clicks":3},` +
+				`{"short_url":"abc321","original_url":"https://ya.ru/2","created_at":"2026-10-17T10:00:00Z","deleted_at":"2026-10-18T10:00:00Z","clicks":0}]`,
+		},
+		{
+			name:   "#4_json_пустой",
+			format: FormatJSON,
+			want:   "[]",
+		},
+		{
+			name:   "#5_ndjson",
+			format: FormatNDJSON,
+			urls:   exportURLs()[:1],
+			want:   "{\"short_url\":\"abc123\",\"original_url\":\"https://ya.ru/1\",\"created_at\":\"2026-10-17T10:00:00Z\",\"clicks\":3}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewRowWriter(tt.format, &buf)
+			require.NoError(t, err)
+			for _, url := range tt.urls {
+				require.NoError(t, writer.Write(url))
+			}
+			require.NoError(t, writer.Close())
+			assert.Equal(t, tt.want, buf.String())
+			if tt.format == FormatJSON {
+				assert.True(t, json.Valid(buf.Bytes()))
+			}
+		})
+	}
+
+	_, err := NewRowWriter("xml", io.Discard)
+	assert.ErrorIs(t, err, ErrFormatUnknown)
+}
+
+func TestNewRowWriter_ImportRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		writer, err := NewRowWriter(format, &buf)
+		require.NoError(t, err)
+		for _, url := range exportURLs() {
+			require.NoError(t, writer.Write(url))
+		}
+		require.NoError(t, writer.Close())
+
+		reader, err := importer.NewRowReader(format, strings.NewReader(buf.String()))
+		require.NoError(t, err)
+		for _, url := range exportURLs() {
+			row, err := reader.Read()
+			require.NoError(t, err)
+			assert.Equal(t, url.ShortURL, row.ShortURL)
+			assert.Equal(t, url.URL.URL, row.OriginalURL)
+		}
+		_, err = reader.Read()
+		assert.ErrorIs(t, err, io.EOF)
+	}
+}
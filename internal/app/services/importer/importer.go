@@ -0,0 +1,412 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// Статусы задания импорта.
+const (
+	// StatusRunning строки ещё читаются или записываются
+	StatusRunning = "running"
+	// StatusDone все прочитанные строки обработаны
+	StatusDone = "done"
+	// StatusFailed импорт остановлен ошибкой чтения потока или хранилища
+	StatusFailed = "failed"
+)
+
+const (
+	// DefaultChunkSize количество ссылок, записываемых одной транзакцией, по умолчанию.
+	DefaultChunkSize = 1000
+	// MaxReportErrors наибольшее количество строк в отчёте об ошибках задания.
+	MaxReportErrors = 1000
+	// JobRetention время хранения завершённого задания.
+	JobRetention = 24 * time.Hour
+	// chunkQueueSize количество прочитанных пачек, ожидающих записи.
+	chunkQueueSize = 4
+	// codeAttempts попытки выдать свободный код строке без сохранённого кода.
+	codeAttempts = 5
+)
+
+// Ошибки импорта.
+var (
+	// ErrJobNotFound задание не найдено или принадлежит другому пользователю.
+	ErrJobNotFound = errors.New("import job not found")
+	// ErrURLExists ссылка уже была сокращена ранее, строка пропущена.
+	ErrURLExists = errors.New("url already exists")
+	// ErrCodeTaken сохраняемый код уже занят другой ссылкой.
+	ErrCodeTaken = errors.New("short url already taken")
+)
+
+// Writer запись импортируемых ссылок.
+type Writer interface {
+	// ImportURLs вставка пачки ссылок, ссылки с занятым URL или кодом пропускаются.
+	ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error)
+	// FindByURL поиск действующей ссылки по URL.
+	FindByURL(ctx context.Context, url string) (*models.URL, error)
+}
+
+// URLPreparer проверка ссылок и выдача кодов.
+type URLPreparer interface {
+	// PrepareURL нормализует и проверит ссылку.
+	PrepareURL(url string) (string, error)
+	// GenerateCode новый код короткой ссылки.
+	GenerateCode() (string, error)
+}
+
+// Options параметры импорта.
+type Options struct {
+	// PreserveCodes сохранить коды ссылок из прежнего сервиса
+	PreserveCodes bool
+}
+
+// RowError строка, которая не была импортирована.
+type RowError struct {
+	Line  int64
+	URL   string
+	Error string
+}
+
+// Progress состояние задания импорта.
+type Progress struct {
+	ID     string
+	Status string
+	// Received прочитано строк
+	Received int64
+	// Imported создано ссылок
+	Imported int64
+	// Skipped ссылка уже была сокращена ранее
+	Skipped int64
+	// Failed строки с ошибками
+	Failed int64
+	// Errors отчёт о пропущенных строках и строках с ошибками
+	Errors []RowError
+	// ErrorsTruncated в отчёт попали не все строки
+	ErrorsTruncated bool
+	// Error причина остановки задания
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Job задание импорта.
+type Job struct {
+	userUUID string
+	mx       sync.RWMutex
+	progress Progress
+	done     chan struct{}
+}
+
+// Progress копия текущего состояния задания.
+func (j *Job) Progress() Progress {
+	j.mx.RLock()
+	defer j.mx.RUnlock()
+	progress := j.progress
+	progress.Errors = append([]RowError(nil), j.progress.Errors...)
+	return progress
+}
+
+// Done закроется, когда все прочитанные строки будут обработаны.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+func (j *Job) received() {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.progress.Received++
+}
+
+func (j *Job) imported(count int) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.progress.Imported += int64(count)
+}
+
+func (j *Job) skip(rowError RowError) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.progress.Skipped++
+	j.report(rowError)
+}
+
+func (j *Job) fail(rowError RowError) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.progress.Failed++
+	j.report(rowError)
+}
+
+// report добавит строку в отчёт, вызывается под блокировкой.
+func (j *Job) report(rowError RowError) {
+	if len(j.progress.Errors) >= MaxReportErrors {
+		j.progress.ErrorsTruncated = true
+		return
+	}
+	j.progress.Errors = append(j.progress.Errors, rowError)
+}
+
+// stop запомнит причину остановки задания.
+func (j *Job) stop(err error) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	if j.progress.Error == "" {
+		j.progress.Error = err.Error()
+	}
+}
+
+// finish завершит задание.
+func (j *Job) finish() {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	j.progress.Status = StatusDone
+	if j.progress.Error != "" {
+		j.progress.Status = StatusFailed
+	}
+	j.progress.FinishedAt = time.Now()
+	close(j.done)
+}
+
+// isExpired завершено ли задание раньше указанного времени.
+func (j *Job) isExpired(before time.Time) bool {
+	j.mx.RLock()
+	defer j.mx.RUnlock()
+	return !j.progress.FinishedAt.IsZero() && j.progress.FinishedAt.Before(before)
+}
+
+// pendingRow проверенная строка, ожидающая записи.
+type pendingRow struct {
+	line      int64
+	url       models.URL
+	preserved bool
+	attempts  int
+}
+
+// Importer импорт ссылок из потока.
+// Задания хранятся только в памяти процесса, после перезапуска сервиса их состояние недоступно.
+type Importer struct {
+	writer    Writer
+	preparer  URLPreparer
+	chunkSize int
+	mx        sync.Mutex
+	jobs      map[string]*Job
+}
+
+// NewImporter конструктор.
+func NewImporter(writer Writer, preparer URLPreparer, chunkSize int) *Importer {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &Importer{
+		writer:    writer,
+		preparer:  preparer,
+		chunkSize: chunkSize,
+		jobs:      make(map[string]*Job),
+	}
+}
+
+// Import читает поток строк до конца и вернёт задание импорта.
+// Строки пишутся пачками по chunkSize в фоне, запись последних пачек может продолжаться после возврата.
+func (i *Importer) Import(ctx context.Context, userUUID string, reader RowReader, options Options) *Job {
+	job := i.newJob(userUUID)
+	chunks := make(chan []pendingRow, chunkQueueSize)
+	go i.write(context.WithoutCancel(ctx), job, chunks)
+
+	err := i.read(ctx, job, reader, options, chunks)
+	close(chunks)
+	if err != nil {
+		logger.LogSugar.Errorf("Импорт %s остановлен при чтении потока: %s", job.progress.ID, err)
+		job.stop(err)
+	}
+	return job
+}
+
+// Job задание пользователя по идентификатору.
+func (i *Importer) Job(id string, userUUID string) (*Job, error) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	job, ok := i.jobs[id]
+	if !ok || job.userUUID != userUUID {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// newJob зарегистрирует новое задание и удалит давно завершённые.
+func (i *Importer) newJob(userUUID string) *Job {
+	job := &Job{
+		userUUID: userUUID,
+		progress: Progress{
+			ID:        uuid.NewString(),
+			Status:    StatusRunning,
+			CreatedAt: time.Now(),
+		},
+		done: make(chan struct{}),
+	}
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	expiredBefore := time.Now().Add(-JobRetention)
+	for id, oldJob := range i.jobs {
+		if oldJob.isExpired(expiredBefore) {
+			delete(i.jobs, id)
+		}
+	}
+	i.jobs[job.progress.ID] = job
+	return job
+}
+
+// read проверит строки потока и передаст их на запись пачками.
+func (i *Importer) read(ctx context.Context, job *Job, reader RowReader, options Options, chunks chan<- []pendingRow) error {
+	chunk := make([]pendingRow, 0, i.chunkSize)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			job.received()
+			job.fail(RowError{Line: lineErr.Line, Error: lineErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		job.received()
+		pending, err := i.prepare(row, options)
+		if err != nil {
+			job.fail(RowError{Line: row.Line, URL: row.OriginalURL, Error: err.Error()})
+			continue
+		}
+		chunk = append(chunk, pending)
+		if len(chunk) < i.chunkSize {
+			continue
+		}
+		select {
+		case chunks <- chunk:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		chunk = make([]pendingRow, 0, i.chunkSize)
+	}
+	if len(chunk) == 0 {
+		return nil
+	}
+	select {
+	case chunks <- chunk:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prepare проверит строку и подберёт ей код.
+func (i *Importer) prepare(row Row, options Options) (pendingRow, error) {
+	originalURL, err := i.preparer.PrepareURL(row.OriginalURL)
+	if err != nil {
+		return pendingRow{}, err
+	}
+	expiresAt, err := url.ResolveExpiresAt(row.ExpiresAt, 0)
+	if err != nil {
+		return pendingRow{}, err
+	}
+	pending := pendingRow{
+		line: row.Line,
+		url: models.URL{
+			URL:       originalURL,
+			ExpiresAt: expiresAt,
+		},
+	}
+	if options.PreserveCodes && row.ShortURL != "" {
+		err = url.ValidateAlias(row.ShortURL)
+		if err != nil {
+			return pendingRow{}, err
+		}
+		pending.url.ShortURL = row.ShortURL
+		pending.preserved = true
+		return pending, nil
+	}
+	pending.url.ShortURL, err = i.preparer.GenerateCode()
+	if err != nil {
+		return pendingRow{}, err
+	}
+	return pending, nil
+}
+
+// write записывает пачки, после ошибки хранилища оставшиеся пачки отбрасываются.
+func (i *Importer) write(ctx context.Context, job *Job, chunks <-chan []pendingRow) {
+	var err error
+	for chunk := range chunks {
+		if err != nil {
+			continue
+		}
+		err = i.writeChunk(ctx, job, chunk)
+		if err != nil {
+			logger.LogSugar.Errorf("Импорт %s остановлен при записи: %s", job.progress.ID, err)
+			job.stop(err)
+		}
+	}
+	job.finish()
+}
+
+// writeChunk запишет пачку, строкам со сгенерированным кодом, который оказался занят, выдаётся новый код.
+func (i *Importer) writeChunk(ctx context.Context, job *Job, chunk []pendingRow) error {
+	for len(chunk) > 0 {
+		urls := make([]models.URL, 0, len(chunk))
+		for _, row := range chunk {
+			urls = append(urls, row.url)
+		}
+		imported, err := i.writer.ImportURLs(ctx, job.userUUID, urls)
+		if err != nil {
+			return err
+		}
+		job.imported(len(imported))
+		// Код вставленной ссылки и её URL
+		importedCodes := make(map[string]string, len(imported))
+		for _, modelURL := range imported {
+			importedCodes[modelURL.ShortURL] = modelURL.URL
+		}
+
+		retry := make([]pendingRow, 0)
+		for _, row := range chunk {
+			if importedURL, ok := importedCodes[row.url.ShortURL]; ok && importedURL == row.url.URL {
+				continue
+			}
+			rowError := RowError{Line: row.line, URL: row.url.URL}
+			_, err = i.writer.FindByURL(ctx, row.url.URL)
+			switch {
+			case err == nil:
+				rowError.Error = ErrURLExists.Error()
+				job.skip(rowError)
+			case !errors.Is(err, storage.ErrNotFound):
+				return err
+			case row.preserved:
+				rowError.Error = ErrCodeTaken.Error()
+				job.fail(rowError)
+			case row.attempts >= codeAttempts:
+				rowError.Error = url.ErrCodeGeneration.Error()
+				job.fail(rowError)
+			default:
+				row.url.ShortURL, err = i.preparer.GenerateCode()
+				if err != nil {
+					rowError.Error = err.Error()
+					job.fail(rowError)
+					continue
+				}
+				row.attempts++
+				retry = append(retry, row)
+			}
+		}
+		chunk = retry
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failWriter хранилище, запись в которое всегда завершается ошибкой.
+type failWriter struct {
+	err error
+}
+
+func (w *failWriter) ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error) {
+	return nil, w.err
+}
+
+func (w *failWriter) FindByURL(ctx context.Context, url string) (*models.URL, error) {
+	return nil, storage.ErrNotFound
+}
+
+// sequencePreparer выдаёт заранее заданные коды по очереди.
+type sequencePreparer struct {
+	codes []string
+}
+
+func (p *sequencePreparer) PrepareURL(url string) (string, error) {
+	return url, nil
+}
+
+func (p *sequencePreparer) GenerateCode() (string, error) {
+	if len(p.codes) == 0 {
+		return "", url.ErrCodeGeneration
+	}
+	code := p.codes[0]
+	p.codes = p.codes[1:]
+	return code, nil
+}
+
+// runImport импорт NDJSON с ожиданием окончания записи.
+func runImport(t *testing.T, importer *Importer, userUUID string, data string, options Options) Progress {
+	t.Helper()
+	reader, err := NewRowReader(FormatNDJSON, strings.NewReader(data))
+	require.NoError(t, err)
+	job := importer.Import(context.Background(), userUUID, reader, options)
+	<-job.Done()
+	return job.Progress()
+}
+
+func TestImporter_Import(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	_, err := memoryStorage.Add(context.Background(), models.URL{ShortURL: "taken1", URL: "https://exists.ru"})
+	require.NoError(t, err)
+
+	importer := NewImporter(memoryStorage, service, 2)
+	data := `{"original_url":"https://ozon.ru","short_url":"old001"}
+{"original_url":"https://google.com","short_url":"old002"}
+{"original_url":"https://exists.ru","short_url":"old003"}
+{"original_url":"https://mail.ru","short_url":"taken1"}
+{"original_url":"not a url"}
+{"original_url":"https://ozon.ru","short_url":"old004"}
+{broken`
+	progress := runImport(t, importer, "user-1", data, Options{PreserveCodes: true})
+
+	assert.Equal(t, StatusDone, progress.Status)
+	assert.Equal(t, int64(7), progress.Received)
+	assert.Equal(t, int64(2), progress.Imported)
+	assert.Equal(t, int64(2), progress.Skipped)
+	assert.Equal(t, int64(3), progress.Failed)
+	assert.Len(t, progress.Errors, 5)
+	assert.False(t, progress.FinishedAt.IsZero())
+
+	modelURL, err := memoryStorage.FindByShortURL(context.Background(), "old001")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", modelURL.URL)
+	userURLs, err := memoryStorage.FindUrlsByUserID(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Len(t, *userURLs, 2)
+
+	lines := make(map[int64]string, len(progress.Errors))
+	for _, rowError := range progress.Errors {
+		lines[rowError.Line] = rowError.Error
+	}
+	assert.Equal(t, ErrURLExists.Error(), lines[3])
+	assert.Equal(t, ErrCodeTaken.Error(), lines[4])
+	assert.Equal(t, ErrURLExists.Error(), lines[6])
+}
+
+func TestImporter_Import_RegeneratesTakenCodes(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.Add(context.Background(), models.URL{ShortURL: "code01", URL: "https://exists.ru"})
+	require.NoError(t, err)
+
+	preparer := &sequencePreparer{codes: []string{"code01", "code02"}}
+	importer := NewImporter(memoryStorage, preparer, 10)
+	progress := runImport(t, importer, "user-1", `{"original_url":"https://ozon.ru","short_url":"old001"}`, Options{})
+
+	assert.Equal(t, int64(1), progress.Imported)
+	assert.Equal(t, int64(0), progress.Failed)
+	modelURL, err := memoryStorage.FindByShortURL(context.Background(), "code02")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru", modelURL.URL)
+}
+
+func TestImporter_Import_ReportTruncated(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	importer := NewImporter(memoryStorage, service, 100)
+
+	var data strings.Builder
+	for i := 0; i < MaxReportErrors+10; i++ {
+		fmt.Fprintf(&data, "{\"original_url\":\"bad url %d\"}\n", i)
+	}
+	progress := runImport(t, importer, "user-1", data.String(), Options{})
+
+	assert.Equal(t, int64(MaxReportErrors+10), progress.Failed)
+	assert.Len(t, progress.Errors, MaxReportErrors)
+	assert.True(t, progress.ErrorsTruncated)
+}
+
+func TestImporter_Import_StorageError(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writerErr := errors.New("storage is down")
+	importer := NewImporter(&failWriter{err: writerErr}, &sequencePreparer{codes: []string{"code01", "code02"}}, 1)
+	progress := runImport(t, importer, "user-1", "{\"original_url\":\"https://ozon.ru\"}\n{\"original_url\":\"https://google.com\"}", Options{})
+
+	assert.Equal(t, StatusFailed, progress.Status)
+	assert.Equal(t, writerErr.Error(), progress.Error)
+	assert.Equal(t, int64(0), progress.Imported)
+}
+
+func TestImporter_Job(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	importer := NewImporter(memoryStorage, service, 0)
+	reader, err := NewRowReader(FormatNDJSON, strings.NewReader(""))
+	require.NoError(t, err)
+	job := importer.Import(context.Background(), "user-1", reader, Options{})
+	<-job.Done()
+
+	found, err := importer.Job(job.Progress().ID, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, job, found)
+
+	_, err = importer.Job(job.Progress().ID, "user-2")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	_, err = importer.Job("unknown", "user-1")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
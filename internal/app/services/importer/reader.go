@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Форматы потока импорта.
+const (
+	// FormatNDJSON по одной JSON записи на строку
+	FormatNDJSON = "ndjson"
+	// FormatCSV CSV с заголовком из колонок original_url, short_url, expires_at
+	FormatCSV = "csv"
+)
+
+// Колонки CSV и поля NDJSON.
+const (
+	columnOriginalURL = "original_url"
+	columnShortURL    = "short_url"
+	columnExpiresAt   = "expires_at"
+)
+
+// maxLineSize наибольший размер строки NDJSON.
+const maxLineSize = 64 * 1024
+
+// ErrFormatUnknown не поддерживаемый формат потока.
+var ErrFormatUnknown = errors.New("unknown import format")
+
+// ErrHeaderInvalid в заголовке CSV нет колонки original_url.
+var ErrHeaderInvalid = errors.New("csv header must contain original_url column")
+
+// ErrRowInvalid строку потока не удалось разобрать.
+var ErrRowInvalid = errors.New("import row is invalid")
+
+// Row строка импорта.
+type Row struct {
+	// Line номер строки в потоке, начиная с 1
+	Line int64 `json:"-"`
+	// OriginalURL оригинальная ссылка
+	OriginalURL string `json:"original_url"`
+	// ShortURL код ссылки в прежнем сервисе (необязательный)
+	ShortURL string `json:"short_url"`
+	// ExpiresAt время, после которого ссылка перестанет работать (необязательный)
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LineError ошибка разбора отдельной строки, после неё чтение потока можно продолжать.
+type LineError struct {
+	Line int64
+	Err  error
+}
+
+// Error текст ошибки.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap исходная ошибка.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// RowReader источник строк импорта.
+type RowReader interface {
+	// Read вернёт следующую строку, io.EOF по окончании потока или *LineError для строки, которую не удалось разобрать.
+	Read() (Row, error)
+}
+
+// NewRowReader читатель потока в заданном формате, строки читаются по мере поступления.
+func NewRowReader(format string, r io.Reader) (RowReader, error) {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r), nil
+	default:
+		return nil, ErrFormatUnknown
+	}
+}
+
+// ndjsonReader читатель NDJSON, пустые строки пропускаются.
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int64
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	return &ndjsonReader{
+		scanner: scanner,
+	}
+}
+
+// Read следующая строка.
+func (r *ndjsonReader) Read() (Row, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var row Row
+		if err := json.Unmarshal(data, &row); err != nil {
+			return Row{}, &LineError{Line: r.line, Err: errors.Join(ErrRowInvalid, err)}
+		}
+		row.Line = r.line
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
+
+// csvReader читатель CSV, колонки определяются по заголовку.
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+	return &csvReader{
+		reader: reader,
+	}
+}
+
+// Read следующая строка.
+func (r *csvReader) Read() (Row, error) {
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return Row{}, err
+		}
+	}
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Row{}, &LineError{Line: int64(parseErr.Line), Err: errors.Join(ErrRowInvalid, err)}
+		}
+		return Row{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	row := Row{
+		Line:        int64(line),
+		OriginalURL: r.field(record, columnOriginalURL),
+		ShortURL:    r.field(record, columnShortURL),
+	}
+	if expiresAt := r.field(record, columnExpiresAt); expiresAt != "" {
+		row.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return Row{}, &LineError{Line: row.Line, Err: errors.Join(ErrRowInvalid, err)}
+		}
+	}
+	return row, nil
+}
+
+// readHeader запомнит номера колонок из первой строки.
+func (r *csvReader) readHeader() error {
+	header, err := r.reader.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[columnOriginalURL]; !ok {
+		return ErrHeaderInvalid
+	}
+	r.columns = columns
+	return nil
+}
+
+// field значение колонки, пустая строка если колонки нет.
+func (r *csvReader) field(record []string, column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
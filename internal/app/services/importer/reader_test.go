@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll прочитает поток до конца, ошибки строк собираются отдельно.
+func readAll(t *testing.T, reader RowReader) ([]Row, []*LineError) {
+	t.Helper()
+	var rows []Row
+	var lineErrors []*LineError
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, lineErrors
+		}
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			lineErrors = append(lineErrors, lineErr)
+			continue
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestNewRowReader(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name       string
+		format     string
+		data       string
+		wantRows   []Row
+		wantErrors []int64
+	}{
+		{
+			name:   "#1_ndjson_пустые_строки_и_ошибки",
+			format: FormatNDJSON,
+			data: `{"original_url":"https://ya.ru","short_url":"abc123"}
+
+{"original_url":"https://google.com","expires_at":"2030-01-02T03:04:05Z"}
+{broken`,
+			wantRows: []Row{
+				{Line: 1, OriginalURL: "https://ya.ru", ShortURL: "abc123"},
+				{Line: 3, OriginalURL: "https://google.com", ExpiresAt: expiresAt},
+			},
+			wantErrors: []int64{4},
+		},
+		{
+			name:   "#2_csv_колонки_по_заголовку",
+			format: FormatCSV,
+			data: `short_url,original_url,expires_at
+abc123,https://ya.ru,
+,https://google.com,2030-01-02T03:04:05Z
+,https://mail.ru,tomorrow`,
+			wantRows: []Row{
+				{Line: 2, OriginalURL: "https://ya.ru", ShortURL: "abc123"},
+				{Line: 3, OriginalURL: "https://google.com", ExpiresAt: expiresAt},
+			},
+			wantErrors: []int64{4},
+		},
+		{
+			name:   "#3_csv_только_url",
+			format: FormatCSV,
+			data:   "original_url\nhttps://ya.ru\n",
+			wantRows: []Row{
+				{Line: 2, OriginalURL: "https://ya.ru"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewRowReader(tt.format, strings.NewReader(tt.data))
+			require.NoError(t, err)
+			rows, lineErrors := readAll(t, reader)
+			assert.Equal(t, len(tt.wantRows), len(rows))
+			for i := range tt.wantRows {
+				assert.Equal(t, tt.wantRows[i].Line, rows[i].Line)
+				assert.Equal(t, tt.wantRows[i].OriginalURL, rows[i].OriginalURL)
+				assert.Equal(t, tt.wantRows[i].ShortURL, rows[i].ShortURL)
+				assert.True(t, tt.wantRows[i].ExpiresAt.Equal(rows[i].ExpiresAt))
+			}
+			lines := make([]int64, 0, len(lineErrors))
+			for _, lineErr := range lineErrors {
+				assert.ErrorIs(t, lineErr, ErrRowInvalid)
+				lines = append(lines, lineErr.Line)
+			}
+			assert.Equal(t, len(tt.wantErrors), len(lines))
+			if len(tt.wantErrors) > 0 {
+				assert.Equal(t, tt.wantErrors, lines)
+			}
+		})
+	}
+}
+
+func TestNewRowReader_Errors(t *testing.T) {
+	_, err := NewRowReader("xml", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrFormatUnknown)
+
+	reader, err := NewRowReader(FormatCSV, strings.NewReader("url,code\nhttps://ya.ru,abc\n"))
+	require.NoError(t, err)
+	_, err = reader.Read()
+	assert.ErrorIs(t, err, ErrHeaderInvalid)
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+// ReloadInterval период проверки изменений файла политики.
+const ReloadInterval = 5 * time.Second
+
+// FilePolicy политика, загружаемая из JSON файла и перечитываемая при его изменении.
+type FilePolicy struct {
+	path     string
+	interval time.Duration
+	stopChan <-chan struct{}
+
+	mx      sync.RWMutex
+	policy  *Policy
+	modTime time.Time
+	size    int64
+}
+
+// NewFilePolicy конструктор, загружает правила и запускает отслеживание изменений файла.
+func NewFilePolicy(path string, interval time.Duration, stop <-chan struct{}) (*FilePolicy, error) {
+	if path == "" {
+		return nil, errors.New("policy file path is empty")
+	}
+	instance := &FilePolicy{
+		path:     path,
+		interval: interval,
+		stopChan: stop,
+	}
+	if err := instance.Reload(); err != nil {
+		return nil, err
+	}
+
+	go instance.watcher()
+
+	return instance, nil
+}
+
+// Check проверит ссылку по текущим правилам.
+func (f *FilePolicy) Check(rawURL string) error {
+	f.mx.RLock()
+	policy := f.policy
+	f.mx.RUnlock()
+	return policy.Check(rawURL)
+}
+
+// Reload перечитает файл политики, при ошибке остаются прежние правила.
+func (f *FilePolicy) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	var rules Rules
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return errors.Join(errors.New("failed to parse policy file"), err)
+	}
+	policy, err := NewPolicy(rules)
+	if err != nil {
+		return err
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.policy = policy
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
+
+func (f *FilePolicy) watcher() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stopChan:
+			logger.LogSugar.Info("Поступил сигнал о закрытии отслеживания файла политики")
+			return
+		case <-ticker.C:
+			if !f.isChanged() {
+				continue
+			}
+			// Ошибочный файл не перечитывается повторно, пока не будет изменён ещё раз
+			if err := f.Reload(); err != nil {
+				logger.LogSugar.Errorf("Не удалось перечитать файл политики %s: %s", f.path, err)
+				continue
+			}
+			logger.LogSugar.Infof("Файл политики %s перечитан", f.path)
+		}
+	}
+}
+
+func (f *FilePolicy) isChanged() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		logger.LogSugar.Errorf("Не удалось проверить файл политики %s: %s", f.path, err)
+		return false
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return false
+	}
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return true
+}
@@ -0,0 +1,170 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	netURL "net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrDestinationDenied ссылка запрещена политикой.
+var ErrDestinationDenied = errors.New("destination denied by policy")
+
+// RuleAllowlistMiss условное имя правила, когда хост не попал ни в одно разрешающее правило.
+const RuleAllowlistMiss = "allowlist"
+
+// Префиксы записи правил.
+const (
+	// regexpRulePrefix правило в виде регулярного выражения для хоста, например "re:^.*\.evil\.ru$"
+	regexpRulePrefix = "re:"
+	// wildcardRulePrefix правило для всех поддоменов, например "*.example.com"
+	wildcardRulePrefix = "*."
+)
+
+// DeniedError ссылка запрещена правилом политики.
+type DeniedError struct {
+	// Rule правило, под которое попала ссылка
+	Rule string
+}
+
+// Error текст ошибки.
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("%s: rule %q", ErrDestinationDenied, e.Rule)
+}
+
+// Is ошибка совместима с ErrDestinationDenied.
+func (e *DeniedError) Is(target error) bool {
+	return target == ErrDestinationDenied
+}
+
+// Rules набор правил в исходном виде, так они хранятся в файле политики.
+type Rules struct {
+	// Allow разрешающие правила, если список не пустой - разрешены только подходящие под него хосты
+	Allow []string `json:"allow"`
+	// Deny запрещающие правила, имеют приоритет над разрешающими
+	Deny []string `json:"deny"`
+}
+
+// rule разобранное правило.
+type rule struct {
+	source string
+	host   string
+	suffix string
+	re     *regexp.Regexp
+	subnet *net.IPNet
+}
+
+// Policy проверка ссылок по спискам разрешённых и запрещённых хостов.
+type Policy struct {
+	allow []rule
+	deny  []rule
+}
+
+// NewPolicy разбор правил.
+// Поддерживаются точное имя хоста, "*.домен" для поддоменов, "re:выражение" и CIDR для IP-адресов.
+func NewPolicy(rules Rules) (*Policy, error) {
+	allow, err := parseRules(rules.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseRules(rules.Deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{
+		allow: allow,
+		deny:  deny,
+	}, nil
+}
+
+// Check проверит хост ссылки, при запрете вернёт *DeniedError.
+func (p *Policy) Check(rawURL string) error {
+	parsedURL, err := netURL.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+	for _, r := range p.deny {
+		if r.match(host) {
+			return &DeniedError{Rule: r.source}
+		}
+	}
+	if len(p.allow) == 0 {
+		return nil
+	}
+	for _, r := range p.allow {
+		if r.match(host) {
+			return nil
+		}
+	}
+	return &DeniedError{Rule: RuleAllowlistMiss}
+}
+
+func (r rule) match(host string) bool {
+	switch {
+	case r.subnet != nil:
+		ip := net.ParseIP(host)
+		return ip != nil && r.subnet.Contains(ip)
+	case r.re != nil:
+		return r.re.MatchString(host)
+	case r.suffix != "":
+		return strings.HasSuffix(host, r.suffix)
+	default:
+		return host == r.host
+	}
+}
+
+func parseRules(sources []string) ([]rule, error) {
+	rules := make([]rule, 0, len(sources))
+	for _, source := range sources {
+		source = strings.TrimSpace(source)
+		if source == "" {
+			continue
+		}
+		r, err := parseRule(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy rule %q: %w", source, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func parseRule(source string) (rule, error) {
+	r := rule{source: source}
+	switch {
+	case strings.HasPrefix(source, regexpRulePrefix):
+		re, err := regexp.Compile(strings.TrimPrefix(source, regexpRulePrefix))
+		if err != nil {
+			return r, err
+		}
+		r.re = re
+	case strings.Contains(source, "/"):
+		_, subnet, err := net.ParseCIDR(source)
+		if err != nil {
+			return r, err
+		}
+		r.subnet = subnet
+	case strings.HasPrefix(source, wildcardRulePrefix):
+		domain, err := idna.Lookup.ToASCII(strings.TrimPrefix(source, wildcardRulePrefix))
+		if err != nil {
+			return r, err
+		}
+		r.suffix = "." + strings.ToLower(domain)
+	default:
+		if ip := net.ParseIP(source); ip != nil {
+			r.host = ip.String()
+			break
+		}
+		host, err := idna.Lookup.ToASCII(source)
+		if err != nil {
+			return r, err
+		}
+		r.host = strings.ToLower(host)
+	}
+	return r, nil
+}
@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	policy, err := NewPolicy(Rules{
+		Deny: []string{
+			"evil.com",
+			"*.phishing.net",
+			`re:^login-.*\.bank\.ru$`,
+			"10.0.0.0/8",
+			"пример.рф",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		wantRule string
+	}{
+		{name: "#1_точный_хост", url: "https://evil.com/a", wantRule: "evil.com"},
+		{name: "#2_поддомен_точного_хоста_разрешён", url: "https://www.evil.com/a"},
+		{name: "#3_поддомен_по_маске", url: "https://a.b.phishing.net/a", wantRule: "*.phishing.net"},
+		{name: "#4_домен_маски_без_поддомена_разрешён", url: "https://phishing.net/a"},
+		{name: "#5_регулярное_выражение", url: "https://login-secure.bank.ru", wantRule: `re:^login-.*\.bank\.ru$`},
+		{name: "#6_ip_в_подсети", url: "http://10.1.2.3:8080/a", wantRule: "10.0.0.0/8"},
+		{name: "#7_ip_вне_подсети", url: "http://11.1.2.3/a"},
+		{name: "#8_idn_правило", url: "https://xn--e1afmkfd.xn--p1ai/a", wantRule: "пример.рф"},
+		{name: "#9_хост_не_из_правил", url: "https://ya.ru/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.url)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			var deniedErr *DeniedError
+			if !errors.As(err, &deniedErr) {
+				t.Fatalf("Check() error = %v, want *DeniedError", err)
+			}
+			if deniedErr.Rule != tt.wantRule {
+				t.Errorf("Check() rule = %v, want %v", deniedErr.Rule, tt.wantRule)
+			}
+			if !errors.Is(err, ErrDestinationDenied) {
+				t.Errorf("Check() error = %v, want %v", err, ErrDestinationDenied)
+			}
+		})
+	}
+}
+
+func TestPolicy_Allowlist(t *testing.T) {
+	policy, err := NewPolicy(Rules{
+		Allow: []string{"*.example.com", "example.com"},
+		Deny:  []string{"admin.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		wantRule string
+	}{
+		{name: "#1_разрешённый_домен", url: "https://example.com/a"},
+		{name: "#2_разрешённый_поддомен", url: "https://docs.example.com/a"},
+		{name: "#3_запрет_важнее_разрешения", url: "https://admin.example.com/a", wantRule: "admin.example.com"},
+		{name: "#4_хост_вне_списка_разрешённых", url: "https://ya.ru/a", wantRule: RuleAllowlistMiss},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.url)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			var deniedErr *DeniedError
+			if !errors.As(err, &deniedErr) || deniedErr.Rule != tt.wantRule {
+				t.Errorf("Check() error = %v, want rule %v", err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestNewPolicy_InvalidRule(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+	}{
+		{name: "#1_ошибка_в_выражении", rules: Rules{Deny: []string{"re:(["}}},
+		{name: "#2_ошибка_в_подсети", rules: Rules{Allow: []string{"10.0.0.0/99"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy(tt.rules); err == nil {
+				t.Error("NewPolicy() expected error")
+			}
+		})
+	}
+}
+
+func TestFilePolicy_Reload(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"deny":["evil.com"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	filePolicy, err := NewFilePolicy(path, 10*time.Millisecond, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = filePolicy.Check("https://evil.com"); !errors.Is(err, ErrDestinationDenied) {
+		t.Fatalf("Check() error = %v, want %v", err, ErrDestinationDenied)
+	}
+
+	// Ошибочный файл не заменяет действующие правила
+	if err = os.WriteFile(path, []byte(`{"deny":["re:(["]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err = filePolicy.Check("https://evil.com"); !errors.Is(err, ErrDestinationDenied) {
+		t.Fatalf("Check() error = %v, want %v", err, ErrDestinationDenied)
+	}
+
+	if err = os.WriteFile(path, []byte(`{"deny":["other-evil.com"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for filePolicy.Check("https://evil.com") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Файл политики не перечитан после изменения")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err = filePolicy.Check("https://other-evil.com"); !errors.Is(err, ErrDestinationDenied) {
+		t.Errorf("Check() error = %v, want %v", err, ErrDestinationDenied)
+	}
+}
+
+func TestNewFilePolicy_Errors(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	if _, err := NewFilePolicy("", ReloadInterval, stop); err == nil {
+		t.Error("NewFilePolicy() expected error for empty path")
+	}
+	if _, err := NewFilePolicy(filepath.Join(t.TempDir(), "absent.json"), ReloadInterval, stop); err == nil {
+		t.Error("NewFilePolicy() expected error for absent file")
+	}
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`not json`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFilePolicy(path, ReloadInterval, stop); err == nil {
+		t.Error("NewFilePolicy() expected error for invalid json")
+	}
+}
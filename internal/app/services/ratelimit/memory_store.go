@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval как часто удаляются вёдра, которые успели наполниться полностью.
+const sweepInterval = time.Minute
+
+// MemoryStore хранилище вёдер в памяти процесса.
+type MemoryStore struct {
+	mx        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Duration
+}
+
+// NewMemoryStore конструктор.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket, 1000),
+	}
+}
+
+// Take забирает токен из ведра ключа.
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.full = time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second))
+
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true}, nil
+	}
+	retryAfter := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return Result{Allowed: false, RetryAfter: retryAfter}, nil
+}
+
+// Len количество отслеживаемых ключей.
+func (s *MemoryStore) Len() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return len(s.buckets)
+}
+
+// sweep удаляет вёдра, которые за время простоя наполнились бы полностью: их состояние равно новому ведру.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.full {
+			delete(s.buckets, key)
+		}
+	}
+}
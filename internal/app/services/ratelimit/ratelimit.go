@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+// Group группа маршрутов с общими лимитами.
+type Group string
+
+// Группы маршрутов.
+const (
+	// GroupShorten сокращение одной ссылки.
+	GroupShorten Group = "shorten"
+	// GroupBatch пакетное сокращение ссылок.
+	GroupBatch Group = "batch"
+	// GroupRedirect переход по короткой ссылке.
+	GroupRedirect Group = "redirect"
+)
+
+// Limit параметры ведра токенов.
+type Limit struct {
+	// Скорость пополнения ведра, запросов в секунду
+	Rate float64
+	// Размер ведра, допустимый всплеск запросов
+	Burst int
+}
+
+// Enabled лимит задан.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// ParseLimit разбор лимита вида "rate:burst", например "10:20". Пустое значение или "0" отключают лимит.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+	rateValue, burstValue, found := strings.Cut(value, ":")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected rate:burst", value)
+	}
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	// NaN и бесконечность ParseFloat принимает, но ведро с такой скоростью не наполняется предсказуемо
+	if err != nil || rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected rate:burst", value)
+	}
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil || burst < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected rate:burst", value)
+	}
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// Result решение ограничителя.
+type Result struct {
+	Allowed bool
+	// Через сколько можно повторить запрос
+	RetryAfter time.Duration
+}
+
+// Store хранилище состояния вёдер. Позволяет заменить in-process хранилище общим для нескольких экземпляров.
+type Store interface {
+	Take(key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Limiter ограничитель запросов по группам маршрутов.
+type Limiter struct {
+	store  Store
+	limits map[Group]Limit
+	now    func() time.Time
+}
+
+// NewLimiter конструктор.
+func NewLimiter(store Store, limits map[Group]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: limits,
+		now:    time.Now,
+	}
+}
+
+// NewLimiterFromConfig ограничитель с лимитами из конфигурации приложения и хранилищем в памяти.
+func NewLimiterFromConfig(cfg *config.Config) (*Limiter, error) {
+	values := map[Group]string{
+		GroupShorten:  cfg.RateLimitShorten,
+		GroupBatch:    cfg.RateLimitBatch,
+		GroupRedirect: cfg.RateLimitRedirect,
+	}
+	limits := make(map[Group]Limit, len(values))
+	var err error
+	for group, value := range values {
+		limit, parseErr := ParseLimit(value)
+		if parseErr != nil {
+			err = errors.Join(err, fmt.Errorf("%s: %w", group, parseErr))
+			continue
+		}
+		limits[group] = limit
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewLimiter(NewMemoryStore(), limits), nil
+}
+
+// Allow проверяет, можно ли выполнить запрос группы для ключа.
+// При ошибке хранилища запрос пропускается, чтобы недоступность счётчиков не останавливала сервис.
+func (l *Limiter) Allow(group Group, key string) Result {
+	limit, ok := l.limits[group]
+	if !ok || !limit.Enabled() {
+		return Result{Allowed: true}
+	}
+	result, err := l.store.Take(string(group)+":"+key, limit, l.now())
+	if err != nil {
+		logger.LogSugar.Errorf("Ошибка хранилища ограничителя запросов: %s", err)
+		return Result{Allowed: true}
+	}
+	return result
+}
+
+// Key ключ ограничения: пользователь, если он подтвердил авторизацию, иначе адрес клиента.
+func Key(userUUID string, ip string) string {
+	if userUUID != "" {
+		return "user:" + userUUID
+	}
+	return "ip:" + ip
+}
+
+// RetryAfterSeconds значение заголовка Retry-After, округляется вверх до целых секунд.
+func RetryAfterSeconds(retryAfter time.Duration) string {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{name: "#1_лимит", value: "10:20", want: Limit{Rate: 10, Burst: 20}},
+		{name: "#2_дробная_скорость", value: "0.5:1", want: Limit{Rate: 0.5, Burst: 1}},
+		{name: "#3_пусто", value: "", want: Limit{}},
+		{name: "#4_отключен", value: "0", want: Limit{}},
+		{name: "#5_без_всплеска", value: "10", wantErr: true},
+		{name: "#6_не_число", value: "a:b", wantErr: true},
+		{name: "#7_отрицательный", value: "-1:5", wantErr: true},
+		{name: "#8_nan", value: "NaN:5", wantErr: true},
+		{name: "#9_бесконечность", value: "Inf:5", wantErr: true},
+		{name: "#10_отрицательная_бесконечность", value: "-Inf:5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimit(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 2, Burst: 3}
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	// Всплеск в пределах ведра
+	for i := 0; i < 3; i++ {
+		result, err := store.Take("key", limit, now)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err := store.Take("key", limit, now)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// Другой ключ не затронут
+	result, _ = store.Take("other", limit, now)
+	assert.True(t, result.Allowed)
+
+	// Через полсекунды появляется один токен
+	result, _ = store.Take("key", limit, now.Add(500*time.Millisecond))
+	assert.True(t, result.Allowed)
+	result, _ = store.Take("key", limit, now.Add(500*time.Millisecond))
+	assert.False(t, result.Allowed)
+
+	// Простаивающие вёдра удаляются
+	assert.Equal(t, 2, store.Len())
+	_, _ = store.Take("new", limit, now.Add(time.Hour))
+	assert.Equal(t, 1, store.Len())
+}
+
+type badStore struct{}
+
+func (badStore) Take(key string, limit Limit, now time.Time) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	limiter := NewLimiter(NewMemoryStore(), map[Group]Limit{
+		GroupShorten: {Rate: 1, Burst: 1},
+		GroupBatch:   {},
+	})
+
+	assert.True(t, limiter.Allow(GroupShorten, "user:1").Allowed)
+	assert.False(t, limiter.Allow(GroupShorten, "user:1").Allowed)
+	assert.True(t, limiter.Allow(GroupShorten, "user:2").Allowed)
+
+	// Отключенный лимит и группа без лимита
+	for i := 0; i < 10; i++ {
+		assert.True(t, limiter.Allow(GroupBatch, "user:1").Allowed)
+		assert.True(t, limiter.Allow(GroupRedirect, "user:1").Allowed)
+	}
+
+	// Ошибка хранилища не блокирует запросы
+	limiter = NewLimiter(badStore{}, map[Group]Limit{GroupShorten: {Rate: 1, Burst: 1}})
+	assert.True(t, limiter.Allow(GroupShorten, "user:1").Allowed)
+}
+
+func TestNewLimiterFromConfig(t *testing.T) {
+	limiter, err := NewLimiterFromConfig(&config.Config{RateLimitShorten: "1:1", RateLimitBatch: "0"})
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 1, Burst: 1}, limiter.limits[GroupShorten])
+	assert.False(t, limiter.limits[GroupBatch].Enabled())
+
+	_, err = NewLimiterFromConfig(&config.Config{RateLimitRedirect: "fast"})
+	assert.Error(t, err)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "user:uuid-1", Key("uuid-1", "127.0.0.1"))
+	assert.Equal(t, "ip:127.0.0.1", Key("", "127.0.0.1"))
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, "1", RetryAfterSeconds(0))
+	assert.Equal(t, "1", RetryAfterSeconds(200*time.Millisecond))
+	assert.Equal(t, "2", RetryAfterSeconds(1500*time.Millisecond))
+}
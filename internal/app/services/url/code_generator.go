@@ -0,0 +1,125 @@
+package url
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+)
+
+// Стратегии генерации кода короткой ссылки.
+const (
+	// GeneratorRandom криптографически случайный код
+	GeneratorRandom = "random"
+	// GeneratorSequence код из порядкового номера
+	GeneratorSequence = "sequence"
+)
+
+// Ограничения на длину генерируемого кода.
+const (
+	// CodeMinSize минимальная длина кода.
+	CodeMinSize = 4
+	// CodeMaxSize максимальная длина кода.
+	CodeMaxSize = 32
+)
+
+// base62Alphabet алфавит генерируемых кодов.
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// sequenceAlphabet перемешанный алфавит base62, чтобы коды соседних номеров не выглядели последовательными.
+const sequenceAlphabet = "HwVIbkRtQ2u3dPvOY68hZjNolEny9CscSK5DiLrXfU17meG0pzWAMgJTFq4Bax"
+
+// CodeGenerator генератор кода короткой ссылки.
+type CodeGenerator interface {
+	Generate() (string, error)
+}
+
+// RandomCodeGenerator код из криптографически случайных символов base62.
+type RandomCodeGenerator struct {
+	length int
+}
+
+// NewRandomCodeGenerator конструктор.
+func NewRandomCodeGenerator(length int) *RandomCodeGenerator {
+	return &RandomCodeGenerator{
+		length: length,
+	}
+}
+
+// Generate новый случайный код.
+func (g *RandomCodeGenerator) Generate() (string, error) {
+	code := make([]byte, 0, g.length)
+	buffer := make([]byte, g.length)
+	for len(code) < g.length {
+		if _, err := rand.Read(buffer); err != nil {
+			return "", err
+		}
+		for _, b := range buffer {
+			// Отбрасываем значения вне алфавита, чтобы символы были равновероятны
+			b &= 63
+			if int(b) >= len(base62Alphabet) {
+				continue
+			}
+			code = append(code, base62Alphabet[b])
+			if len(code) == g.length {
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// SequenceCodeGenerator код из возрастающего номера в base62 с перемешанным алфавитом.
+// Коды короче случайных при той же уникальности, номер дополняется до минимальной длины.
+type SequenceCodeGenerator struct {
+	length  int
+	counter atomic.Uint64
+}
+
+// NewSequenceCodeGenerator конструктор, start - номер, после которого начнётся выдача кодов.
+func NewSequenceCodeGenerator(length int, start uint64) *SequenceCodeGenerator {
+	generator := &SequenceCodeGenerator{
+		length: length,
+	}
+	generator.counter.Store(start)
+	return generator
+}
+
+// Generate код следующего номера.
+func (g *SequenceCodeGenerator) Generate() (string, error) {
+	return encodeSequence(g.counter.Add(1), g.length), nil
+}
+
+// encodeSequence номер записывается младшими разрядами вперёд, поэтому у соседних номеров отличается первый символ.
+func encodeSequence(number uint64, length int) string {
+	base := uint64(len(sequenceAlphabet))
+	code := make([]byte, 0, length)
+	for number > 0 {
+		code = append(code, sequenceAlphabet[number%base])
+		number /= base
+	}
+	for len(code) < length {
+		code = append(code, sequenceAlphabet[0])
+	}
+	return string(code)
+}
+
+// NewCodeGeneratorFromConfig генератор кодов по стратегии из конфигурации приложения.
+func NewCodeGeneratorFromConfig(cfg *config.Config) (CodeGenerator, error) {
+	length := cfg.ShortURLLength
+	if length < CodeMinSize || length > CodeMaxSize {
+		return nil, fmt.Errorf("short url length must be between %d and %d", CodeMinSize, CodeMaxSize)
+	}
+	switch cfg.ShortURLGenerator {
+	case GeneratorRandom:
+		return NewRandomCodeGenerator(length), nil
+	case GeneratorSequence:
+		// Счётчик начинается с текущего времени, чтобы после перезапуска не выдавать уже занятые коды
+		return NewSequenceCodeGenerator(length, uint64(time.Now().UnixMilli())), nil
+	default:
+		return nil, errors.New("unknown short url generator " + cfg.ShortURLGenerator)
+	}
+}
@@ -0,0 +1,147 @@
+package url
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// codesMock генератор, выдающий заранее заданные коды.
+type codesMock struct {
+	codes []string
+	calls int
+}
+
+func (g *codesMock) Generate() (string, error) {
+	if g.calls >= len(g.codes) {
+		return "", errors.New("codes are over")
+	}
+	code := g.codes[g.calls]
+	g.calls++
+	return code, nil
+}
+
+func TestRandomCodeGenerator_Generate(t *testing.T) {
+	generator := NewRandomCodeGenerator(12)
+	codes := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		code, err := generator.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 12 {
+			t.Fatalf("Generate() длина = %d, ожидается 12", len(code))
+		}
+		for _, char := range code {
+			if !strings.ContainsRune(base62Alphabet, char) {
+				t.Fatalf("Generate() символ %q вне алфавита base62", char)
+			}
+		}
+		codes[code] = true
+	}
+	if len(codes) != 1000 {
+		t.Errorf("Generate() ожидается 1000 разных кодов, получено %d", len(codes))
+	}
+}
+
+func TestSequenceCodeGenerator_Generate(t *testing.T) {
+	generator := NewSequenceCodeGenerator(6, 0)
+	codes := make(map[string]bool)
+	previous := ""
+	for i := 0; i < 10000; i++ {
+		code, err := generator.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 6 {
+			t.Fatalf("Generate() длина = %d, ожидается 6", len(code))
+		}
+		if previous != "" && code[0] == previous[0] {
+			t.Fatalf("Generate() у соседних кодов %s и %s совпадает первый символ", previous, code)
+		}
+		codes[code] = true
+		previous = code
+	}
+	if len(codes) != 10000 {
+		t.Errorf("Generate() ожидается 10000 разных кодов, получено %d", len(codes))
+	}
+}
+
+func TestNewCodeGeneratorFromConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator string
+		length    int
+		wantErr   bool
+	}{
+		{name: "#1_случайный", generator: GeneratorRandom, length: 10},
+		{name: "#2_последовательный", generator: GeneratorSequence, length: 8},
+		{name: "#3_неизвестная_стратегия", generator: "uuid", length: 10, wantErr: true},
+		{name: "#4_слишком_короткий", generator: GeneratorRandom, length: CodeMinSize - 1, wantErr: true},
+		{name: "#5_слишком_длинный", generator: GeneratorRandom, length: CodeMaxSize + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := NewCodeGeneratorFromConfig(&config.Config{ShortURLGenerator: tt.generator, ShortURLLength: tt.length})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCodeGeneratorFromConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			code, err := generator.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(code) < tt.length {
+				t.Errorf("Generate() длина = %d, ожидается не меньше %d", len(code), tt.length)
+			}
+		})
+	}
+}
+
+func TestShortURLService_GenerateRetry(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.Add(context.Background(), models.URL{ShortURL: "taken1", URL: "https://ya.ru/taken"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("#1_занятый_и_служебный_коды_пропускаются", func(t *testing.T) {
+		s := NewShortURLService(memoryStorage, memoryStorage)
+		s.SetCodeGenerator(&codesMock{codes: []string{"taken1", "ping", "free01"}})
+		data, err := s.DecodeURL(context.Background(), "", "https://ya.ru/new")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data.ShortURL != "free01" {
+			t.Errorf("DecodeURL() got = %v, want %v", data.ShortURL, "free01")
+		}
+	})
+	t.Run("#2_повтор_внутри_пакета", func(t *testing.T) {
+		s := NewShortURLService(memoryStorage, memoryStorage)
+		s.SetCodeGenerator(&codesMock{codes: []string{"batch1", "batch1", "batch2"}})
+		results, err := s.DecodeURLs(context.Background(), "", []BatchItem{{URL: "https://ya.ru/b1"}, {URL: "https://ya.ru/b2"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].ShortURL != "batch1" || results[1].ShortURL != "batch2" {
+			t.Errorf("DecodeURLs() got = %v, %v", results[0].ShortURL, results[1].ShortURL)
+		}
+	})
+	t.Run("#3_свободный_код_не_найден", func(t *testing.T) {
+		s := NewShortURLService(memoryStorage, memoryStorage)
+		s.SetCodeGenerator(&codesMock{codes: []string{"taken1", "taken1", "taken1", "taken1", "taken1"}})
+		_, err := s.DecodeURL(context.Background(), "", "https://ya.ru/other")
+		if !errors.Is(err, ErrCodeGeneration) {
+			t.Errorf("DecodeURL() error = %v, want %v", err, ErrCodeGeneration)
+		}
+	})
+}
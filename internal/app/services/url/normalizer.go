@@ -0,0 +1,128 @@
+package url
+
+import (
+	"errors"
+	"net"
+	netURL "net/url"
+	"strings"
+
+	"github.com/northmule/shorturl/config"
+	"golang.org/x/net/idna"
+)
+
+// ErrURLInvalid ссылка не прошла проверку.
+var ErrURLInvalid = errors.New("url is invalid")
+
+// allowedSchemes разрешённые схемы ссылок и их порты по умолчанию.
+var allowedSchemes = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// defaultNormalizer правила проверки ссылок по умолчанию.
+var defaultNormalizer = NewNormalizer(NormalizerOptions{})
+
+// NormalizerOptions параметры проверки ссылок.
+type NormalizerOptions struct {
+	// RejectPrivateHosts запрещает ссылки на localhost, loopback и адреса частных сетей
+	RejectPrivateHosts bool
+}
+
+// Normalizer проверка и приведение ссылок к единому виду,
+// чтобы одинаковые адреса, записанные по-разному, не превращались в разные короткие ссылки.
+type Normalizer struct {
+	options NormalizerOptions
+}
+
+// NewNormalizer конструктор.
+func NewNormalizer(options NormalizerOptions) *Normalizer {
+	return &Normalizer{
+		options: options,
+	}
+}
+
+// NewNormalizerFromConfig нормализатор с параметрами из конфигурации приложения.
+func NewNormalizerFromConfig(cfg *config.Config) *Normalizer {
+	return NewNormalizer(NormalizerOptions{
+		RejectPrivateHosts: cfg.URLRejectPrivateHosts,
+	})
+}
+
+// Normalize проверит ссылку и вернёт её нормализованный вид:
+// схема и хост в нижнем регистре, IDN-хост в punycode, без порта по умолчанию, фрагмента и пустого пути "/".
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", errors.Join(ErrURLInvalid, errors.New("url is empty"))
+	}
+	if strings.ContainsAny(rawURL, " \t\r\n") {
+		return "", errors.Join(ErrURLInvalid, errors.New("url contains whitespace"))
+	}
+	parsedURL, err := netURL.Parse(rawURL)
+	if err != nil {
+		return "", errors.Join(ErrURLInvalid, err)
+	}
+
+	scheme := strings.ToLower(parsedURL.Scheme)
+	defaultPort, ok := allowedSchemes[scheme]
+	if !ok {
+		return "", errors.Join(ErrURLInvalid, errors.New("scheme must be http or https"))
+	}
+	if parsedURL.Opaque != "" || parsedURL.Hostname() == "" {
+		return "", errors.Join(ErrURLInvalid, errors.New("url must contain host"))
+	}
+
+	host, err := normalizeHost(parsedURL.Hostname())
+	if err != nil {
+		return "", errors.Join(ErrURLInvalid, err)
+	}
+	if n.options.RejectPrivateHosts && isPrivateHost(host) {
+		return "", errors.Join(ErrURLInvalid, errors.New("private hosts are not allowed"))
+	}
+
+	port := parsedURL.Port()
+	if port == defaultPort {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host = host + ":" + port
+	}
+
+	parsedURL.Scheme = scheme
+	parsedURL.Host = host
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+	if parsedURL.Path == "/" {
+		parsedURL.Path = ""
+		parsedURL.RawPath = ""
+	}
+	return parsedURL.String(), nil
+}
+
+// normalizeHost IP-адрес приводится к каноническому виду, доменное имя - к punycode в нижнем регистре.
+func normalizeHost(host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	host = strings.TrimSuffix(host, ".")
+	asciiHost, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(asciiHost), nil
+}
+
+// isPrivateHost хост указывает на локальную машину или частную сеть.
+func isPrivateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
@@ -0,0 +1,78 @@
+package url
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/northmule/shorturl/config"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    string
+		wantErr error
+	}{
+		{name: "#1_ссылка_без_изменений", rawURL: "https://ya.ru/map?a=1", want: "https://ya.ru/map?a=1"},
+		{name: "#2_схема_и_хост_в_нижнем_регистре", rawURL: "HTTP://X.COM/", want: "http://x.com"},
+		{name: "#3_пустой_путь", rawURL: "http://x.com/", want: "http://x.com"},
+		{name: "#4_регистр_пути_сохраняется", rawURL: "http://X.com/Path/A", want: "http://x.com/Path/A"},
+		{name: "#5_порт_по_умолчанию_http", rawURL: "http://x.com:80/a", want: "http://x.com/a"},
+		{name: "#6_порт_по_умолчанию_https", rawURL: "https://x.com:443/a", want: "https://x.com/a"},
+		{name: "#7_нестандартный_порт", rawURL: "https://x.com:8443/a", want: "https://x.com:8443/a"},
+		{name: "#8_фрагмент_удаляется", rawURL: "https://x.com/a?b=1#section", want: "https://x.com/a?b=1"},
+		{name: "#9_пробелы_по_краям", rawURL: "  https://x.com/a\n", want: "https://x.com/a"},
+		{name: "#10_idn_в_punycode", rawURL: "https://ПРЕЗИДЕНТ.рф/news", want: "https://xn--d1abbgf6aiiy.xn--p1ai/news"},
+		{name: "#11_точка_в_конце_хоста", rawURL: "https://x.com./a", want: "https://x.com/a"},
+		{name: "#12_ipv6", rawURL: "http://[2001:DB8::1]:80/a", want: "http://[2001:db8::1]/a"},
+		{name: "#13_текст_перед_ссылкой", rawURL: "foo http://x.com", wantErr: ErrURLInvalid},
+		{name: "#14_неразрешённая_схема", rawURL: "ftp://x.com/file", wantErr: ErrURLInvalid},
+		{name: "#15_javascript", rawURL: "javascript:alert(1)", wantErr: ErrURLInvalid},
+		{name: "#16_без_схемы", rawURL: "x.com/a", wantErr: ErrURLInvalid},
+		{name: "#17_без_хоста", rawURL: "http:///a", wantErr: ErrURLInvalid},
+		{name: "#18_пустая_строка", rawURL: "", wantErr: ErrURLInvalid},
+		{name: "#19_не_ссылка", rawURL: "Жил был слон!", wantErr: ErrURLInvalid},
+		{name: "#20_localhost_разрешён_по_умолчанию", rawURL: "http://localhost:8080/a", want: "http://localhost:8080/a"},
+	}
+	normalizer := NewNormalizer(NormalizerOptions{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizer.Normalize(tt.rawURL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizer_RejectPrivateHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr error
+	}{
+		{name: "#1_localhost", rawURL: "http://localhost/a", wantErr: ErrURLInvalid},
+		{name: "#2_поддомен_localhost", rawURL: "http://app.LOCALHOST/a", wantErr: ErrURLInvalid},
+		{name: "#3_loopback", rawURL: "http://127.0.0.1:8080/a", wantErr: ErrURLInvalid},
+		{name: "#4_loopback_ipv6", rawURL: "http://[::1]/a", wantErr: ErrURLInvalid},
+		{name: "#5_частная_сеть", rawURL: "http://192.168.1.10/a", wantErr: ErrURLInvalid},
+		{name: "#6_частная_сеть_10", rawURL: "http://10.0.0.1/a", wantErr: ErrURLInvalid},
+		{name: "#7_link_local", rawURL: "http://169.254.169.254/latest", wantErr: ErrURLInvalid},
+		{name: "#8_неопределённый_адрес", rawURL: "http://0.0.0.0/a", wantErr: ErrURLInvalid},
+		{name: "#9_публичный_адрес", rawURL: "http://8.8.8.8/a", wantErr: nil},
+		{name: "#10_публичный_домен", rawURL: "https://ya.ru/a", wantErr: nil},
+	}
+	normalizer := NewNormalizerFromConfig(&config.Config{URLRejectPrivateHosts: true})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := normalizer.Normalize(tt.rawURL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
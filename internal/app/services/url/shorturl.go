@@ -1,11 +1,13 @@
 package url
 
 import (
+	"context"
 	"errors"
-	"math/rand"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/storage/models"
@@ -14,35 +16,105 @@ import (
 // ShortURLDefaultSize размер короткой ссылки.
 const ShortURLDefaultSize = 10
 
+// Ограничения на длину пользовательского псевдонима.
+const (
+	// AliasMinSize минимальная длина псевдонима.
+	AliasMinSize = 3
+	// AliasMaxSize максимальная длина псевдонима.
+	AliasMaxSize = 64
+)
+
+// Ошибки проверки пользовательского псевдонима.
+var (
+	// ErrAliasInvalid псевдоним содержит недопустимые символы или не подходит по длине.
+	ErrAliasInvalid = errors.New("alias is invalid")
+	// ErrAliasReserved псевдоним совпадает со служебным путём сервиса.
+	ErrAliasReserved = errors.New("alias is reserved")
+	// ErrAliasExists псевдоним уже занят другой ссылкой.
+	ErrAliasExists = errors.New("alias already exists")
+)
+
+// ErrCodeGeneration не удалось подобрать свободный код короткой ссылки.
+var ErrCodeGeneration = errors.New("failed to generate free short url")
+
+// generateAttempts количество попыток подобрать свободный код.
+const generateAttempts = 5
+
+// ErrURLExists ссылка уже была сокращена ранее.
+var ErrURLExists = errors.New("url already exists")
+
+// Ошибки получения оригинальной ссылки.
+var (
+	// ErrShortURLNotFound короткая ссылка не найдена.
+	ErrShortURLNotFound = errors.New("short url not found")
+	// ErrShortURLGone короткая ссылка удалена или истёк срок её жизни.
+	ErrShortURLGone = errors.New("short url gone")
+)
+
+// ErrExpirationInvalid не корректно задан срок жизни ссылки.
+var ErrExpirationInvalid = errors.New("expiration is invalid")
+
+// reservedAliases служебные пути, которые нельзя использовать как псевдоним.
+var reservedAliases = []string{"ping", "api", "debug"}
+
+// aliasPattern допустимые символы псевдонима.
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 // ShortURLData параметры данных сервиса.
 type ShortURLData struct {
 	URL       string
 	ShortURL  string
 	URLID     int64
 	DeletedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired истёк ли срок жизни ссылки.
+func (d *ShortURLData) IsExpired() bool {
+	return !d.ExpiresAt.IsZero() && !time.Now().Before(d.ExpiresAt)
+}
+
+// DecodeOptions дополнительные параметры создания короткой ссылки.
+type DecodeOptions struct {
+	// Alias желаемый код короткой ссылки
+	Alias string
+	// ExpiresAt время, после которого ссылка перестаёт работать
+	ExpiresAt time.Time
 }
 
 // ShortURLService сервис сокращения ссылок.
+// Сервис не хранит состояние запросов и может использоваться конкурентно.
 type ShortURLService struct {
-	Finder       Finder
-	Setter       Setter
-	shortURLData ShortURLData
+	Finder     Finder
+	Setter     Setter
+	normalizer *Normalizer
+	policy     DestinationPolicy
+	generator  CodeGenerator
 }
 
 // Setter добавления нового URL.
 type Setter interface {
-	Add(url models.URL) (int64, error)
-	MultiAdd(urls []models.URL) error
+	Add(ctx context.Context, url models.URL) (int64, error)
+	MultiAdd(ctx context.Context, urls []models.URL) error
+	// LikeURLToUser связывает пользователя с ссылкой.
+	LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error
+	// UpdateUserURL смена URL ссылки её владельцем.
+	UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error)
 }
 
 // Finder поиск значений.
 type Finder interface {
 	// FindUrlsByUserID поиск ссылок пользователя
-	FindUrlsByUserID(userUUID string) (*[]models.URL, error)
+	FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error)
 	// FindByShortURL поиск по короткой ссылке.
-	FindByShortURL(shortURL string) (*models.URL, error)
+	FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error)
 	// FindByURL поиск по URL.
-	FindByURL(url string) (*models.URL, error)
+	FindByURL(ctx context.Context, url string) (*models.URL, error)
+}
+
+// DestinationPolicy проверка ссылки по правилам перед сохранением.
+type DestinationPolicy interface {
+	Check(url string) error
 }
 
 // NewShortURLService конструктор сервиса.
@@ -55,66 +127,379 @@ func NewShortURLService(finder Finder, setter Setter) *ShortURLService {
 	return service
 }
 
-// DecodeURL вернёт короткий url.
-func (s *ShortURLService) DecodeURL(url string) (data *ShortURLData, err error) {
-	modelURL, _ := s.Finder.FindByURL(url)
-	if modelURL.ShortURL != "" {
-		s.shortURLData.ShortURL = modelURL.ShortURL
-	} else {
-		s.shortURLData.ShortURL = newRandomString(ShortURLDefaultSize)
+// SetNormalizer устанавливает правила проверки и нормализации ссылок.
+func (s *ShortURLService) SetNormalizer(normalizer *Normalizer) {
+	s.normalizer = normalizer
+}
+
+// SetCodeGenerator устанавливает генератор кодов коротких ссылок.
+func (s *ShortURLService) SetCodeGenerator(generator CodeGenerator) {
+	s.generator = generator
+}
+
+// SetPolicy устанавливает политику разрешённых и запрещённых ссылок.
+func (s *ShortURLService) SetPolicy(policy DestinationPolicy) {
+	s.policy = policy
+}
+
+// NormalizeURL проверит ссылку и приведёт её к виду, в котором она хранится.
+// Если правила не заданы, используются правила по умолчанию.
+func (s *ShortURLService) NormalizeURL(url string) (string, error) {
+	if s.normalizer == nil {
+		return defaultNormalizer.Normalize(url)
+	}
+	return s.normalizer.Normalize(url)
+}
+
+// DecodeURL вернёт короткую ссылку, созданную для пользователя.
+func (s *ShortURLService) DecodeURL(ctx context.Context, userUUID string, url string) (ShortURLData, error) {
+	return s.DecodeURLWithOptions(ctx, userUUID, url, DecodeOptions{})
+}
+
+// DecodeURLWithOptions вернёт короткую ссылку с учётом псевдонима и срока жизни ссылки.
+// Если ссылка уже была сокращена, вернёт её существующий код вместе с ErrURLExists.
+func (s *ShortURLService) DecodeURLWithOptions(ctx context.Context, userUUID string, url string, options DecodeOptions) (ShortURLData, error) {
+	var data ShortURLData
+	url, err := s.PrepareURL(url)
+	if err != nil {
+		return data, err
+	}
+	alias := options.Alias
+	if alias != "" {
+		err = ValidateAlias(alias)
+		if err != nil {
+			return data, err
+		}
+	}
+	modelURL, err := s.Finder.FindByURL(ctx, url)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return data, err
+	}
+	switch {
+	case modelURL != nil:
+		data.ShortURL = modelURL.ShortURL
+	case alias != "":
+		taken, err := s.isShortURLTaken(ctx, alias)
+		if err != nil {
+			return data, err
+		}
+		if taken {
+			return data, ErrAliasExists
+		}
+		data.ShortURL = alias
+	default:
+		data.ShortURL, err = s.generateShortURL(ctx, nil)
+		if err != nil {
+			return data, err
+		}
 	}
 
-	s.shortURLData.URL = url
-	urlID, err := s.Setter.Add(models.URL{
-		ShortURL: s.shortURLData.ShortURL,
-		URL:      s.shortURLData.URL,
+	data.URL = url
+	data.ExpiresAt = options.ExpiresAt
+	urlID, err := s.Setter.Add(ctx, models.URL{
+		ShortURL:  data.ShortURL,
+		URL:       data.URL,
+		ExpiresAt: data.ExpiresAt,
 	})
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if !errors.As(err, &pgErr) || pgErr.Code != storage.CodeErrorDuplicateKey {
+		if !errors.Is(err, storage.ErrConflict) {
 			logger.LogSugar.Errorf("не удалось сохранить URL %s", url)
+			return ShortURLData{}, err
+		}
+		existURL, findErr := s.Finder.FindByURL(ctx, url)
+		// Псевдоним успели занять между проверкой и вставкой
+		if errors.Is(findErr, storage.ErrNotFound) {
+			if alias != "" && data.ShortURL == alias {
+				return ShortURLData{}, ErrAliasExists
+			}
+			return ShortURLData{}, err
+		}
+		if findErr != nil {
+			return ShortURLData{}, findErr
+		}
+		data.ShortURL = existURL.ShortURL
+		data.URLID = int64(existURL.ID)
+		data.ExpiresAt = existURL.ExpiresAt
+		return data, ErrURLExists
+	}
+	data.URLID = urlID
+	err = s.Setter.LikeURLToUser(ctx, urlID, userUUID)
+	if err != nil {
+		return ShortURLData{}, err
+	}
+	return data, nil
+}
+
+// BatchItem элемент пакетного сокращения ссылок.
+type BatchItem struct {
+	// URL оригинальная ссылка
+	URL string
+	// ExpiresAt время, после которого ссылка перестаёт работать
+	ExpiresAt time.Time
+	// TTLSeconds время жизни ссылки в секундах
+	TTLSeconds int64
+}
+
+// BatchResult результат сокращения элемента пакета.
+type BatchResult struct {
+	// URL нормализованная оригинальная ссылка
+	URL string
+	// ShortURL код короткой ссылки
+	ShortURL string
+	// Existing ссылка была сокращена ранее, возвращён её существующий код
+	Existing bool
+	// Err ошибка проверки элемента, код для такого элемента не создаётся
+	Err error
+}
+
+// DecodeURLs сокращение массива ссылок.
+// Результаты возвращаются в порядке элементов запроса, одинаковые ссылки получают одинаковый результат.
+// Ошибки проверки отдельных элементов возвращаются в BatchResult.Err, ошибка метода означает сбой хранилища.
+func (s *ShortURLService) DecodeURLs(ctx context.Context, userUUID string, items []BatchItem) ([]BatchResult, error) {
+	results := make([]BatchResult, len(items))
+	// Индекс первого элемента с такой же ссылкой
+	firstIndexes := make(map[string]int, len(items))
+	duplicates := make(map[int]int)
+	generated := make(map[string]bool, len(items))
+	newIndexes := make([]int, 0, len(items))
+	newURLs := make([]models.URL, 0, len(items))
+	for i, item := range items {
+		url, expiresAt, err := s.prepareBatchItem(item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].URL = url
+		if first, ok := firstIndexes[url]; ok {
+			duplicates[i] = first
+			continue
+		}
+		firstIndexes[url] = i
+
+		modelURL, err := s.Finder.FindByURL(ctx, url)
+		if err == nil {
+			results[i].ShortURL = modelURL.ShortURL
+			results[i].Existing = true
+			continue
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		results[i].ShortURL, err = s.generateShortURL(ctx, generated)
+		if err != nil {
+			return nil, err
+		}
+		generated[results[i].ShortURL] = true
+		newIndexes = append(newIndexes, i)
+		newURLs = append(newURLs, models.URL{
+			ShortURL:  results[i].ShortURL,
+			URL:       url,
+			ExpiresAt: expiresAt,
+		})
+	}
+	if len(newURLs) > 0 {
+		err := s.Setter.MultiAdd(ctx, newURLs)
+		if err != nil {
+			logger.LogSugar.Errorf("не удалось сохранить пакет из %d ссылок", len(newURLs))
+			return nil, err
 		}
-		return nil, err
 	}
-	s.shortURLData.URLID = urlID
-	return &s.shortURLData, nil
+	for _, i := range newIndexes {
+		modelURL, err := s.Finder.FindByURL(ctx, results[i].URL)
+		if err != nil {
+			return nil, err
+		}
+		// Ссылку успели сократить параллельно, вставка была пропущена
+		if modelURL.ShortURL != results[i].ShortURL {
+			results[i].ShortURL = modelURL.ShortURL
+			results[i].Existing = true
+			continue
+		}
+		err = s.Setter.LikeURLToUser(ctx, int64(modelURL.ID), userUUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, first := range duplicates {
+		results[i] = results[first]
+	}
+	return results, nil
 }
 
-// DecodeURLs преобразование массива url.
-func (s *ShortURLService) DecodeURLs(urls []string) ([]models.URL, error) {
-	modelURLs := make([]models.URL, len(urls))
-	modelURL := new(models.URL)
-	for i, url := range urls {
-		modelURL.URL = url
-		modelURL.ShortURL = newRandomString(ShortURLDefaultSize)
-		modelURLs[i] = *modelURL
+// prepareBatchItem проверит элемент пакета и вернёт нормализованную ссылку и время окончания её жизни.
+func (s *ShortURLService) prepareBatchItem(item BatchItem) (string, time.Time, error) {
+	url, err := s.PrepareURL(item.URL)
+	if err != nil {
+		return "", time.Time{}, err
 	}
-	err := s.Setter.MultiAdd(modelURLs)
+	expiresAt, err := ResolveExpiresAt(item.ExpiresAt, item.TTLSeconds)
 	if err != nil {
-		return nil, err
+		return "", time.Time{}, err
 	}
-	return modelURLs, nil
+	return url, expiresAt, nil
 }
 
-// EncodeShortURL вернёт полный url.
-func (s *ShortURLService) EncodeShortURL(shortURL string) (data *ShortURLData, err error) {
-	modelURL, err := s.Finder.FindByShortURL(shortURL)
+// PrepareURL нормализует ссылку и проверит её политикой разрешённых и запрещённых ссылок.
+func (s *ShortURLService) PrepareURL(url string) (string, error) {
+	url, err := s.NormalizeURL(url)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkPolicy(url)
 	if err != nil {
-		return nil, errors.New("short url not found")
+		return "", err
+	}
+	return url, nil
+}
+
+// checkPolicy проверка ссылки политикой, если она задана.
+func (s *ShortURLService) checkPolicy(url string) error {
+	if s.policy == nil {
+		return nil
+	}
+	return s.policy.Check(url)
+}
+
+// UpdateURL сменит URL короткой ссылки пользователя, новый URL проверяется так же, как при сокращении.
+// Чужая или несуществующая ссылка вернёт ErrShortURLNotFound, удалённая - ErrShortURLGone,
+// URL, уже сокращённый другой ссылкой, - ErrURLExists.
+func (s *ShortURLService) UpdateURL(ctx context.Context, userUUID string, shortURL string, url string) (ShortURLData, error) {
+	url, err := s.PrepareURL(url)
+	if err != nil {
+		return ShortURLData{}, err
+	}
+	modelURL, err := s.Setter.UpdateUserURL(ctx, userUUID, shortURL, url)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return ShortURLData{}, ErrShortURLNotFound
+	case errors.Is(err, storage.ErrGone):
+		return ShortURLData{}, ErrShortURLGone
+	case errors.Is(err, storage.ErrConflict):
+		return ShortURLData{}, ErrURLExists
+	case err != nil:
+		return ShortURLData{}, err
+	}
+	return ShortURLData{
+		URL:       modelURL.URL,
+		ShortURL:  modelURL.ShortURL,
+		URLID:     int64(modelURL.ID),
+		ExpiresAt: modelURL.ExpiresAt,
+	}, nil
+}
+
+// EncodeShortURL вернёт полный url.
+// Для удалённой ссылки или ссылки с истёкшим сроком жизни вернёт её данные вместе с ErrShortURLGone.
+func (s *ShortURLService) EncodeShortURL(ctx context.Context, shortURL string) (ShortURLData, error) {
+	modelURL, err := s.Finder.FindByShortURL(ctx, shortURL)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ShortURLData{}, ErrShortURLNotFound
+	}
+	if err != nil && !errors.Is(err, storage.ErrGone) {
+		return ShortURLData{}, err
 	}
-	s.shortURLData.URL = modelURL.URL
-	s.shortURLData.DeletedAt = modelURL.DeletedAt
-	return &s.shortURLData, nil
+	data := ShortURLData{
+		URL:       modelURL.URL,
+		ShortURL:  modelURL.ShortURL,
+		URLID:     int64(modelURL.ID),
+		DeletedAt: modelURL.DeletedAt,
+		ExpiresAt: modelURL.ExpiresAt,
+	}
+	if err != nil || data.IsExpired() {
+		return data, ErrShortURLGone
+	}
+	return data, nil
+}
+
+// ResolveExpiresAt вычислит время окончания жизни ссылки по абсолютному времени или TTL в секундах.
+// Нулевое время означает бессрочную ссылку.
+func ResolveExpiresAt(expiresAt time.Time, ttlSeconds int64) (time.Time, error) {
+	if !expiresAt.IsZero() && ttlSeconds != 0 {
+		return time.Time{}, errors.Join(ErrExpirationInvalid, errors.New("only one of expires_at and ttl_seconds is allowed"))
+	}
+	if ttlSeconds < 0 {
+		return time.Time{}, errors.Join(ErrExpirationInvalid, errors.New("ttl_seconds must be positive"))
+	}
+	if ttlSeconds > 0 {
+		return time.Now().Add(time.Duration(ttlSeconds) * time.Second).UTC(), nil
+	}
+	if expiresAt.IsZero() {
+		return time.Time{}, nil
+	}
+	if !expiresAt.After(time.Now()) {
+		return time.Time{}, errors.Join(ErrExpirationInvalid, errors.New("expires_at must be in the future"))
+	}
+	return expiresAt.UTC(), nil
+}
+
+// ValidateAlias проверка пользовательского псевдонима.
+func ValidateAlias(alias string) error {
+	if len(alias) < AliasMinSize || len(alias) > AliasMaxSize || !aliasPattern.MatchString(alias) {
+		return ErrAliasInvalid
+	}
+	if slices.Contains(reservedAliases, strings.ToLower(alias)) {
+		return ErrAliasReserved
+	}
+	return nil
 }
 
-func newRandomString(size int) string {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
-	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz09")
+// isShortURLTaken проверит, используется ли код другой ссылкой, в том числе удалённой.
+func (s *ShortURLService) isShortURLTaken(ctx context.Context, shortURL string) (bool, error) {
+	_, err := s.Finder.FindByShortURL(ctx, shortURL)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return false, nil
+	case err == nil, errors.Is(err, storage.ErrGone):
+		return true, nil
+	default:
+		return false, err
+	}
+}
 
-	b := make([]rune, size)
-	for i := range b {
-		b[i] = chars[rnd.Intn(len(chars))]
+// GenerateCode новый код короткой ссылки без проверки занятости в хранилище.
+// Используется там, где занятость кода проверяется при вставке, например при импорте.
+func (s *ShortURLService) GenerateCode() (string, error) {
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		code, err := s.codeGenerator().Generate()
+		if err != nil {
+			return "", errors.Join(ErrCodeGeneration, err)
+		}
+		if slices.Contains(reservedAliases, strings.ToLower(code)) {
+			continue
+		}
+		return code, nil
 	}
+	return "", ErrCodeGeneration
+}
 
-	return string(b)
+// codeGenerator заданный генератор кодов или генератор по умолчанию.
+func (s *ShortURLService) codeGenerator() CodeGenerator {
+	if s.generator == nil {
+		return NewRandomCodeGenerator(ShortURLDefaultSize)
+	}
+	return s.generator
+}
+
+// generateShortURL подберёт код, не занятый другой ссылкой, служебным путём или уже выданный в этом запросе.
+func (s *ShortURLService) generateShortURL(ctx context.Context, generated map[string]bool) (string, error) {
+	generator := s.codeGenerator()
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		code, err := generator.Generate()
+		if err != nil {
+			return "", errors.Join(ErrCodeGeneration, err)
+		}
+		if generated[code] || slices.Contains(reservedAliases, strings.ToLower(code)) {
+			logger.LogSugar.Infof("Код %s уже занят, попытка %d", code, attempt+1)
+			continue
+		}
+		taken, err := s.isShortURLTaken(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			logger.LogSugar.Infof("Код %s уже занят, попытка %d", code, attempt+1)
+			continue
+		}
+		return code, nil
+	}
+	return "", ErrCodeGeneration
 }
@@ -1,9 +1,11 @@
 package url
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/storage"
@@ -16,53 +18,106 @@ type storageMock struct {
 }
 
 // Add добавление нового значения
-func (s *storageMock) Add(url models.URL) (int64, error) {
+func (s *storageMock) Add(ctx context.Context, url models.URL) (int64, error) {
 	data := *s.db
 	data[url.ShortURL] = url
 	return 0, nil
 }
 
 // FindByShortURL поиск по короткой ссылке
-func (s *storageMock) FindByShortURL(shortURL string) (*models.URL, error) {
+func (s *storageMock) FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error) {
 	data := *s.db
 	if url, ok := data[shortURL]; ok {
 		return &url, nil
 	}
 
-	return nil, fmt.Errorf("the short link was not found")
+	return nil, storage.ErrNotFound
 }
 
 // FindByURL поиск по URL
-func (s *storageMock) FindByURL(url string) (*models.URL, error) {
+func (s *storageMock) FindByURL(ctx context.Context, url string) (*models.URL, error) {
 	for _, modelURL := range *s.db {
 		if modelURL.URL == url {
 			return &modelURL, nil
 		}
 	}
-	return new(models.URL), nil
+	return nil, storage.ErrNotFound
 }
 
-func (s *storageMock) Ping() error {
+func (s *storageMock) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (s *storageMock) MultiAdd(urls []models.URL) error {
+func (s *storageMock) MultiAdd(ctx context.Context, urls []models.URL) error {
 	return nil
 }
 
-func (s *storageMock) CreateUser(user models.User) (int64, error) {
+func (s *storageMock) ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error) {
+	return urls, nil
+}
+
+func (s *storageMock) ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error {
+	return nil
+}
+func (s *storageMock) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
+	return &models.URLPage{}, nil
+}
+func (s *storageMock) UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error) {
+	return nil, nil
+}
+func (s *storageMock) RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *storageMock) CreateUser(ctx context.Context, user models.User) (int64, error) {
 	return 0, nil
 }
 
-func (s *storageMock) LikeURLToUser(urlID int64, userUUID string) error {
+func (s *storageMock) LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error {
+	return nil
+}
+
+func (s *storageMock) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
+	return nil, nil
+}
+
+func (s *storageMock) SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error {
+	return nil
+}
+
+func (s *storageMock) FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error) {
+	return nil, nil
+}
+
+func (s *storageMock) FindUserByLoginAndPasswordHash(ctx context.Context, login string, passwordHash string) (*models.User, error) {
+	return nil, nil
+}
+
+func (s *storageMock) RegisterUser(ctx context.Context, user models.User) error {
 	return nil
 }
 
-func (s *storageMock) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
+func (s *storageMock) AddClick(ctx context.Context, click models.Click) error {
+	return nil
+}
+
+func (s *storageMock) AddClicks(ctx context.Context, clicks []models.Click) error {
+	return nil
+}
+
+func (s *storageMock) GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error) {
+	return nil, nil
+}
+
+func (s *storageMock) AddAPIKey(ctx context.Context, key models.APIKey) (int64, error) {
+	return 0, nil
+}
+
+func (s *storageMock) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
 	return nil, nil
 }
 
-func (s *storageMock) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
+func (s *storageMock) RevokeAPIKey(ctx context.Context, id int64) error {
 	return nil
 }
 
@@ -74,8 +129,7 @@ func TestShortURLService_DecodeURL(t *testing.T) {
 	NewShortURLService(storageMockInstance, storageMockInstance)
 
 	type fields struct {
-		Storage      storage.StorageQuery
-		shortURLData ShortURLData
+		Storage storage.StorageQuery
 	}
 	type args struct {
 		url string
@@ -90,8 +144,7 @@ func TestShortURLService_DecodeURL(t *testing.T) {
 		{
 			name: "#1_передать_url_получить_короткую_строку",
 			fields: fields{
-				Storage:      storageMockInstance,
-				shortURLData: ShortURLData{},
+				Storage: storageMockInstance,
 			},
 			args: args{
 				url: "https://example.ru",
@@ -106,21 +159,20 @@ func TestShortURLService_DecodeURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &ShortURLService{
-				Finder:       tt.fields.Storage,
-				Setter:       tt.fields.Storage,
-				shortURLData: tt.fields.shortURLData,
+				Finder: tt.fields.Storage,
+				Setter: tt.fields.Storage,
 			}
-			shortURLResult, err := s.DecodeURL(tt.args.url)
+			shortURLResult, err := s.DecodeURL(context.Background(), "", tt.args.url)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DecodeURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			modelURL, _ := s.Finder.FindByShortURL(shortURLResult.ShortURL)
+			modelURL, _ := s.Finder.FindByShortURL(context.Background(), shortURLResult.ShortURL)
 			if modelURL.URL != tt.args.url {
 				t.Errorf("DecodeURL() got = %v, want %v", modelURL.URL, tt.args.url)
 			}
 
-			modelURL, _ = s.Finder.FindByURL(tt.args.url)
+			modelURL, _ = s.Finder.FindByURL(context.Background(), tt.args.url)
 
 			if modelURL.ShortURL != shortURLResult.ShortURL {
 				t.Errorf("DecodeURL() got = %v, want %v", modelURL.ShortURL, shortURLResult.ShortURL)
@@ -136,14 +188,18 @@ func TestShortURLService_EncodeShortURL(t *testing.T) {
 	}
 	NewShortURLService(storageMockInstance, storageMockInstance)
 
-	_, _ = storageMockInstance.Add(models.URL{
+	_, _ = storageMockInstance.Add(context.Background(), models.URL{
 		ShortURL: "123",
 		URL:      "https://example.ru",
 	})
+	_, _ = storageMockInstance.Add(context.Background(), models.URL{
+		ShortURL:  "456",
+		URL:       "https://example.ru/expired",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
 
 	type fields struct {
-		Storage      storage.StorageQuery
-		shortURLData ShortURLData
+		Storage storage.StorageQuery
 	}
 	type args struct {
 		shortURL string
@@ -153,13 +209,12 @@ func TestShortURLService_EncodeShortURL(t *testing.T) {
 		fields   fields
 		args     args
 		wantData *ShortURLData
-		wantErr  bool
+		wantErr  error
 	}{
 		{
 			name: "#1_передать_короткую_ссылку_получить_url",
 			fields: fields{
-				Storage:      storageMockInstance,
-				shortURLData: ShortURLData{},
+				Storage: storageMockInstance,
 			},
 			args: args{
 				shortURL: "123",
@@ -167,18 +222,40 @@ func TestShortURLService_EncodeShortURL(t *testing.T) {
 			wantData: &ShortURLData{
 				URL: "https://example.ru",
 			},
-			wantErr: false,
+		},
+		{
+			name: "#2_короткая_ссылка_не_найдена",
+			fields: fields{
+				Storage: storageMockInstance,
+			},
+			args: args{
+				shortURL: "789",
+			},
+			wantData: &ShortURLData{},
+			wantErr:  ErrShortURLNotFound,
+		},
+		{
+			name: "#3_срок_жизни_ссылки_истёк",
+			fields: fields{
+				Storage: storageMockInstance,
+			},
+			args: args{
+				shortURL: "456",
+			},
+			wantData: &ShortURLData{
+				URL: "https://example.ru/expired",
+			},
+			wantErr: ErrShortURLGone,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &ShortURLService{
-				Finder:       tt.fields.Storage,
-				Setter:       tt.fields.Storage,
-				shortURLData: tt.fields.shortURLData,
+				Finder: tt.fields.Storage,
+				Setter: tt.fields.Storage,
 			}
-			shortURLResult, err := s.EncodeShortURL(tt.args.shortURL)
-			if (err != nil) != tt.wantErr {
+			shortURLResult, err := s.EncodeShortURL(context.Background(), tt.args.shortURL)
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("EncodeShortURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
@@ -235,17 +312,20 @@ func TestShortURLService_DecodeURLs(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &ShortURLService{
-				Finder:       tt.Storage,
-				Setter:       tt.Storage,
-				shortURLData: ShortURLData{},
+				Finder: tt.Storage,
+				Setter: tt.Storage,
+			}
+			items := make([]BatchItem, 0, len(tt.urls))
+			for _, url := range tt.urls {
+				items = append(items, BatchItem{URL: url})
 			}
-			_, err := s.DecodeURLs(tt.urls)
+			_, err := s.DecodeURLs(context.Background(), "", items)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DecodeURL() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			for _, url := range tt.urls {
-				_, err := s.Finder.FindByURL(url)
+				_, err := s.Finder.FindByURL(context.Background(), url)
 				if err != nil {
 					t.Errorf("DecodeURL() error = %v", err)
 				}
@@ -254,10 +334,206 @@ func TestShortURLService_DecodeURLs(t *testing.T) {
 	}
 }
 
-func BenchmarkNewRandomString(b *testing.B) {
+func TestShortURLService_DecodeURLs_Results(t *testing.T) {
 	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.Add(context.Background(), models.URL{ShortURL: "exist1", URL: "https://ya.ru/exist"})
+	s := NewShortURLService(memoryStorage, memoryStorage)
+
+	results, err := s.DecodeURLs(context.Background(), "1111-2222-3333", []BatchItem{
+		{URL: "https://ya.ru/new"},
+		{URL: "https://ya.ru/exist"},
+		{URL: "foo http://ya.ru"},
+		{URL: "HTTPS://YA.RU/new"},
+		{URL: "https://ya.ru/ttl", TTLSeconds: -1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("DecodeURLs() len = %d, want %d", len(results), 5)
+	}
+
+	t.Run("#1_новая_ссылка", func(t *testing.T) {
+		if results[0].Err != nil || results[0].Existing || results[0].ShortURL == "" {
+			t.Errorf("DecodeURLs() got = %+v", results[0])
+		}
+	})
+	t.Run("#2_существующая_ссылка", func(t *testing.T) {
+		if results[1].Err != nil || !results[1].Existing || results[1].ShortURL != "exist1" {
+			t.Errorf("DecodeURLs() got = %+v", results[1])
+		}
+	})
+	t.Run("#3_не_валидная_ссылка", func(t *testing.T) {
+		if !errors.Is(results[2].Err, ErrURLInvalid) || results[2].ShortURL != "" {
+			t.Errorf("DecodeURLs() got = %+v", results[2])
+		}
+	})
+	t.Run("#4_дубль_получает_тот_же_код", func(t *testing.T) {
+		if results[3] != results[0] {
+			t.Errorf("DecodeURLs() got = %+v, want %+v", results[3], results[0])
+		}
+	})
+	t.Run("#5_не_корректный_срок_жизни", func(t *testing.T) {
+		if !errors.Is(results[4].Err, ErrExpirationInvalid) {
+			t.Errorf("DecodeURLs() error = %v, want %v", results[4].Err, ErrExpirationInvalid)
+		}
+	})
+	t.Run("#6_новая_ссылка_связана_с_пользователем", func(t *testing.T) {
+		userURLs, err := memoryStorage.FindUrlsByUserID(context.Background(), "1111-2222-3333")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*userURLs) != 1 || (*userURLs)[0].ShortURL != results[0].ShortURL {
+			t.Errorf("FindUrlsByUserID() got = %+v", *userURLs)
+		}
+	})
+	t.Run("#7_повторный_пакет_возвращает_существующие_коды", func(t *testing.T) {
+		repeated, err := s.DecodeURLs(context.Background(), "1111-2222-3333", []BatchItem{{URL: "https://ya.ru/new"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !repeated[0].Existing || repeated[0].ShortURL != results[0].ShortURL {
+			t.Errorf("DecodeURLs() got = %+v", repeated[0])
+		}
+	})
+}
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr error
+	}{
+		{name: "#1_допустимый_псевдоним", alias: "spring-sale_2024", wantErr: nil},
+		{name: "#2_слишком_короткий", alias: "ab", wantErr: ErrAliasInvalid},
+		{name: "#3_слишком_длинный", alias: strings.Repeat("a", AliasMaxSize+1), wantErr: ErrAliasInvalid},
+		{name: "#4_недопустимые_символы", alias: "spring/sale", wantErr: ErrAliasInvalid},
+		{name: "#5_кириллица", alias: "распродажа", wantErr: ErrAliasInvalid},
+		{name: "#6_зарезервированный_путь", alias: "ping", wantErr: ErrAliasReserved},
+		{name: "#7_зарезервированный_путь_в_другом_регистре", alias: "API", wantErr: ErrAliasReserved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAlias(tt.alias)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateAlias() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShortURLService_DecodeURLWithOptions(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	s := NewShortURLService(memoryStorage, memoryStorage)
+
+	t.Run("#1_псевдоним_становится_короткой_ссылкой", func(t *testing.T) {
+		data, err := s.DecodeURLWithOptions(context.Background(), "", "https://example.ru/spring", DecodeOptions{Alias: "spring-sale"})
+		if err != nil {
+			t.Fatalf("DecodeURLWithOptions() error = %v", err)
+		}
+		if data.ShortURL != "spring-sale" {
+			t.Errorf("DecodeURLWithOptions() got = %v, want %v", data.ShortURL, "spring-sale")
+		}
+	})
+	t.Run("#2_занятый_псевдоним", func(t *testing.T) {
+		_, err := s.DecodeURLWithOptions(context.Background(), "", "https://example.ru/autumn", DecodeOptions{Alias: "spring-sale"})
+		if !errors.Is(err, ErrAliasExists) {
+			t.Errorf("DecodeURLWithOptions() error = %v, want %v", err, ErrAliasExists)
+		}
+	})
+	t.Run("#3_зарезервированный_псевдоним", func(t *testing.T) {
+		_, err := s.DecodeURLWithOptions(context.Background(), "", "https://example.ru/debug", DecodeOptions{Alias: "debug"})
+		if !errors.Is(err, ErrAliasReserved) {
+			t.Errorf("DecodeURLWithOptions() error = %v, want %v", err, ErrAliasReserved)
+		}
+	})
+	t.Run("#4_ссылка_сохраняется_нормализованной", func(t *testing.T) {
+		data, err := s.DecodeURLWithOptions(context.Background(), "", "HTTPS://Example.RU:443/Summer#top", DecodeOptions{})
+		if err != nil {
+			t.Fatalf("DecodeURLWithOptions() error = %v", err)
+		}
+		if data.URL != "https://example.ru/Summer" {
+			t.Errorf("DecodeURLWithOptions() got = %v, want %v", data.URL, "https://example.ru/Summer")
+		}
+	})
+	t.Run("#5_некорректная_ссылка", func(t *testing.T) {
+		_, err := s.DecodeURLWithOptions(context.Background(), "", "foo http://example.ru", DecodeOptions{})
+		if !errors.Is(err, ErrURLInvalid) {
+			t.Errorf("DecodeURLWithOptions() error = %v, want %v", err, ErrURLInvalid)
+		}
+	})
+	t.Run("#6_ссылка_на_частную_сеть_запрещена", func(t *testing.T) {
+		service := NewShortURLService(memoryStorage, memoryStorage)
+		service.SetNormalizer(NewNormalizer(NormalizerOptions{RejectPrivateHosts: true}))
+		results, err := service.DecodeURLs(context.Background(), "", []BatchItem{{URL: "http://127.0.0.1/admin"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !errors.Is(results[0].Err, ErrURLInvalid) {
+			t.Errorf("DecodeURLs() error = %v, want %v", results[0].Err, ErrURLInvalid)
+		}
+	})
+}
+
+func TestShortURLService_UpdateURL(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	s := NewShortURLService(memoryStorage, memoryStorage)
+	userUUID := "111-222-333"
+	own, err := s.DecodeURLWithOptions(context.Background(), userUUID, "https://ozon.ru/old", DecodeOptions{Alias: "own-link"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.DecodeURLWithOptions(context.Background(), userUUID, "https://avito.ru/taken", DecodeOptions{Alias: "other-link"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.DecodeURLWithOptions(context.Background(), userUUID, "https://avito.ru/deleted", DecodeOptions{Alias: "deleted-link"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), userUUID, "deleted-link")
+
+	tests := []struct {
+		name     string
+		userUUID string
+		shortURL string
+		url      string
+		want     string
+		wantErr  error
+	}{
+		{name: "#1_смена_ссылки", userUUID: userUUID, shortURL: own.ShortURL, url: "HTTPS://Ozon.RU/new", want: "https://ozon.ru/new"},
+		{name: "#2_некорректная_ссылка", userUUID: userUUID, shortURL: own.ShortURL, url: "ozon", wantErr: ErrURLInvalid},
+		{name: "#3_чужая_ссылка", userUUID: "444-555-666", shortURL: own.ShortURL, url: "https://ozon.ru/other", wantErr: ErrShortURLNotFound},
+		{name: "#4_удалённая_ссылка", userUUID: userUUID, shortURL: "deleted-link", url: "https://ozon.ru/other", wantErr: ErrShortURLGone},
+		{name: "#5_ссылка_уже_сокращена", userUUID: userUUID, shortURL: own.ShortURL, url: "https://avito.ru/taken", wantErr: ErrURLExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := s.UpdateURL(context.Background(), tt.userUUID, tt.shortURL, tt.url)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && data.URL != tt.want {
+				t.Errorf("UpdateURL() got = %v, want %v", data.URL, tt.want)
+			}
+		})
+	}
+
+	data, err := s.EncodeShortURL(context.Background(), own.ShortURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.URL != "https://ozon.ru/new" {
+		t.Errorf("EncodeShortURL() got = %v, want %v", data.URL, "https://ozon.ru/new")
+	}
+}
+
+func BenchmarkRandomCodeGenerator(b *testing.B) {
+	_ = logger.InitLogger("fatal")
+	generator := NewRandomCodeGenerator(ShortURLDefaultSize)
 	for i := 0; i < b.N; i++ {
-		newRandomString(ShortURLDefaultSize)
+		_, _ = generator.Generate()
 	}
 }
 
@@ -265,15 +541,17 @@ func BenchmarkDecodeURLs(b *testing.B) {
 	_ = logger.InitLogger("fatal")
 	storageMemoryMock := storage.NewMemoryStorage()
 	service := &ShortURLService{
-		Finder:       storageMemoryMock,
-		Setter:       storageMemoryMock,
-		shortURLData: ShortURLData{},
+		Finder: storageMemoryMock,
+		Setter: storageMemoryMock,
 	}
 	testData := strings.Repeat("A ", 100)
-	urls := strings.Split(testData, " ")
+	items := make([]BatchItem, 0, 100)
+	for _, url := range strings.Split(testData, " ") {
+		items = append(items, BatchItem{URL: "https://ya.ru/" + url})
+	}
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = service.DecodeURLs(urls)
+		_, _ = service.DecodeURLs(context.Background(), "", items)
 	}
 }
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// ClickDateLayout формат даты в гистограмме переходов.
+const ClickDateLayout = "2006-01-02"
+
+// newClickStats собирает статистику переходов с разбивкой по дням (UTC).
+func newClickStats(clicks []models.Click) *models.ClickStats {
+	byDay := make(map[string]int64)
+	for _, click := range clicks {
+		byDay[click.CreatedAt.UTC().Format(ClickDateLayout)]++
+	}
+	return newClickStatsByDay(byDay)
+}
+
+// newClickStatsByDay статистика по уже подсчитанным переходам за каждый день.
+func newClickStatsByDay(byDay map[string]int64) *models.ClickStats {
+	stats := &models.ClickStats{
+		Days: make([]models.ClickDay, 0, len(byDay)),
+	}
+	for date, cnt := range byDay {
+		stats.Total += cnt
+		stats.Days = append(stats.Days, models.ClickDay{Date: date, Clicks: cnt})
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Date < stats.Days[j].Date
+	})
+	return stats
+}
+
+// clickCounter количество переходов по дням (UTC) для каждой короткой ссылки, сами переходы не хранятся.
+type clickCounter map[string]map[string]int64
+
+// add учитывает переход.
+func (c clickCounter) add(click models.Click) {
+	byDay, ok := c[click.ShortURL]
+	if !ok {
+		byDay = make(map[string]int64)
+		c[click.ShortURL] = byDay
+	}
+	byDay[click.CreatedAt.UTC().Format(ClickDateLayout)]++
+}
+
+// total количество переходов по короткой ссылке.
+func (c clickCounter) total(shortURL string) int64 {
+	var total int64
+	for _, cnt := range c[shortURL] {
+		total += cnt
+	}
+	return total
+}
+
+// stats статистика переходов по короткой ссылке.
+func (c clickCounter) stats(shortURL string) *models.ClickStats {
+	return newClickStatsByDay(c[shortURL])
+}
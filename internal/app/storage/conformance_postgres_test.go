@@ -0,0 +1,39 @@
+//go:build postgres
+
+package storage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/northmule/shorturl/db"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPostgresStorage_Conformance запускается на локальной БД:
+// TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=shorturl_test sslmode=disable" go test -tags postgres ./internal/app/storage/
+// Перед каждой проверкой таблицы очищаются, поэтому БД должна быть отдельной от рабочей.
+func TestPostgresStorage_Conformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN не задан")
+	}
+	_ = logger.InitLogger("fatal")
+	s, err := storage.NewPostgresStorage(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.RawDB.Close()
+	})
+	require.NoError(t, db.NewMigrations(s.RawDB).Up(context.Background()))
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		_, err := s.RawDB.ExecContext(context.Background(),
+			`truncate table url_history, url_clicks, user_short_url, api_keys, url_list, users restart identity cascade`)
+		require.NoError(t, err)
+		return s
+	})
+}
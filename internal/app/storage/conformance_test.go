@@ -0,0 +1,33 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemoryStorage()
+	})
+}
+
+func TestFileStorage_Conformance(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		file, err := os.OpenFile(filepath.Join(t.TempDir(), "storage.json"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+		require.NoError(t, err)
+		s := storage.NewFileStorage(file)
+		require.NotNil(t, s)
+		t.Cleanup(func() {
+			_ = s.Close()
+		})
+		return s
+	})
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Общие ошибки хранилищ, не зависят от используемого бэкенда.
+var (
+	// ErrNotFound запись не найдена.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict запись с таким ключом уже существует.
+	ErrConflict = errors.New("conflict")
+	// ErrGone запись была удалена.
+	ErrGone = errors.New("gone")
+	// ErrFilterInvalid неверный курсор или параметры отбора.
+	ErrFilterInvalid = errors.New("filter is invalid")
+)
+
+// ErrAPIKeyNotFound ключ доступа не найден или уже отозван.
+var ErrAPIKeyNotFound = fmt.Errorf("api key %w", ErrNotFound)
+
+// CodeErrorDuplicateKey код ошибки с дублем записи в БД.
+const CodeErrorDuplicateKey = "23505"
+
+// conflictError ошибка дубля записи с пояснением.
+func conflictError(message string) error {
+	return fmt.Errorf("%s: %w", message, ErrConflict)
+}
+
+// notFoundError ошибка отсутствия записи с пояснением.
+func notFoundError(message string) error {
+	return fmt.Errorf("%s: %w", message, ErrNotFound)
+}
+
+// wrapPgError переводит ошибки БД в общие ошибки хранилища.
+func wrapPgError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == CodeErrorDuplicateKey {
+		return fmt.Errorf("%s: %w", pgErr.Message, ErrConflict)
+	}
+	return err
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// Типы записей журнала ссылок файлового хранилища.
+const (
+	// fileEventAdd добавление ссылки, при заполненном UserUUID ссылка сразу связывается с пользователем
+	fileEventAdd = "add"
+	// fileEventLink связывание ссылки с пользователем
+	fileEventLink = "link"
+	// fileEventDelete пометка ссылки как удалённой
+	fileEventDelete = "delete"
+	// fileEventRestore снятие пометки удаления
+	fileEventRestore = "restore"
+	// fileEventUpdate смена URL ссылки
+	fileEventUpdate = "update"
+	// fileEventPurge окончательное удаление ссылки
+	fileEventPurge = "purge"
+)
+
+// fileEvent запись журнала ссылок, состояние хранилища восстанавливается повтором записей по порядку.
+type fileEvent struct {
+	Type string `json:"type"`
+	// Link ссылка целиком для add и update
+	Link     *models.URL `json:"link,omitempty"`
+	ShortURL string      `json:"short_url,omitempty"`
+	UserUUID string      `json:"user_uuid,omitempty"`
+	// At время удаления для delete и смены URL для update
+	At *time.Time `json:"at,omitempty"`
+}
+
+// fileURLIndex ссылки файлового хранилища с индексами для поиска без перебора.
+type fileURLIndex struct {
+	// ссылки (ключ короткая ссылка), у удалённых заполнен DeletedAt
+	urls map[string]models.URL
+	// короткая ссылка действующей ссылки (ключ URL)
+	activeURLs map[string]string
+	// короткая ссылка (ключ id ссылки)
+	urlIDs map[uint]string
+	// владелец ссылки (ключ короткая ссылка)
+	owners map[string]string
+	// короткие ссылки пользователя (ключ uuid)
+	userURLs map[string]map[string]struct{}
+	lastID   uint
+}
+
+// newFileURLIndex пустой индекс.
+func newFileURLIndex() *fileURLIndex {
+	return &fileURLIndex{
+		urls:       make(map[string]models.URL, 1000),
+		activeURLs: make(map[string]string, 1000),
+		urlIDs:     make(map[uint]string, 1000),
+		owners:     make(map[string]string, 1000),
+		userURLs:   make(map[string]map[string]struct{}, 100),
+	}
+}
+
+// decodeFileEvent разбор строки журнала.
+// Строки без типа записаны прежней версией хранилища и содержат только ссылку, их id назначаются заново.
+func decodeFileEvent(line []byte) (fileEvent, error) {
+	event := fileEvent{}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return event, err
+	}
+	if event.Type != "" {
+		return event, nil
+	}
+	url := models.URL{}
+	if err := json.Unmarshal(line, &url); err != nil {
+		return event, err
+	}
+	url.ID = 0
+	return fileEvent{Type: fileEventAdd, Link: &url}, nil
+}
+
+// apply применяет запись журнала к индексу, записи о несуществующих ссылках пропускаются.
+func (x *fileURLIndex) apply(event fileEvent) {
+	switch event.Type {
+	case fileEventAdd:
+		if event.Link == nil {
+			return
+		}
+		url := *event.Link
+		if _, ok := x.urls[url.ShortURL]; ok {
+			return
+		}
+		if url.ID == 0 {
+			url.ID = x.lastID + 1
+		}
+		x.lastID = max(x.lastID, url.ID)
+		x.urls[url.ShortURL] = url
+		x.urlIDs[url.ID] = url.ShortURL
+		if url.DeletedAt.IsZero() {
+			x.activeURLs[url.URL] = url.ShortURL
+		}
+		if event.UserUUID != "" {
+			x.link(url.ShortURL, event.UserUUID)
+		}
+	case fileEventLink:
+		if _, ok := x.urls[event.ShortURL]; ok {
+			x.link(event.ShortURL, event.UserUUID)
+		}
+	case fileEventDelete:
+		url, ok := x.urls[event.ShortURL]
+		if !ok || !url.DeletedAt.IsZero() || event.At == nil {
+			return
+		}
+		url.DeletedAt = *event.At
+		x.urls[event.ShortURL] = url
+		x.deactivate(url)
+	case fileEventRestore:
+		url, ok := x.urls[event.ShortURL]
+		if !ok || url.DeletedAt.IsZero() {
+			return
+		}
+		url.DeletedAt = time.Time{}
+		x.urls[event.ShortURL] = url
+		x.activeURLs[url.URL] = url.ShortURL
+	case fileEventUpdate:
+		previous, ok := x.urls[event.ShortURL]
+		if !ok || event.Link == nil {
+			return
+		}
+		x.deactivate(previous)
+		url := *event.Link
+		url.ID = previous.ID
+		x.urls[event.ShortURL] = url
+		if url.DeletedAt.IsZero() {
+			x.activeURLs[url.URL] = url.ShortURL
+		}
+	case fileEventPurge:
+		url, ok := x.urls[event.ShortURL]
+		if !ok {
+			return
+		}
+		x.deactivate(url)
+		delete(x.urls, url.ShortURL)
+		delete(x.urlIDs, url.ID)
+		x.unlink(url.ShortURL)
+	}
+}
+
+// link связывает ссылку с пользователем, прежний владелец теряет ссылку.
+func (x *fileURLIndex) link(shortURL string, userUUID string) {
+	x.unlink(shortURL)
+	x.owners[shortURL] = userUUID
+	if _, ok := x.userURLs[userUUID]; !ok {
+		x.userURLs[userUUID] = make(map[string]struct{})
+	}
+	x.userURLs[userUUID][shortURL] = struct{}{}
+}
+
+// unlink убирает связь ссылки с владельцем.
+func (x *fileURLIndex) unlink(shortURL string) {
+	owner, ok := x.owners[shortURL]
+	if !ok {
+		return
+	}
+	delete(x.owners, shortURL)
+	delete(x.userURLs[owner], shortURL)
+	if len(x.userURLs[owner]) == 0 {
+		delete(x.userURLs, owner)
+	}
+}
+
+// deactivate убирает ссылку из индекса действующих URL, если URL занят именно ей.
+func (x *fileURLIndex) deactivate(url models.URL) {
+	if x.activeURLs[url.URL] == url.ShortURL {
+		delete(x.activeURLs, url.URL)
+	}
+}
+
+// userURLList ссылки пользователя в порядке добавления.
+func (x *fileURLIndex) userURLList(userUUID string) []models.URL {
+	urls := make([]models.URL, 0, len(x.userURLs[userUUID]))
+	for shortURL := range x.userURLs[userUUID] {
+		urls = append(urls, x.urls[shortURL])
+	}
+	slices.SortFunc(urls, func(a, b models.URL) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return urls
+}
+
+// snapshot записи, достаточные для восстановления текущего состояния: одна запись add на ссылку.
+func (x *fileURLIndex) snapshot() []fileEvent {
+	urls := make([]models.URL, 0, len(x.urls))
+	for _, url := range x.urls {
+		urls = append(urls, url)
+	}
+	slices.SortFunc(urls, func(a, b models.URL) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	events := make([]fileEvent, 0, len(urls))
+	for _, url := range urls {
+		events = append(events, fileEvent{Type: fileEventAdd, Link: &url, UserUUID: x.owners[url.ShortURL]})
+	}
+	return events
+}
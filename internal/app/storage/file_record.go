@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+// ErrFileRecordCorrupt запись файла хранилища повреждена: не сходится контрольная сумма или не разбирается JSON.
+var ErrFileRecordCorrupt = errors.New("file record corrupt")
+
+// fileRecordChecksumSize длина контрольной суммы записи в шестнадцатеричном виде.
+const fileRecordChecksumSize = 8
+
+// encodeRecord строка файла хранилища: контрольная сумма CRC32 записи, пробел, JSON записи и перевод строки.
+func encodeRecord(value any) ([]byte, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, 0, fileRecordChecksumSize+len(payload)+2)
+	record = fmt.Appendf(record, "%08x ", crc32.ChecksumIEEE(payload))
+	record = append(record, payload...)
+	return append(record, '\n'), nil
+}
+
+// decodeRecord проверит контрольную сумму строки без перевода строки и вернёт JSON записи.
+// Строки без контрольной суммы записаны прежней версией хранилища и возвращаются как есть.
+func decodeRecord(line []byte) ([]byte, error) {
+	if len(line) > 0 && line[0] == '{' {
+		return line, nil
+	}
+	if len(line) <= fileRecordChecksumSize || line[fileRecordChecksumSize] != ' ' {
+		return nil, ErrFileRecordCorrupt
+	}
+	checksum, err := strconv.ParseUint(string(line[:fileRecordChecksumSize]), 16, 32)
+	if err != nil {
+		return nil, ErrFileRecordCorrupt
+	}
+	payload := line[fileRecordChecksumSize+1:]
+	if crc32.ChecksumIEEE(payload) != uint32(checksum) {
+		return nil, ErrFileRecordCorrupt
+	}
+	return payload, nil
+}
+
+// replayRecords передаёт fn по порядку JSON записей файла.
+// Повреждённые записи и записи, которые fn не смогла разобрать, пропускаются, а всё после последней целой записи
+// считается недописанным при сбое хвостом и обрезается. Вернёт количество и размер целых записей.
+func replayRecords(file *os.File, fn func(payload []byte) error) (int, int64, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	reader := bufio.NewReader(file)
+	var records int
+	var offset, validEnd int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		offset += int64(len(line))
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			payload, decodeErr := decodeRecord(line[:len(line)-1])
+			if decodeErr == nil {
+				if decodeErr = fn(payload); decodeErr != nil {
+					decodeErr = errors.Join(ErrFileRecordCorrupt, decodeErr)
+				}
+			}
+			if decodeErr == nil {
+				records++
+				validEnd = offset
+			} else {
+				logger.LogSugar.Errorf("Пропущена повреждённая запись файла %s на смещении %d: %s", file.Name(), offset-int64(len(line)), decodeErr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	if offset > validEnd {
+		logger.LogSugar.Warnf("Файл %s обрезан до последней целой записи, отброшено байт: %d", file.Name(), offset-validEnd)
+		if err := file.Truncate(validEnd); err != nil {
+			return 0, 0, err
+		}
+	}
+	// Файл мог быть открыт без O_APPEND, запись продолжается с конца целых записей
+	if _, err := file.Seek(validEnd, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	return records, validEnd, nil
+}
+
+// appendRecords дописывает подготовленные записи одной операцией записи и вернёт новый размер файла.
+// Частично записанные при ошибке данные обрезаются, чтобы следующая запись не склеилась с обрывком.
+func appendRecords(file *os.File, size int64, records []byte) (int64, error) {
+	n, err := file.Write(records)
+	if err != nil {
+		if n > 0 {
+			err = errors.Join(err, file.Truncate(size))
+		}
+		return size, err
+	}
+	return size + int64(n), nil
+}
+
+// fileSideLog дополнительный файл хранилища: пользователи, переходы или ключи доступа.
+// Записи хранятся в формате журнала ссылок и сбрасываются на диск по той же политике.
+type fileSideLog struct {
+	file *os.File
+	// размер целых записей, до него обрезается неудачная запись
+	size int64
+	// в файле есть записи, не сброшенные на диск
+	dirty bool
+}
+
+// openFileSideLog открывает файл и передаёт fn его записи, недописанный при сбое хвост обрезается.
+func openFileSideLog(name string, fn func(payload []byte) error) (*fileSideLog, error) {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	_, size, err := replayRecords(file, fn)
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+	return &fileSideLog{file: file, size: size}, nil
+}
+
+// write дописывает подготовленные encodeRecord записи, вызывается под блокировкой хранилища.
+func (l *fileSideLog) write(records []byte, policy FileSyncPolicy) error {
+	size, err := appendRecords(l.file, l.size, records)
+	if err != nil {
+		return err
+	}
+	l.size = size
+	if policy != FileSyncAlways {
+		l.dirty = true
+		return nil
+	}
+	return l.file.Sync()
+}
+
+// sync сбрасывает на диск записи, сделанные после прошлого сброса.
+func (l *fileSideLog) sync() error {
+	if !l.dirty {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.dirty = false
+	return nil
+}
+
+// Name имя файла.
+func (l *fileSideLog) Name() string {
+	return l.file.Name()
+}
+
+// Close закрытие файла.
+func (l *fileSideLog) Close() error {
+	return l.file.Close()
+}
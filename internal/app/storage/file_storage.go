@@ -2,190 +2,848 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 )
 
+// FileSyncPolicy когда записи журнала сбрасываются на диск.
+type FileSyncPolicy string
+
+const (
+	// FileSyncAlways после каждой записи, подтверждённая запись переживает сбой питания
+	FileSyncAlways FileSyncPolicy = "always"
+	// FileSyncInterval периодически, при сбое теряются записи последнего периода
+	FileSyncInterval FileSyncPolicy = "interval"
+	// FileSyncNever сброс остаётся на усмотрение ОС
+	FileSyncNever FileSyncPolicy = "never"
+)
+
+// ErrFileSyncPolicyUnknown неизвестная политика сброса журнала.
+var ErrFileSyncPolicyUnknown = errors.New("unknown file storage sync policy")
+
+// ParseFileSyncPolicy разбор политики сброса журнала.
+func ParseFileSyncPolicy(value string) (FileSyncPolicy, error) {
+	switch policy := FileSyncPolicy(value); policy {
+	case FileSyncAlways, FileSyncInterval, FileSyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%s: %w", value, ErrFileSyncPolicyUnknown)
+	}
+}
+
+// FileStorageOptions параметры обслуживания журнала файлового хранилища.
+type FileStorageOptions struct {
+	// Политика сброса журнала на диск
+	Sync FileSyncPolicy
+	// Период сброса для политики FileSyncInterval
+	SyncInterval time.Duration
+	// Период сжатия журнала, ноль отключает сжатие
+	CompactInterval time.Duration
+}
+
+// NewFileStorageOptionsFromConfig параметры обслуживания журнала из настроек приложения.
+func NewFileStorageOptionsFromConfig(cfg *config.Config) (FileStorageOptions, error) {
+	policy, err := ParseFileSyncPolicy(cfg.FileStorageSync)
+	if err != nil {
+		return FileStorageOptions{}, err
+	}
+	return FileStorageOptions{
+		Sync:            policy,
+		SyncInterval:    cfg.FileStorageSyncInterval,
+		CompactInterval: cfg.FileStorageCompactInterval,
+	}, nil
+}
+
 // FileStorage файловое хранилище.
+// Ссылки хранятся журналом записей add, link, delete и других, при запуске журнал повторяется в индексы в памяти.
+// Каждая запись журнала снабжена контрольной суммой, недописанный при сбое хвост обрезается при запуске.
+// Файлы пользователей, переходов и ключей доступа пишутся в том же формате и сбрасываются на диск по той же политике.
 type FileStorage struct {
-	file        *os.File
-	scanner     *bufio.Scanner
-	cacheValues []string
-	users       *os.File
-	deletedURLs *os.File
+	file *os.File
+	// путь журнала, после сжатия дескриптор остаётся открытым под временным именем
+	path    string
+	users   *fileSideLog
+	clicks  *fileSideLog
+	apiKeys *fileSideLog
+	// ссылки, восстановленные из журнала
+	index *fileURLIndex
+	// пользователи (ключ uuid)
+	userList map[string]models.User
+	// переходы по дням для каждой короткой ссылки, восстановленные из файла переходов
+	clickCounts clickCounter
+	// ключи доступа (ключ id) и индекс ключей по хэшу, восстановленные из файла ключей
+	apiKeysByID   map[int64]models.APIKey
+	apiKeysByHash map[string]int64
+	lastIDForKey  int64
+	// политика сброса журнала на диск
+	sync FileSyncPolicy
+	// в журнале есть записи, не сброшенные на диск
+	dirty bool
+	// количество записей и размер журнала, по ним решается нужно ли сжатие и откатывается неудачная запись
+	records int
+	size    int64
+	// Синхронизация записи в журнал и индексов
+	mx sync.RWMutex
 }
 
 // NewFileStorage конструктор хранилища.
 func NewFileStorage(file *os.File) *FileStorage {
 	instance := &FileStorage{
-		file:        file,
-		scanner:     bufio.NewScanner(file),
-		cacheValues: make([]string, 0),
+		file:          file,
+		path:          file.Name(),
+		index:         newFileURLIndex(),
+		userList:      make(map[string]models.User),
+		clickCounts:   make(clickCounter),
+		apiKeysByID:   make(map[int64]models.APIKey),
+		apiKeysByHash: make(map[string]int64),
+		sync:          FileSyncNever,
 	}
 
+	err := instance.restoreStorage()
+	if err != nil {
+		logger.LogSugar.Errorf("Failed to restore storage %s: error: %s", file.Name(), err)
+		return nil
+	}
 	usersFileName := file.Name() + "user.json"
-
-	fileUsers, err := os.OpenFile(usersFileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	instance.users, err = openFileSideLog(usersFileName, instance.restoreUser)
 	if err != nil {
-		logger.LogSugar.Errorf("Failed to open file %s: error: %s", usersFileName, err)
+		logger.LogSugar.Errorf("Failed to load users %s: error: %s", usersFileName, err)
 		return nil
 	}
-	deletedURLsFileName := file.Name() + "deleted-urls.json"
-	fileDeletedURLs, err := os.OpenFile(deletedURLsFileName, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	clicksFileName := file.Name() + "clicks.json"
+	instance.clicks, err = openFileSideLog(clicksFileName, instance.restoreClick)
 	if err != nil {
-		logger.LogSugar.Errorf("Failed to open file %s: error: %s", deletedURLsFileName, err)
+		logger.LogSugar.Errorf("Failed to restore clicks %s: error: %s", clicksFileName, err)
+		return nil
+	}
+	apiKeysFileName := file.Name() + "api-keys.json"
+	instance.apiKeys, err = openFileSideLog(apiKeysFileName, instance.restoreAPIKey)
+	if err != nil {
+		logger.LogSugar.Errorf("Failed to restore api keys %s: error: %s", apiKeysFileName, err)
 		return nil
 	}
-	instance.users = fileUsers
-	instance.deletedURLs = fileDeletedURLs
-	instance.restoreStorage()
 	return instance
 }
 
-// Add добавление нового значения.
-func (f *FileStorage) Add(url models.URL) (int64, error) {
-	modelRaw, err := json.Marshal(url)
+// writeEvents дописывает записи в журнал одной операцией записи и применяет их к индексам, вызывается под блокировкой.
+func (f *FileStorage) writeEvents(events ...fileEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var lines bytes.Buffer
+	for _, event := range events {
+		record, err := encodeRecord(event)
+		if err != nil {
+			logger.LogSugar.Error(err)
+			return err
+		}
+		lines.Write(record)
+	}
+	size, err := appendRecords(f.file, f.size, lines.Bytes())
 	if err != nil {
-		logger.LogSugar.Error(err)
-		return 0, err
+		logger.LogSugar.Errorf("Ошибка записи журнала ссылок в файл %s: %s", f.path, err)
+		return err
+	}
+	f.size = size
+	f.records += len(events)
+	for _, event := range events {
+		f.index.apply(event)
+	}
+	if f.sync != FileSyncAlways {
+		f.dirty = true
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// StartMaintenance устанавливает политику сброса журнала и запускает его периодический сброс и сжатие.
+// После отмены ctx журнал сбрасывается на диск последний раз.
+func (f *FileStorage) StartMaintenance(ctx context.Context, options FileStorageOptions) {
+	f.mx.Lock()
+	f.sync = options.Sync
+	f.mx.Unlock()
+
+	go f.maintenance(ctx, options)
+}
+
+func (f *FileStorage) maintenance(ctx context.Context, options FileStorageOptions) {
+	var syncTick, compactTick <-chan time.Time
+	if options.Sync == FileSyncInterval && options.SyncInterval > 0 {
+		ticker := time.NewTicker(options.SyncInterval)
+		defer ticker.Stop()
+		syncTick = ticker.C
+	}
+	if options.CompactInterval > 0 {
+		ticker := time.NewTicker(options.CompactInterval)
+		defer ticker.Stop()
+		compactTick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if err := f.Sync(); err != nil {
+				logger.LogSugar.Errorf("Не удалось сбросить журнал %s на диск: %s", f.path, err)
+			}
+			return
+		case <-syncTick:
+			if err := f.Sync(); err != nil {
+				logger.LogSugar.Errorf("Не удалось сбросить журнал %s на диск: %s", f.path, err)
+			}
+		case <-compactTick:
+			if err := f.Compact(ctx); err != nil {
+				logger.LogSugar.Errorf("Не удалось сжать журнал %s: %s", f.path, err)
+			}
+		}
+	}
+}
+
+// Sync сбрасывает на диск записи журнала и файлов пользователей, переходов и ключей, сделанные после прошлого сброса.
+func (f *FileStorage) Sync() error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	var err error
+	if f.dirty {
+		if err = f.file.Sync(); err == nil {
+			f.dirty = false
+		}
+	}
+	return errors.Join(err, f.users.sync(), f.clicks.sync(), f.apiKeys.sync())
+}
+
+// Compact переписывает журнал одной записью на ссылку, окончательно удалённые ссылки и промежуточные записи отбрасываются.
+// Мягко удалённые ссылки остаются в журнале до PurgeDeletedURLs: по ним отвечается 410 Gone и их можно восстановить,
+// поэтому журнал после сжатия растёт вместе со сроком хранения удалённых ссылок.
+// Файлы пользователей, переходов и ключей доступа не сжимаются.
+// Новый журнал пишется во временный файл и заменяет прежний переименованием, поэтому при сбое остаётся один из двух целых журналов.
+func (f *FileStorage) Compact(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	events := f.index.snapshot()
+	if f.records == len(events) {
+		return nil
+	}
+	name := f.path
+	compactName := name + ".compact"
+	compactFile, err := os.OpenFile(compactName, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	size, err := writeFileRecords(compactFile, events)
+	if err == nil {
+		err = compactFile.Sync()
+	}
+	if err == nil {
+		err = os.Rename(compactName, name)
+	}
+	if err != nil {
+		return errors.Join(err, compactFile.Close(), os.Remove(compactName))
+	}
+	// Переименование становится надёжным только после сброса каталога
+	if err = syncDir(filepath.Dir(name)); err != nil {
+		logger.LogSugar.Errorf("Не удалось сбросить каталог журнала %s: %s", name, err)
 	}
-	modelJSON := string(modelRaw)
 
-	f.cacheValues = append(f.cacheValues, modelJSON)
-	_, err = f.file.WriteString(modelJSON + "\n")
+	logger.LogSugar.Infof("Журнал %s сжат: записей было %d, стало %d", name, f.records, len(events))
+	previous := f.file
+	f.file = compactFile
+	f.records = len(events)
+	f.size = size
+	f.dirty = false
+	return previous.Close()
+}
+
+// writeFileRecords записывает журнал целиком и вернёт его размер.
+func writeFileRecords(file *os.File, events []fileEvent) (int64, error) {
+	writer := bufio.NewWriter(file)
+	var size int64
+	for _, event := range events {
+		record, err := encodeRecord(event)
+		if err != nil {
+			return 0, err
+		}
+		n, err := writer.Write(record)
+		if err != nil {
+			return 0, err
+		}
+		size += int64(n)
+	}
+	return size, writer.Flush()
+}
+
+// syncDir сброс каталога на диск, чтобы переименование файла пережило сбой.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
 	if err != nil {
-		logger.LogSugar.Errorf("Ошибка записи строки %s в файл %s", modelJSON, f.file.Name())
+		return err
 	}
+	return errors.Join(d.Sync(), d.Close())
+}
 
-	return 1, nil
+// Add добавление нового значения, при занятом коде или действующей ссылке с тем же URL вернёт ErrConflict.
+func (f *FileStorage) Add(ctx context.Context, url models.URL) (int64, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if _, ok := f.index.urls[url.ShortURL]; ok {
+		return 0, conflictError("short url already exists")
+	}
+	if _, ok := f.index.activeURLs[url.URL]; ok {
+		return 0, conflictError("url already exists")
+	}
+	url.ID = f.index.lastID + 1
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = time.Now()
+	}
+	err := f.writeEvents(fileEvent{Type: fileEventAdd, Link: &url})
+	if err != nil {
+		return 0, err
+	}
+	return int64(url.ID), nil
+}
+
+// CreateUser создает пользователя, повторное создание с тем же uuid ничего не меняет.
+func (f *FileStorage) CreateUser(ctx context.Context, user models.User) (int64, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if _, ok := f.userList[user.UUID]; ok {
+		return 0, nil
+	}
+	return f.writeUser(user)
 }
 
-// CreateUser создает пользователя.
-func (f *FileStorage) CreateUser(user models.User) (int64, error) {
-	modelRaw, err := json.Marshal(user)
+// writeUser дописывает пользователя в файл, вызывается под блокировкой.
+func (f *FileStorage) writeUser(user models.User) (int64, error) {
+	record, err := encodeRecord(user)
 	if err != nil {
 		logger.LogSugar.Error(err)
 		return 0, err
 	}
-	modelJSON := string(modelRaw)
-
-	_, err = f.users.WriteString(modelJSON + "\n")
+	err = f.users.write(record, f.sync)
 	if err != nil {
-		logger.LogSugar.Errorf("Ошибка записи строки %s в файл %s", modelJSON, f.users.Name())
+		logger.LogSugar.Errorf("Ошибка записи пользователя %s в файл %s: %s", user.UUID, f.users.Name(), err)
+		return 0, err
 	}
+	f.userList[user.UUID] = user
 	return 0, nil
 }
 
 // LikeURLToUser Связывание URL с пользователем.
-func (f *FileStorage) LikeURLToUser(urlID int64, userUUID string) error {
-	//todo
-	return nil
+func (f *FileStorage) LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	shortURL, ok := f.index.urlIDs[uint(urlID)]
+	if !ok || f.index.owners[shortURL] == userUUID {
+		return nil
+	}
+	return f.writeEvents(fileEvent{Type: fileEventLink, ShortURL: shortURL, UserUUID: userUUID})
 }
 
-// MultiAdd Вставка массива.
-func (f *FileStorage) MultiAdd(urls []models.URL) error {
+// MultiAdd Вставка массива, уже сокращённые ссылки пропускаются.
+func (f *FileStorage) MultiAdd(ctx context.Context, urls []models.URL) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	events := make([]fileEvent, 0, len(urls))
+	added := make(map[string]bool, len(urls)*2)
 	for _, url := range urls {
-		_, err := f.Add(url)
-		if err != nil {
-			return err
+		if _, ok := f.index.activeURLs[url.URL]; ok || added[url.URL] {
+			continue
+		}
+		if _, ok := f.index.urls[url.ShortURL]; ok || added[url.ShortURL] {
+			return conflictError("short url already exists")
+		}
+		url.ID = f.index.lastID + uint(len(events)) + 1
+		if url.CreatedAt.IsZero() {
+			url.CreatedAt = time.Now()
 		}
+		added[url.URL] = true
+		added[url.ShortURL] = true
+		events = append(events, fileEvent{Type: fileEventAdd, Link: &url})
 	}
-	return nil
+	return f.writeEvents(events...)
 }
 
-// SoftDeletedShortURL Отметка об удалении ссылки.
-func (f *FileStorage) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
-	return nil
+// ImportURLs вставка пачки импортируемых ссылок, ссылки с занятым URL или кодом пропускаются.
+// Вставленные ссылки связываются с пользователем той же записью журнала и возвращаются.
+func (f *FileStorage) ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	imported := make([]models.URL, 0, len(urls))
+	events := make([]fileEvent, 0, len(urls))
+	added := make(map[string]bool, len(urls)*2)
+	for _, url := range urls {
+		if _, ok := f.index.activeURLs[url.URL]; ok || added[url.URL] {
+			continue
+		}
+		if _, ok := f.index.urls[url.ShortURL]; ok || added[url.ShortURL] {
+			continue
+		}
+		url.ID = f.index.lastID + uint(len(events)) + 1
+		if url.CreatedAt.IsZero() {
+			url.CreatedAt = time.Now()
+		}
+		added[url.URL] = true
+		added[url.ShortURL] = true
+		imported = append(imported, url)
+		events = append(events, fileEvent{Type: fileEventAdd, Link: &url, UserUUID: userUUID})
+	}
+	if err := f.writeEvents(events...); err != nil {
+		return nil, err
+	}
+	return imported, nil
 }
 
-// FindByShortURL поиск по короткой ссылке.
-func (f *FileStorage) FindByShortURL(shortURL string) (*models.URL, error) {
-	for _, value := range f.cacheValues {
-		if strings.Contains(value, fmt.Sprintf("\"%s\"", shortURL)) {
-			url := models.URL{}
-			err := json.Unmarshal([]byte(value), &url)
-			if err != nil {
-				logger.LogSugar.Errorf("Ошибка json.Unmarshal: %s", value)
-				return nil, err
-			}
-			return &url, nil
+// SoftDeletedShortURL Отметка об удалении ссылки, чужие и уже удалённые ссылки пропускаются.
+func (f *FileStorage) SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	now := time.Now()
+	events := make([]fileEvent, 0, len(shortURL))
+	for _, value := range shortURL {
+		url, ok := f.index.urls[value]
+		if !ok || !url.DeletedAt.IsZero() || f.index.owners[value] != userUUID {
+			continue
 		}
+		events = append(events, fileEvent{Type: fileEventDelete, ShortURL: value, UserUUID: userUUID, At: &now})
 	}
+	return f.writeEvents(events...)
+}
 
-	return nil, fmt.Errorf("the short link was not found")
+// FindByShortURL поиск по короткой ссылке, удалённая ссылка вернётся вместе с ErrGone.
+func (f *FileStorage) FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	url, ok := f.index.urls[shortURL]
+	if !ok {
+		return nil, notFoundError("short url " + shortURL)
+	}
+	if !url.DeletedAt.IsZero() {
+		return &url, ErrGone
+	}
+	return &url, nil
 }
 
-// FindByURL поиск по URL.
-func (f *FileStorage) FindByURL(url string) (*models.URL, error) {
-	for _, value := range f.cacheValues {
-		if strings.Contains(value, fmt.Sprintf("\"%s\"", url)) {
-			url := models.URL{}
-			err := json.Unmarshal([]byte(value), &url)
-			if err != nil {
-				return nil, err
-			}
-			return &url, nil
-		}
+// FindByURL поиск действующей ссылки по URL.
+func (f *FileStorage) FindByURL(ctx context.Context, url string) (*models.URL, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	shortURL, ok := f.index.activeURLs[url]
+	if !ok {
+		return nil, notFoundError("url " + url)
 	}
-	return new(models.URL), nil
+	modelURL := f.index.urls[shortURL]
+	return &modelURL, nil
 }
 
 // FindUserByLoginAndPasswordHash Поиск пользователя.
-func (f *FileStorage) FindUserByLoginAndPasswordHash(login string, password string) (*models.User, error) {
-	return nil, nil
+func (f *FileStorage) FindUserByLoginAndPasswordHash(ctx context.Context, login string, password string) (*models.User, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	for _, user := range f.userList {
+		if user.Login == login && user.Password == password {
+			return &user, nil
+		}
+	}
+	return nil, notFoundError("user")
+}
+
+// FindUserByUUID Поиск пользователя по uuid.
+func (f *FileStorage) FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	if user, ok := f.userList[userUUID]; ok {
+		return &user, nil
+	}
+	return nil, notFoundError("user " + userUUID)
+}
+
+// RegisterUser заполняет логин и пароль анонимного пользователя.
+// В файл дописывается новая версия пользователя, при чтении побеждает последняя.
+func (f *FileStorage) RegisterUser(ctx context.Context, user models.User) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	for _, value := range f.userList {
+		if value.Login == user.Login && value.UUID != user.UUID {
+			return conflictError("login already exists")
+		}
+	}
+	anonymous, ok := f.userList[user.UUID]
+	if !ok || anonymous.Login != "" {
+		return notFoundError("anonymous user " + user.UUID)
+	}
+	_, err := f.writeUser(user)
+	return err
+}
+
+// restoreUser применяет запись файла пользователей, последняя запись пользователя актуальна.
+func (f *FileStorage) restoreUser(payload []byte) error {
+	user := models.User{}
+	if err := json.Unmarshal(payload, &user); err != nil {
+		return err
+	}
+	f.userList[user.UUID] = user
+	return nil
 }
 
 // FindUrlsByUserID поиск URL-s.
-func (f *FileStorage) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	return nil, nil
+func (f *FileStorage) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	urls := f.index.userURLList(userUUID)
+	return &urls, nil
+}
+
+// FindUserURLs страница ссылок пользователя, отбор и сортировка выполняются перебором ссылок пользователя.
+func (f *FileStorage) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
+	f.mx.RLock()
+	urls := f.index.userURLList(userUUID)
+	f.mx.RUnlock()
+	return paginateURLs(urls, filter)
+}
+
+// RestoreShortURLs Снятие отметки об удалении со ссылок пользователя.
+func (f *FileStorage) RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	now := time.Now()
+	restored := make([]string, 0, len(shortURLs))
+	events := make([]fileEvent, 0, len(shortURLs))
+	restoredURLs := make(map[string]bool, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		url, ok := f.index.urls[shortURL]
+		if !ok || url.DeletedAt.IsZero() || url.DeletedAt.Before(deletedSince) || f.index.owners[shortURL] != userUUID {
+			continue
+		}
+		if !url.ExpiresAt.IsZero() && !now.Before(url.ExpiresAt) {
+			continue
+		}
+		if _, active := f.index.activeURLs[url.URL]; active || restoredURLs[url.URL] {
+			continue
+		}
+		restoredURLs[url.URL] = true
+		restored = append(restored, shortURL)
+		events = append(events, fileEvent{Type: fileEventRestore, ShortURL: shortURL, UserUUID: userUUID})
+	}
+	if err := f.writeEvents(events...); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// UpdateUserURL смена URL ссылки её владельцем, запись журнала хранит и прежний, и новый URL.
+func (f *FileStorage) UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	modelURL, ok := f.index.urls[shortURL]
+	if !ok || f.index.owners[shortURL] != userUUID {
+		return nil, notFoundError("user short url " + shortURL)
+	}
+	if !modelURL.DeletedAt.IsZero() {
+		return &modelURL, ErrGone
+	}
+	if modelURL.URL == url {
+		return &modelURL, nil
+	}
+	if _, ok = f.index.activeURLs[url]; ok {
+		return nil, conflictError("url already exists")
+	}
+	modelURL.URL = url
+	now := time.Now()
+	err := f.writeEvents(fileEvent{Type: fileEventUpdate, Link: &modelURL, ShortURL: shortURL, UserUUID: userUUID, At: &now})
+	if err != nil {
+		return nil, err
+	}
+	return &modelURL, nil
+}
+
+// ExportUserURLs выгрузка ссылок пользователя вместе с удалёнными и количеством переходов.
+// Ссылки копируются под блокировкой и передаются в fn после её снятия, чтобы медленный получатель не задерживал запись.
+func (f *FileStorage) ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error {
+	f.mx.RLock()
+	urls := f.index.userURLList(userUUID)
+	exported := make([]models.ExportURL, 0, len(urls))
+	for _, url := range urls {
+		exported = append(exported, models.ExportURL{URL: url, Clicks: f.clickCounts.total(url.ShortURL)})
+	}
+	f.mx.RUnlock()
+
+	for _, url := range exported {
+		if err := fn(url); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// Close закрытие файла
+// Close сброс журнала на диск и закрытие файлов хранилища.
 func (f *FileStorage) Close() error {
-	return f.file.Close()
+	syncErr := f.Sync()
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return errors.Join(syncErr, f.file.Close(), f.users.Close(), f.clicks.Close(), f.apiKeys.Close())
 }
 
 // Ping проверка доступности.
-func (f *FileStorage) Ping() error {
-	_, err := os.Stat(f.file.Name())
+func (f *FileStorage) Ping(ctx context.Context) error {
+	_, err := os.Stat(f.path)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// restoreStorage восстановит бд из переданного значения
-func (f *FileStorage) restoreStorage() {
-	for f.scanner.Scan() {
-		lineData := string(f.scanner.Bytes())
-		f.cacheValues = append(f.cacheValues, lineData)
-	}
-	if err := f.scanner.Err(); err != nil {
-		logger.LogSugar.Errorf("При восстановлении храналица, обнаружены ошибки: %s", err)
+// restoreStorage повторяет журнал ссылок.
+// Повреждённые записи пропускаются, а всё после последней целой записи считается недописанным при сбое хвостом и обрезается.
+func (f *FileStorage) restoreStorage() error {
+	records, size, err := replayRecords(f.file, func(payload []byte) error {
+		event, err := decodeFileEvent(payload)
+		if err != nil {
+			return err
+		}
+		f.index.apply(event)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	f.records = records
+	f.size = size
+	return nil
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (f *FileStorage) GetCountShortURL() (int64, error) {
-	return int64(len(f.cacheValues)), nil
+func (f *FileStorage) GetCountShortURL(ctx context.Context) (int64, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	return int64(len(f.index.activeURLs)), nil
 }
 
 // GetCountUser кол-во пользвателей
-func (f *FileStorage) GetCountUser() (int64, error) {
-	userFile, err := os.Open(f.users.Name())
+func (f *FileStorage) GetCountUser(ctx context.Context) (int64, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	return int64(len(f.userList)), nil
+}
+
+// SoftDeleteExpiredURLs пометка ссылок с истёкшим сроком жизни как удалённых.
+func (f *FileStorage) SoftDeleteExpiredURLs(ctx context.Context) (int64, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	now := time.Now()
+	events := make([]fileEvent, 0)
+	for shortURL, url := range f.index.urls {
+		if url.ExpiresAt.IsZero() || now.Before(url.ExpiresAt) || !url.DeletedAt.IsZero() {
+			continue
+		}
+		events = append(events, fileEvent{Type: fileEventDelete, ShortURL: shortURL, At: &now})
+	}
+	if err := f.writeEvents(events...); err != nil {
+		return 0, err
+	}
+	return int64(len(events)), nil
+}
+
+// PurgeDeletedURLs окончательное удаление ссылок, удалённых раньше deletedBefore, начиная с самых старых.
+// Записи о ссылке остаются в журнале, при повторе их отменяет запись purge, так же отменяются и переходы по ссылке.
+func (f *FileStorage) PurgeDeletedURLs(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	purge := make([]models.URL, 0, limit)
+	for _, url := range f.index.urls {
+		if !url.DeletedAt.IsZero() && url.DeletedAt.Before(deletedBefore) {
+			purge = append(purge, url)
+		}
+	}
+	slices.SortFunc(purge, func(a, b models.URL) int {
+		return a.DeletedAt.Compare(b.DeletedAt)
+	})
+	if len(purge) > limit {
+		purge = purge[:limit]
+	}
+	events := make([]fileEvent, 0, len(purge))
+	for _, url := range purge {
+		events = append(events, fileEvent{Type: fileEventPurge, ShortURL: url.ShortURL})
+	}
+	if err := f.writeEvents(events...); err != nil {
+		return 0, err
+	}
+	if err := f.purgeClicks(purge); err != nil {
+		return int64(len(events)), err
+	}
+	return int64(len(events)), nil
+}
+
+// fileClickRecord строка файла переходов. Отметка Purged пишется при окончательном удалении ссылки
+// и отменяет её прежние переходы, чтобы они не достались ссылке, которая позже получит тот же код.
+type fileClickRecord struct {
+	models.Click
+	Purged bool `json:"purged,omitempty"`
+}
+
+// purgeClicks отмечает в файле переходов окончательно удалённые ссылки и забывает их переходы, вызывается под блокировкой.
+func (f *FileStorage) purgeClicks(urls []models.URL) error {
+	var lines bytes.Buffer
+	for _, url := range urls {
+		if _, ok := f.clickCounts[url.ShortURL]; !ok {
+			continue
+		}
+		delete(f.clickCounts, url.ShortURL)
+		record, err := encodeRecord(fileClickRecord{Click: models.Click{ShortURL: url.ShortURL, CreatedAt: time.Now().UTC()}, Purged: true})
+		if err != nil {
+			logger.LogSugar.Error(err)
+			return err
+		}
+		lines.Write(record)
+	}
+	if lines.Len() == 0 {
+		return nil
+	}
+	err := f.clicks.write(lines.Bytes(), f.sync)
+	if err != nil {
+		logger.LogSugar.Errorf("Ошибка записи отметок об удалении переходов в файл %s: %s", f.clicks.Name(), err)
+	}
+	return err
+}
+
+// AddClick сохраняет переход по короткой ссылке.
+func (f *FileStorage) AddClick(ctx context.Context, click models.Click) error {
+	return f.AddClicks(ctx, []models.Click{click})
+}
+
+// AddClicks сохраняет пачку переходов одной записью в файл и учитывает их в статистике.
+func (f *FileStorage) AddClicks(ctx context.Context, clicks []models.Click) error {
+	var lines bytes.Buffer
+	for _, click := range clicks {
+		record, err := encodeRecord(click)
+		if err != nil {
+			logger.LogSugar.Error(err)
+			return err
+		}
+		lines.Write(record)
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	err := f.clicks.write(lines.Bytes(), f.sync)
+	if err != nil {
+		logger.LogSugar.Errorf("Ошибка записи переходов в файл %s: %s", f.clicks.Name(), err)
+		return err
+	}
+	for _, click := range clicks {
+		f.clickCounts.add(click)
+	}
+	return nil
+}
+
+// GetClickStats статистика переходов по короткой ссылке.
+func (f *FileStorage) GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	return f.clickCounts.stats(shortURL), nil
+}
+
+// restoreClick применяет запись файла переходов.
+// Отметка об окончательном удалении ссылки отменяет все её переходы, записанные раньше.
+func (f *FileStorage) restoreClick(payload []byte) error {
+	record := fileClickRecord{}
+	if err := json.Unmarshal(payload, &record); err != nil {
+		return err
+	}
+	if record.Purged {
+		delete(f.clickCounts, record.ShortURL)
+		return nil
+	}
+	f.clickCounts.add(record.Click)
+	return nil
+}
+
+// AddAPIKey сохраняет ключ доступа.
+func (f *FileStorage) AddAPIKey(ctx context.Context, key models.APIKey) (int64, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if _, ok := f.apiKeysByHash[key.KeyHash]; ok {
+		return 0, conflictError("api key already exists")
+	}
+	key.ID = f.lastIDForKey + 1
+	err := f.writeAPIKey(key)
 	if err != nil {
 		return 0, err
 	}
-	b := bufio.NewScanner(userFile)
-	var cnt int64
-	for b.Scan() {
-		cnt++
+	return key.ID, nil
+}
+
+// FindAPIKeyByHash поиск действующего ключа доступа по хэшу.
+func (f *FileStorage) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+	id, ok := f.apiKeysByHash[keyHash]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
 	}
+	key := f.apiKeysByID[id]
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &key, nil
+}
 
-	return cnt, nil
+// RevokeAPIKey отзыв ключа доступа, в файл дописывается новая версия ключа.
+func (f *FileStorage) RevokeAPIKey(ctx context.Context, id int64) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	key, ok := f.apiKeysByID[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+	revokedAt := time.Now().UTC()
+	key.RevokedAt = &revokedAt
+	return f.writeAPIKey(key)
+}
+
+// writeAPIKey дописывает ключ доступа в файл и применяет его к индексам, вызывается под блокировкой.
+func (f *FileStorage) writeAPIKey(key models.APIKey) error {
+	record, err := encodeRecord(key)
+	if err != nil {
+		logger.LogSugar.Error(err)
+		return err
+	}
+	err = f.apiKeys.write(record, f.sync)
+	if err != nil {
+		logger.LogSugar.Errorf("Ошибка записи ключа доступа %d в файл %s: %s", key.ID, f.apiKeys.Name(), err)
+		return err
+	}
+	f.applyAPIKey(key)
+	return nil
+}
+
+// applyAPIKey обновляет индексы ключей доступа, последняя запись ключа актуальна.
+func (f *FileStorage) applyAPIKey(key models.APIKey) {
+	f.apiKeysByID[key.ID] = key
+	f.apiKeysByHash[key.KeyHash] = key.ID
+	if key.ID > f.lastIDForKey {
+		f.lastIDForKey = key.ID
+	}
+}
+
+// restoreAPIKey применяет запись файла ключей доступа.
+func (f *FileStorage) restoreAPIKey(payload []byte) error {
+	key := models.APIKey{}
+	if err := json.Unmarshal(payload, &key); err != nil {
+		return err
+	}
+	f.applyAPIKey(key)
+	return nil
 }
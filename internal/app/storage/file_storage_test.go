@@ -2,15 +2,21 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type demoData []models.URL
@@ -60,7 +66,7 @@ func TestFileStorage_restoreStorageData(t *testing.T) {
 		storage := NewFileStorage(fileStorage)
 
 		for _, url := range demoURLs {
-			modelURL, err := storage.FindByURL(url.URL)
+			modelURL, err := storage.FindByURL(context.Background(), url.URL)
 			if err != nil {
 				t.Error(err)
 			}
@@ -68,7 +74,7 @@ func TestFileStorage_restoreStorageData(t *testing.T) {
 				t.Errorf("Значений не найдено: storage.FindByURL(%s)", url.URL)
 			}
 
-			modelURL, err = storage.FindByShortURL(url.ShortURL)
+			modelURL, err = storage.FindByShortURL(context.Background(), url.ShortURL)
 			if err != nil {
 				t.Error(err)
 			}
@@ -96,12 +102,30 @@ func TestFileStorage_Add(t *testing.T) {
 			ShortURL: "aaa",
 			URL:      "bbbbbbb",
 		}
-		_, err = fileStorage.Add(url)
+		_, err = fileStorage.Add(context.Background(), url)
 		if err != nil {
 			t.Errorf("Add() error = %v", err)
 		}
 	})
 
+	t.Run("Повторная_короткая_ссылка_отклоняется", func(t *testing.T) {
+		file, err := os.CreateTemp("/tmp", "TestFileStorage_Add_*.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		fileStorage := NewFileStorage(file)
+
+		_, err = fileStorage.Add(context.Background(), models.URL{ShortURL: "spring-sale", URL: "https://ya.ru/spring"})
+		if err != nil {
+			t.Errorf("Add() error = %v", err)
+		}
+		_, err = fileStorage.Add(context.Background(), models.URL{ShortURL: "spring-sale", URL: "https://ya.ru/autumn"})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Add() ожидалась ошибка дубликата, получено %v", err)
+		}
+	})
+
 	t.Run("Проверка_добавленного_значения", func(t *testing.T) {
 		file, err := os.CreateTemp("/tmp", "TestFileStorage_Add_*.json")
 		if err != nil {
@@ -115,8 +139,8 @@ func TestFileStorage_Add(t *testing.T) {
 			ShortURL: "aaa",
 			URL:      "bbbbbbb",
 		}
-		_, _ = fileStorage.Add(url)
-		findValue, err := fileStorage.FindByURL(url.URL)
+		_, _ = fileStorage.Add(context.Background(), url)
+		findValue, err := fileStorage.FindByURL(context.Background(), url.URL)
 		if findValue == nil {
 			t.Errorf("FindByURL() error = %v", err)
 		}
@@ -133,7 +157,7 @@ func TestFileStorage_Add(t *testing.T) {
 
 		for i := 0; i < 200; i++ {
 			go func() {
-				fileStorage.Add(models.URL{
+				fileStorage.Add(context.Background(), models.URL{
 					ID:       uint(i),
 					ShortURL: fmt.Sprintf("text%d", i),
 					URL:      fmt.Sprintf("https://ya.ru/%d", i),
@@ -142,11 +166,11 @@ func TestFileStorage_Add(t *testing.T) {
 		}
 
 		time.Sleep(time.Millisecond * 100)
-		_, err = fileStorage.Add(models.URL{ShortURL: "endKey", URL: "https://ya.ru"})
+		_, err = fileStorage.Add(context.Background(), models.URL{ShortURL: "endKey", URL: "https://ya.ru"})
 		if err != nil {
 			t.Errorf("Add() error = %v", err)
 		}
-		findValue, err := fileStorage.FindByURL("https://ya.ru")
+		findValue, err := fileStorage.FindByURL(context.Background(), "https://ya.ru")
 		if findValue == nil {
 			t.Errorf("FindByURL() error = %v", err)
 		}
@@ -173,7 +197,7 @@ func TestCreateUser(t *testing.T) {
 		UUID:  "testuuid",
 	}
 
-	_, err = storage.CreateUser(user)
+	_, err = storage.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Errorf("CreateUser returned an error: %v", err)
 	}
@@ -191,7 +215,11 @@ func TestCreateUser(t *testing.T) {
 	}
 	line := scanner.Text()
 	var storedUser models.User
-	err = json.Unmarshal([]byte(line), &storedUser)
+	payload, err := decodeRecord([]byte(line))
+	if err != nil {
+		t.Fatalf("Failed to decode user record: %v", err)
+	}
+	err = json.Unmarshal(payload, &storedUser)
 	if err != nil {
 		t.Errorf("Failed to unmarshal user data: %v", err)
 	}
@@ -213,15 +241,14 @@ func TestGetCountShortURL(t *testing.T) {
 		t.Fatalf("Failed to initialize FileStorage")
 	}
 	defer storage.Close()
-	tempFile.Close()
 	url := models.URL{
 		ID:       1,
 		ShortURL: "aaa",
 		URL:      "bbbbbbb",
 	}
-	_, _ = storage.Add(url)
+	_, _ = storage.Add(context.Background(), url)
 
-	cnt, _ := storage.GetCountShortURL()
+	cnt, _ := storage.GetCountShortURL(context.Background())
 
 	assert.Equal(t, int64(1), cnt)
 }
@@ -245,11 +272,613 @@ func TestGetCountUser(t *testing.T) {
 		UUID:  "testuuid",
 	}
 
-	_, err = storage.CreateUser(user)
+	_, err = storage.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Errorf("CreateUser returned an error: %v", err)
 	}
 
-	cnt, _ := storage.GetCountUser()
+	cnt, _ := storage.GetCountUser(context.Background())
 	assert.Equal(t, int64(1), cnt)
 }
+
+func TestFileStorage_ClickStats(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-storage-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer os.Remove(tempFile.Name() + "clicks.json")
+
+	storage := NewFileStorage(tempFile)
+	if storage == nil {
+		t.Fatalf("Failed to initialize FileStorage")
+	}
+	defer storage.Close()
+
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC), UserAgent: "test-agent"})
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)})
+	_ = storage.AddClicks(context.Background(), []models.Click{
+		{ShortURL: "bbb", CreatedAt: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)},
+		{ShortURL: "bbb", CreatedAt: time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC)},
+	})
+
+	stats, err := storage.GetClickStats(context.Background(), "aaa")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Total)
+	assert.Equal(t, []models.ClickDay{
+		{Date: "2026-10-17", Clicks: 1},
+		{Date: "2026-10-18", Clicks: 1},
+	}, stats.Days)
+}
+
+func TestFileStorage_ClickStatsRestore(t *testing.T) {
+	storage, name := newTestFileStorage(t)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.AddClicks(ctx, []models.Click{
+		{ShortURL: "aaa", CreatedAt: day},
+		{ShortURL: "aaa", CreatedAt: day.Add(time.Hour)},
+	}))
+	require.NoError(t, storage.Close())
+
+	// Повреждённая строка не мешает подсчёту остальных переходов
+	clicksFile, err := os.OpenFile(name+"clicks.json", os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	_, err = clicksFile.WriteString("{\"short_url\":\"aaa\",\n")
+	require.NoError(t, err)
+	require.NoError(t, clicksFile.Close())
+
+	restored := reopenFileStorage(t, name)
+	require.NoError(t, restored.AddClick(ctx, models.Click{ShortURL: "aaa", CreatedAt: day.Add(24 * time.Hour)}))
+	stats, err := restored.GetClickStats(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Total)
+	assert.Equal(t, []models.ClickDay{
+		{Date: "2026-10-18", Clicks: 2},
+		{Date: "2026-10-19", Clicks: 1},
+	}, stats.Days)
+}
+
+func TestFileStorage_PurgeDropsClicks(t *testing.T) {
+	storage, name := newTestFileStorage(t)
+	ctx := context.Background()
+	_, err := storage.CreateUser(ctx, models.User{UUID: "user-1"})
+	require.NoError(t, err)
+	for _, shortURL := range []string{"short1", "short2"} {
+		id, err := storage.Add(ctx, models.URL{ShortURL: shortURL, URL: "https://ya.ru/" + shortURL})
+		require.NoError(t, err)
+		require.NoError(t, storage.LikeURLToUser(ctx, id, "user-1"))
+		require.NoError(t, storage.AddClicks(ctx, []models.Click{{ShortURL: shortURL}, {ShortURL: shortURL}}))
+	}
+	require.NoError(t, storage.SoftDeletedShortURL(ctx, "user-1", "short1"))
+	cnt, err := storage.PurgeDeletedURLs(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	stats, err := storage.GetClickStats(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Total)
+	// Освободившийся код получает новая ссылка, переходы прежней ей не достаются
+	_, err = storage.Add(ctx, models.URL{ShortURL: "short1", URL: "https://ya.ru/new"})
+	require.NoError(t, err)
+	require.NoError(t, storage.AddClick(ctx, models.Click{ShortURL: "short1"}))
+	require.NoError(t, storage.Close())
+
+	restored := reopenFileStorage(t, name)
+	for shortURL, want := range map[string]int64{"short1": 1, "short2": 2} {
+		stats, err = restored.GetClickStats(ctx, shortURL)
+		require.NoError(t, err)
+		assert.Equal(t, want, stats.Total, shortURL)
+	}
+}
+
+func TestFileStorage_RegisterUser(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-storage-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer os.Remove(tempFile.Name() + "user.json")
+
+	storage := NewFileStorage(tempFile)
+	if storage == nil {
+		t.Fatalf("Failed to initialize FileStorage")
+	}
+	defer storage.Close()
+
+	_, _ = storage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	_, _ = storage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	_, _ = storage.CreateUser(context.Background(), models.User{UUID: "uuid-2"})
+
+	err = storage.RegisterUser(context.Background(), models.User{UUID: "uuid-1", Login: "cat", Password: "hash"})
+	assert.NoError(t, err)
+	user, _ := storage.FindUserByLoginAndPasswordHash(context.Background(), "cat", "hash")
+	assert.Equal(t, "uuid-1", user.UUID)
+
+	err = storage.RegisterUser(context.Background(), models.User{UUID: "uuid-2", Login: "cat", Password: "hash"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	cnt, _ := storage.GetCountUser(context.Background())
+	assert.Equal(t, int64(2), cnt)
+}
+
+func TestFileStorage_APIKeys(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "test-storage-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer os.Remove(tempFile.Name() + "api-keys.json")
+
+	storage := NewFileStorage(tempFile)
+	if storage == nil {
+		t.Fatalf("Failed to initialize FileStorage")
+	}
+	defer storage.Close()
+
+	id, err := storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-1", KeyHash: "hash-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+	id2, err := storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-2", KeyHash: "hash-2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), id2)
+
+	_, err = storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-2", KeyHash: "hash-1"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	assert.NoError(t, storage.RevokeAPIKey(context.Background(), id))
+	assert.ErrorIs(t, storage.RevokeAPIKey(context.Background(), id), ErrAPIKeyNotFound)
+
+	key, err := storage.FindAPIKeyByHash(context.Background(), "hash-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, key)
+	key, err = storage.FindAPIKeyByHash(context.Background(), "hash-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-2", key.UserUUID)
+}
+
+func TestFileStorage_APIKeysConcurrent(t *testing.T) {
+	storage, name := newTestFileStorage(t)
+	const workers = 20
+
+	var wg sync.WaitGroup
+	ids := make(chan int64, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-1", KeyHash: fmt.Sprintf("hash-%d", i)})
+			assert.NoError(t, err)
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	unique := make(map[int64]struct{}, workers)
+	for id := range ids {
+		unique[id] = struct{}{}
+	}
+	assert.Len(t, unique, workers, "идентификаторы ключей не повторяются")
+	assert.NoError(t, storage.RevokeAPIKey(context.Background(), workers))
+	require.NoError(t, storage.Close())
+
+	// После перезапуска нумерация продолжается, отзыв сохраняется
+	storage = reopenFileStorage(t, name)
+	id, err := storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-1", KeyHash: "hash-new"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers+1), id)
+	key, err := storage.FindAPIKeyByHash(context.Background(), "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "uuid-1", key.UserUUID)
+	_, err = storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-2", KeyHash: "hash-1"})
+	assert.ErrorIs(t, err, ErrConflict)
+	revoked := 0
+	for i := 0; i < workers; i++ {
+		if _, err = storage.FindAPIKeyByHash(context.Background(), fmt.Sprintf("hash-%d", i)); errors.Is(err, ErrAPIKeyNotFound) {
+			revoked++
+		}
+	}
+	assert.Equal(t, 1, revoked)
+}
+
+func TestFileStorage_UserURLsReplay(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	tempFile, err := os.CreateTemp("", "test-storage-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer os.Remove(tempFile.Name() + "user.json")
+	defer os.Remove(tempFile.Name() + "clicks.json")
+	defer os.Remove(tempFile.Name() + "api-keys.json")
+
+	ctx := context.Background()
+	storage := NewFileStorage(tempFile)
+	require.NotNil(t, storage)
+
+	id, err := storage.Add(ctx, models.URL{ShortURL: "short1", URL: "https://ozon.ru"})
+	require.NoError(t, err)
+	require.NoError(t, storage.LikeURLToUser(ctx, id, "user-1"))
+	_, err = storage.Add(ctx, models.URL{ShortURL: "short9", URL: "https://ozon.ru"})
+	assert.ErrorIs(t, err, ErrConflict)
+	imported, err := storage.ImportURLs(ctx, "user-1", []models.URL{
+		{ShortURL: "short2", URL: "https://avito.ru"},
+		{ShortURL: "short3", URL: "https://avito.ru/3"},
+		{ShortURL: "short4", URL: "https://ozon.ru"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, imported, 2)
+	_, err = storage.ImportURLs(ctx, "user-2", []models.URL{{ShortURL: "short5", URL: "https://avito.ru/5"}})
+	require.NoError(t, err)
+	_ = storage.AddClick(ctx, models.Click{ShortURL: "short1"})
+
+	// Чужую ссылку удалить нельзя
+	require.NoError(t, storage.SoftDeletedShortURL(ctx, "user-1", "short2", "short3", "short5"))
+	_, err = storage.FindByShortURL(ctx, "short5")
+	assert.NoError(t, err)
+	_, err = storage.FindByShortURL(ctx, "short2")
+	assert.ErrorIs(t, err, ErrGone)
+
+	restored, err := storage.RestoreShortURLs(ctx, "user-1", time.Now().Add(-time.Hour), "short3", "short5")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short3"}, restored)
+
+	updated, err := storage.UpdateUserURL(ctx, "user-1", "short1", "https://ozon.ru/new")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ozon.ru/new", updated.URL)
+	_, err = storage.UpdateUserURL(ctx, "user-2", "short1", "https://ozon.ru/other")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	cnt, err := storage.PurgeDeletedURLs(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cnt)
+	require.NoError(t, storage.Close())
+
+	// Состояние восстанавливается повтором журнала
+	file, err := os.OpenFile(tempFile.Name(), os.O_RDWR|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	storage = NewFileStorage(file)
+	require.NotNil(t, storage)
+	defer storage.Close()
+
+	urls, err := storage.FindUrlsByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, *urls, 2)
+	assert.Equal(t, "short1", (*urls)[0].ShortURL)
+	assert.Equal(t, "https://ozon.ru/new", (*urls)[0].URL)
+	assert.Equal(t, "short3", (*urls)[1].ShortURL)
+	_, err = storage.FindByShortURL(ctx, "short2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.FindByURL(ctx, "https://ozon.ru")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	page, err := storage.FindUserURLs(ctx, "user-2", models.URLFilter{})
+	require.NoError(t, err)
+	require.Len(t, page.URLs, 1)
+	assert.Equal(t, "short5", page.URLs[0].ShortURL)
+
+	exported := make([]models.ExportURL, 0)
+	err = storage.ExportUserURLs(ctx, "user-1", func(url models.ExportURL) error {
+		exported = append(exported, url)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, exported, 2)
+	assert.Equal(t, int64(1), exported[0].Clicks)
+
+	// Новые ссылки получают id после восстановленных
+	id, err = storage.Add(ctx, models.URL{ShortURL: "short6", URL: "https://avito.ru/6"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), id)
+	total, _ := storage.GetCountShortURL(ctx)
+	assert.Equal(t, int64(4), total)
+}
+
+// newTestFileStorage файловое хранилище во временном файле, файлы удаляются после теста.
+func newTestFileStorage(t *testing.T) (*FileStorage, string) {
+	t.Helper()
+	_ = logger.InitLogger("fatal")
+	tempFile, err := os.CreateTemp("", "test-storage-*.json")
+	require.NoError(t, err)
+	name := tempFile.Name()
+	t.Cleanup(func() {
+		for _, suffix := range []string{"", "user.json", "clicks.json", "api-keys.json", ".compact"} {
+			_ = os.Remove(name + suffix)
+		}
+	})
+	storage := NewFileStorage(tempFile)
+	require.NotNil(t, storage)
+	return storage, name
+}
+
+// reopenFileStorage повторное открытие хранилища, как при перезапуске сервиса.
+func reopenFileStorage(t *testing.T, name string) *FileStorage {
+	t.Helper()
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	storage := NewFileStorage(file)
+	require.NotNil(t, storage)
+	t.Cleanup(func() {
+		_ = storage.Close()
+	})
+	return storage
+}
+
+func TestFileStorage_Recovery(t *testing.T) {
+	tests := []struct {
+		name string
+		// порча журнала из трёх записей
+		damage    func(t *testing.T, name string, lines [][]byte)
+		wantShort []string
+		// журнал обрезается до последней целой записи
+		wantSize func(lines [][]byte) int64
+	}{
+		{
+			name: "#1_недописанная_последняя_запись",
+			damage: func(t *testing.T, name string, lines [][]byte) {
+				file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0666)
+				require.NoError(t, err)
+				_, err = file.Write([]byte(`0badc0de {"type":"add","link":{"short_url":"short4"`))
+				require.NoError(t, err)
+				require.NoError(t, file.Close())
+			},
+			wantShort: []string{"short1", "short2", "short3"},
+			wantSize: func(lines [][]byte) int64 {
+				return int64(len(lines[0]) + len(lines[1]) + len(lines[2]))
+			},
+		},
+		{
+			name: "#2_неверная_контрольная_сумма_последней_записи",
+			damage: func(t *testing.T, name string, lines [][]byte) {
+				lines[2][0] ^= 0x01
+				require.NoError(t, os.WriteFile(name, bytes.Join(lines, nil), 0666))
+			},
+			wantShort: []string{"short1", "short2"},
+			wantSize: func(lines [][]byte) int64 {
+				return int64(len(lines[0]) + len(lines[1]))
+			},
+		},
+		{
+			name: "#3_испорченная_запись_в_середине_пропускается",
+			damage: func(t *testing.T, name string, lines [][]byte) {
+				lines[1][len(lines[1])-3] = 'X'
+				require.NoError(t, os.WriteFile(name, bytes.Join(lines, nil), 0666))
+			},
+			wantShort: []string{"short1", "short3"},
+			wantSize: func(lines [][]byte) int64 {
+				return int64(len(lines[0]) + len(lines[1]) + len(lines[2]))
+			},
+		},
+		{
+			name: "#4_обрезанный_файл",
+			damage: func(t *testing.T, name string, lines [][]byte) {
+				require.NoError(t, os.Truncate(name, int64(len(lines[0])+len(lines[1])+10)))
+			},
+			wantShort: []string{"short1", "short2"},
+			wantSize: func(lines [][]byte) int64 {
+				return int64(len(lines[0]) + len(lines[1]))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			storage, name := newTestFileStorage(t)
+			_, err := storage.ImportURLs(ctx, "user-1", []models.URL{
+				{ShortURL: "short1", URL: "https://ozon.ru/1"},
+			})
+			require.NoError(t, err)
+			_, err = storage.Add(ctx, models.URL{ShortURL: "short2", URL: "https://ozon.ru/2"})
+			require.NoError(t, err)
+			_, err = storage.Add(ctx, models.URL{ShortURL: "short3", URL: "https://ozon.ru/3"})
+			require.NoError(t, err)
+			require.NoError(t, storage.Close())
+
+			raw, err := os.ReadFile(name)
+			require.NoError(t, err)
+			lines := bytes.SplitAfter(raw, []byte("\n"))
+			require.Len(t, lines, 4)
+			tt.damage(t, name, lines[:3])
+
+			storage = reopenFileStorage(t, name)
+			for _, shortURL := range []string{"short1", "short2", "short3"} {
+				_, err = storage.FindByShortURL(ctx, shortURL)
+				if slices.Contains(tt.wantShort, shortURL) {
+					assert.NoError(t, err, shortURL)
+				} else {
+					assert.ErrorIs(t, err, ErrNotFound, shortURL)
+				}
+			}
+			info, err := os.Stat(name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSize(lines), info.Size())
+
+			// Новые записи дописываются после целых записей и читаются после перезапуска
+			_, err = storage.Add(ctx, models.URL{ShortURL: "short5", URL: "https://avito.ru/5"})
+			require.NoError(t, err)
+			require.NoError(t, storage.Close())
+			storage = reopenFileStorage(t, name)
+			_, err = storage.FindByShortURL(ctx, "short5")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFileStorage_SideFilesRecovery(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	storage, name := newTestFileStorage(t)
+	_, err := storage.CreateUser(ctx, models.User{UUID: "user-1"})
+	require.NoError(t, err)
+	_, err = storage.AddAPIKey(ctx, models.APIKey{KeyHash: "hash-1"})
+	require.NoError(t, err)
+	require.NoError(t, storage.AddClick(ctx, models.Click{ShortURL: "short1", CreatedAt: day}))
+	require.NoError(t, storage.Close())
+
+	// Недописанная при сбое запись в конце каждого файла
+	sizes := make(map[string]int64)
+	for _, suffix := range []string{"user.json", "clicks.json", "api-keys.json"} {
+		info, err := os.Stat(name + suffix)
+		require.NoError(t, err)
+		sizes[suffix] = info.Size()
+		file, err := os.OpenFile(name+suffix, os.O_WRONLY|os.O_APPEND, 0666)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(`0badc0de {"uuid":"user-2"`))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	storage = reopenFileStorage(t, name)
+	for suffix, size := range sizes {
+		info, err := os.Stat(name + suffix)
+		require.NoError(t, err)
+		assert.Equal(t, size, info.Size(), suffix)
+	}
+	_, err = storage.FindUserByUUID(ctx, "user-1")
+	assert.NoError(t, err)
+	_, err = storage.FindAPIKeyByHash(ctx, "hash-1")
+	assert.NoError(t, err)
+
+	// Новые записи дописываются после целых записей и читаются после перезапуска
+	_, err = storage.CreateUser(ctx, models.User{UUID: "user-2"})
+	require.NoError(t, err)
+	require.NoError(t, storage.AddClick(ctx, models.Click{ShortURL: "short1", CreatedAt: day}))
+	require.NoError(t, storage.Close())
+	storage = reopenFileStorage(t, name)
+	cnt, err := storage.GetCountUser(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cnt)
+	stats, err := storage.GetClickStats(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Total)
+}
+
+func TestFileStorage_SideFilesLegacy(t *testing.T) {
+	ctx := context.Background()
+	storage, name := newTestFileStorage(t)
+	require.NoError(t, storage.Close())
+	// Файлы прежней версии хранилища без контрольных сумм
+	require.NoError(t, os.WriteFile(name+"user.json", []byte(`{"uuid":"user-1"}`+"\n"), 0666))
+	require.NoError(t, os.WriteFile(name+"api-keys.json", []byte(`{"id":1,"key_hash":"hash-1"}`+"\n"), 0666))
+
+	storage = reopenFileStorage(t, name)
+	_, err := storage.FindUserByUUID(ctx, "user-1")
+	assert.NoError(t, err)
+	_, err = storage.FindAPIKeyByHash(ctx, "hash-1")
+	assert.NoError(t, err)
+}
+
+func TestFileStorage_Compact(t *testing.T) {
+	ctx := context.Background()
+	storage, name := newTestFileStorage(t)
+	_, err := storage.ImportURLs(ctx, "user-1", []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1"},
+		{ShortURL: "short2", URL: "https://ozon.ru/2"},
+		{ShortURL: "short3", URL: "https://ozon.ru/3"},
+	})
+	require.NoError(t, err)
+	_, err = storage.UpdateUserURL(ctx, "user-1", "short1", "https://avito.ru/1")
+	require.NoError(t, err)
+	require.NoError(t, storage.SoftDeletedShortURL(ctx, "user-1", "short2", "short3"))
+	_, err = storage.PurgeDeletedURLs(ctx, time.Now().Add(time.Minute), 1)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.Compact(ctx))
+	raw, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(raw, []byte("\n")))
+	_, err = os.Stat(name + ".compact")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Повторное сжатие без новых записей файл не переписывает
+	require.NoError(t, storage.Compact(ctx))
+
+	// Хранилище продолжает писать в новый журнал
+	_, err = storage.Add(ctx, models.URL{ShortURL: "short4", URL: "https://avito.ru/4"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+
+	storage = reopenFileStorage(t, name)
+	urls, err := storage.FindUrlsByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, *urls, 2)
+	assert.Equal(t, "https://avito.ru/1", (*urls)[0].URL)
+	assert.True(t, (*urls)[0].DeletedAt.IsZero())
+	assert.False(t, (*urls)[1].DeletedAt.IsZero())
+	url, err := storage.FindByShortURL(ctx, "short4")
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), url.ID)
+}
+
+func TestFileStorage_CompactTwice(t *testing.T) {
+	ctx := context.Background()
+	storage, name := newTestFileStorage(t)
+	for i := 1; i <= 3; i++ {
+		_, err := storage.Add(ctx, models.URL{ShortURL: fmt.Sprintf("short%d", i), URL: fmt.Sprintf("https://ozon.ru/%d", i)})
+		require.NoError(t, err)
+		// Запись link сжатие сольёт с add, иначе сжимать было бы нечего
+		require.NoError(t, storage.LikeURLToUser(ctx, int64(i), "user-1"))
+		require.NoError(t, storage.Compact(ctx))
+		// Проверка доступности смотрит на журнал под постоянным именем, а не на временный файл сжатия
+		require.NoError(t, storage.Ping(ctx))
+	}
+	_, err := storage.Add(ctx, models.URL{ShortURL: "short4", URL: "https://ozon.ru/4"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+	_, err = os.Stat(name + ".compact")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(name + ".compact.compact")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	storage = reopenFileStorage(t, name)
+	for i := 1; i <= 4; i++ {
+		_, err = storage.FindByShortURL(ctx, fmt.Sprintf("short%d", i))
+		assert.NoError(t, err, i)
+	}
+	urls, err := storage.FindUrlsByUserID(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, *urls, 3)
+}
+
+func TestFileStorage_Maintenance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	storage, name := newTestFileStorage(t)
+	storage.StartMaintenance(ctx, FileStorageOptions{Sync: FileSyncInterval, SyncInterval: 5 * time.Millisecond, CompactInterval: 10 * time.Millisecond})
+
+	_, err := storage.ImportURLs(ctx, "user-1", []models.URL{{ShortURL: "short1", URL: "https://ozon.ru/1"}})
+	require.NoError(t, err)
+	require.NoError(t, storage.SoftDeletedShortURL(ctx, "user-1", "short1"))
+	assert.Eventually(t, func() bool {
+		raw, err := os.ReadFile(name)
+		return err == nil && bytes.Count(raw, []byte("\n")) == 1
+	}, time.Second, 5*time.Millisecond)
+	storage.mx.RLock()
+	assert.False(t, storage.dirty)
+	storage.mx.RUnlock()
+	cancel()
+}
+
+func TestParseFileSyncPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    FileSyncPolicy
+		wantErr bool
+	}{
+		{name: "#1_always", value: "always", want: FileSyncAlways},
+		{name: "#2_interval", value: "interval", want: FileSyncInterval},
+		{name: "#3_never", value: "never", want: FileSyncNever},
+		{name: "#4_неизвестная_политика", value: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseFileSyncPolicy(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrFileSyncPolicyUnknown)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, policy)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// Snapshotter хранилище, которое сохраняет своё состояние на диск по запросу.
+type Snapshotter interface {
+	Snapshot() error
+}
+
+// MemorySnapshotOptions параметры снимков хранилища в памяти.
+type MemorySnapshotOptions struct {
+	// Путь к файлу снимка, пустой путь отключает снимки
+	Path string
+	// Период сохранения снимка, ноль оставляет только сохранение по запросу
+	Interval time.Duration
+}
+
+// NewMemorySnapshotOptionsFromConfig параметры снимков из настроек приложения.
+func NewMemorySnapshotOptionsFromConfig(cfg *config.Config) MemorySnapshotOptions {
+	return MemorySnapshotOptions{
+		Path:     cfg.MemorySnapshotPath,
+		Interval: cfg.MemorySnapshotInterval,
+	}
+}
+
+// memorySnapshot состояние хранилища в памяти в виде для записи на диск, индексы не сохраняются и строятся при загрузке.
+type memorySnapshot struct {
+	URLs          []models.URL              `json:"urls"`
+	Users         []models.User             `json:"users"`
+	DeletedURLs   map[string]time.Time      `json:"deleted_urls"`
+	UserURLs      map[string]string         `json:"user_urls"`
+	Clicks        map[string][]models.Click `json:"clicks"`
+	APIKeys       []models.APIKey           `json:"api_keys"`
+	URLHistory    []models.URLHistory       `json:"url_history"`
+	LastIDForURL  uint                      `json:"last_id_for_url"`
+	LastIDForUser int                       `json:"last_id_for_user"`
+	LastIDForKey  int64                     `json:"last_id_for_key"`
+}
+
+// EnableSnapshots загружает сохранённый снимок и запускает периодическое сохранение до отмены ctx.
+// Последний снимок при остановке сервиса сохраняется вызовом Snapshot.
+func (s *MemoryStorage) EnableSnapshots(ctx context.Context, options MemorySnapshotOptions) error {
+	if options.Path == "" {
+		return nil
+	}
+	if err := s.LoadSnapshot(options.Path); err != nil {
+		return err
+	}
+	s.snapshotMx.Lock()
+	s.snapshotPath = options.Path
+	s.snapshotMx.Unlock()
+	if options.Interval > 0 {
+		go s.snapshots(ctx, options.Interval)
+	}
+	return nil
+}
+
+func (s *MemoryStorage) snapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.periodicSnapshot(ctx); err != nil {
+				logger.LogSugar.Errorf("Не удалось сохранить снимок хранилища: %s", err)
+			}
+		}
+	}
+}
+
+// periodicSnapshot сохраняет снимок, если сохранение ещё не остановлено.
+// Отмена проверяется под блокировкой, чтобы после отмены ctx запись гарантированно не началась.
+func (s *MemoryStorage) periodicSnapshot(ctx context.Context) error {
+	s.snapshotMx.Lock()
+	defer s.snapshotMx.Unlock()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return s.saveSnapshot(s.snapshotPath)
+}
+
+// Snapshot сохраняет снимок по пути из EnableSnapshots, без включённых снимков ничего не делает.
+func (s *MemoryStorage) Snapshot() error {
+	s.snapshotMx.Lock()
+	defer s.snapshotMx.Unlock()
+	if s.snapshotPath == "" {
+		return nil
+	}
+	return s.saveSnapshot(s.snapshotPath)
+}
+
+// SaveSnapshot сохраняет снимок хранилища в файл.
+func (s *MemoryStorage) SaveSnapshot(path string) error {
+	s.snapshotMx.Lock()
+	defer s.snapshotMx.Unlock()
+	return s.saveSnapshot(path)
+}
+
+// saveSnapshot снимок пишется во временный файл и заменяет прежний переименованием, вызывается под snapshotMx.
+func (s *MemoryStorage) saveSnapshot(path string) error {
+	raw, err := s.encodeSnapshot()
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(raw)
+	if err == nil {
+		err = file.Sync()
+	}
+	err = errors.Join(err, file.Close())
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		return errors.Join(err, os.Remove(tmpPath))
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// encodeSnapshot снимок состояния хранилища в JSON, карты сериализуются под блокировкой.
+func (s *MemoryStorage) encodeSnapshot() ([]byte, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	snapshot := memorySnapshot{
+		URLs:          make([]models.URL, 0, len(*s.db)),
+		Users:         make([]models.User, 0, len(s.users)),
+		DeletedURLs:   s.deletedURLs,
+		UserURLs:      s.userURLs,
+		Clicks:        s.clicks,
+		APIKeys:       make([]models.APIKey, 0, len(s.apiKeys)),
+		URLHistory:    s.urlHistory,
+		LastIDForURL:  s.lastIDForURL,
+		LastIDForUser: s.lastIDForUser,
+		LastIDForKey:  s.lastIDForKey,
+	}
+	for _, url := range *s.db {
+		snapshot.URLs = append(snapshot.URLs, url)
+	}
+	for _, user := range s.users {
+		snapshot.Users = append(snapshot.Users, user)
+	}
+	for _, key := range s.apiKeys {
+		snapshot.APIKeys = append(snapshot.APIKeys, key)
+	}
+	return json.Marshal(snapshot)
+}
+
+// LoadSnapshot заменяет состояние хранилища снимком из файла, отсутствие файла не ошибка.
+func (s *MemoryStorage) LoadSnapshot(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	snapshot := memorySnapshot{}
+	if err = json.Unmarshal(raw, &snapshot); err != nil {
+		return err
+	}
+
+	db := make(map[string]models.URL, len(snapshot.URLs))
+	for _, url := range snapshot.URLs {
+		db[url.ShortURL] = url
+	}
+	users := make(map[int]models.User, len(snapshot.Users))
+	for _, user := range snapshot.Users {
+		users[user.ID] = user
+	}
+	apiKeys := make(map[int64]models.APIKey, len(snapshot.APIKeys))
+	for _, key := range snapshot.APIKeys {
+		apiKeys[key.ID] = key
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.db = &db
+	s.users = users
+	s.apiKeys = apiKeys
+	s.deletedURLs = nonNilMap(snapshot.DeletedURLs)
+	s.userURLs = nonNilMap(snapshot.UserURLs)
+	s.clicks = nonNilMap(snapshot.Clicks)
+	s.urlHistory = snapshot.URLHistory
+	s.lastIDForURL = snapshot.LastIDForURL
+	s.lastIDForUser = snapshot.LastIDForUser
+	s.lastIDForKey = snapshot.LastIDForKey
+	s.rebuildIndexes()
+	logger.LogSugar.Infof("Хранилище восстановлено из снимка %s, ссылок: %d", path, len(db))
+	return nil
+}
+
+// nonNilMap пустая карта вместо отсутствующей в снимке.
+func nonNilMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return make(map[K]V)
+	}
+	return m
+}
@@ -1,16 +1,17 @@
 package storage
 
 import (
-	"errors"
-	"fmt"
+	"cmp"
+	"context"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 )
 
 // MemoryStorage структура хранилища в памяти.
+// Все карты защищены mx, индексы обновляются вместе с основными данными под той же блокировкой.
 type MemoryStorage struct {
 	// ссылки (ключ короткая ссылка, значение полная)
 	db    *map[string]models.URL
@@ -19,157 +20,557 @@ type MemoryStorage struct {
 	deletedURLs map[string]time.Time
 	// ссылки пользователя (ключ короткая ссылка, значение - uuid пользователя)
 	userURLs map[string]string
+	// переходы по ссылкам (ключ короткая ссылка)
+	clicks map[string][]models.Click
+	// ключи доступа (ключ id)
+	apiKeys map[int64]models.APIKey
+	// история смены URL ссылок
+	urlHistory []models.URLHistory
+	// короткая ссылка действующей ссылки (ключ URL)
+	activeURLs map[string]string
+	// короткая ссылка (ключ id ссылки)
+	urlIDs map[uint]string
+	// короткие ссылки пользователя (ключ uuid)
+	userShortURLs map[string]map[string]struct{}
+	// id ключа доступа (ключ хэш ключа)
+	apiKeyHashes map[string]int64
+	// id пользователя (ключ uuid)
+	userIDs map[string]int
+	// id зарегистрированного пользователя (ключ логин)
+	userLogins map[string]int
 	// Синхронизация конккуретного доступа
 	mx            sync.RWMutex
 	lastIDForURL  uint
 	lastIDForUser int
+	lastIDForKey  int64
+	// Путь к файлу снимка и синхронизация его записи
+	snapshotPath string
+	snapshotMx   sync.Mutex
 }
 
 // NewMemoryStorage конструктор хранилища.
 func NewMemoryStorage() *MemoryStorage {
 	databaseData := make(map[string]models.URL, 1000)
-	// Демо данные
-	databaseData["e98192e19505472476a49f10388428ab"] = models.URL{
-		ID:       1,
-		ShortURL: "e98192e19505472476a49f10388428ab",
-		URL:      "https://ya.ru",
-	}
 
 	instance := MemoryStorage{
-		db:          &databaseData,
-		users:       make(map[int]models.User, 100),
-		deletedURLs: make(map[string]time.Time, 100),
-		userURLs:    make(map[string]string, 100),
+		db:            &databaseData,
+		users:         make(map[int]models.User, 100),
+		deletedURLs:   make(map[string]time.Time, 100),
+		userURLs:      make(map[string]string, 100),
+		clicks:        make(map[string][]models.Click, 100),
+		apiKeys:       make(map[int64]models.APIKey, 10),
+		activeURLs:    make(map[string]string, 1000),
+		urlIDs:        make(map[uint]string, 1000),
+		userShortURLs: make(map[string]map[string]struct{}, 100),
+		apiKeyHashes:  make(map[string]int64, 10),
+		userIDs:       make(map[string]int, 100),
+		userLogins:    make(map[string]int, 100),
 	}
 
 	return &instance
 }
 
-// Add добавление нового значения.
-func (s *MemoryStorage) Add(url models.URL) (int64, error) {
+// Add добавление нового значения, занятые короткая ссылка или действующий URL дают ErrConflict.
+func (s *MemoryStorage) Add(ctx context.Context, url models.URL) (int64, error) {
 	s.mx.Lock()
 	defer s.mx.Unlock()
-	data := *s.db
-	if _, ok := data[url.ShortURL]; ok {
-		duplicateKeyError := pgconn.PgError{
-			Code: CodeErrorDuplicateKey,
-		}
-		err := errors.New("short url already exists")
-		return 0, errors.Join(err, &duplicateKeyError)
+	if _, ok := (*s.db)[url.ShortURL]; ok {
+		return 0, conflictError("short url already exists")
 	}
+	if _, ok := s.activeURLs[url.URL]; ok {
+		return 0, conflictError("url already exists")
+	}
+	return int64(s.insert(url).ID), nil
+}
+
+// insert сохраняет новую ссылку и заполняет индексы, вызывается под блокировкой после проверки конфликтов.
+func (s *MemoryStorage) insert(url models.URL) models.URL {
 	s.lastIDForURL++
 	url.ID = s.lastIDForURL
-	data[url.ShortURL] = url
-	return int64(url.ID), nil
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = time.Now()
+	}
+	(*s.db)[url.ShortURL] = url
+	s.urlIDs[url.ID] = url.ShortURL
+	s.activeURLs[url.URL] = url.ShortURL
+	return url
+}
+
+// link связывает ссылку с пользователем, прежний владелец теряет ссылку, вызывается под блокировкой.
+func (s *MemoryStorage) link(shortURL string, userUUID string) {
+	if owner, ok := s.userURLs[shortURL]; ok {
+		s.unlinkOwner(shortURL, owner)
+	}
+	s.userURLs[shortURL] = userUUID
+	if _, ok := s.userShortURLs[userUUID]; !ok {
+		s.userShortURLs[userUUID] = make(map[string]struct{})
+	}
+	s.userShortURLs[userUUID][shortURL] = struct{}{}
+}
+
+// unlinkOwner убирает ссылку из индекса ссылок пользователя.
+func (s *MemoryStorage) unlinkOwner(shortURL string, owner string) {
+	delete(s.userShortURLs[owner], shortURL)
+	if len(s.userShortURLs[owner]) == 0 {
+		delete(s.userShortURLs, owner)
+	}
+}
+
+// markDeleted помечает ссылку удалённой и освобождает её URL, вызывается под блокировкой.
+func (s *MemoryStorage) markDeleted(shortURL string, deletedAt time.Time) {
+	s.deletedURLs[shortURL] = deletedAt
+	if url, ok := (*s.db)[shortURL]; ok && s.activeURLs[url.URL] == shortURL {
+		delete(s.activeURLs, url.URL)
+	}
+}
+
+// rebuildIndexes заполняет индексы по основным данным, например после загрузки снимка.
+func (s *MemoryStorage) rebuildIndexes() {
+	s.activeURLs = make(map[string]string, len(*s.db))
+	s.urlIDs = make(map[uint]string, len(*s.db))
+	s.userShortURLs = make(map[string]map[string]struct{}, 100)
+	for shortURL, url := range *s.db {
+		s.urlIDs[url.ID] = shortURL
+		if _, deleted := s.deletedURLs[shortURL]; !deleted {
+			s.activeURLs[url.URL] = shortURL
+		}
+	}
+	for shortURL, userUUID := range s.userURLs {
+		if _, ok := s.userShortURLs[userUUID]; !ok {
+			s.userShortURLs[userUUID] = make(map[string]struct{})
+		}
+		s.userShortURLs[userUUID][shortURL] = struct{}{}
+	}
+	s.apiKeyHashes = make(map[string]int64, len(s.apiKeys))
+	for id, key := range s.apiKeys {
+		s.apiKeyHashes[key.KeyHash] = id
+	}
+	s.userIDs = make(map[string]int, len(s.users))
+	s.userLogins = make(map[string]int, len(s.users))
+	for id, user := range s.users {
+		s.indexUser(id, user)
+	}
+}
+
+// indexUser заполняет индексы пользователя по uuid и логину, вызывается под блокировкой.
+func (s *MemoryStorage) indexUser(id int, user models.User) {
+	s.userIDs[user.UUID] = id
+	if user.Login != "" {
+		s.userLogins[user.Login] = id
+	}
 }
 
-// CreateUser создает пользователя.
-func (s *MemoryStorage) CreateUser(user models.User) (int64, error) {
+// CreateUser создает пользователя, повторное создание с тем же uuid вернёт существующего.
+func (s *MemoryStorage) CreateUser(ctx context.Context, user models.User) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if id, ok := s.userIDs[user.UUID]; ok {
+		return int64(id), nil
+	}
 	s.lastIDForUser++
 	user.ID = s.lastIDForUser
 	s.users[user.ID] = user
+	s.indexUser(user.ID, user)
 	return int64(user.ID), nil
 
 }
 
-// SoftDeletedShortURL Отметка об удалении ссылки.
-func (s *MemoryStorage) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
+// SoftDeletedShortURL Отметка об удалении ссылки, чужие и уже удалённые ссылки пропускаются.
+func (s *MemoryStorage) SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := time.Now()
 	for _, value := range shortURL {
-		s.deletedURLs[value] = time.Now()
+		if owner, ok := s.userURLs[value]; !ok || owner != userUUID {
+			continue
+		}
+		if _, deleted := s.deletedURLs[value]; deleted {
+			continue
+		}
+		s.markDeleted(value, now)
 	}
 	return nil
 }
 
+// RestoreShortURLs Снятие отметки об удалении со ссылок пользователя.
+func (s *MemoryStorage) RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := time.Now()
+	restored := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		deletedTime, deleted := s.deletedURLs[shortURL]
+		if !deleted || deletedTime.Before(deletedSince) || s.userURLs[shortURL] != userUUID {
+			continue
+		}
+		url, ok := (*s.db)[shortURL]
+		if !ok || (!url.ExpiresAt.IsZero() && !now.Before(url.ExpiresAt)) {
+			continue
+		}
+		if _, taken := s.activeURLs[url.URL]; taken {
+			continue
+		}
+		delete(s.deletedURLs, shortURL)
+		s.activeURLs[url.URL] = shortURL
+		restored = append(restored, shortURL)
+	}
+	return restored, nil
+}
+
 // LikeURLToUser Связывание URL с пользователем.
-func (s *MemoryStorage) LikeURLToUser(urlID int64, userUUID string) error {
-	for shortURL, value := range *s.db {
-		if int64(value.ID) == urlID {
-			s.userURLs[shortURL] = userUUID
+func (s *MemoryStorage) LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if shortURL, ok := s.urlIDs[uint(urlID)]; ok {
+		s.link(shortURL, userUUID)
+	}
+	return nil
+}
+
+// MultiAdd Вставка массива, уже сокращённые ссылки пропускаются.
+// Занятая короткая ссылка даёт ErrConflict, и тогда не вставляется ни одна ссылка пачки.
+func (s *MemoryStorage) MultiAdd(ctx context.Context, urls []models.URL) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	insert := make([]models.URL, 0, len(urls))
+	added := make(map[string]bool, len(urls)*2)
+	for _, url := range urls {
+		if _, ok := s.activeURLs[url.URL]; ok || added[url.URL] {
+			continue
 		}
+		if _, ok := (*s.db)[url.ShortURL]; ok || added[url.ShortURL] {
+			return conflictError("short url already exists")
+		}
+		added[url.URL] = true
+		added[url.ShortURL] = true
+		insert = append(insert, url)
+	}
+	for _, url := range insert {
+		s.insert(url)
 	}
 	return nil
 }
 
-// MultiAdd Вставка массива.
-func (s *MemoryStorage) MultiAdd(urls []models.URL) error {
+// ImportURLs вставка пачки импортируемых ссылок, ссылки с занятым URL или кодом пропускаются.
+// Вставленные ссылки связываются с пользователем и возвращаются.
+func (s *MemoryStorage) ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	imported := make([]models.URL, 0, len(urls))
 	for _, url := range urls {
-		s.removeItemByURL(url.URL)
-		_, _ = s.Add(url)
+		if _, ok := (*s.db)[url.ShortURL]; ok {
+			continue
+		}
+		if _, ok := s.activeURLs[url.URL]; ok {
+			continue
+		}
+		url = s.insert(url)
+		s.link(url.ShortURL, userUUID)
+		imported = append(imported, url)
+	}
+	return imported, nil
+}
+
+// userURLList ссылки пользователя с заполненным временем удаления, вызывается под блокировкой.
+func (s *MemoryStorage) userURLList(userUUID string) []models.URL {
+	urls := make([]models.URL, 0, len(s.userShortURLs[userUUID]))
+	for shortURL := range s.userShortURLs[userUUID] {
+		url, ok := (*s.db)[shortURL]
+		if !ok {
+			continue
+		}
+		if deletedTime, deleted := s.deletedURLs[shortURL]; deleted {
+			url.DeletedAt = deletedTime
+		}
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// FindUserURLs страница ссылок пользователя, отбор и сортировка выполняются перебором ссылок пользователя.
+func (s *MemoryStorage) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
+	s.mx.RLock()
+	urls := s.userURLList(userUUID)
+	s.mx.RUnlock()
+	return paginateURLs(urls, filter)
+}
+
+// ExportUserURLs выгрузка ссылок пользователя вместе с удалёнными и количеством переходов.
+// Ссылки копируются под блокировкой и передаются в fn после её снятия, чтобы медленный получатель не задерживал запись.
+func (s *MemoryStorage) ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error {
+	s.mx.RLock()
+	urls := make([]models.ExportURL, 0, len(s.userShortURLs[userUUID]))
+	for _, url := range s.userURLList(userUUID) {
+		urls = append(urls, models.ExportURL{URL: url, Clicks: int64(len(s.clicks[url.ShortURL]))})
+	}
+	s.mx.RUnlock()
+
+	slices.SortFunc(urls, func(a, b models.ExportURL) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	for _, url := range urls {
+		if err := fn(url); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// UpdateUserURL смена URL ссылки её владельцем с записью прежнего URL в историю.
+func (s *MemoryStorage) UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	modelURL, ok := (*s.db)[shortURL]
+	if !ok || s.userURLs[shortURL] != userUUID {
+		return nil, notFoundError("user short url " + shortURL)
+	}
+	if deletedTime, deleted := s.deletedURLs[shortURL]; deleted {
+		modelURL.DeletedAt = deletedTime
+		return &modelURL, ErrGone
+	}
+	if modelURL.URL == url {
+		return &modelURL, nil
+	}
+	if _, ok := s.activeURLs[url]; ok {
+		return nil, conflictError("url already exists")
+	}
+	s.urlHistory = append(s.urlHistory, models.URLHistory{
+		ID:          int64(len(s.urlHistory) + 1),
+		URLID:       modelURL.ID,
+		UserUUID:    userUUID,
+		PreviousURL: modelURL.URL,
+		URL:         url,
+		ChangedAt:   time.Now(),
+	})
+	if s.activeURLs[modelURL.URL] == shortURL {
+		delete(s.activeURLs, modelURL.URL)
+	}
+	modelURL.URL = url
+	(*s.db)[shortURL] = modelURL
+	s.activeURLs[url] = shortURL
+	return &modelURL, nil
+}
+
 // FindByShortURL поиск по короткой ссылке.
-func (s *MemoryStorage) FindByShortURL(shortURL string) (*models.URL, error) {
+func (s *MemoryStorage) FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error) {
 	s.mx.RLock()
 	defer s.mx.RUnlock()
 	data := *s.db
 	if url, ok := data[shortURL]; ok {
 		if deletedTime, ok2 := s.deletedURLs[shortURL]; ok2 {
 			url.DeletedAt = deletedTime
+			return &url, ErrGone
 		}
 		return &url, nil
 	}
 
-	return nil, fmt.Errorf("the short link was not found")
+	return nil, notFoundError("short url " + shortURL)
 }
 
-// FindByURL поиск по URL.
-func (s *MemoryStorage) FindByURL(url string) (*models.URL, error) {
-	var urlModel models.URL
+// FindByURL поиск действующей ссылки по URL.
+func (s *MemoryStorage) FindByURL(ctx context.Context, url string) (*models.URL, error) {
 	s.mx.RLock()
 	defer s.mx.RUnlock()
-	for _, modelURL := range *s.db {
-		if modelURL.URL == url {
-			return &modelURL, nil
-		}
+	shortURL, ok := s.activeURLs[url]
+	if !ok {
+		return nil, notFoundError("url " + url)
 	}
-	return &urlModel, fmt.Errorf("the url link was not found")
+	modelURL := (*s.db)[shortURL]
+	return &modelURL, nil
 }
 
-func (s *MemoryStorage) removeItemByURL(url string) {
-	for shortURL, modelURL := range *s.db {
-		if modelURL.URL == url {
-			delete(*s.db, shortURL)
+// Ping проверка доступности.
+func (s *MemoryStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+// FindUserByLoginAndPasswordHash Поиск пользователя.
+func (s *MemoryStorage) FindUserByLoginAndPasswordHash(ctx context.Context, login string, password string) (*models.User, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	if id, ok := s.userLogins[login]; ok {
+		if user := s.users[id]; user.Password == password {
+			return &user, nil
 		}
 	}
+	return nil, notFoundError("user")
 }
 
-// Ping проверка доступности.
-func (s *MemoryStorage) Ping() error {
+// FindUserByUUID Поиск пользователя по uuid.
+func (s *MemoryStorage) FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	if id, ok := s.userIDs[userUUID]; ok {
+		user := s.users[id]
+		return &user, nil
+	}
+	return nil, notFoundError("user " + userUUID)
+}
+
+// RegisterUser заполняет логин и пароль анонимного пользователя, ссылки остаются за ним.
+func (s *MemoryStorage) RegisterUser(ctx context.Context, user models.User) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if id, ok := s.userLogins[user.Login]; ok && s.users[id].UUID != user.UUID {
+		return conflictError("login already exists")
+	}
+	id, ok := s.userIDs[user.UUID]
+	if !ok || s.users[id].Login != "" {
+		return notFoundError("anonymous user " + user.UUID)
+	}
+	anonymous := s.users[id]
+	anonymous.Name = user.Name
+	anonymous.Login = user.Login
+	anonymous.Password = user.Password
+	s.users[id] = anonymous
+	s.indexUser(id, anonymous)
 	return nil
 }
 
-// FindUserByLoginAndPasswordHash Поиск пользователя.
-func (s *MemoryStorage) FindUserByLoginAndPasswordHash(login string, password string) (*models.User, error) {
-	for _, value := range s.users {
-		if value.Login == login && value.Password == password {
-			return &value, nil
+// FindUrlsByUserID поиск URL-s, ссылки упорядочены по id, у удалённых заполнено время удаления.
+func (s *MemoryStorage) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	urls := s.userURLList(userUUID)
+	slices.SortFunc(urls, func(a, b models.URL) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return &urls, nil
+}
+
+// GetCountShortURL кол-во сокращенных URL
+func (s *MemoryStorage) GetCountShortURL(ctx context.Context) (int64, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return int64(len(*s.db) - len(s.deletedURLs)), nil
+}
+
+// GetCountUser кол-во пользвателей
+func (s *MemoryStorage) GetCountUser(ctx context.Context) (int64, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return int64(len(s.users)), nil
+}
+
+// SoftDeleteExpiredURLs пометка ссылок с истёкшим сроком жизни как удалённых.
+func (s *MemoryStorage) SoftDeleteExpiredURLs(ctx context.Context) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	now := time.Now()
+	var cnt int64
+	for shortURL, url := range *s.db {
+		if url.ExpiresAt.IsZero() || now.Before(url.ExpiresAt) {
+			continue
+		}
+		if _, ok := s.deletedURLs[shortURL]; ok {
+			continue
 		}
+		s.markDeleted(shortURL, now)
+		cnt++
 	}
-	return nil, nil
+	return cnt, nil
 }
 
-// FindUrlsByUserID поиск URL-s.
-func (s *MemoryStorage) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	urls := make([]models.URL, 0, 100)
-	for shortURL, uuid := range s.userURLs {
-		if uuid != userUUID {
-			continue
+// PurgeDeletedURLs окончательное удаление ссылок, удалённых раньше deletedBefore, начиная с самых старых.
+func (s *MemoryStorage) PurgeDeletedURLs(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	purge := make([]string, 0, limit)
+	for shortURL, deletedAt := range s.deletedURLs {
+		if deletedAt.Before(deletedBefore) {
+			purge = append(purge, shortURL)
 		}
+	}
+	slices.SortFunc(purge, func(a, b string) int {
+		return s.deletedURLs[a].Compare(s.deletedURLs[b])
+	})
+	if len(purge) > limit {
+		purge = purge[:limit]
+	}
+	var cnt int64
+	for _, shortURL := range purge {
 		if url, ok := (*s.db)[shortURL]; ok {
-			urls = append(urls, url)
+			s.urlHistory = slices.DeleteFunc(s.urlHistory, func(history models.URLHistory) bool {
+				return history.URLID == url.ID
+			})
+			delete(*s.db, shortURL)
+			delete(s.urlIDs, url.ID)
+			cnt++
+		}
+		if owner, ok := s.userURLs[shortURL]; ok {
+			s.unlinkOwner(shortURL, owner)
 		}
+		delete(s.deletedURLs, shortURL)
+		delete(s.userURLs, shortURL)
+		delete(s.clicks, shortURL)
 	}
-	return &urls, nil
+	return cnt, nil
 }
 
-// GetCountShortURL кол-во сокращенных URL
-func (s *MemoryStorage) GetCountShortURL() (int64, error) {
-	return int64(len(*(s.db))), nil
+// AddClick сохраняет переход по короткой ссылке.
+func (s *MemoryStorage) AddClick(ctx context.Context, click models.Click) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.clicks[click.ShortURL] = append(s.clicks[click.ShortURL], click)
+	return nil
 }
 
-// GetCountUser кол-во пользвателей
-func (s *MemoryStorage) GetCountUser() (int64, error) {
-	return int64(len(s.users)), nil
+// AddClicks сохраняет пачку переходов по коротким ссылкам.
+func (s *MemoryStorage) AddClicks(ctx context.Context, clicks []models.Click) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for _, click := range clicks {
+		s.clicks[click.ShortURL] = append(s.clicks[click.ShortURL], click)
+	}
+	return nil
+}
+
+// GetClickStats статистика переходов по короткой ссылке.
+func (s *MemoryStorage) GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return newClickStats(s.clicks[shortURL]), nil
+}
+
+// AddAPIKey сохраняет ключ доступа.
+func (s *MemoryStorage) AddAPIKey(ctx context.Context, key models.APIKey) (int64, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if _, ok := s.apiKeyHashes[key.KeyHash]; ok {
+		return 0, conflictError("api key already exists")
+	}
+	s.lastIDForKey++
+	key.ID = s.lastIDForKey
+	s.apiKeys[key.ID] = key
+	s.apiKeyHashes[key.KeyHash] = key.ID
+	return key.ID, nil
+}
+
+// FindAPIKeyByHash поиск действующего ключа доступа по хэшу.
+func (s *MemoryStorage) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	id, ok := s.apiKeyHashes[keyHash]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	key := s.apiKeys[id]
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey отзыв ключа доступа.
+func (s *MemoryStorage) RevokeAPIKey(ctx context.Context, id int64) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	key, ok := s.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+	revokedAt := time.Now().UTC()
+	key.RevokedAt = &revokedAt
+	s.apiKeys[id] = key
+	return nil
 }
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -10,6 +13,7 @@ import (
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMemoryStorage_StorageMethods(t *testing.T) {
@@ -35,15 +39,15 @@ func TestMemoryStorage_StorageMethods(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := storage.Add(tt.testData)
+			_, err := storage.Add(context.Background(), tt.testData)
 			if err != nil {
 				t.Errorf("Add() error = %#v", err)
 			}
-			url, _ := storage.FindByURL(tt.want.URL)
+			url, _ := storage.FindByURL(context.Background(), tt.want.URL)
 			if url.ShortURL != tt.want.ShortURL {
 				t.Errorf("Add() ShortURL = %v, want %v", url.ShortURL, tt.want.ShortURL)
 			}
-			url, _ = storage.FindByShortURL(tt.want.ShortURL)
+			url, _ = storage.FindByShortURL(context.Background(), tt.want.ShortURL)
 			if url.URL != tt.want.URL {
 				t.Errorf("Add() ShortURL = %v, want %v", url.URL, tt.want.URL)
 			}
@@ -51,6 +55,40 @@ func TestMemoryStorage_StorageMethods(t *testing.T) {
 	}
 }
 
+func TestMemoryStorage_Errors(t *testing.T) {
+	storage := NewMemoryStorage()
+	urlID, err := storage.Add(context.Background(), models.URL{ShortURL: "1111", URL: "https://ya.ru/1"})
+	assert.NoError(t, err)
+	assert.NoError(t, storage.LikeURLToUser(context.Background(), urlID, "111-222-333"))
+
+	_, err = storage.Add(context.Background(), models.URL{ShortURL: "1111", URL: "https://ya.ru/2"})
+	assert.ErrorIs(t, err, ErrConflict)
+	// Действующий URL повторно не сохраняется, как и в Postgres
+	_, err = storage.Add(context.Background(), models.URL{ShortURL: "3333", URL: "https://ya.ru/1"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	url, err := storage.FindByShortURL(context.Background(), "2222")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, url)
+	url, err = storage.FindByURL(context.Background(), "https://ya.ru/2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, url)
+
+	// Чужая ссылка не удаляется
+	assert.NoError(t, storage.SoftDeletedShortURL(context.Background(), "444-555-666", "1111"))
+	_, err = storage.FindByShortURL(context.Background(), "1111")
+	assert.NoError(t, err)
+
+	assert.NoError(t, storage.SoftDeletedShortURL(context.Background(), "111-222-333", "1111"))
+	url, err = storage.FindByShortURL(context.Background(), "1111")
+	assert.ErrorIs(t, err, ErrGone)
+	assert.Equal(t, "https://ya.ru/1", url.URL)
+	assert.False(t, url.DeletedAt.IsZero())
+	// Удалённая ссылка не считается действующей
+	_, err = storage.FindByURL(context.Background(), "https://ya.ru/1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
 // TestMemoryStorage_concurrentAdd Не должен упасть с fatal error: concurrent map writes
 func TestMemoryStorage_concurrentAdd(t *testing.T) {
 	_ = logger.InitLogger("fatal")
@@ -58,12 +96,12 @@ func TestMemoryStorage_concurrentAdd(t *testing.T) {
 
 	for i := 0; i < 200; i++ {
 		go func() {
-			storage.Add(models.URL{ShortURL: fmt.Sprintf("text%d", i), URL: "https://ya.ru"})
+			storage.Add(context.Background(), models.URL{ShortURL: fmt.Sprintf("text%d", i), URL: fmt.Sprintf("https://ya.ru/%d", i)})
 		}()
 	}
 
 	time.Sleep(time.Millisecond * 100)
-	storage.Add(models.URL{ShortURL: "endKey", URL: "https://ya.ru"})
+	storage.Add(context.Background(), models.URL{ShortURL: "endKey", URL: "https://ya.ru"})
 	if _, ok := (*storage.db)["endKey"]; !ok {
 		t.Errorf("expected 'endKey' to be in the map")
 	}
@@ -77,7 +115,7 @@ func TestMemoryStorage_CreateUser(t *testing.T) {
 		Password: "Login",
 		UUID:     uuid.NewString(),
 	}
-	id, _ := storage.CreateUser(user)
+	id, _ := storage.CreateUser(context.Background(), user)
 	if id != int64(user.ID) {
 		t.Errorf("CreateUser() id = %v, want %v", id, user.ID)
 	}
@@ -92,8 +130,8 @@ func TestMemoryStorage_FindUserByLoginAndPasswordHash(t *testing.T) {
 		Password: "Password",
 		UUID:     uuid.NewString(),
 	}
-	_, _ = storage.CreateUser(newUser)
-	user, _ := storage.FindUserByLoginAndPasswordHash("Login", "Password")
+	_, _ = storage.CreateUser(context.Background(), newUser)
+	user, _ := storage.FindUserByLoginAndPasswordHash(context.Background(), "Login", "Password")
 	if user.Login != "Login" {
 		t.Errorf("FindUserByLoginAndPasswordHash() Login = %v, want %v", user.Login, "Login")
 	}
@@ -103,19 +141,19 @@ func TestMemoryStorage__FindUrlsByUserID(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	storage := NewMemoryStorage()
 	userUUID := "1111-2222-33333-44444"
-	_, _ = storage.CreateUser(models.User{
+	_, _ = storage.CreateUser(context.Background(), models.User{
 		Name:     "name",
 		Login:    "Login",
 		Password: "Password",
 		UUID:     userUUID,
 	})
-	urlID, _ := storage.Add(models.URL{
+	urlID, _ := storage.Add(context.Background(), models.URL{
 		ShortURL: "qqwww",
 		URL:      "https://google.com",
 	})
-	_ = storage.LikeURLToUser(urlID, userUUID)
+	_ = storage.LikeURLToUser(context.Background(), urlID, userUUID)
 
-	userURLs, _ := storage.FindUrlsByUserID(userUUID)
+	userURLs, _ := storage.FindUrlsByUserID(context.Background(), userUUID)
 	if len(*userURLs) == 0 {
 		t.Errorf("FindUrlsByUserID() userURLs = %v, want %v", 0, 1)
 	}
@@ -136,20 +174,20 @@ func BenchmarkMemoryStorage(b *testing.B) {
 	b.Run("поиск_ссылок_созданных_пользователем", func(b *testing.B) {
 		storage := NewMemoryStorage()
 		userUUID := "1111-2222-33333-44444"
-		_, _ = storage.CreateUser(models.User{
+		_, _ = storage.CreateUser(context.Background(), models.User{
 			Name:     "name",
 			Login:    "Login",
 			Password: "Password",
 			UUID:     userUUID,
 		})
-		urlID, _ := storage.Add(models.URL{
+		urlID, _ := storage.Add(context.Background(), models.URL{
 			ShortURL: "qqwww",
 			URL:      "https://google.com",
 		})
-		_ = storage.LikeURLToUser(urlID, userUUID)
+		_ = storage.LikeURLToUser(context.Background(), urlID, userUUID)
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			userURLs, _ := storage.FindUrlsByUserID(userUUID)
+			userURLs, _ := storage.FindUrlsByUserID(context.Background(), userUUID)
 			if len(*userURLs) == 0 {
 				b.Errorf("URL-s не найдены")
 			}
@@ -159,7 +197,7 @@ func BenchmarkMemoryStorage(b *testing.B) {
 	b.Run("поиск_пользователя_по_логину_и_паролю", func(b *testing.B) {
 		storage := NewMemoryStorage()
 		userUUID := "1111-2222-33333-44444"
-		_, _ = storage.CreateUser(models.User{
+		_, _ = storage.CreateUser(context.Background(), models.User{
 			Name:     "name",
 			Login:    "Login",
 			Password: "Password",
@@ -167,7 +205,7 @@ func BenchmarkMemoryStorage(b *testing.B) {
 		})
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			user, _ := storage.FindUserByLoginAndPasswordHash("Login", "Password")
+			user, _ := storage.FindUserByLoginAndPasswordHash(context.Background(), "Login", "Password")
 			if user.Login != "Login" {
 				b.Errorf("Логин не совпадает")
 			}
@@ -179,7 +217,7 @@ func BenchmarkMemoryStorage(b *testing.B) {
 		b.ResetTimer()
 		testID := int64(0)
 		for i := 0; i < b.N; i++ {
-			userID, _ := storage.CreateUser(models.User{
+			userID, _ := storage.CreateUser(context.Background(), models.User{
 				Name:     "name",
 				Login:    "Login",
 				Password: "Password",
@@ -194,30 +232,30 @@ func BenchmarkMemoryStorage(b *testing.B) {
 
 	b.Run("поиск_по_url", func(b *testing.B) {
 		storage := NewMemoryStorage()
-		storage.Add(models.URL{
+		storage.Add(context.Background(), models.URL{
 			ShortURL: "111fghfhfgh1",
 			URL:      "https://google.com",
 		})
 
 		for i := 1; i < 100000; i++ {
-			storage.Add(models.URL{
+			storage.Add(context.Background(), models.URL{
 				ShortURL: "asdfsfadf",
 				URL:      "https://habr.ru/news_" + string(rune(i)),
 			})
 		}
 
-		storage.Add(models.URL{
+		storage.Add(context.Background(), models.URL{
 			ShortURL: "2222vbxcbcvbc2",
 			URL:      "https://ya.ru",
 		})
 		var url *models.URL
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			url, _ = storage.FindByURL("https://google.com")
+			url, _ = storage.FindByURL(context.Background(), "https://google.com")
 			if url == nil {
 				b.Errorf("URL не найден")
 			}
-			url, _ = storage.FindByURL("https://ya.ru")
+			url, _ = storage.FindByURL(context.Background(), "https://ya.ru")
 			if url == nil {
 				b.Errorf("URL не найден")
 			}
@@ -237,19 +275,138 @@ func BenchmarkMemoryStorage(b *testing.B) {
 
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			storage.MultiAdd(urls)
+			storage.MultiAdd(context.Background(), urls)
 		}
 	})
 }
 
 func TestMemoryStorage_GetCountShortURL(t *testing.T) {
 	storage := NewMemoryStorage()
-	cnt, _ := storage.GetCountShortURL()
+	cnt, _ := storage.GetCountShortURL(context.Background())
+	assert.Equal(t, int64(0), cnt)
+	storage.Add(context.Background(), models.URL{ShortURL: "123", URL: "https://ya.ru"})
+	storage.Add(context.Background(), models.URL{ShortURL: "321", URL: "https://ya1.ru"})
+	cnt, _ = storage.GetCountShortURL(context.Background())
+	assert.Equal(t, int64(2), cnt)
+}
+
+func TestMemoryStorage_SoftDeleteExpiredURLs(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.Add(context.Background(), models.URL{ShortURL: "expired", URL: "https://ya.ru/expired", ExpiresAt: time.Now().Add(-time.Minute)})
+	storage.Add(context.Background(), models.URL{ShortURL: "active", URL: "https://ya.ru/active", ExpiresAt: time.Now().Add(time.Hour)})
+
+	cnt, err := storage.SoftDeleteExpiredURLs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	url, _ := storage.FindByShortURL(context.Background(), "expired")
+	assert.False(t, url.DeletedAt.IsZero())
+	url, _ = storage.FindByShortURL(context.Background(), "active")
+	assert.True(t, url.DeletedAt.IsZero())
+
+	// Удалённые ссылки не учитываются в статистике
+	total, _ := storage.GetCountShortURL(context.Background())
+	assert.Equal(t, int64(1), total)
+
+	cnt, _ = storage.SoftDeleteExpiredURLs(context.Background())
+	assert.Equal(t, int64(0), cnt)
+}
+
+func TestMemoryStorage_PurgeDeletedURLs(t *testing.T) {
+	storage := NewMemoryStorage()
+	userUUID := "111-222-333"
+	_, err := storage.ImportURLs(context.Background(), userUUID, []models.URL{
+		{ShortURL: "old1", URL: "https://ozon.ru/1"},
+		{ShortURL: "old2", URL: "https://ozon.ru/2"},
+		{ShortURL: "old3", URL: "https://ozon.ru/3"},
+		{ShortURL: "fresh", URL: "https://avito.ru"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.SoftDeletedShortURL(context.Background(), userUUID, "old1", "old2", "old3", "fresh"))
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "old1"})
+	now := time.Now()
+	storage.deletedURLs["old1"] = now.Add(-72 * time.Hour)
+	storage.deletedURLs["old2"] = now.Add(-71 * time.Hour)
+	storage.deletedURLs["old3"] = now.Add(-70 * time.Hour)
+
+	cnt, err := storage.PurgeDeletedURLs(context.Background(), now.Add(-time.Hour), 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cnt)
+	// Первыми очищаются самые давно удалённые ссылки
+	_, err = storage.FindByShortURL(context.Background(), "old1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.FindByShortURL(context.Background(), "old2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.FindByShortURL(context.Background(), "old3")
+	assert.ErrorIs(t, err, ErrGone)
+	_, ok := storage.clicks["old1"]
+	assert.False(t, ok)
+
+	cnt, err = storage.PurgeDeletedURLs(context.Background(), now.Add(-time.Hour), 2)
+	require.NoError(t, err)
 	assert.Equal(t, int64(1), cnt)
-	storage.Add(models.URL{ShortURL: "123", URL: "https://ya.ru"})
-	storage.Add(models.URL{ShortURL: "321", URL: "https://ya1.ru"})
-	cnt, _ = storage.GetCountShortURL()
-	assert.Equal(t, int64(3), cnt)
+	cnt, _ = storage.PurgeDeletedURLs(context.Background(), now.Add(-time.Hour), 2)
+	assert.Equal(t, int64(0), cnt)
+
+	urls, _ := storage.FindUrlsByUserID(context.Background(), userUUID)
+	assert.Len(t, *urls, 1)
+}
+
+func TestMemoryStorage_ClickStats(t *testing.T) {
+	storage := NewMemoryStorage()
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)})
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)})
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 18, 1, 0, 0, 0, time.UTC)})
+	_ = storage.AddClick(context.Background(), models.Click{ShortURL: "bbb", CreatedAt: time.Date(2026, 10, 18, 1, 0, 0, 0, time.UTC)})
+
+	stats, err := storage.GetClickStats(context.Background(), "aaa")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Total)
+	assert.Equal(t, []models.ClickDay{
+		{Date: "2026-10-17", Clicks: 1},
+		{Date: "2026-10-18", Clicks: 2},
+	}, stats.Days)
+
+	err = storage.AddClicks(context.Background(), []models.Click{
+		{ShortURL: "ccc", CreatedAt: time.Date(2026, 10, 18, 1, 0, 0, 0, time.UTC)},
+		{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC)},
+	})
+	assert.NoError(t, err)
+	stats, _ = storage.GetClickStats(context.Background(), "aaa")
+	assert.Equal(t, int64(4), stats.Total)
+
+	stats, err = storage.GetClickStats(context.Background(), "ddd")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Total)
+	assert.Empty(t, stats.Days)
+}
+
+func TestMemoryStorage_RegisterUser(t *testing.T) {
+	storage := NewMemoryStorage()
+	_, _ = storage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	_, _ = storage.CreateUser(context.Background(), models.User{UUID: "uuid-1"})
+	_, _ = storage.CreateUser(context.Background(), models.User{UUID: "uuid-2"})
+
+	cnt, _ := storage.GetCountUser(context.Background())
+	assert.Equal(t, int64(2), cnt)
+
+	err := storage.RegisterUser(context.Background(), models.User{UUID: "uuid-1", Login: "cat", Password: "hash"})
+	assert.NoError(t, err)
+	user, _ := storage.FindUserByUUID(context.Background(), "uuid-1")
+	assert.Equal(t, "cat", user.Login)
+	user, _ = storage.FindUserByLoginAndPasswordHash(context.Background(), "cat", "hash")
+	assert.Equal(t, "uuid-1", user.UUID)
+
+	// Логин занят
+	err = storage.RegisterUser(context.Background(), models.User{UUID: "uuid-2", Login: "cat", Password: "hash"})
+	assert.Error(t, err)
+	// Пользователь уже зарегистрирован
+	err = storage.RegisterUser(context.Background(), models.User{UUID: "uuid-1", Login: "dog", Password: "hash"})
+	assert.Error(t, err)
+	// Пользователь не найден
+	user, err = storage.FindUserByUUID(context.Background(), "uuid-3")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, user)
 }
 
 func TestMemoryStorage_GetCountUser(t *testing.T) {
@@ -261,7 +418,352 @@ func TestMemoryStorage_GetCountUser(t *testing.T) {
 		Password: "Login",
 		UUID:     uuid.NewString(),
 	}
-	_, _ = storage.CreateUser(user)
-	cnt, _ := storage.GetCountUser()
+	_, _ = storage.CreateUser(context.Background(), user)
+	cnt, _ := storage.GetCountUser(context.Background())
 	assert.Equal(t, int64(1), cnt)
 }
+
+func TestMemoryStorage_APIKeys(t *testing.T) {
+	storage := NewMemoryStorage()
+	id, err := storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-1", KeyHash: "hash-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	// Хэш ключа уникален
+	_, err = storage.AddAPIKey(context.Background(), models.APIKey{UserUUID: "uuid-2", KeyHash: "hash-1"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	key, err := storage.FindAPIKeyByHash(context.Background(), "hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-1", key.UserUUID)
+
+	assert.NoError(t, storage.RevokeAPIKey(context.Background(), id))
+	assert.ErrorIs(t, storage.RevokeAPIKey(context.Background(), id), ErrAPIKeyNotFound)
+	key, err = storage.FindAPIKeyByHash(context.Background(), "hash-1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Nil(t, key)
+}
+
+func TestMemoryStorage_ImportURLs(t *testing.T) {
+	storage := NewMemoryStorage()
+	storage.Add(context.Background(), models.URL{ShortURL: "taken", URL: "https://ya.ru/taken"})
+
+	imported, err := storage.ImportURLs(context.Background(), "111-222-333", []models.URL{
+		{ShortURL: "new1", URL: "https://ya.ru/new1"},
+		{ShortURL: "taken", URL: "https://ya.ru/new2"},
+		{ShortURL: "new3", URL: "https://ya.ru/taken"},
+		{ShortURL: "new4", URL: "https://ya.ru/new1"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, imported, 1)
+	assert.Equal(t, "new1", imported[0].ShortURL)
+
+	userURLs, err := storage.FindUrlsByUserID(context.Background(), "111-222-333")
+	assert.NoError(t, err)
+	assert.Len(t, *userURLs, 1)
+}
+
+func TestMemoryStorage_FindUserURLs(t *testing.T) {
+	storage := NewMemoryStorage()
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	_, err := storage.ImportURLs(context.Background(), "111-222-333", []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1", CreatedAt: day},
+		{ShortURL: "short2", URL: "https://Avito.ru/2", CreatedAt: day},
+		{ShortURL: "short3", URL: "https://ozon.ru/3", CreatedAt: day.Add(time.Hour)},
+		{ShortURL: "short4", URL: "https://user@ozon.ru:8080/4", CreatedAt: day.Add(2 * time.Hour)},
+	})
+	require.NoError(t, err)
+	_ = storage.SoftDeletedShortURL(context.Background(), "111-222-333", "short3")
+
+	shortURLs := func(filter models.URLFilter) []string {
+		var result []string
+		for {
+			page, err := storage.FindUserURLs(context.Background(), "111-222-333", filter)
+			require.NoError(t, err)
+			for _, url := range page.URLs {
+				result = append(result, url.ShortURL)
+			}
+			if page.NextCursor == "" {
+				return result
+			}
+			filter.Cursor = page.NextCursor
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter models.URLFilter
+		want   []string
+	}{
+		{name: "#1_по_возрастанию_без_удалённых", filter: models.URLFilter{Limit: 1}, want: []string{"short1", "short2", "short4"}},
+		{name: "#2_по_убыванию_с_удалёнными", filter: models.URLFilter{Limit: 3, Sort: models.SortCreatedDesc, IncludeDeleted: true}, want: []string{"short4", "short3", "short2", "short1"}},
+		{name: "#3_по_домену", filter: models.URLFilter{Limit: 1, Host: "OZON.RU", IncludeDeleted: true}, want: []string{"short1", "short3", "short4"}},
+		{name: "#4_по_подстроке", filter: models.URLFilter{Search: "AVITO"}, want: []string{"short2"}},
+		{name: "#5_по_дате_создания", filter: models.URLFilter{CreatedFrom: day.Add(time.Hour), CreatedTo: day.Add(2 * time.Hour), IncludeDeleted: true}, want: []string{"short3"}},
+		{name: "#6_другой_пользователь", filter: models.URLFilter{}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.want == nil {
+				page, err := storage.FindUserURLs(context.Background(), "444-555-666", tt.filter)
+				require.NoError(t, err)
+				assert.Empty(t, page.URLs)
+				return
+			}
+			assert.Equal(t, tt.want, shortURLs(tt.filter))
+		})
+	}
+
+	_, err = storage.FindUserURLs(context.Background(), "111-222-333", models.URLFilter{Cursor: "bm90LWEtY3Vyc29y"})
+	assert.ErrorIs(t, err, ErrFilterInvalid)
+	_, err = storage.FindUserURLs(context.Background(), "111-222-333", models.URLFilter{Sort: "random"})
+	assert.ErrorIs(t, err, ErrFilterInvalid)
+}
+
+func TestMemoryStorage_UpdateUserURL(t *testing.T) {
+	storage := NewMemoryStorage()
+	_, err := storage.ImportURLs(context.Background(), "111-222-333", []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1"},
+		{ShortURL: "short2", URL: "https://ozon.ru/2"},
+		{ShortURL: "short3", URL: "https://ozon.ru/3"},
+	})
+	require.NoError(t, err)
+	_ = storage.SoftDeletedShortURL(context.Background(), "111-222-333", "short3")
+
+	url, err := storage.UpdateUserURL(context.Background(), "111-222-333", "short1", "https://avito.ru/1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://avito.ru/1", url.URL)
+	_, err = storage.FindByURL(context.Background(), "https://ozon.ru/1")
+	assert.ErrorIs(t, err, ErrNotFound)
+	require.Len(t, storage.urlHistory, 1)
+	assert.Equal(t, "https://ozon.ru/1", storage.urlHistory[0].PreviousURL)
+	assert.Equal(t, "https://avito.ru/1", storage.urlHistory[0].URL)
+
+	_, err = storage.UpdateUserURL(context.Background(), "111-222-333", "short1", "https://avito.ru/1")
+	assert.NoError(t, err)
+	assert.Len(t, storage.urlHistory, 1)
+
+	_, err = storage.UpdateUserURL(context.Background(), "444-555-666", "short1", "https://avito.ru/2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = storage.UpdateUserURL(context.Background(), "111-222-333", "short3", "https://avito.ru/3")
+	assert.ErrorIs(t, err, ErrGone)
+	_, err = storage.UpdateUserURL(context.Background(), "111-222-333", "short2", "https://avito.ru/1")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Len(t, storage.urlHistory, 1)
+}
+
+func TestMemoryStorage_RestoreShortURLs(t *testing.T) {
+	storage := NewMemoryStorage()
+	_, err := storage.ImportURLs(context.Background(), "111-222-333", []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1"},
+		{ShortURL: "short2", URL: "https://ozon.ru/2"},
+		{ShortURL: "short3", URL: "https://ozon.ru/3", ExpiresAt: time.Now().Add(time.Hour)},
+		{ShortURL: "short4", URL: "https://ozon.ru/4"},
+	})
+	require.NoError(t, err)
+	_ = storage.SoftDeletedShortURL(context.Background(), "111-222-333", "short1", "short2", "short3")
+	storage.deletedURLs["short2"] = time.Now().Add(-48 * time.Hour)
+	(*storage.db)["short3"] = models.URL{ID: 3, ShortURL: "short3", URL: "https://ozon.ru/3", ExpiresAt: time.Now().Add(-time.Minute)}
+	short5ID, err := storage.Add(context.Background(), models.URL{ShortURL: "short5", URL: "https://ozon.ru/1"})
+	require.NoError(t, err)
+	require.NoError(t, storage.LikeURLToUser(context.Background(), short5ID, "777-888-999"))
+
+	deletedSince := time.Now().Add(-24 * time.Hour)
+	restored, err := storage.RestoreShortURLs(context.Background(), "111-222-333", deletedSince, "short1", "short2", "short3", "short4")
+	require.NoError(t, err)
+	assert.Empty(t, restored, "URL занят, удалена слишком давно, срок истёк, не удалена")
+
+	// Чужая ссылка не удаляется
+	_ = storage.SoftDeletedShortURL(context.Background(), "111-222-333", "short5")
+	restored, err = storage.RestoreShortURLs(context.Background(), "111-222-333", deletedSince, "short1")
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+	_ = storage.SoftDeletedShortURL(context.Background(), "777-888-999", "short5")
+	restored, err = storage.RestoreShortURLs(context.Background(), "444-555-666", deletedSince, "short1")
+	require.NoError(t, err)
+	assert.Empty(t, restored)
+	restored, err = storage.RestoreShortURLs(context.Background(), "111-222-333", deletedSince, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short1"}, restored)
+	_, err = storage.FindByShortURL(context.Background(), "short1")
+	assert.NoError(t, err)
+}
+
+func TestMemoryStorage_Snapshot(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	storage := NewMemoryStorage()
+	userUUID := "111-222-333"
+	_, err := storage.ImportURLs(ctx, userUUID, []models.URL{
+		{ShortURL: "aaa", URL: "https://ya.ru"},
+		{ShortURL: "bbb", URL: "https://ozon.ru"},
+	})
+	require.NoError(t, err)
+	_, err = storage.CreateUser(ctx, models.User{UUID: userUUID, Login: "user", Password: "hash"})
+	require.NoError(t, err)
+	require.NoError(t, storage.SoftDeletedShortURL(ctx, userUUID, "bbb"))
+	require.NoError(t, storage.AddClick(ctx, models.Click{ShortURL: "aaa", CreatedAt: time.Date(2026, 10, 18, 1, 0, 0, 0, time.UTC)}))
+	require.NoError(t, storage.SaveSnapshot(path))
+
+	restored := NewMemoryStorage()
+	require.NoError(t, restored.LoadSnapshot(path))
+
+	url, err := restored.FindByShortURL(ctx, "aaa")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ya.ru", url.URL)
+	_, err = restored.FindByShortURL(ctx, "bbb")
+	assert.ErrorIs(t, err, ErrGone)
+	urls, err := restored.FindUrlsByUserID(ctx, userUUID)
+	require.NoError(t, err)
+	assert.Len(t, *urls, 2)
+	user, err := restored.FindUserByUUID(ctx, userUUID)
+	require.NoError(t, err)
+	assert.Equal(t, "user", user.Login)
+	stats, _ := restored.GetClickStats(ctx, "aaa")
+	assert.Equal(t, int64(1), stats.Total)
+
+	// Нумерация продолжается после восстановления
+	id, err := restored.Add(ctx, models.URL{ShortURL: "ccc", URL: "https://avito.ru"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), id)
+
+	// Временный файл не остаётся после записи
+	_, err = os.Stat(path + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestMemoryStorage_SnapshotMissingFile(t *testing.T) {
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.LoadSnapshot(filepath.Join(t.TempDir(), "missing.json")))
+	cnt, _ := storage.GetCountShortURL(context.Background())
+	assert.Equal(t, int64(0), cnt)
+
+	// Без включённых снимков сохранение ничего не делает
+	assert.NoError(t, storage.Snapshot())
+}
+
+func TestMemoryStorage_EnableSnapshots(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	storage := NewMemoryStorage()
+	require.NoError(t, storage.EnableSnapshots(ctx, MemorySnapshotOptions{Path: path, Interval: 10 * time.Millisecond}))
+	t.Cleanup(func() {
+		// После отмены и захвата блокировки периодических записей больше не будет
+		cancel()
+		storage.snapshotMx.Lock()
+		defer storage.snapshotMx.Unlock()
+	})
+	_, err := storage.Add(ctx, models.URL{ShortURL: "aaa", URL: "https://ya.ru"})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		restored := NewMemoryStorage()
+		if restored.LoadSnapshot(path) != nil {
+			return false
+		}
+		cnt, _ := restored.GetCountShortURL(ctx)
+		return cnt == 1
+	}, time.Second, 10*time.Millisecond)
+
+	restored := NewMemoryStorage()
+	require.NoError(t, restored.EnableSnapshots(ctx, MemorySnapshotOptions{Path: path}))
+	_, err = restored.FindByShortURL(ctx, "aaa")
+	assert.NoError(t, err)
+}
+
+func TestMemoryStorage_MultiAdd(t *testing.T) {
+	storage := NewMemoryStorage()
+	_, err := storage.Add(context.Background(), models.URL{ShortURL: "exist", URL: "https://ya.ru/exist"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		urls    []models.URL
+		wantErr error
+		want    map[string]string
+	}{
+		{
+			name: "#1_занятый_URL_пропускается_без_перезаписи",
+			urls: []models.URL{
+				{ShortURL: "new1", URL: "https://ya.ru/1"},
+				{ShortURL: "new2", URL: "https://ya.ru/exist"},
+				{ShortURL: "new3", URL: "https://ya.ru/1"},
+			},
+			want: map[string]string{"https://ya.ru/1": "new1", "https://ya.ru/exist": "exist"},
+		},
+		{
+			name: "#2_занятая_короткая_ссылка_отменяет_пачку",
+			urls: []models.URL{
+				{ShortURL: "new4", URL: "https://ya.ru/4"},
+				{ShortURL: "exist", URL: "https://ya.ru/5"},
+			},
+			wantErr: ErrConflict,
+			want:    map[string]string{"https://ya.ru/4": "", "https://ya.ru/5": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := storage.MultiAdd(context.Background(), tt.urls)
+			assert.ErrorIs(t, err, tt.wantErr)
+			for url, shortURL := range tt.want {
+				found, err := storage.FindByURL(context.Background(), url)
+				if shortURL == "" {
+					assert.ErrorIs(t, err, ErrNotFound)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, shortURL, found.ShortURL)
+			}
+		})
+	}
+	_, err = storage.FindByShortURL(context.Background(), "new2")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStorage_SnapshotIndexes(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	storage := NewMemoryStorage()
+	_, err := storage.ImportURLs(ctx, "111-222-333", []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1"},
+		{ShortURL: "short2", URL: "https://ozon.ru/2"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, storage.SoftDeletedShortURL(ctx, "111-222-333", "short2"))
+	_, err = storage.CreateUser(ctx, models.User{UUID: "111-222-333"})
+	require.NoError(t, err)
+	require.NoError(t, storage.RegisterUser(ctx, models.User{UUID: "111-222-333", Login: "cat", Password: "hash"}))
+	require.NoError(t, storage.SaveSnapshot(path))
+
+	restored := NewMemoryStorage()
+	require.NoError(t, restored.LoadSnapshot(path))
+	url, err := restored.FindByURL(ctx, "https://ozon.ru/1")
+	require.NoError(t, err)
+	assert.Equal(t, "short1", url.ShortURL)
+	_, err = restored.FindByURL(ctx, "https://ozon.ru/2")
+	assert.ErrorIs(t, err, ErrNotFound)
+	urls, _ := restored.FindUrlsByUserID(ctx, "111-222-333")
+	require.Len(t, *urls, 2)
+	assert.Equal(t, "short1", (*urls)[0].ShortURL)
+	assert.True(t, (*urls)[0].DeletedAt.IsZero())
+	assert.Equal(t, "short2", (*urls)[1].ShortURL)
+	assert.False(t, (*urls)[1].DeletedAt.IsZero(), "у удалённой ссылки заполнено время удаления")
+	// Индексы пользователей по uuid и логину восстанавливаются вместе со снимком
+	user, err := restored.FindUserByUUID(ctx, "111-222-333")
+	require.NoError(t, err)
+	assert.Equal(t, "cat", user.Login)
+	user, err = restored.FindUserByLoginAndPasswordHash(ctx, "cat", "hash")
+	require.NoError(t, err)
+	assert.Equal(t, "111-222-333", user.UUID)
+	err = restored.RegisterUser(ctx, models.User{UUID: "444-555-666", Login: "cat", Password: "hash"})
+	assert.ErrorIs(t, err, ErrConflict)
+	require.NoError(t, restored.LikeURLToUser(ctx, int64(url.ID), "444-555-666"))
+	urls, _ = restored.FindUrlsByUserID(ctx, "444-555-666")
+	assert.Len(t, *urls, 1)
+	urls, _ = restored.FindUrlsByUserID(ctx, "111-222-333")
+	assert.Len(t, *urls, 1)
+}
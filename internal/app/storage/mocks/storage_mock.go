@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/northmule/shorturl/internal/app/storage (interfaces: DBQuery)
+// Source: github.com/northmule/shorturl/internal/app/storage (interfaces: DBQuery,StorageQuery)
 //
 // Generated by this command:
 //
-//	mockgen -destination=internal/app/storage/mock/storage_mock.go -package=mocks github.com/northmule/shorturl/internal/app/storage DBQuery
+//	mockgen -destination=internal/app/storage/mocks/storage_mock.go -package=mocks github.com/northmule/shorturl/internal/app/storage DBQuery,StorageQuery
 //
 
 // Package mocks is a generated GoMock package.
@@ -13,7 +13,9 @@ import (
 	context "context"
 	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
+	models "github.com/northmule/shorturl/internal/app/storage/models"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,19 +42,19 @@ func (m *MockDBQuery) EXPECT() *MockDBQueryMockRecorder {
 	return m.recorder
 }
 
-// Begin mocks base method.
-func (m *MockDBQuery) Begin() (*sql.Tx, error) {
+// BeginTx mocks base method.
+func (m *MockDBQuery) BeginTx(arg0 context.Context, arg1 *sql.TxOptions) (*sql.Tx, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Begin")
+	ret := m.ctrl.Call(m, "BeginTx", arg0, arg1)
 	ret0, _ := ret[0].(*sql.Tx)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Begin indicates an expected call of Begin.
-func (mr *MockDBQueryMockRecorder) Begin() *gomock.Call {
+// BeginTx indicates an expected call of BeginTx.
+func (mr *MockDBQueryMockRecorder) BeginTx(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockDBQuery)(nil).Begin))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTx", reflect.TypeOf((*MockDBQuery)(nil).BeginTx), arg0, arg1)
 }
 
 // ExecContext mocks base method.
@@ -127,3 +129,372 @@ func (mr *MockDBQueryMockRecorder) QueryRowContext(arg0, arg1 any, arg2 ...any)
 	varargs := append([]any{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockDBQuery)(nil).QueryRowContext), varargs...)
 }
+
+// MockStorageQuery is a mock of StorageQuery interface.
+type MockStorageQuery struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageQueryMockRecorder
+}
+
+// MockStorageQueryMockRecorder is the mock recorder for MockStorageQuery.
+type MockStorageQueryMockRecorder struct {
+	mock *MockStorageQuery
+}
+
+// NewMockStorageQuery creates a new mock instance.
+func NewMockStorageQuery(ctrl *gomock.Controller) *MockStorageQuery {
+	mock := &MockStorageQuery{ctrl: ctrl}
+	mock.recorder = &MockStorageQueryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageQuery) EXPECT() *MockStorageQueryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockStorageQuery) Add(arg0 context.Context, arg1 models.URL) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockStorageQueryMockRecorder) Add(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockStorageQuery)(nil).Add), arg0, arg1)
+}
+
+// AddAPIKey mocks base method.
+func (m *MockStorageQuery) AddAPIKey(arg0 context.Context, arg1 models.APIKey) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockStorageQueryMockRecorder) AddAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockStorageQuery)(nil).AddAPIKey), arg0, arg1)
+}
+
+// AddClick mocks base method.
+func (m *MockStorageQuery) AddClick(arg0 context.Context, arg1 models.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClick", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClick indicates an expected call of AddClick.
+func (mr *MockStorageQueryMockRecorder) AddClick(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClick", reflect.TypeOf((*MockStorageQuery)(nil).AddClick), arg0, arg1)
+}
+
+// AddClicks mocks base method.
+func (m *MockStorageQuery) AddClicks(arg0 context.Context, arg1 []models.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClicks", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClicks indicates an expected call of AddClicks.
+func (mr *MockStorageQueryMockRecorder) AddClicks(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClicks", reflect.TypeOf((*MockStorageQuery)(nil).AddClicks), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStorageQuery) CreateUser(arg0 context.Context, arg1 models.User) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStorageQueryMockRecorder) CreateUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorageQuery)(nil).CreateUser), arg0, arg1)
+}
+
+// ExportUserURLs mocks base method.
+func (m *MockStorageQuery) ExportUserURLs(arg0 context.Context, arg1 string, arg2 func(models.ExportURL) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserURLs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUserURLs indicates an expected call of ExportUserURLs.
+func (mr *MockStorageQueryMockRecorder) ExportUserURLs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserURLs", reflect.TypeOf((*MockStorageQuery)(nil).ExportUserURLs), arg0, arg1, arg2)
+}
+
+// FindAPIKeyByHash mocks base method.
+func (m *MockStorageQuery) FindAPIKeyByHash(arg0 context.Context, arg1 string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAPIKeyByHash", arg0, arg1)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAPIKeyByHash indicates an expected call of FindAPIKeyByHash.
+func (mr *MockStorageQueryMockRecorder) FindAPIKeyByHash(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAPIKeyByHash", reflect.TypeOf((*MockStorageQuery)(nil).FindAPIKeyByHash), arg0, arg1)
+}
+
+// FindByShortURL mocks base method.
+func (m *MockStorageQuery) FindByShortURL(arg0 context.Context, arg1 string) (*models.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByShortURL", arg0, arg1)
+	ret0, _ := ret[0].(*models.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByShortURL indicates an expected call of FindByShortURL.
+func (mr *MockStorageQueryMockRecorder) FindByShortURL(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByShortURL", reflect.TypeOf((*MockStorageQuery)(nil).FindByShortURL), arg0, arg1)
+}
+
+// FindByURL mocks base method.
+func (m *MockStorageQuery) FindByURL(arg0 context.Context, arg1 string) (*models.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByURL", arg0, arg1)
+	ret0, _ := ret[0].(*models.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByURL indicates an expected call of FindByURL.
+func (mr *MockStorageQueryMockRecorder) FindByURL(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByURL", reflect.TypeOf((*MockStorageQuery)(nil).FindByURL), arg0, arg1)
+}
+
+// FindUrlsByUserID mocks base method.
+func (m *MockStorageQuery) FindUrlsByUserID(arg0 context.Context, arg1 string) (*[]models.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUrlsByUserID", arg0, arg1)
+	ret0, _ := ret[0].(*[]models.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUrlsByUserID indicates an expected call of FindUrlsByUserID.
+func (mr *MockStorageQueryMockRecorder) FindUrlsByUserID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUrlsByUserID", reflect.TypeOf((*MockStorageQuery)(nil).FindUrlsByUserID), arg0, arg1)
+}
+
+// FindUserByLoginAndPasswordHash mocks base method.
+func (m *MockStorageQuery) FindUserByLoginAndPasswordHash(arg0 context.Context, arg1, arg2 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByLoginAndPasswordHash", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByLoginAndPasswordHash indicates an expected call of FindUserByLoginAndPasswordHash.
+func (mr *MockStorageQueryMockRecorder) FindUserByLoginAndPasswordHash(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByLoginAndPasswordHash", reflect.TypeOf((*MockStorageQuery)(nil).FindUserByLoginAndPasswordHash), arg0, arg1, arg2)
+}
+
+// FindUserByUUID mocks base method.
+func (m *MockStorageQuery) FindUserByUUID(arg0 context.Context, arg1 string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserByUUID", arg0, arg1)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserByUUID indicates an expected call of FindUserByUUID.
+func (mr *MockStorageQueryMockRecorder) FindUserByUUID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByUUID", reflect.TypeOf((*MockStorageQuery)(nil).FindUserByUUID), arg0, arg1)
+}
+
+// FindUserURLs mocks base method.
+func (m *MockStorageQuery) FindUserURLs(arg0 context.Context, arg1 string, arg2 models.URLFilter) (*models.URLPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserURLs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.URLPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUserURLs indicates an expected call of FindUserURLs.
+func (mr *MockStorageQueryMockRecorder) FindUserURLs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserURLs", reflect.TypeOf((*MockStorageQuery)(nil).FindUserURLs), arg0, arg1, arg2)
+}
+
+// GetClickStats mocks base method.
+func (m *MockStorageQuery) GetClickStats(arg0 context.Context, arg1 string) (*models.ClickStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClickStats", arg0, arg1)
+	ret0, _ := ret[0].(*models.ClickStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClickStats indicates an expected call of GetClickStats.
+func (mr *MockStorageQueryMockRecorder) GetClickStats(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClickStats", reflect.TypeOf((*MockStorageQuery)(nil).GetClickStats), arg0, arg1)
+}
+
+// ImportURLs mocks base method.
+func (m *MockStorageQuery) ImportURLs(arg0 context.Context, arg1 string, arg2 []models.URL) ([]models.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportURLs", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportURLs indicates an expected call of ImportURLs.
+func (mr *MockStorageQueryMockRecorder) ImportURLs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportURLs", reflect.TypeOf((*MockStorageQuery)(nil).ImportURLs), arg0, arg1, arg2)
+}
+
+// LikeURLToUser mocks base method.
+func (m *MockStorageQuery) LikeURLToUser(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LikeURLToUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LikeURLToUser indicates an expected call of LikeURLToUser.
+func (mr *MockStorageQueryMockRecorder) LikeURLToUser(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LikeURLToUser", reflect.TypeOf((*MockStorageQuery)(nil).LikeURLToUser), arg0, arg1, arg2)
+}
+
+// MultiAdd mocks base method.
+func (m *MockStorageQuery) MultiAdd(arg0 context.Context, arg1 []models.URL) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MultiAdd", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MultiAdd indicates an expected call of MultiAdd.
+func (mr *MockStorageQueryMockRecorder) MultiAdd(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MultiAdd", reflect.TypeOf((*MockStorageQuery)(nil).MultiAdd), arg0, arg1)
+}
+
+// Ping mocks base method.
+func (m *MockStorageQuery) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStorageQueryMockRecorder) Ping(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStorageQuery)(nil).Ping), arg0)
+}
+
+// RegisterUser mocks base method.
+func (m *MockStorageQuery) RegisterUser(arg0 context.Context, arg1 models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterUser indicates an expected call of RegisterUser.
+func (mr *MockStorageQueryMockRecorder) RegisterUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockStorageQuery)(nil).RegisterUser), arg0, arg1)
+}
+
+// RestoreShortURLs mocks base method.
+func (m *MockStorageQuery) RestoreShortURLs(arg0 context.Context, arg1 string, arg2 time.Time, arg3 ...string) ([]string, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RestoreShortURLs", varargs...)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreShortURLs indicates an expected call of RestoreShortURLs.
+func (mr *MockStorageQueryMockRecorder) RestoreShortURLs(arg0, arg1, arg2 any, arg3 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreShortURLs", reflect.TypeOf((*MockStorageQuery)(nil).RestoreShortURLs), varargs...)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStorageQuery) RevokeAPIKey(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStorageQueryMockRecorder) RevokeAPIKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStorageQuery)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// SoftDeletedShortURL mocks base method.
+func (m *MockStorageQuery) SoftDeletedShortURL(arg0 context.Context, arg1 string, arg2 ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SoftDeletedShortURL", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDeletedShortURL indicates an expected call of SoftDeletedShortURL.
+func (mr *MockStorageQueryMockRecorder) SoftDeletedShortURL(arg0, arg1 any, arg2 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeletedShortURL", reflect.TypeOf((*MockStorageQuery)(nil).SoftDeletedShortURL), varargs...)
+}
+
+// UpdateUserURL mocks base method.
+func (m *MockStorageQuery) UpdateUserURL(arg0 context.Context, arg1, arg2, arg3 string) (*models.URL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserURL", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*models.URL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserURL indicates an expected call of UpdateUserURL.
+func (mr *MockStorageQueryMockRecorder) UpdateUserURL(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserURL", reflect.TypeOf((*MockStorageQuery)(nil).UpdateUserURL), arg0, arg1, arg2, arg3)
+}
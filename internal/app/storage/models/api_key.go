@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// APIKey долгоживущий ключ доступа межсервисных клиентов, привязан к пользователю.
+// Сам ключ не хранится, хранится только его хэш.
+type APIKey struct {
+	ID        int64      `json:"id"`
+	UserUUID  string     `json:"user_uuid"`
+	Name      string     `json:"name"`
+	KeyHash   string     `json:"key_hash"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Click переход по короткой ссылке.
+type Click struct {
+	ShortURL  string    `json:"short_url"`
+	CreatedAt time.Time `json:"created_at"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	RemoteIP  string    `json:"remote_ip,omitempty"`
+}
+
+// ClickDay количество переходов за день.
+type ClickDay struct {
+	Date   string `json:"date"`
+	Clicks int64  `json:"clicks"`
+}
+
+// ClickStats статистика переходов по короткой ссылке.
+type ClickStats struct {
+	Total int64      `json:"total"`
+	Days  []ClickDay `json:"days"`
+}
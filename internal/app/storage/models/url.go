@@ -8,4 +8,23 @@ type URL struct {
 	ShortURL  string    `json:"short_url"`
 	URL       string    `json:"url"`
 	DeletedAt time.Time `json:"deleted_at,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// ExportURL ссылка пользователя для выгрузки.
+type ExportURL struct {
+	URL
+	// Clicks количество переходов, ноль если хранилище не ведёт статистику
+	Clicks int64
+}
+
+// URLHistory прежнее назначение короткой ссылки, запись создаётся при каждой смене URL.
+type URLHistory struct {
+	ID          int64
+	URLID       uint
+	UserUUID    string
+	PreviousURL string
+	URL         string
+	ChangedAt   time.Time
 }
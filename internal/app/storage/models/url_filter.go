@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Порядок ссылок пользователя.
+const (
+	// SortCreatedAsc сначала старые
+	SortCreatedAsc = "created_asc"
+	// SortCreatedDesc сначала новые
+	SortCreatedDesc = "created_desc"
+)
+
+const (
+	// URLPageDefaultLimit размер страницы ссылок по умолчанию.
+	URLPageDefaultLimit = 100
+	// URLPageMaxLimit наибольший размер страницы ссылок.
+	URLPageMaxLimit = 1000
+)
+
+// URLFilter отбор ссылок пользователя.
+type URLFilter struct {
+	// Limit размер страницы, ноль означает URLPageDefaultLimit
+	Limit int
+	// Cursor курсор следующей страницы из URLPage.NextCursor
+	Cursor string
+	// CreatedFrom ссылки созданные не раньше (необязательный)
+	CreatedFrom time.Time
+	// CreatedTo ссылки созданные раньше (необязательный)
+	CreatedTo time.Time
+	// Host домен оригинальной ссылки без учёта регистра (необязательный)
+	Host string
+	// IncludeDeleted вернуть вместе с удалёнными ссылками
+	IncludeDeleted bool
+	// Search подстрока оригинальной или короткой ссылки без учёта регистра (необязательный)
+	Search string
+	// Sort порядок SortCreatedAsc или SortCreatedDesc, по умолчанию SortCreatedAsc
+	Sort string
+}
+
+// URLPage страница ссылок пользователя.
+type URLPage struct {
+	URLs []URL
+	// NextCursor курсор следующей страницы, пустой на последней странице
+	NextCursor string
+}
@@ -4,47 +4,77 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 	_ "go.uber.org/mock/mockgen/model"
 )
 
-// CodeErrorDuplicateKey код ошибки с дублем записи в БД.
-const CodeErrorDuplicateKey = "23505"
-
 // DBQuery общие методы для работы с хранилищем.
 type DBQuery interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 	PingContext(ctx context.Context) error
-	Begin() (*sql.Tx, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 // StorageQuery общий интерфес хранилища
 type StorageQuery interface {
-	// Add добавляет URL.
-	Add(url models.URL) (int64, error)
+	// Add добавляет URL, при занятом коде вернёт ErrConflict.
+	Add(ctx context.Context, url models.URL) (int64, error)
 	// CreateUser создание пользователя.
-	CreateUser(user models.User) (int64, error)
+	CreateUser(ctx context.Context, user models.User) (int64, error)
 	// LikeURLToUser связывает пользователя с ссылкой.
-	LikeURLToUser(urlID int64, userUUID string) error
-	// FindByShortURL поиск по короткой ссылке.
-	FindByShortURL(shortURL string) (*models.URL, error)
-	// FindByURL поиск по URL.
-	FindByURL(url string) (*models.URL, error)
+	LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error
+	// FindByShortURL поиск по короткой ссылке, ErrNotFound если её нет, удалённая ссылка вернётся вместе с ErrGone.
+	FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error)
+	// FindByURL поиск действующей ссылки по URL, ErrNotFound если её нет.
+	FindByURL(ctx context.Context, url string) (*models.URL, error)
 	// Ping проверка соединения с БД.
-	Ping() error
-	// MultiAdd вставка массива адресов.
-	MultiAdd(urls []models.URL) error
+	Ping(ctx context.Context) error
+	// MultiAdd вставка массива адресов, уже сокращённые ссылки пропускаются.
+	MultiAdd(ctx context.Context, urls []models.URL) error
+	// ImportURLs вставка пачки импортируемых ссылок, ссылки с занятым URL или кодом пропускаются.
+	ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error)
 	// FindUrlsByUserID поиск ссылок пользователя
-	FindUrlsByUserID(userUUID string) (*[]models.URL, error)
+	FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error)
+	// FindUserURLs страница ссылок пользователя по отбору, при неверном курсоре или порядке вернёт ErrFilterInvalid.
+	FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error)
+	// ExportUserURLs выгрузка ссылок пользователя, ссылки передаются в fn по мере чтения, ошибка fn прерывает выгрузку.
+	ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error
+	// UpdateUserURL смена URL ссылки её владельцем, прежний URL сохраняется в историю.
+	// ErrNotFound если ссылка не принадлежит пользователю, ErrGone для удалённой, ErrConflict если URL занят другой ссылкой.
+	UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error)
 	// SoftDeletedShortURL пометка ссылки как удалённой.
-	SoftDeletedShortURL(userUUID string, shortURL ...string) error
+	SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error
+	// RestoreShortURLs снимает пометку удаления со ссылок пользователя, удалённых не раньше deletedSince.
+	// Ссылка с истёкшим сроком жизни или с URL, занятым действующей ссылкой, не восстанавливается; вернёт восстановленные коды.
+	RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error)
+	// FindUserByUUID поиск пользователя по uuid, ErrNotFound если его нет.
+	FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error)
+	// FindUserByLoginAndPasswordHash поиск пользователя по логину и хэшу пароля, ErrNotFound если его нет.
+	FindUserByLoginAndPasswordHash(ctx context.Context, login string, passwordHash string) (*models.User, error)
+	// RegisterUser регистрация анонимного пользователя, при занятом логине вернёт ErrConflict.
+	RegisterUser(ctx context.Context, user models.User) error
+	// AddClick сохраняет переход по короткой ссылке.
+	AddClick(ctx context.Context, click models.Click) error
+	// AddClicks сохраняет пачку переходов по коротким ссылкам.
+	AddClicks(ctx context.Context, clicks []models.Click) error
+	// GetClickStats статистика переходов по короткой ссылке.
+	GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error)
+	// AddAPIKey сохраняет ключ доступа.
+	AddAPIKey(ctx context.Context, key models.APIKey) (int64, error)
+	// FindAPIKeyByHash поиск действующего ключа доступа по хэшу.
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// RevokeAPIKey отзыв ключа доступа.
+	RevokeAPIKey(ctx context.Context, id int64) error
 }
 
 // PostgresStorage хранилище в БД.
@@ -69,42 +99,42 @@ func NewPostgresStorage(dsn string) (*PostgresStorage, error) {
 }
 
 // Add добавление нового значения.
-func (p *PostgresStorage) Add(url models.URL) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) Add(ctx context.Context, url models.URL) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	var urlID int64
 	// ON CONFLICT (url) where deleted_at IS NULL DO UPDATE SET url=$2
-	err := p.DB.QueryRowContext(ctx, "insert into url_list (short_url, url) values ($1, $2) returning id", url.ShortURL, url.URL).Scan(&urlID)
-	return urlID, err
+	err := p.DB.QueryRowContext(ctx, "insert into url_list (short_url, url, expires_at) values ($1, $2, $3) returning id", url.ShortURL, url.URL, nullTime(url.ExpiresAt)).Scan(&urlID)
+	return urlID, wrapPgError(err)
 }
 
 // CreateUser добавление нового значения.
-func (p *PostgresStorage) CreateUser(user models.User) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) CreateUser(ctx context.Context, user models.User) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	_, err := p.DB.ExecContext(ctx, `
-			insert into users (name, login, password, uuid) values ($1, $2, $3, $4) ON CONFLICT (uuid) DO UPDATE SET uuid = $4 returning id`, user.Name, user.Login, user.Password, user.UUID)
-	return 0, err
+			insert into users (name, login, password, uuid) values ($1, NULLIF($2, ''), NULLIF($3, ''), $4) ON CONFLICT (uuid) DO UPDATE SET uuid = $4 returning id`, user.Name, user.Login, user.Password, user.UUID)
+	return 0, wrapPgError(err)
 }
 
 // LikeURLToUser Связывание URL с пользователем.
-func (p *PostgresStorage) LikeURLToUser(urlID int64, userUUID string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	_, err := p.DB.ExecContext(ctx, `insert into user_short_url (user_id, url_id) values ((select id from users where uuid=$1 limit 1), $2)`, userUUID, urlID)
 	if err != nil {
 		logger.LogSugar.Error(err.Error())
 	}
-	return err
+	return wrapPgError(err)
 }
 
 // FindByShortURL поиск по короткой ссылке.
-func (p *PostgresStorage) FindByShortURL(shortURL string) (*models.URL, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	rows, err := p.DB.QueryContext(
 		ctx,
-		"select id, short_url, url, deleted_at from url_list where short_url = $1 limit 1",
+		"select id, short_url, url, deleted_at, expires_at from url_list where short_url = $1 limit 1",
 		shortURL,
 	)
 	if err != nil {
@@ -116,28 +146,34 @@ func (p *PostgresStorage) FindByShortURL(shortURL string) (*models.URL, error) {
 		logger.LogSugar.Errorf("При вызове FindByShortURL(%s) произошла ошибка %s", shortURL, err)
 		return nil, err
 	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, notFoundError("short url " + shortURL)
+	}
 	url := models.URL{}
-	var deletedAt sql.NullTime
-	if rows.Next() {
-		err := rows.Scan(&url.ID, &url.ShortURL, &url.URL, &deletedAt)
-		if err != nil {
-			logger.LogSugar.Errorf("При обработке значений в FindByShortURL(%s) произошла ошибка %s", shortURL, err)
-			return nil, err
-		}
+	var deletedAt, expiresAt sql.NullTime
+	err = rows.Scan(&url.ID, &url.ShortURL, &url.URL, &deletedAt, &expiresAt)
+	if err != nil {
+		logger.LogSugar.Errorf("При обработке значений в FindByShortURL(%s) произошла ошибка %s", shortURL, err)
+		return nil, err
+	}
+	if expiresAt.Valid {
+		url.ExpiresAt = expiresAt.Time
 	}
 	if deletedAt.Valid {
 		url.DeletedAt = deletedAt.Time
+		return &url, ErrGone
 	}
 	return &url, nil
 }
 
 // FindByURL поиск по URL.
-func (p *PostgresStorage) FindByURL(url string) (*models.URL, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) FindByURL(ctx context.Context, url string) (*models.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	rows, err := p.DB.QueryContext(
 		ctx,
-		"select id, short_url, url from url_list where url = $1 and deleted_at is null limit 1",
+		"select id, short_url, url, expires_at from url_list where url = $1 and deleted_at is null limit 1",
 		url,
 	)
 	if err != nil {
@@ -146,47 +182,57 @@ func (p *PostgresStorage) FindByURL(url string) (*models.URL, error) {
 	}
 	err = rows.Err()
 	if err != nil {
-		logger.LogSugar.Errorf("При вызове FindByShortURL(%s) произошла ошибка %s", url, err)
+		logger.LogSugar.Errorf("При вызове FindByURL(%s) произошла ошибка %s", url, err)
 		return nil, err
 	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, notFoundError("url " + url)
+	}
 	modelURL := models.URL{}
-	if rows.Next() {
-		err := rows.Scan(&modelURL.ID, &modelURL.ShortURL, &modelURL.URL)
-		if err != nil {
-			logger.LogSugar.Errorf("При обработке значений в FindByURL(%s) произошла ошибка %s", url, err)
-			return nil, err
-		}
+	var expiresAt sql.NullTime
+	err = rows.Scan(&modelURL.ID, &modelURL.ShortURL, &modelURL.URL, &expiresAt)
+	if err != nil {
+		logger.LogSugar.Errorf("При обработке значений в FindByURL(%s) произошла ошибка %s", url, err)
+		return nil, err
+	}
+	if expiresAt.Valid {
+		modelURL.ExpiresAt = expiresAt.Time
 	}
 
 	return &modelURL, nil
 }
 
 // Ping проверка соединения.
-func (p *PostgresStorage) Ping() error {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	return p.DB.PingContext(ctx)
 }
 
 // MultiAdd Вставка значений в бд пачками.
-func (p *PostgresStorage) MultiAdd(urls []models.URL) error {
+func (p *PostgresStorage) MultiAdd(ctx context.Context, urls []models.URL) error {
 	var err error
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
-	tx, err := p.DB.Begin()
+	tx, err := p.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	prepareInsert, err := tx.PrepareContext(ctx, `insert into url_list (short_url, url) values ($1, $2) ON CONFLICT (url) where deleted_at IS NULL DO NOTHING;`)
+	prepareInsert, err := tx.PrepareContext(ctx, `insert into url_list (short_url, url, expires_at) values ($1, $2, $3) ON CONFLICT (url) where deleted_at IS NULL DO NOTHING;`)
 	if err != nil {
 		return err
 	}
+	defer prepareInsert.Close()
 	for _, url := range urls {
-		_, err = prepareInsert.ExecContext(ctx, url.ShortURL, url.URL)
+		_, err = prepareInsert.ExecContext(ctx, url.ShortURL, url.URL, nullTime(url.ExpiresAt))
 		if err != nil {
 			logger.LogSugar.Errorf("Значение %#v не добавлено в таблицу url_list", url)
-			return errors.Join(err, tx.Rollback())
+			return wrapPgError(err)
 		}
 	}
 	err = tx.Commit()
@@ -196,14 +242,97 @@ func (p *PostgresStorage) MultiAdd(urls []models.URL) error {
 	return nil
 }
 
+// ImportURLs Вставка пачки импортируемых ссылок одной транзакцией через COPY во временную таблицу.
+// Ссылки с уже занятым URL или кодом пропускаются, вставленные связываются с пользователем и возвращаются.
+func (p *PostgresStorage) ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	conn, err := p.RawDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var imported []models.URL
+	err = conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("copy is not supported by database driver")
+		}
+		imported, err = copyURLs(ctx, stdlibConn.Conn(), userUUID, urls)
+		return err
+	})
+	if err != nil {
+		logger.LogSugar.Errorf("Пачка из %d ссылок не импортирована: %s", len(urls), err)
+		return nil, wrapPgError(err)
+	}
+	return imported, nil
+}
+
+// copyURLs копирует ссылки во временную таблицу и переносит из неё незанятые в url_list.
+func copyURLs(ctx context.Context, conn *pgx.Conn, userUUID string, urls []models.URL) ([]models.URL, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, `create temp table url_import (short_url varchar(100), url varchar(2000), expires_at timestamp) on commit drop`)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"url_import"}, []string{"short_url", "url", "expires_at"}, pgx.CopyFromSlice(len(urls), func(i int) ([]any, error) {
+		var expiresAt any
+		if !urls[i].ExpiresAt.IsZero() {
+			expiresAt = urls[i].ExpiresAt.UTC()
+		}
+		return []any{urls[i].ShortURL, urls[i].URL, expiresAt}, nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `insert into url_list (short_url, url, expires_at) select short_url, url, expires_at from url_import on conflict do nothing returning id, short_url, url`)
+	if err != nil {
+		return nil, err
+	}
+	imported := make([]models.URL, 0, len(urls))
+	ids := make([]int64, 0, len(urls))
+	for rows.Next() {
+		var url models.URL
+		err = rows.Scan(&url.ID, &url.ShortURL, &url.URL)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		imported = append(imported, url)
+		ids = append(ids, int64(url.ID))
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `insert into user_short_url (user_id, url_id) select u.id, unnest($2::int8[]) from users u where u.uuid = $1`, userUUID, ids)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
 // FindUserByLoginAndPasswordHash Поиск пользователя.
-func (p *PostgresStorage) FindUserByLoginAndPasswordHash(login string, passwordHash string) (*models.User, error) {
-	var err error
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) FindUserByLoginAndPasswordHash(ctx context.Context, login string, passwordHash string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	rows, err := p.DB.QueryContext(
 		ctx,
-		"select id, name, login, password from users where login = $1 and password = $2 and deleted_at is null limit 1",
+		"select id, name, login, password, coalesce(uuid::text, '') from users where login = $1 and password = $2 and deleted_at is null limit 1",
 		login,
 		passwordHash,
 	)
@@ -211,30 +340,69 @@ func (p *PostgresStorage) FindUserByLoginAndPasswordHash(login string, passwordH
 		logger.LogSugar.Errorf("При вызове FindUserByLoginAndPasswordHash(%s) произошла ошибка %s", login, err)
 		return nil, err
 	}
-	err = rows.Err()
+	return p.scanUser(rows)
+}
+
+// FindUserByUUID Поиск пользователя по uuid.
+func (p *PostgresStorage) FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	rows, err := p.DB.QueryContext(
+		ctx,
+		"select id, name, coalesce(login, ''), coalesce(password, ''), uuid::text from users where uuid = $1 and deleted_at is null limit 1",
+		userUUID,
+	)
 	if err != nil {
-		logger.LogSugar.Errorf("При вызове FindUserByLoginAndPasswordHash(%s) произошла ошибка %s", login, err)
+		logger.LogSugar.Errorf("При вызове FindUserByUUID(%s) произошла ошибка %s", userUUID, err)
 		return nil, err
 	}
-	user := models.User{}
-	if rows.Next() {
-		err = rows.Scan(&user.ID, &user.Name, &user.Login, &user.Password)
-		if err != nil {
-			logger.LogSugar.Errorf("При обработке значений в FindUserByLoginAndPasswordHash(%s) произошла ошибка %s", login, err)
-			return nil, err
-		}
+	return p.scanUser(rows)
+}
+
+// RegisterUser заполняет логин и пароль анонимного пользователя, ссылки остаются за ним.
+func (p *PostgresStorage) RegisterUser(ctx context.Context, user models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	result, err := p.DB.ExecContext(ctx, `update users set name = $1, login = $2, password = $3
+				where uuid = $4 and login is null and deleted_at is null`, user.Name, user.Login, user.Password, user.UUID)
+	if err != nil {
+		return wrapPgError(err)
 	}
+	cnt, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return notFoundError("anonymous user " + user.UUID)
+	}
+	return nil
+}
 
+// scanUser первая строка выборки пользователей, ErrNotFound если пользователь не найден.
+func (p *PostgresStorage) scanUser(rows *sql.Rows) (*models.User, error) {
+	defer rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, notFoundError("user")
+	}
+	user := models.User{}
+	err := rows.Scan(&user.ID, &user.Name, &user.Login, &user.Password, &user.UUID)
+	if err != nil {
+		logger.LogSugar.Errorf("При обработке значений пользователя произошла ошибка %s", err)
+		return nil, err
+	}
 	return &user, nil
 }
 
-// FindUrlsByUserID поиск URL-s.
-func (p *PostgresStorage) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+// FindUrlsByUserID поиск URL-s, у удалённых ссылок заполнено время удаления.
+func (p *PostgresStorage) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	rows, err := p.DB.QueryContext(
 		ctx,
-		`select ul.id, ul.short_url, ul.url from url_list as ul
+		`select ul.id, ul.short_url, ul.url, ul.deleted_at from url_list as ul
 				left join user_short_url as usu on usu.url_id=ul.id
 				where usu.user_id=(select id from users where uuid=$1 limit 1) order by ul.id asc`,
 		userUUID,
@@ -251,22 +419,203 @@ func (p *PostgresStorage) FindUrlsByUserID(userUUID string) (*[]models.URL, erro
 	var urls []models.URL
 	for rows.Next() {
 		var url models.URL
-		err := rows.Scan(&url.ID, &url.ShortURL, &url.URL)
+		var deletedAt sql.NullTime
+		err := rows.Scan(&url.ID, &url.ShortURL, &url.URL, &deletedAt)
 		if err != nil {
 			logger.LogSugar.Errorf("При обработке значений в FindUrlsByUserID(%s) произошла ошибка %s", userUUID, err)
 			return nil, err
 		}
+		if deletedAt.Valid {
+			url.DeletedAt = deletedAt.Time
+		}
 		urls = append(urls, url)
 	}
 
 	return &urls, nil
 }
 
+// urlHostExpression домен оригинальной ссылки, совпадает с выражением индекса url_list_host_idx.
+const urlHostExpression = `lower(substring(ul.url from '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/:?#]+)'))`
+
+// FindUserURLs страница ссылок пользователя, курсор задаёт позицию по (created_at, id).
+func (p *PostgresStorage) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
+	filter, err := normalizeURLFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeURLCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+
+	args := []any{userUUID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	var query strings.Builder
+	query.WriteString(`select ul.id, ul.short_url, ul.url, ul.created_at, ul.deleted_at, ul.expires_at from url_list as ul
+				join user_short_url as usu on usu.url_id=ul.id
+				where usu.user_id=(select id from users where uuid=$1 limit 1)`)
+	if !filter.IncludeDeleted {
+		query.WriteString(" and ul.deleted_at is null")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query.WriteString(" and ul.created_at >= " + arg(filter.CreatedFrom.UTC()))
+	}
+	if !filter.CreatedTo.IsZero() {
+		query.WriteString(" and ul.created_at < " + arg(filter.CreatedTo.UTC()))
+	}
+	if filter.Host != "" {
+		query.WriteString(" and " + urlHostExpression + " = " + arg(filter.Host))
+	}
+	if filter.Search != "" {
+		search := arg("%" + escapeLike(filter.Search) + "%")
+		query.WriteString(" and (ul.url ilike " + search + " or ul.short_url ilike " + search + ")")
+	}
+	order := "asc"
+	comparison := ">"
+	if filter.Sort == models.SortCreatedDesc {
+		order = "desc"
+		comparison = "<"
+	}
+	if cursor != nil {
+		query.WriteString(fmt.Sprintf(" and (ul.created_at, ul.id) %s (%s, %s)", comparison, arg(cursor.createdAt), arg(int64(cursor.id))))
+	}
+	query.WriteString(fmt.Sprintf(" order by ul.created_at %s, ul.id %s limit %s", order, order, arg(filter.Limit+1)))
+
+	rows, err := p.DB.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове FindUserURLs(%s) произошла ошибка %s", userUUID, err)
+		return nil, err
+	}
+	defer rows.Close()
+	urls := make([]models.URL, 0, filter.Limit+1)
+	for rows.Next() {
+		var url models.URL
+		var deletedAt, expiresAt sql.NullTime
+		err = rows.Scan(&url.ID, &url.ShortURL, &url.URL, &url.CreatedAt, &deletedAt, &expiresAt)
+		if err != nil {
+			logger.LogSugar.Errorf("При обработке значений в FindUserURLs(%s) произошла ошибка %s", userUUID, err)
+			return nil, err
+		}
+		if deletedAt.Valid {
+			url.DeletedAt = deletedAt.Time
+		}
+		if expiresAt.Valid {
+			url.ExpiresAt = expiresAt.Time
+		}
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return newURLPage(urls, filter.Limit), nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// ExportUserURLs выгрузка ссылок пользователя вместе с удалёнными и количеством переходов.
+// Строки читаются курсором по мере передачи, общий таймаут запросов не применяется: выгрузка ограничена контекстом вызова.
+func (p *PostgresStorage) ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error {
+	rows, err := p.DB.QueryContext(
+		ctx,
+		`select ul.id, ul.short_url, ul.url, ul.created_at, ul.deleted_at, ul.expires_at, c.cnt from url_list as ul
+				join user_short_url as usu on usu.url_id=ul.id
+				left join lateral (select count(*) as cnt from url_clicks as uc where uc.short_url=ul.short_url) as c on true
+				where usu.user_id=(select id from users where uuid=$1 limit 1) order by ul.id asc`,
+		userUUID,
+	)
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове ExportUserURLs(%s) произошла ошибка %s", userUUID, err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var exportURL models.ExportURL
+		var deletedAt, expiresAt sql.NullTime
+		err = rows.Scan(&exportURL.ID, &exportURL.ShortURL, &exportURL.URL.URL, &exportURL.CreatedAt, &deletedAt, &expiresAt, &exportURL.Clicks)
+		if err != nil {
+			logger.LogSugar.Errorf("При обработке значений в ExportUserURLs(%s) произошла ошибка %s", userUUID, err)
+			return err
+		}
+		if deletedAt.Valid {
+			exportURL.DeletedAt = deletedAt.Time
+		}
+		if expiresAt.Valid {
+			exportURL.ExpiresAt = expiresAt.Time
+		}
+		if err = fn(exportURL); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateUserURL смена URL ссылки её владельцем в одной транзакции с записью прежнего URL в url_history.
+// Строка ссылки блокируется до конца транзакции, чтобы параллельные изменения не потеряли запись истории.
+func (p *PostgresStorage) UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var modelURL models.URL
+	var userID int64
+	var deletedAt, expiresAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `select ul.id, ul.short_url, ul.url, ul.created_at, ul.deleted_at, ul.expires_at, usu.user_id from url_list as ul
+				join user_short_url as usu on usu.url_id=ul.id
+				where ul.short_url=$1 and usu.user_id=(select id from users where uuid=$2 limit 1)
+				limit 1 for update of ul`, shortURL, userUUID).
+		Scan(&modelURL.ID, &modelURL.ShortURL, &modelURL.URL, &modelURL.CreatedAt, &deletedAt, &expiresAt, &userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError("user short url " + shortURL)
+	}
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове UpdateUserURL(%s, %s) произошла ошибка %s", userUUID, shortURL, err)
+		return nil, err
+	}
+	if expiresAt.Valid {
+		modelURL.ExpiresAt = expiresAt.Time
+	}
+	if deletedAt.Valid {
+		modelURL.DeletedAt = deletedAt.Time
+		return &modelURL, ErrGone
+	}
+	if modelURL.URL == url {
+		return &modelURL, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `insert into url_history (url_id, user_id, previous_url, url) values ($1, $2, $3, $4)`, modelURL.ID, userID, modelURL.URL, url)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `update url_list set url=$1 where id=$2`, url, modelURL.ID)
+	if err != nil {
+		return nil, wrapPgError(err)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	modelURL.URL = url
+	return &modelURL, nil
+}
+
 // SoftDeletedShortURL Отметка об удалении ссылки.
-func (p *PostgresStorage) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
-	_, err := p.DB.ExecContext(ctx, `update url_list set deleted_at=now() where short_url = ANY($1)
+	_, err := p.DB.ExecContext(ctx, `update url_list set deleted_at=(now() at time zone 'utc') where short_url = ANY($1)
 				and id in (
 					select uu.url_id from user_short_url as uu where uu.user_id =
 					                                    (select us.id from users as us where us.uuid=$2 limit 1)
@@ -274,15 +623,51 @@ func (p *PostgresStorage) SoftDeletedShortURL(userUUID string, shortURL ...strin
 	return err
 }
 
+// RestoreShortURLs Снятие отметки об удалении со ссылок пользователя одним запросом.
+// Из удалённых ссылок с одинаковым URL восстанавливается только последняя, чтобы не нарушить уникальный индекс url_list_url_idx.
+func (p *PostgresStorage) RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	rows, err := p.DB.QueryContext(ctx, `with candidates as (
+					select distinct on (ul.url) ul.id from url_list as ul
+					join user_short_url as usu on usu.url_id=ul.id
+					where ul.short_url = ANY($1) and usu.user_id=(select id from users where uuid=$2 limit 1)
+						and ul.deleted_at >= $3
+						and (ul.expires_at is null or ul.expires_at > (now() at time zone 'utc'))
+						and not exists (select 1 from url_list as active where active.url=ul.url and active.deleted_at is null)
+					order by ul.url, ul.deleted_at desc
+				)
+				update url_list set deleted_at=null where id in (select id from candidates) returning short_url`,
+		shortURLs, userUUID, deletedSince.UTC())
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове RestoreShortURLs(%s) произошла ошибка %s", userUUID, err)
+		return nil, wrapPgError(err)
+	}
+	defer rows.Close()
+	restored := make([]string, 0, len(shortURLs))
+	for rows.Next() {
+		var shortURL string
+		if err = rows.Scan(&shortURL); err != nil {
+			return nil, err
+		}
+		restored = append(restored, shortURL)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapPgError(err)
+	}
+	return restored, nil
+}
+
 // GetCountShortURL кол-во сокращенных URL
-func (p *PostgresStorage) GetCountShortURL() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) GetCountShortURL(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	var cnt int64
-	rows, err := p.DB.QueryContext(ctx, `select count(*) as cnt from url_list`)
+	rows, err := p.DB.QueryContext(ctx, `select count(*) as cnt from url_list where deleted_at is null`)
 	if err != nil {
 		return cnt, err
 	}
+	defer rows.Close()
 	err = rows.Err()
 	if err != nil {
 		return cnt, err
@@ -298,14 +683,15 @@ func (p *PostgresStorage) GetCountShortURL() (int64, error) {
 }
 
 // GetCountUser кол-во пользвателей
-func (p *PostgresStorage) GetCountUser() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), config.DataBaseConnectionTimeOut*time.Second)
+func (p *PostgresStorage) GetCountUser(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
 	defer cancel()
 	var cnt int64
 	rows, err := p.DB.QueryContext(ctx, `select count(*) as cnt from users`)
 	if err != nil {
 		return cnt, err
 	}
+	defer rows.Close()
 	err = rows.Err()
 	if err != nil {
 		return cnt, err
@@ -319,3 +705,173 @@ func (p *PostgresStorage) GetCountUser() (int64, error) {
 
 	return cnt, nil
 }
+
+// SoftDeleteExpiredURLs пометка ссылок с истёкшим сроком жизни как удалённых.
+func (p *PostgresStorage) SoftDeleteExpiredURLs(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	result, err := p.DB.ExecContext(ctx, `update url_list set deleted_at=(now() at time zone 'utc')
+				where expires_at is not null and expires_at <= (now() at time zone 'utc') and deleted_at is null`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PurgeDeletedURLs окончательное удаление ссылок, удалённых раньше deletedBefore, начиная с самых старых.
+// Связи с пользователями и история удаляются каскадом, переходы по ссылкам удаляются тем же запросом.
+func (p *PostgresStorage) PurgeDeletedURLs(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	var cnt int64
+	err := p.DB.QueryRowContext(ctx, `with purged as (
+					delete from url_list where id in (
+						select id from url_list where deleted_at < $1 order by deleted_at limit $2
+					) returning short_url
+				), purged_clicks as (
+					delete from url_clicks where short_url in (select short_url from purged)
+				)
+				select count(*) from purged`, deletedBefore.UTC(), limit).Scan(&cnt)
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове PurgeDeletedURLs произошла ошибка %s", err)
+		return 0, err
+	}
+	return cnt, nil
+}
+
+// AddClick сохраняет переход по короткой ссылке.
+func (p *PostgresStorage) AddClick(ctx context.Context, click models.Click) error {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	_, err := p.DB.ExecContext(ctx, `insert into url_clicks (short_url, created_at, referer, user_agent, remote_ip) values ($1, $2, $3, $4, $5)`,
+		click.ShortURL, click.CreatedAt.UTC(), click.Referer, click.UserAgent, click.RemoteIP)
+	return err
+}
+
+// AddClicks сохраняет пачку переходов одним запросом.
+func (p *PostgresStorage) AddClicks(ctx context.Context, clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	const columns = 5
+	var query strings.Builder
+	query.WriteString(`insert into url_clicks (short_url, created_at, referer, user_agent, remote_ip) values `)
+	args := make([]any, 0, len(clicks)*columns)
+	for i, click := range clicks {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * columns
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5)
+		args = append(args, click.ShortURL, click.CreatedAt.UTC(), click.Referer, click.UserAgent, click.RemoteIP)
+	}
+	_, err := p.DB.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// GetClickStats статистика переходов по короткой ссылке.
+func (p *PostgresStorage) GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	rows, err := p.DB.QueryContext(
+		ctx,
+		`select to_char(created_at, 'YYYY-MM-DD') as day, count(*) as cnt from url_clicks
+				where short_url = $1 group by day order by day asc`,
+		shortURL,
+	)
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове GetClickStats(%s) произошла ошибка %s", shortURL, err)
+		return nil, err
+	}
+	err = rows.Err()
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове GetClickStats(%s) произошла ошибка %s", shortURL, err)
+		return nil, err
+	}
+	stats := &models.ClickStats{
+		Days: make([]models.ClickDay, 0),
+	}
+	for rows.Next() {
+		var day models.ClickDay
+		err = rows.Scan(&day.Date, &day.Clicks)
+		if err != nil {
+			logger.LogSugar.Errorf("При обработке значений в GetClickStats(%s) произошла ошибка %s", shortURL, err)
+			return nil, err
+		}
+		stats.Total += day.Clicks
+		stats.Days = append(stats.Days, day)
+	}
+
+	return stats, nil
+}
+
+// AddAPIKey сохраняет ключ доступа.
+func (p *PostgresStorage) AddAPIKey(ctx context.Context, key models.APIKey) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	row := p.DB.QueryRowContext(ctx, `insert into api_keys (user_uuid, name, key_hash, created_at) values ($1, $2, $3, $4) returning id`,
+		key.UserUUID, key.Name, key.KeyHash, key.CreatedAt.UTC())
+	var id int64
+	err := row.Scan(&id)
+	if err != nil {
+		return 0, wrapPgError(err)
+	}
+	return id, nil
+}
+
+// FindAPIKeyByHash поиск действующего ключа доступа по хэшу.
+func (p *PostgresStorage) FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	rows, err := p.DB.QueryContext(
+		ctx,
+		"select id, user_uuid::text, name, key_hash, created_at from api_keys where key_hash = $1 and revoked_at is null limit 1",
+		keyHash,
+	)
+	if err != nil {
+		logger.LogSugar.Errorf("При вызове FindAPIKeyByHash произошла ошибка %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, ErrAPIKeyNotFound
+	}
+	key := models.APIKey{}
+	err = rows.Scan(&key.ID, &key.UserUUID, &key.Name, &key.KeyHash, &key.CreatedAt)
+	if err != nil {
+		logger.LogSugar.Errorf("При обработке значений ключа доступа произошла ошибка %s", err)
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey отзыв ключа доступа.
+func (p *PostgresStorage) RevokeAPIKey(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, config.DataBaseConnectionTimeOut)
+	defer cancel()
+	result, err := p.DB.ExecContext(ctx, `update api_keys set revoked_at = (now() at time zone 'utc') where id = $1 and revoked_at is null`, id)
+	if err != nil {
+		return err
+	}
+	cnt, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// nullTime нулевое время сохраняется в БД как NULL.
+func nullTime(value time.Time) sql.NullTime {
+	if value.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: value.UTC(), Valid: true}
+}
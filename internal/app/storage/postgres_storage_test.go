@@ -3,10 +3,15 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/northmule/shorturl/internal/app/logger"
 	mocks "github.com/northmule/shorturl/internal/app/storage/mocks"
 	"github.com/northmule/shorturl/internal/app/storage/models"
@@ -33,7 +38,7 @@ func TestPostgresStorage_Add(t *testing.T) {
 		defer ctrl.Finish()
 		m := mocks.NewMockDBQuery(ctrl)
 		row := &sql.Row{}
-		m.EXPECT().QueryRowContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(row)
+		m.EXPECT().QueryRowContext(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(row)
 		storage := PostgresStorage{DB: m}
 		defer func() {
 			// вызов Next в Add
@@ -41,7 +46,7 @@ func TestPostgresStorage_Add(t *testing.T) {
 				logger.LogSugar.Infof("Recovered in %v", r)
 			}
 		}()
-		storage.Add(models.URL{})
+		storage.Add(context.Background(), models.URL{})
 	})
 
 }
@@ -61,7 +66,7 @@ func TestPostgresStorage_FindByShortURL(t *testing.T) {
 				logger.LogSugar.Infof("Recovered in %v", r)
 			}
 		}()
-		storage.FindByShortURL("")
+		storage.FindByShortURL(context.Background(), "")
 	})
 }
 
@@ -80,7 +85,7 @@ func TestPostgresStorage_FindByURL(t *testing.T) {
 				logger.LogSugar.Infof("Recovered in %v", r)
 			}
 		}()
-		storage.FindByURL("")
+		storage.FindByURL(context.Background(), "")
 	})
 }
 
@@ -92,13 +97,23 @@ func TestPostgresStorage_Ping(t *testing.T) {
 		m := mocks.NewMockDBQuery(ctrl)
 		m.EXPECT().PingContext(gomock.Any()).Return(nil)
 		storage := PostgresStorage{DB: m}
-		err := storage.Ping()
+		err := storage.Ping(context.Background())
 		if err != nil {
 			fmt.Println(err)
 		}
 	})
 }
 
+// arrayValueConverter передаёт срезы строк как есть, как это делает pgx для параметров-массивов.
+type arrayValueConverter struct{}
+
+func (arrayValueConverter) ConvertValue(v any) (driver.Value, error) {
+	if values, ok := v.([]string); ok {
+		return values, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
 type PostgresStorageTestSuite struct {
 	suite.Suite
 	mock sqlmock.Sqlmock
@@ -108,7 +123,7 @@ type PostgresStorageTestSuite struct {
 
 func (o *PostgresStorageTestSuite) SetupTest() {
 	var err error
-	o.DB, o.mock, err = sqlmock.New()
+	o.DB, o.mock, err = sqlmock.New(sqlmock.ValueConverterOption(arrayValueConverter{}))
 	o.pg = PostgresStorage{
 		DB:    o.DB,
 		RawDB: o.DB,
@@ -131,28 +146,249 @@ func (o *PostgresStorageTestSuite) TestMultiAdd() {
 
 	exp := o.mock.ExpectPrepare("insert into")
 	for _, url := range urls {
-		exp.ExpectExec().WithArgs(url.ShortURL, url.URL).
+		exp.ExpectExec().WithArgs(url.ShortURL, url.URL, nullTime(url.ExpiresAt)).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 	}
 	o.mock.ExpectCommit()
-	err := o.pg.MultiAdd(urls)
+	err := o.pg.MultiAdd(context.Background(), urls)
+	require.NoError(o.T(), err)
+
+}
+
+func (o *PostgresStorageTestSuite) TestMultiAddPrepareError() {
+	o.mock.ExpectBegin()
+	o.mock.ExpectPrepare("insert into").WillReturnError(errors.New("prepare failed"))
+	// Транзакция откатывается и не удерживает соединение
+	o.mock.ExpectRollback()
+	err := o.pg.MultiAdd(context.Background(), []models.URL{{URL: "https://ya.ru/1", ShortURL: "abc123"}})
+	require.Error(o.T(), err)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
+}
+
+func (o *PostgresStorageTestSuite) TestImportURLsDriverUnsupported() {
+	imported, err := o.pg.ImportURLs(context.Background(), "111-222-333", []models.URL{
+		{URL: "https://ya.ru/1", ShortURL: "abc123"},
+	})
+	require.Error(o.T(), err)
+	require.Nil(o.T(), imported)
+}
+
+func (o *PostgresStorageTestSuite) TestExportUserURLs() {
+	createdAt := time.Now().UTC()
+	deletedAt := createdAt.Add(time.Hour)
+	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url, ul.created_at, ul.deleted_at, ul.expires_at, c.cnt from url_list").
+		WithArgs("111-222-333").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_url", "url", "created_at", "deleted_at", "expires_at", "cnt"}).
+			AddRow(1, "abc123", "https://ya.ru/1", createdAt, nil, nil, 5).
+			AddRow(2, "abc321", "https://ya.ru/2", createdAt, deletedAt, nil, 0))
+
+	var urls []models.ExportURL
+	err := o.pg.ExportUserURLs(context.Background(), "111-222-333", func(url models.ExportURL) error {
+		urls = append(urls, url)
+		return nil
+	})
+	require.NoError(o.T(), err)
+	require.Len(o.T(), urls, 2)
+	require.Equal(o.T(), int64(5), urls[0].Clicks)
+	require.True(o.T(), urls[0].DeletedAt.IsZero())
+	require.Equal(o.T(), deletedAt, urls[1].DeletedAt)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
+}
+
+func (o *PostgresStorageTestSuite) TestFindUserURLs() {
+	columns := []string{"id", "short_url", "url", "created_at", "deleted_at", "expires_at"}
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	createdFrom := createdAt.Add(-time.Hour)
+	o.mock.ExpectQuery(`and ul.deleted_at is null and ul.created_at >= \$2 and lower\(substring\(ul.url .+\)\) = \$3 and \(ul.url ilike \$4 or ul.short_url ilike \$4\) order by ul.created_at desc, ul.id desc limit \$5`).
+		WithArgs("111-222-333", createdFrom, "ya.ru", `%100\%%`, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(2, "abc321", "https://ya.ru/100%", createdAt, nil, nil).
+			AddRow(1, "abc123", "https://ya.ru/100%/1", createdAt, nil, nil))
+	page, err := o.pg.FindUserURLs(context.Background(), "111-222-333", models.URLFilter{
+		Limit:       1,
+		CreatedFrom: createdFrom,
+		Host:        "YA.ru",
+		Search:      "100%",
+		Sort:        models.SortCreatedDesc,
+	})
+	require.NoError(o.T(), err)
+	require.Len(o.T(), page.URLs, 1)
+	require.Equal(o.T(), "abc321", page.URLs[0].ShortURL)
+	require.NotEmpty(o.T(), page.NextCursor)
+
+	o.mock.ExpectQuery(`\(ul.created_at, ul.id\) < \(\$2, \$3\) order by ul.created_at desc, ul.id desc limit \$4`).
+		WithArgs("111-222-333", createdAt, int64(2), models.URLPageDefaultLimit+1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "abc123", "https://ya.ru/100%/1", createdAt, createdAt, nil))
+	page, err = o.pg.FindUserURLs(context.Background(), "111-222-333", models.URLFilter{
+		Cursor:         page.NextCursor,
+		IncludeDeleted: true,
+		Sort:           models.SortCreatedDesc,
+	})
+	require.NoError(o.T(), err)
+	require.Len(o.T(), page.URLs, 1)
+	require.Equal(o.T(), createdAt, page.URLs[0].DeletedAt)
+	require.Empty(o.T(), page.NextCursor)
+
+	_, err = o.pg.FindUserURLs(context.Background(), "111-222-333", models.URLFilter{Cursor: "???"})
+	require.ErrorIs(o.T(), err, ErrFilterInvalid)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
+}
+
+func (o *PostgresStorageTestSuite) TestUpdateUserURL() {
+	columns := []string{"id", "short_url", "url", "created_at", "deleted_at", "expires_at", "user_id"}
+	createdAt := time.Now().UTC()
+
+	o.mock.ExpectBegin()
+	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url, ul.created_at, ul.deleted_at, ul.expires_at, usu.user_id from url_list").
+		WithArgs("abc123", "111-222-333").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "abc123", "https://ya.ru/1", createdAt, nil, nil, 7))
+	o.mock.ExpectExec("insert into url_history").
+		WithArgs(uint(1), int64(7), "https://ya.ru/1", "https://ya.ru/2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	o.mock.ExpectExec("update url_list set url").
+		WithArgs("https://ya.ru/2", uint(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	o.mock.ExpectCommit()
+	url, err := o.pg.UpdateUserURL(context.Background(), "111-222-333", "abc123", "https://ya.ru/2")
 	require.NoError(o.T(), err)
+	require.Equal(o.T(), "https://ya.ru/2", url.URL)
 
+	o.mock.ExpectBegin()
+	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url").
+		WithArgs("abc123", "111-222-333").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "abc123", "https://ya.ru/1", createdAt, nil, nil, 7))
+	o.mock.ExpectExec("insert into url_history").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	o.mock.ExpectExec("update url_list set url").
+		WillReturnError(&pgconn.PgError{Code: CodeErrorDuplicateKey, Message: "duplicate key"})
+	o.mock.ExpectRollback()
+	_, err = o.pg.UpdateUserURL(context.Background(), "111-222-333", "abc123", "https://ya.ru/3")
+	require.ErrorIs(o.T(), err, ErrConflict)
+
+	o.mock.ExpectBegin()
+	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url").
+		WithArgs("abc123", "111-222-333").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "abc123", "https://ya.ru/1", createdAt, createdAt, nil, 7))
+	o.mock.ExpectRollback()
+	_, err = o.pg.UpdateUserURL(context.Background(), "111-222-333", "abc123", "https://ya.ru/3")
+	require.ErrorIs(o.T(), err, ErrGone)
+
+	o.mock.ExpectBegin()
+	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url").
+		WithArgs("abc123", "444-555-666").
+		WillReturnRows(sqlmock.NewRows(columns))
+	o.mock.ExpectRollback()
+	_, err = o.pg.UpdateUserURL(context.Background(), "444-555-666", "abc123", "https://ya.ru/3")
+	require.ErrorIs(o.T(), err, ErrNotFound)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
+}
+
+func (o *PostgresStorageTestSuite) TestRestoreShortURLs() {
+	deletedSince := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	o.mock.ExpectQuery(`select distinct on \(ul.url\) ul.id from url_list(.|\n)+update url_list set deleted_at=null`).
+		WithArgs([]string{"abc123", "abc321"}, "111-222-333", deletedSince).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("abc123"))
+	restored, err := o.pg.RestoreShortURLs(context.Background(), "111-222-333", deletedSince, "abc123", "abc321")
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), []string{"abc123"}, restored)
+
+	o.mock.ExpectQuery("update url_list set deleted_at=null").
+		WillReturnError(&pgconn.PgError{Code: CodeErrorDuplicateKey, Message: "duplicate key"})
+	_, err = o.pg.RestoreShortURLs(context.Background(), "111-222-333", deletedSince, "abc123")
+	require.ErrorIs(o.T(), err, ErrConflict)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
+}
+
+func (o *PostgresStorageTestSuite) TestAddConflict() {
+	o.mock.ExpectQuery("insert into url_list").
+		WithArgs("abc123", "https://ya.ru/1", sql.NullTime{}).
+		WillReturnError(&pgconn.PgError{Code: CodeErrorDuplicateKey, Message: "duplicate key"})
+	_, err := o.pg.Add(context.Background(), models.URL{ShortURL: "abc123", URL: "https://ya.ru/1"})
+	require.ErrorIs(o.T(), err, ErrConflict)
+	var pgErr *pgconn.PgError
+	require.False(o.T(), errors.As(err, &pgErr))
+}
+
+func (o *PostgresStorageTestSuite) TestFindByShortURLErrors() {
+	columns := []string{"id", "short_url", "url", "deleted_at", "expires_at"}
+	o.mock.ExpectQuery("select id, short_url, url, deleted_at, expires_at from url_list").
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(columns))
+	url, err := o.pg.FindByShortURL(context.Background(), "unknown")
+	require.ErrorIs(o.T(), err, ErrNotFound)
+	require.Nil(o.T(), url)
+
+	deletedAt := time.Now().UTC()
+	o.mock.ExpectQuery("select id, short_url, url, deleted_at, expires_at from url_list").
+		WithArgs("abc123").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "abc123", "https://ya.ru/1", deletedAt, nil))
+	url, err = o.pg.FindByShortURL(context.Background(), "abc123")
+	require.ErrorIs(o.T(), err, ErrGone)
+	require.Equal(o.T(), "https://ya.ru/1", url.URL)
+	require.Equal(o.T(), deletedAt, url.DeletedAt)
+
+	o.mock.ExpectQuery("select id, short_url, url, expires_at from url_list").
+		WithArgs("https://ya.ru/2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_url", "url", "expires_at"}))
+	url, err = o.pg.FindByURL(context.Background(), "https://ya.ru/2")
+	require.ErrorIs(o.T(), err, ErrNotFound)
+	require.Nil(o.T(), url)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
 }
 
 func (o *PostgresStorageTestSuite) TestFindUserByLoginAndPasswordHash() {
 	login := "cat"
 	pwd := "has_has"
 
-	o.mock.ExpectQuery("select id, name, login, password from users").
+	o.mock.ExpectQuery("select id, name, login, password, (.+) from users").
 		WithArgs(login, pwd).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "login", "password"}).
-			AddRow("1", "Кот в Сапогах", "cat", "has_has"))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "login", "password", "uuid"}).
+			AddRow("1", "Кот в Сапогах", "cat", "has_has", "111-222-333"))
 
-	user, err := o.pg.FindUserByLoginAndPasswordHash(login, pwd)
+	user, err := o.pg.FindUserByLoginAndPasswordHash(context.Background(), login, pwd)
 	require.NoError(o.T(), err)
 	require.Equal(o.T(), login, user.Login)
+	require.Equal(o.T(), "111-222-333", user.UUID)
+
+	o.mock.ExpectQuery("select id, name, login, password, (.+) from users").
+		WithArgs(login, "wrong").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "login", "password", "uuid"}))
+	user, err = o.pg.FindUserByLoginAndPasswordHash(context.Background(), login, "wrong")
+	require.ErrorIs(o.T(), err, ErrNotFound)
+	require.Nil(o.T(), user)
+}
+
+func (o *PostgresStorageTestSuite) TestFindUserByUUID() {
+	o.mock.ExpectQuery("select id, name, (.+) from users where uuid").
+		WithArgs("111-222-333").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "login", "password", "uuid"}).
+			AddRow("1", "", "", "", "111-222-333"))
+
+	user, err := o.pg.FindUserByUUID(context.Background(), "111-222-333")
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), "111-222-333", user.UUID)
+	require.Equal(o.T(), "", user.Login)
+}
+
+func (o *PostgresStorageTestSuite) TestRegisterUser() {
+	testUser := models.User{
+		Name:     "Кот в Сапогах",
+		Login:    "cat",
+		Password: "has_has",
+		UUID:     "111-222-333",
+	}
+	o.mock.ExpectExec("update users set name").
+		WithArgs(testUser.Name, testUser.Login, testUser.Password, testUser.UUID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	err := o.pg.RegisterUser(context.Background(), testUser)
+	require.NoError(o.T(), err)
+
+	o.mock.ExpectExec("update users set name").
+		WithArgs(testUser.Name, testUser.Login, testUser.Password, testUser.UUID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err = o.pg.RegisterUser(context.Background(), testUser)
+	require.Error(o.T(), err)
 }
 
 func (o *PostgresStorageTestSuite) TestCreateUser() {
@@ -167,27 +403,31 @@ func (o *PostgresStorageTestSuite) TestCreateUser() {
 		WithArgs(testUser.Name, testUser.Login, testUser.Password, testUser.UUID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	_, err := o.pg.CreateUser(testUser)
+	_, err := o.pg.CreateUser(context.Background(), testUser)
 	require.NoError(o.T(), err)
 
 }
 
 func (o *PostgresStorageTestSuite) TestFindUrlsByUserID() {
 	userUUID := "1111-2222-3333-4444"
-	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url").
+	deletedAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	o.mock.ExpectQuery("select ul.id, ul.short_url, ul.url, ul.deleted_at").
 		WithArgs(userUUID).
-		WillReturnRows(sqlmock.NewRows([]string{"ul.id", "ul.short_url", "ul.url"}).
-			AddRow("1", "short123", "https://yandex.ru"))
-	urls, err := o.pg.FindUrlsByUserID(userUUID)
+		WillReturnRows(sqlmock.NewRows([]string{"ul.id", "ul.short_url", "ul.url", "ul.deleted_at"}).
+			AddRow("1", "short123", "https://yandex.ru", nil).
+			AddRow("2", "short456", "https://ozon.ru", deletedAt))
+	urls, err := o.pg.FindUrlsByUserID(context.Background(), userUUID)
 	require.NoError(o.T(), err)
-	require.Equal(o.T(), 1, len(*urls))
+	require.Equal(o.T(), 2, len(*urls))
+	require.True(o.T(), (*urls)[0].DeletedAt.IsZero())
+	require.Equal(o.T(), deletedAt, (*urls)[1].DeletedAt)
 }
 
 func (o *PostgresStorageTestSuite) TestGetCountUser() {
 	o.mock.ExpectQuery("select count").
 		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).
 			AddRow(43))
-	cnt, err := o.pg.GetCountUser()
+	cnt, err := o.pg.GetCountUser(context.Background())
 	require.NoError(o.T(), err)
 	require.Equal(o.T(), int64(43), cnt)
 }
@@ -196,7 +436,113 @@ func (o *PostgresStorageTestSuite) TestGetGetCountShortURL() {
 	o.mock.ExpectQuery("select count").
 		WillReturnRows(sqlmock.NewRows([]string{"cnt"}).
 			AddRow(51))
-	cnt, err := o.pg.GetCountShortURL()
+	cnt, err := o.pg.GetCountShortURL(context.Background())
 	require.NoError(o.T(), err)
 	require.Equal(o.T(), int64(51), cnt)
 }
+
+func (o *PostgresStorageTestSuite) TestSoftDeleteExpiredURLs() {
+	o.mock.ExpectExec("update url_list set deleted_at").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	cnt, err := o.pg.SoftDeleteExpiredURLs(context.Background())
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), int64(3), cnt)
+}
+
+func (o *PostgresStorageTestSuite) TestPurgeDeletedURLs() {
+	deletedBefore := time.Date(2026, 9, 18, 10, 0, 0, 0, time.UTC)
+	o.mock.ExpectQuery("delete from url_list").
+		WithArgs(deletedBefore, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	cnt, err := o.pg.PurgeDeletedURLs(context.Background(), deletedBefore, 100)
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), int64(7), cnt)
+
+	o.mock.ExpectQuery("delete from url_list").
+		WillReturnError(errors.New("db error"))
+	_, err = o.pg.PurgeDeletedURLs(context.Background(), deletedBefore, 100)
+	require.Error(o.T(), err)
+}
+
+func (o *PostgresStorageTestSuite) TestAddClick() {
+	click := models.Click{
+		ShortURL:  "short123",
+		CreatedAt: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+		Referer:   "https://example.com",
+		UserAgent: "test-agent",
+		RemoteIP:  "10.0.0.1",
+	}
+	o.mock.ExpectExec("insert into url_clicks").
+		WithArgs(click.ShortURL, click.CreatedAt, click.Referer, click.UserAgent, click.RemoteIP).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	err := o.pg.AddClick(context.Background(), click)
+	require.NoError(o.T(), err)
+}
+
+func (o *PostgresStorageTestSuite) TestGetClickStats() {
+	o.mock.ExpectQuery("select to_char").
+		WithArgs("short123").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "cnt"}).
+			AddRow("2026-10-17", 2).
+			AddRow("2026-10-18", 5))
+	stats, err := o.pg.GetClickStats(context.Background(), "short123")
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), int64(7), stats.Total)
+	require.Equal(o.T(), []models.ClickDay{
+		{Date: "2026-10-17", Clicks: 2},
+		{Date: "2026-10-18", Clicks: 5},
+	}, stats.Days)
+}
+
+func (o *PostgresStorageTestSuite) TestAddClicks() {
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	clicks := []models.Click{
+		{ShortURL: "short1", CreatedAt: createdAt, Referer: "https://example.com", UserAgent: "agent", RemoteIP: "10.0.0.1"},
+		{ShortURL: "short2", CreatedAt: createdAt},
+	}
+	o.mock.ExpectExec(regexp.QuoteMeta("insert into url_clicks (short_url, created_at, referer, user_agent, remote_ip) values ($1, $2, $3, $4, $5), ($6, $7, $8, $9, $10)")).
+		WithArgs("short1", createdAt, "https://example.com", "agent", "10.0.0.1", "short2", createdAt, "", "", "").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	err := o.pg.AddClicks(context.Background(), clicks)
+	require.NoError(o.T(), err)
+
+	err = o.pg.AddClicks(context.Background(), nil)
+	require.NoError(o.T(), err)
+}
+
+func (o *PostgresStorageTestSuite) TestAPIKeys() {
+	createdAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	key := models.APIKey{UserUUID: "111-222-333", Name: "billing", KeyHash: "hash", CreatedAt: createdAt}
+
+	o.mock.ExpectQuery("insert into api_keys").
+		WithArgs(key.UserUUID, key.Name, key.KeyHash, createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	id, err := o.pg.AddAPIKey(context.Background(), key)
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), int64(7), id)
+
+	o.mock.ExpectQuery("select id, user_uuid::text, name, key_hash, created_at from api_keys").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_uuid", "name", "key_hash", "created_at"}).AddRow(7, key.UserUUID, key.Name, key.KeyHash, createdAt))
+	found, err := o.pg.FindAPIKeyByHash(context.Background(), "hash")
+	require.NoError(o.T(), err)
+	require.Equal(o.T(), key.UserUUID, found.UserUUID)
+
+	o.mock.ExpectQuery("select id, user_uuid::text, name, key_hash, created_at from api_keys").
+		WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_uuid", "name", "key_hash", "created_at"}))
+	found, err = o.pg.FindAPIKeyByHash(context.Background(), "unknown")
+	require.ErrorIs(o.T(), err, ErrNotFound)
+	require.Nil(o.T(), found)
+
+	o.mock.ExpectExec("update api_keys set revoked_at").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(o.T(), o.pg.RevokeAPIKey(context.Background(), 7))
+
+	o.mock.ExpectExec("update api_keys set revoked_at").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.ErrorIs(o.T(), o.pg.RevokeAPIKey(context.Background(), 7), ErrAPIKeyNotFound)
+	require.NoError(o.T(), o.mock.ExpectationsWereMet())
+}
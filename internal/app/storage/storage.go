@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"os"
+	"time"
 
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/db"
@@ -12,28 +14,62 @@ import (
 
 // Storage Общие интерфейс всех методов хранилищ
 type Storage interface {
-	// Add добавляет URL.
-	Add(url models.URL) (int64, error)
+	// Add добавляет URL, при занятом коде вернёт ErrConflict.
+	Add(ctx context.Context, url models.URL) (int64, error)
 	// CreateUser создание пользователя.
-	CreateUser(user models.User) (int64, error)
+	CreateUser(ctx context.Context, user models.User) (int64, error)
 	// LikeURLToUser связывает пользователя с ссылкой.
-	LikeURLToUser(urlID int64, userUUID string) error
-	// FindByShortURL поиск по короткой ссылке.
-	FindByShortURL(shortURL string) (*models.URL, error)
-	// FindByURL поиск по URL.
-	FindByURL(url string) (*models.URL, error)
+	LikeURLToUser(ctx context.Context, urlID int64, userUUID string) error
+	// FindByShortURL поиск по короткой ссылке, ErrNotFound если её нет, удалённая ссылка вернётся вместе с ErrGone.
+	FindByShortURL(ctx context.Context, shortURL string) (*models.URL, error)
+	// FindByURL поиск действующей ссылки по URL, ErrNotFound если её нет.
+	FindByURL(ctx context.Context, url string) (*models.URL, error)
 	// Ping проверка соединения с БД.
-	Ping() error
-	// MultiAdd вставка массива адресов.
-	MultiAdd(urls []models.URL) error
+	Ping(ctx context.Context) error
+	// MultiAdd вставка массива адресов, уже сокращённые ссылки пропускаются.
+	MultiAdd(ctx context.Context, urls []models.URL) error
+	// ImportURLs вставка пачки импортируемых ссылок, ссылки с занятым URL или кодом пропускаются.
+	ImportURLs(ctx context.Context, userUUID string, urls []models.URL) ([]models.URL, error)
 	// FindUrlsByUserID поиск ссылок пользователя
-	FindUrlsByUserID(userUUID string) (*[]models.URL, error)
+	FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error)
+	// FindUserURLs страница ссылок пользователя по отбору, при неверном курсоре или порядке вернёт ErrFilterInvalid.
+	FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error)
+	// ExportUserURLs выгрузка ссылок пользователя, ссылки передаются в fn по мере чтения, ошибка fn прерывает выгрузку.
+	ExportUserURLs(ctx context.Context, userUUID string, fn func(models.ExportURL) error) error
+	// UpdateUserURL смена URL ссылки её владельцем, прежний URL сохраняется в историю.
+	// ErrNotFound если ссылка не принадлежит пользователю, ErrGone для удалённой, ErrConflict если URL занят другой ссылкой.
+	UpdateUserURL(ctx context.Context, userUUID string, shortURL string, url string) (*models.URL, error)
 	// SoftDeletedShortURL пометка ссылки как удалённой.
-	SoftDeletedShortURL(userUUID string, shortURL ...string) error
+	SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error
+	// RestoreShortURLs снимает пометку удаления со ссылок пользователя, удалённых не раньше deletedSince.
+	// Ссылка с истёкшим сроком жизни или с URL, занятым действующей ссылкой, не восстанавливается; вернёт восстановленные коды.
+	RestoreShortURLs(ctx context.Context, userUUID string, deletedSince time.Time, shortURLs ...string) ([]string, error)
+	// FindUserByUUID поиск пользователя по uuid, ErrNotFound если его нет.
+	FindUserByUUID(ctx context.Context, userUUID string) (*models.User, error)
+	// FindUserByLoginAndPasswordHash поиск пользователя по логину и хэшу пароля, ErrNotFound если его нет.
+	FindUserByLoginAndPasswordHash(ctx context.Context, login string, passwordHash string) (*models.User, error)
+	// RegisterUser регистрация анонимного пользователя, при занятом логине вернёт ErrConflict.
+	RegisterUser(ctx context.Context, user models.User) error
 	// GetCountShortURL количество коротких ссылок
-	GetCountShortURL() (int64, error)
+	GetCountShortURL(ctx context.Context) (int64, error)
 	// GetCountUser количество пользователей
-	GetCountUser() (int64, error)
+	GetCountUser(ctx context.Context) (int64, error)
+	// SoftDeleteExpiredURLs пометка ссылок с истёкшим сроком жизни как удалённых.
+	SoftDeleteExpiredURLs(ctx context.Context) (int64, error)
+	// PurgeDeletedURLs окончательно удаляет не больше limit ссылок, удалённых раньше deletedBefore, вместе с их переходами.
+	PurgeDeletedURLs(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+	// AddClick сохраняет переход по короткой ссылке.
+	AddClick(ctx context.Context, click models.Click) error
+	// AddClicks сохраняет пачку переходов по коротким ссылкам.
+	AddClicks(ctx context.Context, clicks []models.Click) error
+	// GetClickStats статистика переходов по короткой ссылке.
+	GetClickStats(ctx context.Context, shortURL string) (*models.ClickStats, error)
+	// AddAPIKey сохраняет ключ доступа.
+	AddAPIKey(ctx context.Context, key models.APIKey) (int64, error)
+	// FindAPIKeyByHash поиск действующего ключа доступа по хэшу.
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// RevokeAPIKey отзыв ключа доступа.
+	RevokeAPIKey(ctx context.Context, id int64) error
 }
 
 // NewStorage Создаёт нужный storage
@@ -56,15 +92,27 @@ func NewStorage(ctx context.Context, cfg *config.Config) (Storage, error) {
 	}
 
 	if cfg.FileStoragePath != "" {
+		options, err := NewFileStorageOptionsFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
 		file, err := os.OpenFile(cfg.FileStoragePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 		if err != nil {
 			logger.LogSugar.Errorf("Failed to open file %s: error: %s", cfg.FileStoragePath, err)
 			return nil, err
 		}
 		s := NewFileStorage(file)
+		if s == nil {
+			return nil, errors.New("failed to init file storage " + cfg.FileStoragePath)
+		}
+		s.StartMaintenance(ctx, options)
 		return s, nil
 	}
 
 	s := NewMemoryStorage()
+	if err := s.EnableSnapshots(ctx, NewMemorySnapshotOptionsFromConfig(cfg)); err != nil {
+		logger.LogSugar.Errorf("Failed to load memory snapshot %s: error: %s", cfg.MemorySnapshotPath, err)
+		return nil, err
+	}
 	return s, nil
 }
@@ -0,0 +1,304 @@
+// Package storagetest общие проверки поведения хранилищ ссылок.
+// Любая реализация storage.Storage прогоняет их через Run, чтобы хранилища вели себя одинаково.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Пользователи проверок, uuid записаны так, как их принимает колонка uuid в Postgres.
+const (
+	userA = "8d1c2a34-5b6e-4f70-9a81-b2c3d4e5f601"
+	userB = "8d1c2a34-5b6e-4f70-9a81-b2c3d4e5f602"
+	userC = "8d1c2a34-5b6e-4f70-9a81-b2c3d4e5f603"
+)
+
+// Factory создаёт пустое хранилище для одной проверки, освобождение ресурсов регистрируется через t.Cleanup.
+type Factory func(t *testing.T) storage.Storage
+
+// Run прогоняет все проверки, каждая получает своё хранилище.
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s storage.Storage)
+	}{
+		{name: "#1_добавление_и_поиск", run: testAdd},
+		{name: "#2_повторное_добавление", run: testDuplicate},
+		{name: "#3_пакетная_вставка", run: testBatch},
+		{name: "#4_связь_с_пользователем", run: testLinkToUser},
+		{name: "#5_мягкое_удаление", run: testSoftDelete},
+		{name: "#6_количество_ссылок_и_пользователей", run: testCounts},
+		{name: "#7_конкурентная_запись", run: testConcurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStorage(t))
+		})
+	}
+}
+
+func testAdd(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	id, err := s.Add(ctx, models.URL{ShortURL: "short1", URL: "https://ya.ru/1"})
+	require.NoError(t, err)
+	assert.Positive(t, id)
+
+	url, err := s.FindByShortURL(ctx, "short1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ya.ru/1", url.URL)
+	assert.Equal(t, uint(id), url.ID)
+	assert.True(t, url.DeletedAt.IsZero())
+
+	url, err = s.FindByURL(ctx, "https://ya.ru/1")
+	require.NoError(t, err)
+	assert.Equal(t, "short1", url.ShortURL)
+
+	url, err = s.FindByShortURL(ctx, "unknown")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Nil(t, url)
+	url, err = s.FindByURL(ctx, "https://ya.ru/unknown")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Nil(t, url)
+}
+
+func testDuplicate(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	_, err := s.Add(ctx, models.URL{ShortURL: "short1", URL: "https://ya.ru/1"})
+	require.NoError(t, err)
+
+	_, err = s.Add(ctx, models.URL{ShortURL: "short1", URL: "https://ya.ru/2"})
+	assert.ErrorIs(t, err, storage.ErrConflict, "занятая короткая ссылка")
+	_, err = s.Add(ctx, models.URL{ShortURL: "short2", URL: "https://ya.ru/1"})
+	assert.ErrorIs(t, err, storage.ErrConflict, "уже сокращённый URL")
+
+	// Прежняя ссылка не перезаписывается
+	url, err := s.FindByURL(ctx, "https://ya.ru/1")
+	require.NoError(t, err)
+	assert.Equal(t, "short1", url.ShortURL)
+	_, err = s.FindByShortURL(ctx, "short2")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func testBatch(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	_, err := s.Add(ctx, models.URL{ShortURL: "exist", URL: "https://ya.ru/exist"})
+	require.NoError(t, err)
+
+	err = s.MultiAdd(ctx, []models.URL{
+		{ShortURL: "short1", URL: "https://ya.ru/1"},
+		{ShortURL: "short2", URL: "https://ya.ru/exist"},
+		{ShortURL: "short3", URL: "https://ya.ru/1"},
+		{ShortURL: "short4", URL: "https://ya.ru/4"},
+	})
+	require.NoError(t, err)
+	for url, shortURL := range map[string]string{
+		"https://ya.ru/1":     "short1",
+		"https://ya.ru/exist": "exist",
+		"https://ya.ru/4":     "short4",
+	} {
+		found, err := s.FindByURL(ctx, url)
+		require.NoError(t, err)
+		assert.Equal(t, shortURL, found.ShortURL, url)
+	}
+	for _, shortURL := range []string{"short2", "short3"} {
+		_, err = s.FindByShortURL(ctx, shortURL)
+		assert.ErrorIs(t, err, storage.ErrNotFound, shortURL)
+	}
+
+	// Занятая короткая ссылка отменяет всю пачку
+	err = s.MultiAdd(ctx, []models.URL{
+		{ShortURL: "short5", URL: "https://ya.ru/5"},
+		{ShortURL: "exist", URL: "https://ya.ru/6"},
+	})
+	assert.ErrorIs(t, err, storage.ErrConflict)
+	_, err = s.FindByShortURL(ctx, "short5")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	err = s.MultiAdd(ctx, []models.URL{
+		{ShortURL: "short6", URL: "https://ya.ru/6"},
+		{ShortURL: "short6", URL: "https://ya.ru/7"},
+	})
+	assert.ErrorIs(t, err, storage.ErrConflict, "короткая ссылка повторяется внутри пачки")
+	_, err = s.FindByShortURL(ctx, "short6")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	cnt, err := s.GetCountShortURL(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cnt)
+}
+
+func testLinkToUser(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	for _, userUUID := range []string{userA, userB} {
+		_, err := s.CreateUser(ctx, models.User{UUID: userUUID})
+		require.NoError(t, err)
+	}
+	for i := 1; i <= 4; i++ {
+		id, err := s.Add(ctx, models.URL{ShortURL: fmt.Sprintf("short%d", i), URL: fmt.Sprintf("https://ya.ru/%d", i)})
+		require.NoError(t, err)
+		require.NoError(t, s.LikeURLToUser(ctx, id, userA))
+	}
+
+	urls, err := s.FindUrlsByUserID(ctx, userA)
+	require.NoError(t, err)
+	shortURLs := make([]string, 0, len(*urls))
+	for _, url := range *urls {
+		shortURLs = append(shortURLs, url.ShortURL)
+	}
+	assert.Equal(t, []string{"short1", "short2", "short3", "short4"}, shortURLs, "ссылки упорядочены по id")
+
+	urls, err = s.FindUrlsByUserID(ctx, userB)
+	require.NoError(t, err)
+	assert.Empty(t, *urls)
+
+	user, err := s.FindUserByUUID(ctx, userA)
+	require.NoError(t, err)
+	assert.Equal(t, userA, user.UUID)
+	_, err = s.FindUserByUUID(ctx, userC)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func testSoftDelete(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userUUID := userA
+	for _, uuid := range []string{userUUID, userB} {
+		_, err := s.CreateUser(ctx, models.User{UUID: uuid})
+		require.NoError(t, err)
+	}
+	id, err := s.Add(ctx, models.URL{ShortURL: "short1", URL: "https://ya.ru/1"})
+	require.NoError(t, err)
+	require.NoError(t, s.LikeURLToUser(ctx, id, userUUID))
+
+	// Чужая ссылка не удаляется
+	require.NoError(t, s.SoftDeletedShortURL(ctx, userB, "short1"))
+	_, err = s.FindByShortURL(ctx, "short1")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SoftDeletedShortURL(ctx, userUUID, "short1", "unknown"))
+	url, err := s.FindByShortURL(ctx, "short1")
+	assert.ErrorIs(t, err, storage.ErrGone)
+	require.NotNil(t, url)
+	assert.Equal(t, "https://ya.ru/1", url.URL)
+	assert.False(t, url.DeletedAt.IsZero())
+	_, err = s.FindByURL(ctx, "https://ya.ru/1")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	// Удалённая ссылка остаётся у владельца с временем удаления
+	urls, err := s.FindUrlsByUserID(ctx, userUUID)
+	require.NoError(t, err)
+	require.Len(t, *urls, 1)
+	assert.Equal(t, "short1", (*urls)[0].ShortURL)
+	assert.False(t, (*urls)[0].DeletedAt.IsZero())
+
+	// URL удалённой ссылки можно сократить заново
+	_, err = s.Add(ctx, models.URL{ShortURL: "short2", URL: "https://ya.ru/1"})
+	require.NoError(t, err)
+	url, err = s.FindByURL(ctx, "https://ya.ru/1")
+	require.NoError(t, err)
+	assert.Equal(t, "short2", url.ShortURL)
+}
+
+func testCounts(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	cnt, err := s.GetCountShortURL(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), cnt)
+	cnt, err = s.GetCountUser(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	userUUID := userA
+	_, err = s.CreateUser(ctx, models.User{UUID: userUUID})
+	require.NoError(t, err)
+	// Повторное создание не добавляет пользователя
+	_, err = s.CreateUser(ctx, models.User{UUID: userUUID})
+	require.NoError(t, err)
+	_, err = s.CreateUser(ctx, models.User{UUID: userB})
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		id, err := s.Add(ctx, models.URL{ShortURL: fmt.Sprintf("short%d", i), URL: fmt.Sprintf("https://ya.ru/%d", i)})
+		require.NoError(t, err)
+		require.NoError(t, s.LikeURLToUser(ctx, id, userUUID))
+	}
+	require.NoError(t, s.SoftDeletedShortURL(ctx, userUUID, "short2"))
+
+	cnt, err = s.GetCountShortURL(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cnt, "удалённые ссылки не учитываются")
+	cnt, err = s.GetCountUser(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cnt)
+}
+
+func testConcurrency(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userUUID := userA
+	const workers = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.CreateUser(ctx, models.User{UUID: userUUID}); err != nil {
+				errs <- err
+				return
+			}
+			id, err := s.Add(ctx, models.URL{ShortURL: fmt.Sprintf("short%d", i), URL: fmt.Sprintf("https://ya.ru/%d", i)})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err = s.LikeURLToUser(ctx, id, userUUID); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	cnt, err := s.GetCountShortURL(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers), cnt)
+	cnt, err = s.GetCountUser(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cnt)
+	urls, err := s.FindUrlsByUserID(ctx, userUUID)
+	require.NoError(t, err)
+	assert.Len(t, *urls, workers)
+
+	// Один и тот же URL сокращается только одной ссылкой
+	var added, conflicts int
+	var mx sync.Mutex
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Add(ctx, models.URL{ShortURL: fmt.Sprintf("same%d", i), URL: "https://ya.ru/same"})
+			mx.Lock()
+			defer mx.Unlock()
+			switch {
+			case err == nil:
+				added++
+			case errors.Is(err, storage.ErrConflict):
+				conflicts++
+			default:
+				t.Errorf("Add() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, added)
+	assert.Equal(t, workers-1, conflicts)
+}
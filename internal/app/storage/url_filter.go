@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	netURL "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// urlCursor позиция последней ссылки страницы в порядке (created_at, id).
+type urlCursor struct {
+	createdAt time.Time
+	id        uint
+}
+
+// encodeURLCursor курсор страницы, следующей за ссылкой.
+func encodeURLCursor(url models.URL) string {
+	value := strconv.FormatInt(url.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatUint(uint64(url.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// decodeURLCursor разбор курсора, пустой курсор означает первую страницу.
+func decodeURLCursor(cursor string) (*urlCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("cursor: %w", ErrFilterInvalid)
+	}
+	createdAtRaw, idRaw, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("cursor: %w", ErrFilterInvalid)
+	}
+	createdAt, err := strconv.ParseInt(createdAtRaw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cursor: %w", ErrFilterInvalid)
+	}
+	id, err := strconv.ParseUint(idRaw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cursor: %w", ErrFilterInvalid)
+	}
+	return &urlCursor{createdAt: time.Unix(0, createdAt).UTC(), id: uint(id)}, nil
+}
+
+// normalizeURLFilter значения по умолчанию для размера страницы и порядка.
+func normalizeURLFilter(filter models.URLFilter) (models.URLFilter, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = models.URLPageDefaultLimit
+	}
+	if filter.Limit > models.URLPageMaxLimit {
+		filter.Limit = models.URLPageMaxLimit
+	}
+	switch filter.Sort {
+	case "":
+		filter.Sort = models.SortCreatedAsc
+	case models.SortCreatedAsc, models.SortCreatedDesc:
+	default:
+		return filter, fmt.Errorf("sort %s: %w", filter.Sort, ErrFilterInvalid)
+	}
+	filter.Host = strings.ToLower(filter.Host)
+	return filter, nil
+}
+
+// urlHost домен ссылки в нижнем регистре.
+func urlHost(url string) string {
+	parsed, err := netURL.Parse(url)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// matchURLFilter подходит ли ссылка под отбор, курсор не учитывается.
+func matchURLFilter(url models.URL, filter models.URLFilter) bool {
+	if !filter.IncludeDeleted && !url.DeletedAt.IsZero() {
+		return false
+	}
+	if !filter.CreatedFrom.IsZero() && url.CreatedAt.Before(filter.CreatedFrom) {
+		return false
+	}
+	if !filter.CreatedTo.IsZero() && !url.CreatedAt.Before(filter.CreatedTo) {
+		return false
+	}
+	if filter.Host != "" && urlHost(url.URL) != filter.Host {
+		return false
+	}
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		if !strings.Contains(strings.ToLower(url.URL), search) && !strings.Contains(strings.ToLower(url.ShortURL), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareURLs порядок ссылок по (created_at, id).
+func compareURLs(a, b models.URL) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// paginateURLs отбор, сортировка и постраничная выдача ссылок для хранилищ без собственных индексов.
+func paginateURLs(urls []models.URL, filter models.URLFilter) (*models.URLPage, error) {
+	filter, err := normalizeURLFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeURLCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	direction := 1
+	if filter.Sort == models.SortCreatedDesc {
+		direction = -1
+	}
+
+	matched := make([]models.URL, 0, len(urls))
+	for _, url := range urls {
+		if !matchURLFilter(url, filter) {
+			continue
+		}
+		if cursor != nil {
+			position := compareURLs(url, models.URL{CreatedAt: cursor.createdAt, ID: cursor.id})
+			if position*direction <= 0 {
+				continue
+			}
+		}
+		matched = append(matched, url)
+	}
+	slices.SortFunc(matched, func(a, b models.URL) int {
+		return compareURLs(a, b) * direction
+	})
+	return newURLPage(matched, filter.Limit), nil
+}
+
+// newURLPage страница из отсортированных ссылок, выбранных с запасом на одну ссылку для определения следующей страницы.
+func newURLPage(urls []models.URL, limit int) *models.URLPage {
+	page := &models.URLPage{URLs: urls}
+	if len(urls) > limit {
+		page.URLs = urls[:limit]
+		page.NextCursor = encodeURLCursor(page.URLs[limit-1])
+	}
+	return page
+}
@@ -0,0 +1,204 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+)
+
+// OverflowPolicy поведение конвейера при заполненной очереди.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop событие отбрасывается, переход по ссылке не ждёт записи статистики.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock отправитель ждёт освобождения места в очереди.
+	OverflowBlock
+)
+
+// ErrClickDropped событие перехода отброшено.
+var ErrClickDropped = errors.New("click event dropped")
+
+// ClickPipelineOptions параметры конвейера событий переходов.
+type ClickPipelineOptions struct {
+	// Размер очереди событий
+	QueueSize int
+	// Количество воркеров записи
+	Workers int
+	// Количество событий, при котором пачка записывается не дожидаясь интервала
+	BatchSize int
+	// Максимальное время ожидания записи неполной пачки
+	FlushInterval time.Duration
+	// Поведение при заполненной очереди
+	Policy OverflowPolicy
+}
+
+// DefaultClickPipelineOptions параметры конвейера по умолчанию.
+func DefaultClickPipelineOptions() ClickPipelineOptions {
+	return ClickPipelineOptions{
+		QueueSize:     10000,
+		Workers:       2,
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		Policy:        OverflowDrop,
+	}
+}
+
+// ClickWriter пакетная запись переходов по ссылкам.
+type ClickWriter interface {
+	AddClicks(ctx context.Context, clicks []models.Click) error
+}
+
+// ClickPipeline буферизированный конвейер записи переходов по ссылкам.
+type ClickPipeline struct {
+	writer   ClickWriter
+	options  ClickPipelineOptions
+	events   chan models.Click
+	stopChan <-chan struct{}
+	dropped  atomic.Int64
+	wg       sync.WaitGroup
+	// Постановка в очередь идёт под чтением, закрытие под записью:
+	// после closed ни одно событие не попадёт в очередь, которую уже вычитали воркеры
+	mx     sync.RWMutex
+	closed bool
+}
+
+// NewClickPipeline конструктор.
+func NewClickPipeline(writer ClickWriter, options ClickPipelineOptions, stop <-chan struct{}) *ClickPipeline {
+	defaults := DefaultClickPipelineOptions()
+	if options.QueueSize <= 0 {
+		options.QueueSize = defaults.QueueSize
+	}
+	if options.Workers <= 0 {
+		options.Workers = defaults.Workers
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaults.BatchSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaults.FlushInterval
+	}
+
+	instance := &ClickPipeline{
+		writer:   writer,
+		options:  options,
+		events:   make(chan models.Click, options.QueueSize),
+		stopChan: stop,
+	}
+
+	instance.wg.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go instance.worker()
+	}
+
+	return instance
+}
+
+// AddClick ставит событие перехода в очередь на запись, после остановки событие отбрасывается.
+func (p *ClickPipeline) AddClick(ctx context.Context, click models.Click) error {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	if p.closed {
+		p.dropped.Add(1)
+		return ErrClickDropped
+	}
+
+	if p.options.Policy == OverflowBlock {
+		select {
+		case p.events <- click:
+			return nil
+		case <-p.stopChan:
+			p.dropped.Add(1)
+			return ErrClickDropped
+		case <-ctx.Done():
+			p.dropped.Add(1)
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case p.events <- click:
+		return nil
+	default:
+		p.dropped.Add(1)
+		return ErrClickDropped
+	}
+}
+
+// QueueDepth количество событий, ожидающих записи.
+func (p *ClickPipeline) QueueDepth() int {
+	return len(p.events)
+}
+
+// Dropped количество отброшенных событий.
+func (p *ClickPipeline) Dropped() int64 {
+	return p.dropped.Load()
+}
+
+// Wait ожидает записи оставшихся в очереди событий после сигнала остановки.
+func (p *ClickPipeline) Wait() {
+	p.wg.Wait()
+}
+
+func (p *ClickPipeline) worker() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.options.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]models.Click, 0, p.options.BatchSize)
+	for {
+		select {
+		case <-p.stopChan:
+			logger.LogSugar.Info("Поступил сигнал о закрытии воркера статистики переходов")
+			p.close()
+			p.drain(batch)
+			return
+		case click := <-p.events:
+			batch = append(batch, click)
+			if len(batch) >= p.options.BatchSize {
+				batch = p.flush(batch)
+			}
+		case <-ticker.C:
+			batch = p.flush(batch)
+		}
+	}
+}
+
+// close запрещает постановку в очередь и дожидается отправителей, уже начавших её.
+func (p *ClickPipeline) close() {
+	p.mx.Lock()
+	p.closed = true
+	p.mx.Unlock()
+}
+
+// drain записывает всё, что осталось в очереди на момент остановки.
+func (p *ClickPipeline) drain(batch []models.Click) {
+	for {
+		select {
+		case click := <-p.events:
+			batch = append(batch, click)
+			if len(batch) >= p.options.BatchSize {
+				batch = p.flush(batch)
+			}
+		default:
+			p.flush(batch)
+			return
+		}
+	}
+}
+
+func (p *ClickPipeline) flush(batch []models.Click) []models.Click {
+	if len(batch) == 0 {
+		return batch
+	}
+	err := p.writer.AddClicks(context.Background(), batch)
+	if err != nil {
+		p.dropped.Add(int64(len(batch)))
+		logger.LogSugar.Errorf("Не удалось записать %d переходов по ссылкам: %s", len(batch), err)
+	}
+	return batch[:0]
+}
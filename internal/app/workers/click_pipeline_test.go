@@ -0,0 +1,235 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClickWriter struct {
+	mx      sync.Mutex
+	batches [][]models.Click
+	err     error
+	block   chan struct{}
+}
+
+func (m *mockClickWriter) AddClicks(ctx context.Context, clicks []models.Click) error {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	batch := make([]models.Click, len(clicks))
+	copy(batch, clicks)
+	m.batches = append(m.batches, batch)
+	return m.err
+}
+
+func (m *mockClickWriter) total() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	var cnt int
+	for _, batch := range m.batches {
+		cnt += len(batch)
+	}
+	return cnt
+}
+
+func (m *mockClickWriter) batchCount() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.batches)
+}
+
+func TestClickPipeline_FlushOnBatchSize(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize:     10,
+		Workers:       1,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+	}, stop)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	}
+
+	assert.Eventually(t, func() bool {
+		return writer.total() == 3
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, writer.batchCount())
+}
+
+func TestClickPipeline_FlushOnInterval(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize:     10,
+		Workers:       1,
+		BatchSize:     100,
+		FlushInterval: 10 * time.Millisecond,
+	}, stop)
+
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+
+	assert.Eventually(t, func() bool {
+		return writer.total() == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestClickPipeline_DropWhenFull(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{block: make(chan struct{})}
+	stop := make(chan struct{})
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize:     2,
+		Workers:       1,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		Policy:        OverflowDrop,
+	}, stop)
+
+	// Первое событие забирает воркер и зависает на записи
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	assert.Eventually(t, func() bool {
+		return pipeline.QueueDepth() == 0
+	}, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	assert.Equal(t, 2, pipeline.QueueDepth())
+
+	err := pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"})
+	assert.ErrorIs(t, err, ErrClickDropped)
+	assert.Equal(t, int64(1), pipeline.Dropped())
+
+	close(writer.block)
+	close(stop)
+	pipeline.Wait()
+	assert.Equal(t, 3, writer.total())
+}
+
+func TestClickPipeline_BlockWhenFull(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{block: make(chan struct{})}
+	stop := make(chan struct{})
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize:     1,
+		Workers:       1,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		Policy:        OverflowBlock,
+	}, stop)
+
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	assert.Eventually(t, func() bool {
+		return pipeline.QueueDepth() == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+
+	added := make(chan error)
+	go func() {
+		added <- pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"})
+	}()
+
+	select {
+	case <-added:
+		t.Fatal("Ожидается ожидание места в очереди")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(writer.block)
+	assert.NoError(t, <-added)
+	close(stop)
+	pipeline.Wait()
+	assert.Equal(t, 3, writer.total())
+	assert.Equal(t, int64(0), pipeline.Dropped())
+}
+
+func TestClickPipeline_DrainOnStop(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{}
+	stop := make(chan struct{})
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize:     100,
+		Workers:       2,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	}, stop)
+
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	}
+	close(stop)
+	pipeline.Wait()
+
+	assert.Equal(t, 25, writer.total())
+	assert.ErrorIs(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}), ErrClickDropped)
+}
+
+func TestClickPipeline_AddDuringStop(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{}
+	stop := make(chan struct{})
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize:     1000,
+		Workers:       2,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	}, stop)
+
+	const senders, clicks = 10, 100
+	var wg sync.WaitGroup
+	wg.Add(senders)
+	for i := 0; i < senders; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < clicks; j++ {
+				_ = pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"})
+			}
+		}()
+	}
+	close(stop)
+	wg.Wait()
+	pipeline.Wait()
+
+	// Каждое событие либо записано, либо учтено как отброшенное
+	assert.Equal(t, int64(senders*clicks), int64(writer.total())+pipeline.Dropped())
+	assert.Equal(t, 0, pipeline.QueueDepth())
+}
+
+func TestClickPipeline_WriteError(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	writer := &mockClickWriter{err: errors.New("error")}
+	stop := make(chan struct{})
+
+	pipeline := NewClickPipeline(writer, ClickPipelineOptions{
+		QueueSize: 10,
+		Workers:   1,
+		BatchSize: 2,
+	}, stop)
+
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	assert.NoError(t, pipeline.AddClick(context.Background(), models.Click{ShortURL: "aaa"}))
+	close(stop)
+	pipeline.Wait()
+
+	assert.Equal(t, int64(2), pipeline.Dropped())
+}
@@ -0,0 +1,108 @@
+package workers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+// PurgeInterval период поиска давно удалённых ссылок.
+const PurgeInterval = time.Hour
+
+// PurgeOptions параметры окончательного удаления ссылок.
+type PurgeOptions struct {
+	// Сколько времени удалённая ссылка хранится до окончательного удаления
+	Retention time.Duration
+	// Период запуска очистки
+	Interval time.Duration
+	// Количество ссылок, удаляемых одним запросом
+	BatchSize int
+	// Пауза между пачками, чтобы не занимать хранилище надолго
+	BatchPause time.Duration
+}
+
+// NewPurgeOptionsFromConfig параметры очистки из настроек приложения.
+func NewPurgeOptionsFromConfig(cfg *config.Config) PurgeOptions {
+	return PurgeOptions{
+		Retention:  cfg.PurgeRetention,
+		Interval:   PurgeInterval,
+		BatchSize:  cfg.PurgeBatchSize,
+		BatchPause: cfg.PurgeBatchPause,
+	}
+}
+
+// DeletedPurger окончательно удаляет давно удалённые ссылки.
+type DeletedPurger interface {
+	PurgeDeletedURLs(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// PurgeWorker воркер, окончательно удаляющий ссылки, удалённые раньше срока хранения.
+type PurgeWorker struct {
+	purger   DeletedPurger
+	options  PurgeOptions
+	stopChan <-chan struct{}
+	purged   atomic.Int64
+}
+
+// NewPurgeWorker конструктор.
+func NewPurgeWorker(purger DeletedPurger, options PurgeOptions, stop <-chan struct{}) *PurgeWorker {
+	instance := &PurgeWorker{
+		purger:   purger,
+		options:  options,
+		stopChan: stop,
+	}
+
+	go instance.worker()
+
+	return instance
+}
+
+// Purged количество ссылок, окончательно удалённых с момента запуска.
+func (p *PurgeWorker) Purged() int64 {
+	return p.purged.Load()
+}
+
+func (p *PurgeWorker) worker() {
+	ticker := time.NewTicker(p.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopChan:
+			logger.LogSugar.Info("Поступил сигнал о закрытии воркера очистки удалённых ссылок")
+			return
+		case <-ticker.C:
+			p.purge()
+		}
+	}
+}
+
+// purge удаляет ссылки пачками, пока очередная пачка не окажется неполной.
+func (p *PurgeWorker) purge() {
+	deletedBefore := time.Now().Add(-p.options.Retention)
+	var total int64
+	defer func() {
+		if total > 0 {
+			logger.LogSugar.Infof("Окончательно удалено ссылок: %d", total)
+		}
+	}()
+	for {
+		cnt, err := p.purger.PurgeDeletedURLs(context.Background(), deletedBefore, p.options.BatchSize)
+		if err != nil {
+			logger.LogSugar.Errorf("Не удалось окончательно удалить ссылки: %s", err)
+			return
+		}
+		total += cnt
+		p.purged.Add(cnt)
+		if cnt < int64(p.options.BatchSize) {
+			return
+		}
+		select {
+		case <-p.stopChan:
+			return
+		case <-time.After(p.options.BatchPause):
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDeletedPurger struct {
+	calls atomic.Int64
+	// сколько ссылок осталось удалить
+	left atomic.Int64
+	err  error
+}
+
+func (m *mockDeletedPurger) PurgeDeletedURLs(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	m.calls.Add(1)
+	if m.err != nil {
+		return 0, m.err
+	}
+	cnt := min(m.left.Load(), int64(limit))
+	m.left.Add(-cnt)
+	return cnt, nil
+}
+
+func TestPurgeWorker(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	mockPurger := &mockDeletedPurger{}
+	mockPurger.left.Store(25)
+	stopChan := make(chan struct{})
+
+	options := PurgeOptions{Retention: time.Hour, Interval: 10 * time.Millisecond, BatchSize: 10, BatchPause: time.Millisecond}
+	worker := NewPurgeWorker(mockPurger, options, stopChan)
+	time.Sleep(55 * time.Millisecond)
+	close(stopChan)
+
+	assert.Equal(t, int64(25), worker.Purged())
+	// Три пачки в первый запуск (10, 10, 5) и хотя бы один пустой запуск после
+	assert.GreaterOrEqual(t, mockPurger.calls.Load(), int64(4))
+}
+
+func TestPurgeWorker_Error(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	mockPurger := &mockDeletedPurger{err: errors.New("db error")}
+	mockPurger.left.Store(25)
+	stopChan := make(chan struct{})
+
+	options := PurgeOptions{Retention: time.Hour, Interval: 10 * time.Millisecond, BatchSize: 10, BatchPause: time.Millisecond}
+	worker := NewPurgeWorker(mockPurger, options, stopChan)
+	time.Sleep(35 * time.Millisecond)
+	close(stopChan)
+
+	assert.Equal(t, int64(0), worker.Purged())
+	assert.Greater(t, mockPurger.calls.Load(), int64(0))
+}
@@ -1,6 +1,8 @@
 package workers
 
 import (
+	"context"
+
 	"github.com/northmule/shorturl/internal/app/logger"
 )
 
@@ -31,7 +33,7 @@ func NewWorker(deleter Deleter, stop <-chan struct{}) *Worker {
 
 // Deleter в фоне удаляет адреса пользователей.
 type Deleter interface {
-	SoftDeletedShortURL(userUUID string, shortURL ...string) error
+	SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error
 }
 
 type job struct {
@@ -67,7 +69,7 @@ func (w *Worker) worker() {
 			return
 		case jobs := <-w.jobChan:
 			logger.LogSugar.Infof("Удаляю ссылки %v для пользователя %s", jobs.url, jobs.userUUID)
-			err := w.deleter.SoftDeletedShortURL(jobs.userUUID, jobs.url...)
+			err := w.deleter.SoftDeletedShortURL(context.Background(), jobs.userUUID, jobs.url...)
 			if err != nil {
 				logger.LogSugar.Infof(err.Error())
 			}
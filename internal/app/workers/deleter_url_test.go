@@ -1,6 +1,7 @@
 package workers
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -12,7 +13,7 @@ type MockDeleter struct {
 	DeleteCalled bool
 }
 
-func (m *MockDeleter) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
+func (m *MockDeleter) SoftDeletedShortURL(ctx context.Context, userUUID string, shortURL ...string) error {
 	m.DeleteCalled = true
 	return nil
 }
@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+// ExpiredSweepInterval период поиска ссылок с истёкшим сроком жизни.
+const ExpiredSweepInterval = time.Minute
+
+// ExpiredSweeper воркер, помечающий ссылки с истёкшим сроком жизни как удалённые.
+type ExpiredSweeper struct {
+	deleter  ExpiredDeleter
+	interval time.Duration
+	stopChan <-chan struct{}
+}
+
+// ExpiredDeleter удаляет ссылки с истёкшим сроком жизни.
+type ExpiredDeleter interface {
+	SoftDeleteExpiredURLs(ctx context.Context) (int64, error)
+}
+
+// NewExpiredSweeper конструктор.
+func NewExpiredSweeper(deleter ExpiredDeleter, interval time.Duration, stop <-chan struct{}) *ExpiredSweeper {
+	instance := ExpiredSweeper{
+		deleter:  deleter,
+		interval: interval,
+		stopChan: stop,
+	}
+
+	go instance.sweeper()
+
+	return &instance
+}
+
+func (s *ExpiredSweeper) sweeper() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopChan:
+			logger.LogSugar.Info("Поступил сигнал о закрытии воркера истёкших ссылок")
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+func (s *ExpiredSweeper) sweep() {
+	cnt, err := s.deleter.SoftDeleteExpiredURLs(context.Background())
+	if err != nil {
+		logger.LogSugar.Errorf("Не удалось удалить ссылки с истёкшим сроком жизни: %s", err)
+		return
+	}
+	if cnt > 0 {
+		logger.LogSugar.Infof("Удалено ссылок с истёкшим сроком жизни: %d", cnt)
+	}
+}
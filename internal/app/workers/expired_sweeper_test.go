@@ -0,0 +1,39 @@
+package workers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+)
+
+type mockExpiredDeleter struct {
+	calls atomic.Int64
+}
+
+func (m *mockExpiredDeleter) SoftDeleteExpiredURLs(ctx context.Context) (int64, error) {
+	m.calls.Add(1)
+	return 1, nil
+}
+
+func TestExpiredSweeper(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	mockDeleter := &mockExpiredDeleter{}
+	stopChan := make(chan struct{})
+
+	NewExpiredSweeper(mockDeleter, 10*time.Millisecond, stopChan)
+	time.Sleep(55 * time.Millisecond)
+	close(stopChan)
+
+	calls := mockDeleter.calls.Load()
+	if calls == 0 {
+		t.Error("Expected SoftDeleteExpiredURLs to be called")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if mockDeleter.calls.Load() > calls+1 {
+		t.Error("Expected sweeper to stop after stop signal")
+	}
+}
@@ -0,0 +1,159 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.12.4
+// source: shorturl/account.proto
+
+package contract
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AccountHandler_Register_FullMethodName = "/contract.AccountHandler/Register"
+	AccountHandler_Login_FullMethodName    = "/contract.AccountHandler/Login"
+)
+
+// AccountHandlerClient is the client API for AccountHandler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AccountHandlerClient interface {
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+}
+
+type accountHandlerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAccountHandlerClient(cc grpc.ClientConnInterface) AccountHandlerClient {
+	return &accountHandlerClient{cc}
+}
+
+func (c *accountHandlerClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, AccountHandler_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *accountHandlerClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, AccountHandler_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AccountHandlerServer is the server API for AccountHandler service.
+// All implementations must embed UnimplementedAccountHandlerServer
+// for forward compatibility.
+type AccountHandlerServer interface {
+	Register(context.Context, *RegisterRequest) (*AccountResponse, error)
+	Login(context.Context, *LoginRequest) (*AccountResponse, error)
+	mustEmbedUnimplementedAccountHandlerServer()
+}
+
+// UnimplementedAccountHandlerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAccountHandlerServer struct{}
+
+func (UnimplementedAccountHandlerServer) Register(context.Context, *RegisterRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedAccountHandlerServer) Login(context.Context, *LoginRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAccountHandlerServer) mustEmbedUnimplementedAccountHandlerServer() {}
+func (UnimplementedAccountHandlerServer) testEmbeddedByValue()                        {}
+
+// UnsafeAccountHandlerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AccountHandlerServer will
+// result in compilation errors.
+type UnsafeAccountHandlerServer interface {
+	mustEmbedUnimplementedAccountHandlerServer()
+}
+
+func RegisterAccountHandlerServer(s grpc.ServiceRegistrar, srv AccountHandlerServer) {
+	// If the following call pancis, it indicates UnimplementedAccountHandlerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AccountHandler_ServiceDesc, srv)
+}
+
+func _AccountHandler_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountHandlerServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountHandler_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountHandlerServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AccountHandler_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountHandlerServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountHandler_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountHandlerServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AccountHandler_ServiceDesc is the grpc.ServiceDesc for AccountHandler service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AccountHandler_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "contract.AccountHandler",
+	HandlerType: (*AccountHandlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _AccountHandler_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _AccountHandler_Login_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "shorturl/account.proto",
+}
@@ -0,0 +1,268 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        v3.12.4
+// source: shorturl/analytics.proto
+
+package contract
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type URLStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShortUrl string `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+}
+
+func (x *URLStatsRequest) Reset() {
+	*x = URLStatsRequest{}
+	mi := &file_shorturl_analytics_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLStatsRequest) ProtoMessage() {}
+
+func (x *URLStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_shorturl_analytics_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLStatsRequest.ProtoReflect.Descriptor instead.
+func (*URLStatsRequest) Descriptor() ([]byte, []int) {
+	return file_shorturl_analytics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *URLStatsRequest) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+type URLStatsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ShortUrl string                  `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	Total    int64                   `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Days     []*URLStatsResponse_Day `protobuf:"bytes,3,rep,name=days,proto3" json:"days,omitempty"`
+}
+
+func (x *URLStatsResponse) Reset() {
+	*x = URLStatsResponse{}
+	mi := &file_shorturl_analytics_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLStatsResponse) ProtoMessage() {}
+
+func (x *URLStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_shorturl_analytics_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLStatsResponse.ProtoReflect.Descriptor instead.
+func (*URLStatsResponse) Descriptor() ([]byte, []int) {
+	return file_shorturl_analytics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *URLStatsResponse) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *URLStatsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *URLStatsResponse) GetDays() []*URLStatsResponse_Day {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+type URLStatsResponse_Day struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Date   string `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Clicks int64  `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
+}
+
+func (x *URLStatsResponse_Day) Reset() {
+	*x = URLStatsResponse_Day{}
+	mi := &file_shorturl_analytics_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *URLStatsResponse_Day) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*URLStatsResponse_Day) ProtoMessage() {}
+
+func (x *URLStatsResponse_Day) ProtoReflect() protoreflect.Message {
+	mi := &file_shorturl_analytics_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use URLStatsResponse_Day.ProtoReflect.Descriptor instead.
+func (*URLStatsResponse_Day) Descriptor() ([]byte, []int) {
+	return file_shorturl_analytics_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *URLStatsResponse_Day) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *URLStatsResponse_Day) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+var File_shorturl_analytics_proto protoreflect.FileDescriptor
+
+var file_shorturl_analytics_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x75, 0x72, 0x6c, 0x2f, 0x61, 0x6e, 0x61, 0x6c, 0x79,
+	0x74, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x63, 0x6f, 0x6e, 0x74,
+	0x72, 0x61, 0x63, 0x74, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70, 0x69,
+	0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0x2e, 0x0a, 0x0f, 0x55, 0x52, 0x4c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x5f, 0x75,
+	0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x55,
+	0x72, 0x6c, 0x22, 0xac, 0x01, 0x0a, 0x10, 0x55, 0x52, 0x4c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x68, 0x6f, 0x72, 0x74,
+	0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x68, 0x6f, 0x72,
+	0x74, 0x55, 0x72, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x32, 0x0a, 0x04, 0x64, 0x61,
+	0x79, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x61, 0x63, 0x74, 0x2e, 0x55, 0x52, 0x4c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x44, 0x61, 0x79, 0x52, 0x04, 0x64, 0x61, 0x79, 0x73, 0x1a, 0x31,
+	0x0a, 0x03, 0x44, 0x61, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6c, 0x69,
+	0x63, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x63, 0x6c, 0x69, 0x63, 0x6b,
+	0x73, 0x32, 0x7f, 0x0a, 0x10, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x74, 0x69, 0x63, 0x73, 0x48, 0x61,
+	0x6e, 0x64, 0x6c, 0x65, 0x72, 0x12, 0x6b, 0x0a, 0x08, 0x55, 0x52, 0x4c, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x12, 0x19, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x2e, 0x55, 0x52, 0x4c,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x2e, 0x55, 0x52, 0x4c, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x28, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x22,
+	0x12, 0x20, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x75, 0x73, 0x65, 0x72, 0x2f, 0x75, 0x72, 0x6c, 0x73,
+	0x2f, 0x7b, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x5f, 0x75, 0x72, 0x6c, 0x7d, 0x2f, 0x73, 0x74, 0x61,
+	0x74, 0x73, 0x42, 0x0b, 0x5a, 0x09, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x74, 0x2f, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_shorturl_analytics_proto_rawDescOnce sync.Once
+	file_shorturl_analytics_proto_rawDescData = file_shorturl_analytics_proto_rawDesc
+)
+
+func file_shorturl_analytics_proto_rawDescGZIP() []byte {
+	file_shorturl_analytics_proto_rawDescOnce.Do(func() {
+		file_shorturl_analytics_proto_rawDescData = protoimpl.X.CompressGZIP(file_shorturl_analytics_proto_rawDescData)
+	})
+	return file_shorturl_analytics_proto_rawDescData
+}
+
+var file_shorturl_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_shorturl_analytics_proto_goTypes = []any{
+	(*URLStatsRequest)(nil),      // 0: contract.URLStatsRequest
+	(*URLStatsResponse)(nil),     // 1: contract.URLStatsResponse
+	(*URLStatsResponse_Day)(nil), // 2: contract.URLStatsResponse.Day
+}
+var file_shorturl_analytics_proto_depIdxs = []int32{
+	2, // 0: contract.URLStatsResponse.days:type_name -> contract.URLStatsResponse.Day
+	0, // 1: contract.AnalyticsHandler.URLStats:input_type -> contract.URLStatsRequest
+	1, // 2: contract.AnalyticsHandler.URLStats:output_type -> contract.URLStatsResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_shorturl_analytics_proto_init() }
+func file_shorturl_analytics_proto_init() {
+	if File_shorturl_analytics_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_shorturl_analytics_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_shorturl_analytics_proto_goTypes,
+		DependencyIndexes: file_shorturl_analytics_proto_depIdxs,
+		MessageInfos:      file_shorturl_analytics_proto_msgTypes,
+	}.Build()
+	File_shorturl_analytics_proto = out.File
+	file_shorturl_analytics_proto_rawDesc = nil
+	file_shorturl_analytics_proto_goTypes = nil
+	file_shorturl_analytics_proto_depIdxs = nil
+}
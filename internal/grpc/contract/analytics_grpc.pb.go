@@ -0,0 +1,121 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.12.4
+// source: shorturl/analytics.proto
+
+package contract
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnalyticsHandler_URLStats_FullMethodName = "/contract.AnalyticsHandler/URLStats"
+)
+
+// AnalyticsHandlerClient is the client API for AnalyticsHandler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalyticsHandlerClient interface {
+	URLStats(ctx context.Context, in *URLStatsRequest, opts ...grpc.CallOption) (*URLStatsResponse, error)
+}
+
+type analyticsHandlerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyticsHandlerClient(cc grpc.ClientConnInterface) AnalyticsHandlerClient {
+	return &analyticsHandlerClient{cc}
+}
+
+func (c *analyticsHandlerClient) URLStats(ctx context.Context, in *URLStatsRequest, opts ...grpc.CallOption) (*URLStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(URLStatsResponse)
+	err := c.cc.Invoke(ctx, AnalyticsHandler_URLStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnalyticsHandlerServer is the server API for AnalyticsHandler service.
+// All implementations must embed UnimplementedAnalyticsHandlerServer
+// for forward compatibility.
+type AnalyticsHandlerServer interface {
+	URLStats(context.Context, *URLStatsRequest) (*URLStatsResponse, error)
+	mustEmbedUnimplementedAnalyticsHandlerServer()
+}
+
+// UnimplementedAnalyticsHandlerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnalyticsHandlerServer struct{}
+
+func (UnimplementedAnalyticsHandlerServer) URLStats(context.Context, *URLStatsRequest) (*URLStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method URLStats not implemented")
+}
+func (UnimplementedAnalyticsHandlerServer) mustEmbedUnimplementedAnalyticsHandlerServer() {}
+func (UnimplementedAnalyticsHandlerServer) testEmbeddedByValue()                          {}
+
+// UnsafeAnalyticsHandlerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyticsHandlerServer will
+// result in compilation errors.
+type UnsafeAnalyticsHandlerServer interface {
+	mustEmbedUnimplementedAnalyticsHandlerServer()
+}
+
+func RegisterAnalyticsHandlerServer(s grpc.ServiceRegistrar, srv AnalyticsHandlerServer) {
+	// If the following call pancis, it indicates UnimplementedAnalyticsHandlerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnalyticsHandler_ServiceDesc, srv)
+}
+
+func _AnalyticsHandler_URLStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(URLStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsHandlerServer).URLStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsHandler_URLStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsHandlerServer).URLStats(ctx, req.(*URLStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AnalyticsHandler_ServiceDesc is the grpc.ServiceDesc for AnalyticsHandler service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyticsHandler_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "contract.AnalyticsHandler",
+	HandlerType: (*AnalyticsHandlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "URLStats",
+			Handler:    _AnalyticsHandler_URLStats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "shorturl/analytics.proto",
+}
@@ -0,0 +1,153 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.12.4
+// source: shorturl/import.proto
+
+package contract
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ImportHandler_Import_FullMethodName = "/contract.ImportHandler/Import"
+	ImportHandler_Status_FullMethodName = "/contract.ImportHandler/Status"
+)
+
+// ImportHandlerClient is the client API for ImportHandler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ImportHandlerClient interface {
+	Import(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportRequest, ImportResponse], error)
+	Status(ctx context.Context, in *ImportStatusRequest, opts ...grpc.CallOption) (*ImportResponse, error)
+}
+
+type importHandlerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewImportHandlerClient(cc grpc.ClientConnInterface) ImportHandlerClient {
+	return &importHandlerClient{cc}
+}
+
+func (c *importHandlerClient) Import(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportRequest, ImportResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImportHandler_ServiceDesc.Streams[0], ImportHandler_Import_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportRequest, ImportResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImportHandler_ImportClient = grpc.ClientStreamingClient[ImportRequest, ImportResponse]
+
+func (c *importHandlerClient) Status(ctx context.Context, in *ImportStatusRequest, opts ...grpc.CallOption) (*ImportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportResponse)
+	err := c.cc.Invoke(ctx, ImportHandler_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ImportHandlerServer is the server API for ImportHandler service.
+// All implementations must embed UnimplementedImportHandlerServer
+// for forward compatibility.
+type ImportHandlerServer interface {
+	Import(grpc.ClientStreamingServer[ImportRequest, ImportResponse]) error
+	Status(context.Context, *ImportStatusRequest) (*ImportResponse, error)
+	mustEmbedUnimplementedImportHandlerServer()
+}
+
+// UnimplementedImportHandlerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedImportHandlerServer struct{}
+
+func (UnimplementedImportHandlerServer) Import(grpc.ClientStreamingServer[ImportRequest, ImportResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedImportHandlerServer) Status(context.Context, *ImportStatusRequest) (*ImportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedImportHandlerServer) mustEmbedUnimplementedImportHandlerServer() {}
+func (UnimplementedImportHandlerServer) testEmbeddedByValue()                       {}
+
+// UnsafeImportHandlerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ImportHandlerServer will
+// result in compilation errors.
+type UnsafeImportHandlerServer interface {
+	mustEmbedUnimplementedImportHandlerServer()
+}
+
+func RegisterImportHandlerServer(s grpc.ServiceRegistrar, srv ImportHandlerServer) {
+	// If the following call pancis, it indicates UnimplementedImportHandlerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ImportHandler_ServiceDesc, srv)
+}
+
+func _ImportHandler_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ImportHandlerServer).Import(&grpc.GenericServerStream[ImportRequest, ImportResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImportHandler_ImportServer = grpc.ClientStreamingServer[ImportRequest, ImportResponse]
+
+func _ImportHandler_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImportHandlerServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImportHandler_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImportHandlerServer).Status(ctx, req.(*ImportStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ImportHandler_ServiceDesc is the grpc.ServiceDesc for ImportHandler service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ImportHandler_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "contract.ImportHandler",
+	HandlerType: (*ImportHandlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Status",
+			Handler:    _ImportHandler_Status_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Import",
+			Handler:       _ImportHandler_Import_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "shorturl/import.proto",
+}
@@ -20,16 +20,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UserUrlsHandler_View_FullMethodName   = "/contract.UserUrlsHandler/View"
-	UserUrlsHandler_Delete_FullMethodName = "/contract.UserUrlsHandler/Delete"
+	UserUrlsHandler_View_FullMethodName    = "/contract.UserUrlsHandler/View"
+	UserUrlsHandler_Update_FullMethodName  = "/contract.UserUrlsHandler/Update"
+	UserUrlsHandler_Delete_FullMethodName  = "/contract.UserUrlsHandler/Delete"
+	UserUrlsHandler_Restore_FullMethodName = "/contract.UserUrlsHandler/Restore"
+	UserUrlsHandler_Export_FullMethodName  = "/contract.UserUrlsHandler/Export"
 )
 
 // UserUrlsHandlerClient is the client API for UserUrlsHandler service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type UserUrlsHandlerClient interface {
-	View(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ViewResponse, error)
+	View(ctx context.Context, in *ViewRequest, opts ...grpc.CallOption) (*ViewResponse, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportResponse], error)
 }
 
 type userUrlsHandlerClient struct {
@@ -40,7 +46,7 @@ func NewUserUrlsHandlerClient(cc grpc.ClientConnInterface) UserUrlsHandlerClient
 	return &userUrlsHandlerClient{cc}
 }
 
-func (c *userUrlsHandlerClient) View(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ViewResponse, error) {
+func (c *userUrlsHandlerClient) View(ctx context.Context, in *ViewRequest, opts ...grpc.CallOption) (*ViewResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ViewResponse)
 	err := c.cc.Invoke(ctx, UserUrlsHandler_View_FullMethodName, in, out, cOpts...)
@@ -50,6 +56,16 @@ func (c *userUrlsHandlerClient) View(ctx context.Context, in *empty.Empty, opts
 	return out, nil
 }
 
+func (c *userUrlsHandlerClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateResponse)
+	err := c.cc.Invoke(ctx, UserUrlsHandler_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userUrlsHandlerClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(empty.Empty)
@@ -60,12 +76,44 @@ func (c *userUrlsHandlerClient) Delete(ctx context.Context, in *DeleteRequest, o
 	return out, nil
 }
 
+func (c *userUrlsHandlerClient) Restore(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*RestoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreResponse)
+	err := c.cc.Invoke(ctx, UserUrlsHandler_Restore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userUrlsHandlerClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &UserUrlsHandler_ServiceDesc.Streams[0], UserUrlsHandler_Export_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRequest, ExportResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserUrlsHandler_ExportClient = grpc.ServerStreamingClient[ExportResponse]
+
 // UserUrlsHandlerServer is the server API for UserUrlsHandler service.
 // All implementations must embed UnimplementedUserUrlsHandlerServer
 // for forward compatibility.
 type UserUrlsHandlerServer interface {
-	View(context.Context, *empty.Empty) (*ViewResponse, error)
+	View(context.Context, *ViewRequest) (*ViewResponse, error)
+	Update(context.Context, *UpdateRequest) (*UpdateResponse, error)
 	Delete(context.Context, *DeleteRequest) (*empty.Empty, error)
+	Restore(context.Context, *RestoreRequest) (*RestoreResponse, error)
+	Export(*ExportRequest, grpc.ServerStreamingServer[ExportResponse]) error
 	mustEmbedUnimplementedUserUrlsHandlerServer()
 }
 
@@ -76,12 +124,21 @@ type UserUrlsHandlerServer interface {
 // pointer dereference when methods are called.
 type UnimplementedUserUrlsHandlerServer struct{}
 
-func (UnimplementedUserUrlsHandlerServer) View(context.Context, *empty.Empty) (*ViewResponse, error) {
+func (UnimplementedUserUrlsHandlerServer) View(context.Context, *ViewRequest) (*ViewResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method View not implemented")
 }
+func (UnimplementedUserUrlsHandlerServer) Update(context.Context, *UpdateRequest) (*UpdateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
 func (UnimplementedUserUrlsHandlerServer) Delete(context.Context, *DeleteRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedUserUrlsHandlerServer) Restore(context.Context, *RestoreRequest) (*RestoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedUserUrlsHandlerServer) Export(*ExportRequest, grpc.ServerStreamingServer[ExportResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
 func (UnimplementedUserUrlsHandlerServer) mustEmbedUnimplementedUserUrlsHandlerServer() {}
 func (UnimplementedUserUrlsHandlerServer) testEmbeddedByValue()                         {}
 
@@ -104,7 +161,7 @@ func RegisterUserUrlsHandlerServer(s grpc.ServiceRegistrar, srv UserUrlsHandlerS
 }
 
 func _UserUrlsHandler_View_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(empty.Empty)
+	in := new(ViewRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -116,7 +173,25 @@ func _UserUrlsHandler_View_Handler(srv interface{}, ctx context.Context, dec fun
 		FullMethod: UserUrlsHandler_View_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UserUrlsHandlerServer).View(ctx, req.(*empty.Empty))
+		return srv.(UserUrlsHandlerServer).View(ctx, req.(*ViewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserUrlsHandler_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserUrlsHandlerServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserUrlsHandler_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserUrlsHandlerServer).Update(ctx, req.(*UpdateRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -139,6 +214,35 @@ func _UserUrlsHandler_Delete_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserUrlsHandler_Restore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserUrlsHandlerServer).Restore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserUrlsHandler_Restore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserUrlsHandlerServer).Restore(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserUrlsHandler_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserUrlsHandlerServer).Export(m, &grpc.GenericServerStream[ExportRequest, ExportResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type UserUrlsHandler_ExportServer = grpc.ServerStreamingServer[ExportResponse]
+
 // UserUrlsHandler_ServiceDesc is the grpc.ServiceDesc for UserUrlsHandler service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -150,11 +254,25 @@ var UserUrlsHandler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "View",
 			Handler:    _UserUrlsHandler_View_Handler,
 		},
+		{
+			MethodName: "Update",
+			Handler:    _UserUrlsHandler_Update_Handler,
+		},
 		{
 			MethodName: "Delete",
 			Handler:    _UserUrlsHandler_Delete_Handler,
 		},
+		{
+			MethodName: "Restore",
+			Handler:    _UserUrlsHandler_Restore_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Export",
+			Handler:       _UserUrlsHandler_Export_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "shorturl/user_urls.proto",
 }
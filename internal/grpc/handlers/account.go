@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/grpc/contract"
+	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
+	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AccountHandler хэндлер регистрации и входа пользователей.
+type AccountHandler struct {
+	contract.UnimplementedAccountHandlerServer
+	account *auntificator.Account
+}
+
+// NewAccountHandler конструктор.
+func NewAccountHandler(account *auntificator.Account) *AccountHandler {
+	return &AccountHandler{
+		account: account,
+	}
+}
+
+// Register регистрация текущего пользователя, ссылки созданные до регистрации сохраняются.
+func (a *AccountHandler) Register(ctx context.Context, request *contract.RegisterRequest) (*contract.AccountResponse, error) {
+	userUUID, err := utils.FillUserUUID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+
+	user, err := a.account.Register(ctx, userUUID, request.GetName(), request.GetLogin(), request.GetPassword())
+	if err != nil {
+		switch {
+		case errors.Is(err, auntificator.ErrCredentialsInvalid):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, auntificator.ErrLoginExists), errors.Is(err, auntificator.ErrAlreadyRegistered):
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		default:
+			logger.LogSugar.Error(err)
+			return nil, status.Error(codes.Internal, "error register user")
+		}
+	}
+
+	response := &contract.AccountResponse{
+		Uuid:          user.UUID,
+		Login:         user.Login,
+		Authorization: utils.GetUserToken(ctx),
+	}
+	return response, nil
+}
+
+// Login вход по логину и паролю, токен возвращается в ответе и заголовке authorization.
+func (a *AccountHandler) Login(ctx context.Context, request *contract.LoginRequest) (*contract.AccountResponse, error) {
+	authResult, err := a.account.Login(ctx, request.GetLogin(), request.GetPassword())
+	if err != nil {
+		if errors.Is(err, auntificator.ErrWrongCredentials) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		logger.LogSugar.Error(err)
+		return nil, status.Error(codes.Internal, "error login user")
+	}
+
+	err = grpc.SetHeader(ctx, metadata.Pairs(mData.Authorization, authResult.AuthString))
+	if err != nil {
+		logger.LogSugar.Error(err)
+	}
+
+	response := &contract.AccountResponse{
+		Uuid:          authResult.UserUUID,
+		Login:         request.GetLogin(),
+		Authorization: authResult.AuthString,
+	}
+	return response, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/northmule/shorturl/internal/grpc/contract"
+	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccountHandler_RegisterAndLogin(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.CreateUser(context.Background(), models.User{UUID: "1111-2222-3333-444"})
+
+	s := grpc.NewServer()
+	contract.RegisterAccountHandlerServer(s, NewAccountHandler(auntificator.NewAccount(memoryStorage, auntificator.NewDefaultTokenManager())))
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := contract.NewAccountHandlerClient(conn)
+
+	userCtx := metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"}))
+
+	_, err = client.Register(context.Background(), &contract.RegisterRequest{Login: "cat", Password: "password"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Register(userCtx, &contract.RegisterRequest{Login: "cat", Password: "1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	registered, err := client.Register(userCtx, &contract.RegisterRequest{Login: "cat", Password: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1111-2222-3333-444", registered.GetUuid())
+
+	_, err = client.Register(userCtx, &contract.RegisterRequest{Login: "dog", Password: "password"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.Login(context.Background(), &contract.LoginRequest{Login: "cat", Password: "wrong_password"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	var header metadata.MD
+	loggedIn, err := client.Login(context.Background(), &contract.LoginRequest{Login: "cat", Password: "password"}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, "1111-2222-3333-444", loggedIn.GetUuid())
+	assert.NotEmpty(t, loggedIn.GetAuthorization())
+	assert.Equal(t, []string{loggedIn.GetAuthorization()}, header.Get(mData.Authorization))
+}
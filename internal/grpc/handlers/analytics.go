@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/northmule/shorturl/internal/app/handlers"
+	"github.com/northmule/shorturl/internal/grpc/contract"
+	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AnalyticsHandler хэндлер статистики переходов по ссылкам пользователя.
+type AnalyticsHandler struct {
+	contract.UnimplementedAnalyticsHandlerServer
+	finder      handlers.URLFinder
+	clickFinder handlers.ClickStatsFinder
+}
+
+// NewAnalyticsHandler конструктор.
+func NewAnalyticsHandler(finder handlers.URLFinder, clickFinder handlers.ClickStatsFinder) *AnalyticsHandler {
+	instance := &AnalyticsHandler{
+		finder:      finder,
+		clickFinder: clickFinder,
+	}
+	return instance
+}
+
+// URLStats статистика переходов по короткой ссылке пользователя.
+func (a *AnalyticsHandler) URLStats(ctx context.Context, request *contract.URLStatsRequest) (*contract.URLStatsResponse, error) {
+	if request.GetShortUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "expected short_url value")
+	}
+	userUUID, err := utils.FillUserUUID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+
+	userURLs, err := a.finder.FindUrlsByUserID(ctx, userUUID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	owned := false
+	if userURLs != nil {
+		for _, urlItem := range *userURLs {
+			if urlItem.ShortURL == request.GetShortUrl() {
+				owned = true
+				break
+			}
+		}
+	}
+	if !owned {
+		return nil, status.Error(codes.NotFound, "url not found")
+	}
+
+	stats, err := a.clickFinder.GetClickStats(ctx, request.GetShortUrl())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &contract.URLStatsResponse{
+		ShortUrl: request.GetShortUrl(),
+		Total:    stats.Total,
+	}
+	for _, day := range stats.Days {
+		response.Days = append(response.Days, &contract.URLStatsResponse_Day{
+			Date:   day.Date,
+			Clicks: day.Clicks,
+		})
+	}
+
+	return response, nil
+}
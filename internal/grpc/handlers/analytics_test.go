@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/northmule/shorturl/internal/grpc/contract"
+	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAnalyticsHandler_URLStats(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	id, _ := memoryStorage.Add(context.Background(), models.URL{
+		URL:      "http://ya.ru/stats",
+		ShortURL: "stats",
+	})
+	_ = memoryStorage.LikeURLToUser(context.Background(), id, "1111-2222-3333-444")
+	otherID, _ := memoryStorage.Add(context.Background(), models.URL{
+		URL:      "http://ya.ru/other",
+		ShortURL: "other",
+	})
+	_ = memoryStorage.LikeURLToUser(context.Background(), otherID, "5555-6666-7777-888")
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "stats", CreatedAt: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)})
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "stats", CreatedAt: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)})
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "stats", CreatedAt: time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC)})
+
+	userCtx := func() context.Context {
+		md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+		return metadata.NewOutgoingContext(context.Background(), md)
+	}
+
+	tests := []struct {
+		name         string
+		shortURL     string
+		expectedCode codes.Code
+		ctx          func() context.Context
+	}{
+		{
+			name:         "нет_пользователя",
+			shortURL:     "stats",
+			expectedCode: codes.InvalidArgument,
+			ctx: func() context.Context {
+				return context.Background()
+			},
+		},
+		{
+			name:         "ссылка_не_передана",
+			shortURL:     "",
+			expectedCode: codes.InvalidArgument,
+			ctx:          userCtx,
+		},
+		{
+			name:         "чужая_ссылка",
+			shortURL:     "other",
+			expectedCode: codes.NotFound,
+			ctx:          userCtx,
+		},
+		{
+			name:         "статистика_получена",
+			shortURL:     "stats",
+			expectedCode: codes.OK,
+			ctx:          userCtx,
+		},
+	}
+
+	s := grpc.NewServer()
+	contract.RegisterAnalyticsHandlerServer(s, NewAnalyticsHandler(memoryStorage, memoryStorage))
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := contract.NewAnalyticsHandlerClient(conn)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := client.URLStats(tt.ctx(), &contract.URLStatsRequest{ShortUrl: tt.shortURL})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if status.Code(err) == codes.OK {
+				assert.Equal(t, int64(3), response.GetTotal())
+				assert.Equal(t, 2, len(response.GetDays()))
+				assert.Equal(t, "2026-10-18", response.GetDays()[1].GetDate())
+				assert.Equal(t, int64(2), response.GetDays()[1].GetClicks())
+			}
+		})
+	}
+}
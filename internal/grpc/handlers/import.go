@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/grpc/contract"
+	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ImportHandler хэндлер массового импорта ссылок.
+type ImportHandler struct {
+	contract.UnimplementedImportHandlerServer
+	importer *importer.Importer
+}
+
+// NewImportHandler конструктор.
+func NewImportHandler(importer *importer.Importer) *ImportHandler {
+	instance := &ImportHandler{
+		importer: importer,
+	}
+	return instance
+}
+
+// Import приём потока ссылок для импорта, параметры импорта берутся из первого сообщения.
+// Ответ отправляется после получения всего потока, запись последних пачек может продолжаться, ход доступен через Status.
+// Задания хранятся в памяти процесса и теряются при перезапуске сервиса.
+func (i *ImportHandler) Import(stream grpc.ClientStreamingServer[contract.ImportRequest, contract.ImportResponse]) error {
+	userUUID, err := utils.FillUserUUID(stream.Context())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "expected rows")
+	}
+	if err != nil {
+		return err
+	}
+
+	reader := &streamRowReader{
+		stream:  stream,
+		pending: first.GetRows(),
+	}
+	job := i.importer.Import(stream.Context(), userUUID, reader, importer.Options{
+		PreserveCodes: first.GetPreserveCodes(),
+	})
+	return stream.SendAndClose(progressToResponse(job.Progress()))
+}
+
+// Status состояние задания импорта пользователя.
+func (i *ImportHandler) Status(ctx context.Context, request *contract.ImportStatusRequest) (*contract.ImportResponse, error) {
+	userUUID, err := utils.FillUserUUID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+	job, err := i.importer.Job(request.GetJobId(), userUUID)
+	if errors.Is(err, importer.ErrJobNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return progressToResponse(job.Progress()), nil
+}
+
+// streamRowReader строки импорта из потока сообщений, строки нумеруются по порядку во всём потоке.
+type streamRowReader struct {
+	stream  grpc.ClientStreamingServer[contract.ImportRequest, contract.ImportResponse]
+	pending []*contract.ImportRequest_Row
+	line    int64
+}
+
+// Read следующая строка.
+func (r *streamRowReader) Read() (importer.Row, error) {
+	for len(r.pending) == 0 {
+		request, err := r.stream.Recv()
+		if err != nil {
+			return importer.Row{}, err
+		}
+		r.pending = request.GetRows()
+	}
+	row := r.pending[0]
+	r.pending = r.pending[1:]
+	r.line++
+	return importer.Row{
+		Line:        r.line,
+		OriginalURL: row.GetOriginalUrl(),
+		ShortURL:    row.GetShortUrl(),
+		ExpiresAt:   timestampToTime(row.GetExpiresAt()),
+	}, nil
+}
+
+func progressToResponse(progress importer.Progress) *contract.ImportResponse {
+	response := &contract.ImportResponse{
+		JobId:           progress.ID,
+		Status:          progress.Status,
+		Received:        progress.Received,
+		Imported:        progress.Imported,
+		Skipped:         progress.Skipped,
+		Failed:          progress.Failed,
+		Errors:          make([]*contract.ImportResponse_Error, 0, len(progress.Errors)),
+		ErrorsTruncated: progress.ErrorsTruncated,
+		Error:           progress.Error,
+	}
+	for _, rowError := range progress.Errors {
+		response.Errors = append(response.Errors, &contract.ImportResponse_Error{
+			Line:  rowError.Line,
+			Url:   rowError.URL,
+			Error: rowError.Error,
+		})
+	}
+	return response
+}
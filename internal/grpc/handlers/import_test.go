@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"testing"
+
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/importer"
+	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/grpc/contract"
+	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestImportHandler_Import(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	urlImporter := importer.NewImporter(memoryStorage, service, 2)
+
+	s := grpc.NewServer()
+	contract.RegisterImportHandlerServer(s, NewImportHandler(urlImporter))
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := contract.NewImportHandlerClient(conn)
+
+	userCtx := func(userUUID string) context.Context {
+		md := metadata.New(map[string]string{mData.UserUUID: userUUID})
+		return metadata.NewOutgoingContext(context.Background(), md)
+	}
+
+	stream, err := client.Import(userCtx("1111-2222-3333-444"))
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&contract.ImportRequest{
+		PreserveCodes: true,
+		Rows: []*contract.ImportRequest_Row{
+			{OriginalUrl: "https://ozon.ru", ShortUrl: "ozon01"},
+			{OriginalUrl: "bad url"},
+		},
+	}))
+	require.NoError(t, stream.Send(&contract.ImportRequest{
+		Rows: []*contract.ImportRequest_Row{
+			{OriginalUrl: "https://avito.ru", ShortUrl: "avito1"},
+		},
+	}))
+	response, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), response.GetReceived())
+	assert.Equal(t, int64(1), response.GetFailed())
+	require.Len(t, response.GetErrors(), 1)
+	assert.Equal(t, int64(2), response.GetErrors()[0].GetLine())
+
+	job, err := urlImporter.Job(response.GetJobId(), "1111-2222-3333-444")
+	require.NoError(t, err)
+	<-job.Done()
+
+	statusResponse, err := client.Status(userCtx("1111-2222-3333-444"), &contract.ImportStatusRequest{JobId: response.GetJobId()})
+	require.NoError(t, err)
+	assert.Equal(t, importer.StatusDone, statusResponse.GetStatus())
+	assert.Equal(t, int64(2), statusResponse.GetImported())
+	_, err = memoryStorage.FindByShortURL(context.Background(), "avito1")
+	assert.NoError(t, err)
+
+	_, err = client.Status(userCtx("5555-6666-7777-888"), &contract.ImportStatusRequest{JobId: response.GetJobId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream, err = client.Import(context.Background())
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -2,7 +2,9 @@ package interceptors
 
 import (
 	"context"
+	"errors"
 
+	AppContext "github.com/northmule/shorturl/internal/app/context"
 	"github.com/northmule/shorturl/internal/app/handlers/middlewarehandler"
 	"github.com/northmule/shorturl/internal/app/logger"
 	"github.com/northmule/shorturl/internal/app/services/auntificator"
@@ -18,65 +20,135 @@ import (
 type CheckAuth struct {
 	userCreator                       middlewarehandler.UserCreator
 	session                           storage.SessionAdapter
+	tokens                            *auntificator.TokenManager
+	apiKeys                           auntificator.APIKeyResolver
 	checkAuthExpectedMethods          []string
 	accessVerificationExpectedMethods []string
 }
 
 // NewCheckAuth конструктор структуры.
-func NewCheckAuth(userCreator middlewarehandler.UserCreator, session storage.SessionAdapter) *CheckAuth {
+func NewCheckAuth(userCreator middlewarehandler.UserCreator, session storage.SessionAdapter, tokens *auntificator.TokenManager, apiKeys auntificator.APIKeyResolver) *CheckAuth {
 	return &CheckAuth{
 		userCreator:                       userCreator,
 		session:                           session,
-		checkAuthExpectedMethods:          []string{"/contract.ShortenerHandler/Shortener", "/contract.ShortenerHandler/ShortenerJSON", "/contract.UserUrlsHandler/View", "/contract.UserUrlsHandler/Delete"},
-		accessVerificationExpectedMethods: []string{"/contract.UserUrlsHandler/View"},
+		tokens:                            tokens,
+		apiKeys:                           apiKeys,
+		checkAuthExpectedMethods:          []string{"/contract.ShortenerHandler/Shortener", "/contract.ShortenerHandler/ShortenerJSON", "/contract.ShortenerHandler/ShortenerBatch", "/contract.UserUrlsHandler/View", "/contract.UserUrlsHandler/Update", "/contract.UserUrlsHandler/Delete", "/contract.UserUrlsHandler/Restore", "/contract.UserUrlsHandler/Export", "/contract.AnalyticsHandler/URLStats", "/contract.AccountHandler/Register", "/contract.ImportHandler/Import", "/contract.ImportHandler/Status"},
+		accessVerificationExpectedMethods: []string{"/contract.UserUrlsHandler/View", "/contract.UserUrlsHandler/Update", "/contract.UserUrlsHandler/Restore", "/contract.UserUrlsHandler/Export", "/contract.AnalyticsHandler/URLStats"},
 	}
 }
 
 // AuthEveryone авторизация пользователя.
 func (c *CheckAuth) AuthEveryone(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 
-	if !isMethodExpected(info, c.checkAuthExpectedMethods) {
+	if !isMethodExpected(info.FullMethod, c.checkAuthExpectedMethods) {
 		return handler(ctx, req)
 	}
 
-	checkAuthService := auntificator.NewCheckAuth(c.userCreator)
+	ctx, err := c.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+
+}
+
+// AuthEveryoneStream авторизация пользователя для потоковых методов.
+func (c *CheckAuth) AuthEveryoneStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	if !isMethodExpected(info.FullMethod, c.checkAuthExpectedMethods) {
+		return handler(srv, stream)
+	}
+
+	ctx, err := c.authorize(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorize вернёт контекст с uuid авторизованного пользователя.
+func (c *CheckAuth) authorize(ctx context.Context) (context.Context, error) {
+	checkAuthService := auntificator.NewCheckAuth(c.userCreator, c.tokens, c.apiKeys)
 
 	authorizationToken := utils.GetUserToken(ctx)
 
-	authResult, err := checkAuthService.Auth(authorizationToken)
+	authResult, err := checkAuthService.Auth(ctx, authorizationToken)
 	if err != nil {
+		if errors.Is(err, auntificator.ErrTokenExpired) {
+			return nil, status.Error(codes.Unauthenticated, "token expired")
+		}
+		if errors.Is(err, auntificator.ErrAPIKeyInvalid) {
+			return nil, status.Error(codes.Unauthenticated, "invalid api key")
+		}
 		return nil, status.Error(codes.Unauthenticated, "missing user token")
 	}
-	if authResult.IsNewUser {
+	if authResult.IsNewUser || authResult.IsTokenRenewed {
 		ctx = utils.AppendMData(ctx, metadata.Authorization, authResult.AuthString)
 	}
 
 	ctx = utils.AppendMData(ctx, metadata.UserUUID, authResult.UserUUID)
-
-	return handler(ctx, req)
-
+	if !authResult.IsNewUser {
+		ctx = context.WithValue(ctx, AppContext.KeyAuthenticatedUser, authResult.UserUUID)
+	}
+	return ctx, nil
 }
 
 // AccessVerificationUserUrls проверка доступа пользователя.
 func (c *CheckAuth) AccessVerificationUserUrls(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 
-	if !isMethodExpected(info, c.accessVerificationExpectedMethods) {
+	if !isMethodExpected(info.FullMethod, c.accessVerificationExpectedMethods) {
 		return handler(ctx, req)
 	}
 
+	if err := verifyAccess(ctx); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// AccessVerificationUserUrlsStream проверка доступа пользователя для потоковых методов.
+func (c *CheckAuth) AccessVerificationUserUrlsStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	if !isMethodExpected(info.FullMethod, c.accessVerificationExpectedMethods) {
+		return handler(srv, stream)
+	}
+
+	if err := verifyAccess(stream.Context()); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+// verifyAccess запрос должен содержать токен пользователя.
+func verifyAccess(ctx context.Context) error {
 	authorizationToken := utils.GetUserToken(ctx)
 
 	if authorizationToken == "" {
 		logger.LogSugar.Infof("The user's UUID was not found in the cookie %s when requesting /api/user/urls", authorizationToken)
-		return nil, status.Errorf(codes.Unauthenticated, "unauthenticated")
+		return status.Errorf(codes.Unauthenticated, "unauthenticated")
 	}
+	return nil
+}
 
-	return handler(ctx, req)
+// contextServerStream поток с изменённым контекстом.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context контекст потока.
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
 }
 
-func isMethodExpected(info *grpc.UnaryServerInfo, expected []string) bool {
+func isMethodExpected(fullMethod string, expected []string) bool {
 	for _, method := range expected {
-		if method == info.FullMethod {
+		if method == fullMethod {
 			return true
 		}
 	}
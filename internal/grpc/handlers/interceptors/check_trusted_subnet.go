@@ -28,7 +28,7 @@ func NewCheckTrustedSubnet(configApp *config.Config) *CheckTrustedSubnet {
 // GrantAccess предоставить доступ
 func (c *CheckTrustedSubnet) GrantAccess(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 
-	if !isMethodExpected(info, c.grantAccessExpectedMethods) {
+	if !isMethodExpected(info.FullMethod, c.grantAccessExpectedMethods) {
 		return handler(ctx, req)
 	}
 
@@ -0,0 +1,72 @@
+package interceptors
+
+import (
+	"context"
+
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
+	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimit ограничение частоты запросов.
+type RateLimit struct {
+	limiter        *ratelimit.Limiter
+	trustedProxies *auntificator.CheckTrustedSubnet
+	methods        map[string]ratelimit.Group
+}
+
+// NewRateLimit конструктор, адрес клиента из метаданных принимается только от прокси из сети trustedProxies.
+func NewRateLimit(limiter *ratelimit.Limiter, trustedProxies *auntificator.CheckTrustedSubnet) *RateLimit {
+	return &RateLimit{
+		limiter:        limiter,
+		trustedProxies: trustedProxies,
+		methods: map[string]ratelimit.Group{
+			"/contract.ShortenerHandler/Shortener":      ratelimit.GroupShorten,
+			"/contract.ShortenerHandler/ShortenerJSON":  ratelimit.GroupShorten,
+			"/contract.ShortenerHandler/ShortenerBatch": ratelimit.GroupBatch,
+			"/contract.RedirectHandler/Redirect":        ratelimit.GroupRedirect,
+			"/contract.ImportHandler/Import":            ratelimit.GroupBatch,
+		},
+	}
+}
+
+// Limit ограничивает запросы по пользователю или адресу клиента, подключается после AuthEveryone.
+func (r *RateLimit) Limit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := r.allow(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// LimitStream ограничивает потоковые запросы, подключается после AuthEveryoneStream.
+func (r *RateLimit) LimitStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := r.allow(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// allow вернёт ошибку ResourceExhausted, если лимит группы метода исчерпан.
+func (r *RateLimit) allow(ctx context.Context, fullMethod string) error {
+	group, ok := r.methods[fullMethod]
+	if !ok || r.limiter == nil {
+		return nil
+	}
+
+	userUUID, _ := ctx.Value(AppContext.KeyAuthenticatedUser).(string)
+	key := ratelimit.Key(userUUID, utils.RemoteIP(ctx, r.trustedProxies))
+	result := r.limiter.Allow(group, key)
+	if !result.Allowed {
+		logger.LogSugar.Infof("Превышен лимит запросов %s для %s", group, key)
+		retryAfter := ratelimit.RetryAfterSeconds(result.RetryAfter)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+		return status.Errorf(codes.ResourceExhausted, "too many requests, retry after %s seconds", retryAfter)
+	}
+	return nil
+}
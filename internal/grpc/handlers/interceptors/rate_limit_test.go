@@ -0,0 +1,60 @@
+package interceptors
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/northmule/shorturl/config"
+	AppContext "github.com/northmule/shorturl/internal/app/context"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
+	"github.com/northmule/shorturl/internal/app/services/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit_Limit(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Group]ratelimit.Limit{
+		ratelimit.GroupShorten:  {Rate: 1, Burst: 1},
+		ratelimit.GroupRedirect: {Rate: 1, Burst: 1},
+	})
+	inter := NewRateLimit(limiter, auntificator.NewTrustedSubnet(&config.Config{TrustedSubnet: "192.168.1.0/24"}))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	ipCtx := func(ip string) context.Context {
+		// Запрос от доверенного прокси, адрес клиента передан в X-Real-IP
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 5000}})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("X-Real-IP", ip))
+	}
+	userCtx := func(ip string, userUUID string) context.Context {
+		return context.WithValue(ipCtx(ip), AppContext.KeyAuthenticatedUser, userUUID)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+	}{
+		{name: "#1_первый_переход", ctx: ipCtx("10.0.0.1"), method: "/contract.RedirectHandler/Redirect", code: codes.OK},
+		{name: "#2_лимит_адреса", ctx: ipCtx("10.0.0.1"), method: "/contract.RedirectHandler/Redirect", code: codes.ResourceExhausted},
+		{name: "#3_другой_адрес", ctx: ipCtx("10.0.0.2"), method: "/contract.RedirectHandler/Redirect", code: codes.OK},
+		{name: "#4_пользователь", ctx: userCtx("10.0.0.3", "uuid-1"), method: "/contract.ShortenerHandler/Shortener", code: codes.OK},
+		{name: "#5_лимит_пользователя_с_другого_адреса", ctx: userCtx("10.0.0.4", "uuid-1"), method: "/contract.ShortenerHandler/ShortenerJSON", code: codes.ResourceExhausted},
+		{name: "#6_группа_без_лимита", ctx: ipCtx("10.0.0.1"), method: "/contract.ShortenerHandler/ShortenerBatch", code: codes.OK},
+		{name: "#7_метод_без_ограничения", ctx: ipCtx("10.0.0.1"), method: "/contract.StatsHandler/Stats", code: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inter.Limit(tt.ctx, "request", &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
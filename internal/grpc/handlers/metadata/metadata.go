@@ -6,6 +6,8 @@ const (
 	UserUUID = "userUUID"
 	// Authorization мета данные
 	Authorization = "authorization"
+	// APIKey ключ доступа межсервисных клиентов
+	APIKey = "x-api-key"
 	// RequestTime мета данные
 	RequestTime = "requestTime"
 )
@@ -23,7 +23,7 @@ func NewPingHandler(pinger handlers.Pinger) *PingHandler {
 
 // CheckStorageConnect обработка запроса проверки соединения с БД .
 func (p *PingHandler) CheckStorageConnect(ctx context.Context, request *empty.Empty) (*contract.CheckStorageConnectResponse, error) {
-	err := p.pinger.Ping()
+	err := p.pinger.Ping(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "no connect db")
 	}
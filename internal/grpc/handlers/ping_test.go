@@ -10,9 +10,9 @@ import (
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/northmule/shorturl/internal/app/storage"
-	"github.com/northmule/shorturl/internal/app/storage/models"
+	"github.com/northmule/shorturl/internal/app/storage/mocks"
 	"github.com/northmule/shorturl/internal/grpc/contract"
-	"github.com/stretchr/testify/mock"
+	"go.uber.org/mock/gomock"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -20,77 +20,6 @@ import (
 	"google.golang.org/grpc/test/bufconn"
 )
 
-type MockPostgresStorageOk struct {
-	mock.Mock
-}
-
-func (m *MockPostgresStorageOk) Add(url models.URL) (int64, error) {
-	return 0, nil
-}
-func (m *MockPostgresStorageOk) FindByShortURL(shortURL string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageOk) FindByURL(url string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageOk) Ping() error {
-	return nil
-}
-func (m *MockPostgresStorageOk) MultiAdd(url []models.URL) error {
-	return nil
-}
-func (m *MockPostgresStorageOk) CreateUser(user models.User) (int64, error) {
-	return 0, nil
-}
-
-func (m *MockPostgresStorageOk) LikeURLToUser(urlID int64, userUUID string) error {
-	return nil
-}
-
-func (m *MockPostgresStorageOk) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	return nil, nil
-}
-
-func (m *MockPostgresStorageOk) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
-	return nil
-}
-
-type MockPostgresStorageBad struct {
-	mock.Mock
-}
-
-func (m *MockPostgresStorageBad) Add(url models.URL) (int64, error) {
-	return 0, nil
-}
-func (m *MockPostgresStorageBad) FindByShortURL(shortURL string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageBad) FindByURL(url string) (*models.URL, error) {
-	return nil, nil
-}
-func (m *MockPostgresStorageBad) Ping() error {
-	args := m.Called()
-	return args.Error(0)
-}
-func (m *MockPostgresStorageBad) MultiAdd(url []models.URL) error {
-	return nil
-}
-func (m *MockPostgresStorageBad) CreateUser(user models.User) (int64, error) {
-	return 0, nil
-}
-
-func (m *MockPostgresStorageBad) LikeURLToUser(urlID int64, userUUID string) error {
-	return nil
-}
-
-func (m *MockPostgresStorageBad) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
-	return nil, nil
-}
-
-func (m *MockPostgresStorageBad) SoftDeletedShortURL(userUUID string, shortURL ...string) error {
-	return nil
-}
-
 func registerServer(s *grpc.Server) func(context.Context, string) (net.Conn, error) {
 	listener := bufconn.Listen(1024 * 1024)
 
@@ -113,8 +42,9 @@ func TestPingHandler_CheckStorageConnect(t *testing.T) {
 	}
 	defer os.Remove(file.Name())
 
-	postgresStorage := new(MockPostgresStorageOk)
-	postgresStorage.On("Ping").Return("Ok")
+	ctrl := gomock.NewController(t)
+	postgresStorage := mocks.NewMockStorageQuery(ctrl)
+	postgresStorage.EXPECT().Ping(gomock.Any()).Return(nil)
 
 	tests := []struct {
 		name    string
@@ -171,8 +101,8 @@ func TestPingHandler_CheckStorageConnect(t *testing.T) {
 	}
 
 	t.Run("Возврат_ошибки_подключения", func(t *testing.T) {
-		mockStorage := new(MockPostgresStorageBad)
-		mockStorage.On("Ping").Return(errors.New("bad test request"))
+		mockStorage := mocks.NewMockStorageQuery(ctrl)
+		mockStorage.EXPECT().Ping(gomock.Any()).Return(errors.New("bad test request"))
 		s := grpc.NewServer()
 		contract.RegisterPingHandlerServer(s, NewPingHandler(mockStorage))
 
@@ -2,9 +2,16 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/northmule/shorturl/internal/app/handlers"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	"github.com/northmule/shorturl/internal/app/services/url"
+	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/northmule/shorturl/internal/grpc/contract"
+	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -12,13 +19,17 @@ import (
 // RedirectHandler хэндлер для обработки коротких ссылок.
 type RedirectHandler struct {
 	contract.UnimplementedRedirectHandlerServer
-	service *url.ShortURLService
+	service        *url.ShortURLService
+	clickRecorder  handlers.ClickRecorder
+	trustedProxies *auntificator.CheckTrustedSubnet
 }
 
 // NewRedirectHandler конструктор хэндлера.
-func NewRedirectHandler(urlService *url.ShortURLService) *RedirectHandler {
+func NewRedirectHandler(urlService *url.ShortURLService, clickRecorder handlers.ClickRecorder, trustedProxies *auntificator.CheckTrustedSubnet) *RedirectHandler {
 	redirectHandler := &RedirectHandler{
-		service: urlService,
+		service:        urlService,
+		clickRecorder:  clickRecorder,
+		trustedProxies: trustedProxies,
 	}
 	return redirectHandler
 }
@@ -29,16 +40,38 @@ func (r *RedirectHandler) Redirect(ctx context.Context, request *contract.Redire
 	if request.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "expected id value")
 	}
-	modelURL, err := r.service.EncodeShortURL(request.GetId())
-	if err != nil {
+	modelURL, err := r.service.EncodeShortURL(ctx, request.GetId())
+	switch {
+	case errors.Is(err, url.ErrShortURLNotFound):
 		return nil, status.Error(codes.NotFound, "")
-	}
-	if !modelURL.DeletedAt.IsZero() {
-		return nil, status.Error(codes.NotFound, "expected id value")
+	case errors.Is(err, url.ErrShortURLGone):
+		return nil, status.Error(codes.NotFound, "url gone")
+	case err != nil:
+		logger.LogSugar.Errorf("Не удалось получить ссылку %s: %s", request.GetId(), err)
+		return nil, status.Error(codes.Internal, "error get url")
 	}
 
+	r.recordClick(ctx, request.GetId())
+
 	response := &contract.RedirectResponse{}
 	response.Url = modelURL.URL
 
 	return response, nil
 }
+
+// recordClick сохраняет переход, ошибка сохранения не мешает переходу по ссылке.
+func (r *RedirectHandler) recordClick(ctx context.Context, shortURL string) {
+	if r.clickRecorder == nil {
+		return
+	}
+	err := r.clickRecorder.AddClick(ctx, models.Click{
+		ShortURL:  shortURL,
+		CreatedAt: time.Now().UTC(),
+		Referer:   utils.GetMDValue(ctx, "grpcgateway-referer", "referer"),
+		UserAgent: utils.GetMDValue(ctx, "grpcgateway-user-agent", "user-agent"),
+		RemoteIP:  utils.RemoteIP(ctx, r.trustedProxies),
+	})
+	if err != nil {
+		logger.LogSugar.Errorf("Не удалось сохранить переход по ссылке %s: %s", shortURL, err)
+	}
+}
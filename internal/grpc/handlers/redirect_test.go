@@ -7,6 +7,7 @@ import (
 
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/northmule/shorturl/internal/grpc/contract"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -61,8 +62,9 @@ func TestRedirectHandler_Redirect(t *testing.T) {
 	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
 
 	ctx := context.Background()
+	_, _ = memoryStorage.Add(ctx, models.URL{ShortURL: "e98192e19505472476a49f10388428ab", URL: "https://ya.ru"})
 	s := grpc.NewServer()
-	contract.RegisterRedirectHandlerServer(s, NewRedirectHandler(shortURLService))
+	contract.RegisterRedirectHandlerServer(s, NewRedirectHandler(shortURLService, memoryStorage, nil))
 
 	dopts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
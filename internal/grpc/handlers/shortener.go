@@ -4,33 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"time"
 
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/northmule/shorturl/config"
-	"github.com/northmule/shorturl/internal/app/handlers"
+	"github.com/northmule/shorturl/internal/app/services/policy"
 	"github.com/northmule/shorturl/internal/app/services/url"
-	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/grpc/contract"
 	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // ShortenerHandler хэндлер сокращения ссылок.
 type ShortenerHandler struct {
 	contract.UnimplementedShortenerHandlerServer
 	service *url.ShortURLService
-	finder  handlers.Finder
-	setter  handlers.LikeURLToUserSetter
 }
 
 // NewShortenerHandler конструктор.
-func NewShortenerHandler(urlService *url.ShortURLService, finder handlers.Finder, setter handlers.LikeURLToUserSetter) *ShortenerHandler {
+func NewShortenerHandler(urlService *url.ShortURLService) *ShortenerHandler {
 	shortenerHandler := &ShortenerHandler{
 		service: urlService,
-		finder:  finder,
-		setter:  setter,
 	}
 	return shortenerHandler
 }
@@ -38,7 +34,8 @@ func NewShortenerHandler(urlService *url.ShortURLService, finder handlers.Finder
 // Shortener обработчик создания короткой ссылки.
 func (s *ShortenerHandler) Shortener(ctx context.Context, request *contract.ShortenerRequest) (*contract.ShortenerResponse, error) {
 
-	if !strings.Contains(request.GetUrl(), "http://") && !strings.Contains(request.GetUrl(), "https://") {
+	originalURL, err := s.service.NormalizeURL(request.GetUrl())
+	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "expected url")
 	}
 
@@ -47,7 +44,7 @@ func (s *ShortenerHandler) Shortener(ctx context.Context, request *contract.Shor
 		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
 	}
 
-	shortURL, err := s.fillShortURL(userUUID, request.GetUrl())
+	shortURL, err := s.fillShortURL(ctx, userUUID, originalURL, url.DecodeOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -61,18 +58,33 @@ func (s *ShortenerHandler) Shortener(ctx context.Context, request *contract.Shor
 // ShortenerJSON аналог метода http по сигнатуре ответа
 func (s *ShortenerHandler) ShortenerJSON(ctx context.Context, request *contract.ShortenerJSONRequest) (*contract.ShortenerJSONResponse, error) {
 
-	if !strings.Contains(request.GetUrl(), "http://") && !strings.Contains(request.GetUrl(), "https://") {
+	originalURL, err := s.service.NormalizeURL(request.GetUrl())
+	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "expected url")
 	}
 
+	if request.GetAlias() != "" {
+		if err := url.ValidateAlias(request.GetAlias()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	expiresAt, err := url.ResolveExpiresAt(timestampToTime(request.GetExpiresAt()), request.GetTtlSeconds())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	userUUID, err := utils.FillUserUUID(ctx)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
 	}
 
-	shortURL, err := s.fillShortURL(userUUID, request.GetUrl())
+	shortURL, err := s.fillShortURL(ctx, userUUID, originalURL, url.DecodeOptions{
+		Alias:     request.GetAlias(),
+		ExpiresAt: expiresAt,
+	})
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
 	}
 
 	response := &contract.ShortenerJSONResponse{}
@@ -82,37 +94,43 @@ func (s *ShortenerHandler) ShortenerJSON(ctx context.Context, request *contract.
 }
 
 // ShortenerBatch обработка списка адресов.
+// Ответ содержит элементы в порядке запроса, ошибки отдельных элементов передаются в поле error.
 func (s *ShortenerHandler) ShortenerBatch(ctx context.Context, request *contract.ShortenerBatchRequest) (*contract.ShortenerBatchResponse, error) {
 
 	if len(request.Items) == 0 {
 		return nil, status.Errorf(codes.InvalidArgument, "expected Items")
 	}
 
-	urls := make([]string, 0)
-	for _, requestItem := range request.Items {
-		if !strings.Contains(requestItem.GetOriginalUrl(), "http://") && !strings.Contains(requestItem.GetOriginalUrl(), "https://") {
-			continue
-		}
-		urls = append(urls, requestItem.GetOriginalUrl())
+	userUUID, err := utils.FillUserUUID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
 	}
-	if len(urls) == 0 {
-		return nil, status.Errorf(codes.InvalidArgument, "expected urls")
+
+	items := make([]url.BatchItem, 0, len(request.Items))
+	for _, requestItem := range request.Items {
+		items = append(items, url.BatchItem{
+			URL:        requestItem.GetOriginalUrl(),
+			ExpiresAt:  timestampToTime(requestItem.GetExpiresAt()),
+			TTLSeconds: requestItem.GetTtlSeconds(),
+		})
 	}
-	modelURLs, err := s.service.DecodeURLs(urls)
+	results, err := s.service.DecodeURLs(ctx, userUUID, items)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	responseItems := make([]*contract.ShortenerBatchResponse_Item, 0)
-	for _, requestItem := range request.Items {
-		for _, modelURL := range modelURLs {
-			if requestItem.GetOriginalUrl() == modelURL.URL {
-				responseItems = append(responseItems, &contract.ShortenerBatchResponse_Item{
-					CorrelationId: requestItem.CorrelationId,
-					ShortUrl:      fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, modelURL.ShortURL),
-				})
-			}
+	responseItems := make([]*contract.ShortenerBatchResponse_Item, 0, len(results))
+	for i, result := range results {
+		responseItem := &contract.ShortenerBatchResponse_Item{
+			CorrelationId: request.Items[i].GetCorrelationId(),
+		}
+		if result.Err != nil {
+			responseItem.Error = result.Err.Error()
+		} else {
+			responseItem.ShortUrl = fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, result.ShortURL)
+			responseItem.Existing = result.Existing
 		}
+		responseItems = append(responseItems, responseItem)
 	}
 
 	response := &contract.ShortenerBatchResponse{}
@@ -121,33 +139,36 @@ func (s *ShortenerHandler) ShortenerBatch(ctx context.Context, request *contract
 	return response, nil
 }
 
-func (s *ShortenerHandler) fillShortURL(userUUID string, url string) (string, error) {
-	var (
-		shortURL    string
-		isURLExists bool
-	)
-	shortURLData, err := s.service.DecodeURL(url)
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == storage.CodeErrorDuplicateKey {
-			isURLExists = true
-		} else {
-			return "", status.Error(codes.Internal, err.Error())
-		}
+func (s *ShortenerHandler) fillShortURL(ctx context.Context, userUUID string, originalURL string, options url.DecodeOptions) (string, error) {
+	shortURLData, err := s.service.DecodeURLWithOptions(ctx, userUUID, originalURL, options)
+	switch {
+	case err == nil:
+		return shortURLData.ShortURL, nil
+	case errors.Is(err, url.ErrURLExists):
+		return shortURLData.ShortURL, status.Errorf(codes.AlreadyExists, "url already exists")
+	case errors.Is(err, url.ErrAliasExists):
+		return "", status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, url.ErrAliasInvalid), errors.Is(err, url.ErrAliasReserved), errors.Is(err, url.ErrURLInvalid):
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, policy.ErrDestinationDenied):
+		return "", status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return "", status.Error(codes.Internal, err.Error())
 	}
-	if isURLExists {
-		modelURL, err := s.finder.FindByURL(url)
-		if err != nil {
-			return "", status.Error(codes.Internal, err.Error())
-		}
+}
 
-		return modelURL.ShortURL, status.Errorf(codes.AlreadyExists, "url already exists")
-	}
-	shortURL = shortURLData.ShortURL
-	err = s.setter.LikeURLToUser(shortURLData.URLID, userUUID)
-	if err != nil {
-		return "", status.Error(codes.Internal, err.Error())
+// timestampToTime отсутствующее значение преобразуется в нулевое время.
+func timestampToTime(value *timestamp.Timestamp) time.Time {
+	if value == nil {
+		return time.Time{}
 	}
+	return value.AsTime()
+}
 
-	return shortURL, nil
+// timeToTimestamp нулевое время преобразуется в отсутствующее значение.
+func timeToTimestamp(value time.Time) *timestamp.Timestamp {
+	if value.IsZero() {
+		return nil
+	}
+	return timestamppb.New(value)
 }
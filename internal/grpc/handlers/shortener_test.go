@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/northmule/shorturl/internal/app/services/policy"
 	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/northmule/shorturl/internal/grpc/contract"
 	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +38,16 @@ func TestShortenerHandler_Shortener(t *testing.T) {
 				return context.Background()
 			},
 		},
+		{
+			name: "текст_перед_ссылкой",
+			url:  "foo http://ya.ru/map1",
+			code: codes.InvalidArgument,
+			ctx: func() context.Context {
+				md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+				ctx := metadata.NewOutgoingContext(context.Background(), md)
+				return ctx
+			},
+		},
 		{
 			name: "нет_пользователя",
 			url:  "https://ya.ru/map1",
@@ -57,7 +72,7 @@ func TestShortenerHandler_Shortener(t *testing.T) {
 	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
 
 	s := grpc.NewServer()
-	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService, memoryStorage, memoryStorage))
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -132,7 +147,7 @@ func TestShortenerHandler_ShortenerJSON(t *testing.T) {
 	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
 
 	s := grpc.NewServer()
-	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService, memoryStorage, memoryStorage))
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -167,12 +182,114 @@ func TestShortenerHandler_ShortenerJSON(t *testing.T) {
 	}
 }
 
-func TestShortenerHandler_ShortenerBatch(t *testing.T) {
+func TestShortenerHandler_ShortenerJSON_Alias(t *testing.T) {
 
 	tests := []struct {
 		name  string
-		items []*contract.ShortenerBatchRequest_Item
+		url   string
+		alias string
 		code  codes.Code
+	}{
+		{
+			name:  "псевдоним_свободен",
+			url:   "https://ya.ru/spring",
+			alias: "spring-sale",
+			code:  codes.OK,
+		},
+		{
+			name:  "псевдоним_занят",
+			url:   "https://ya.ru/autumn",
+			alias: "spring-sale",
+			code:  codes.AlreadyExists,
+		},
+		{
+			name:  "псевдоним_зарезервирован",
+			url:   "https://ya.ru/api",
+			alias: "api",
+			code:  codes.InvalidArgument,
+		},
+	}
+
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+
+	s := grpc.NewServer()
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+			ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+			dopts := []grpc.DialOption{
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(registerServer(s)),
+			}
+			conn, err := grpc.NewClient(":///test.server", dopts...)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer conn.Close()
+			client := contract.NewShortenerHandlerClient(conn)
+
+			response, err := client.ShortenerJSON(ctx, &contract.ShortenerJSONRequest{Url: tt.url, Alias: tt.alias})
+
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code == codes.OK {
+				assert.True(t, strings.HasSuffix(response.Result, "/"+tt.alias))
+			}
+		})
+	}
+}
+
+func TestShortenerHandler_ShortenerJSON_Policy(t *testing.T) {
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+	destinationPolicy, err := policy.NewPolicy(policy.Rules{Deny: []string{"*.phishing.net"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortURLService.SetPolicy(destinationPolicy)
+
+	s := grpc.NewServer()
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
+
+	md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := contract.NewShortenerHandlerClient(conn)
+
+	_, err = client.ShortenerJSON(ctx, &contract.ShortenerJSONRequest{Url: "https://login.phishing.net/a"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "*.phishing.net")
+
+	batchResponse, err := client.ShortenerBatch(ctx, &contract.ShortenerBatchRequest{Items: []*contract.ShortenerBatchRequest_Item{{
+		CorrelationId: "1",
+		OriginalUrl:   "https://login.phishing.net/b",
+	}}})
+	assert.Equal(t, codes.OK, status.Code(err))
+	assert.Contains(t, batchResponse.Items[0].Error, "*.phishing.net")
+	assert.Empty(t, batchResponse.Items[0].ShortUrl)
+
+	_, err = client.ShortenerJSON(ctx, &contract.ShortenerJSONRequest{Url: "https://ya.ru/allowed"})
+	assert.Equal(t, codes.OK, status.Code(err))
+}
+
+func TestShortenerHandler_ShortenerBatch(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		items  []*contract.ShortenerBatchRequest_Item
+		code   codes.Code
+		errors []bool
 	}{
 		{
 			name:  "не_переданы_items",
@@ -185,7 +302,8 @@ func TestShortenerHandler_ShortenerBatch(t *testing.T) {
 				CorrelationId: "1",
 				OriginalUrl:   "Парам_пам_пам",
 			}},
-			code: codes.InvalidArgument,
+			code:   codes.OK,
+			errors: []bool{true},
 		},
 		{
 			name: "ссылки_валидные",
@@ -193,7 +311,20 @@ func TestShortenerHandler_ShortenerBatch(t *testing.T) {
 				CorrelationId: "1",
 				OriginalUrl:   "http://ya.ru/result",
 			}},
-			code: codes.OK,
+			code:   codes.OK,
+			errors: []bool{false},
+		},
+		{
+			name: "ссылка_нормализуется",
+			items: []*contract.ShortenerBatchRequest_Item{{
+				CorrelationId: "1",
+				OriginalUrl:   "HTTP://YA.RU:80/normalized#top",
+			}, {
+				CorrelationId: "2",
+				OriginalUrl:   "foo http://ya.ru",
+			}},
+			code:   codes.OK,
+			errors: []bool{false, true},
 		},
 	}
 
@@ -201,12 +332,13 @@ func TestShortenerHandler_ShortenerBatch(t *testing.T) {
 	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
 
 	s := grpc.NewServer()
-	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService, memoryStorage, memoryStorage))
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			ctx := context.Background()
+			md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+			ctx := metadata.NewOutgoingContext(context.Background(), md)
 
 			dopts := []grpc.DialOption{
 				grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -230,8 +362,104 @@ func TestShortenerHandler_ShortenerBatch(t *testing.T) {
 			}
 
 			if status.Code(err) == codes.OK {
-				assert.Equal(t, 1, len(response.Items))
+				assert.Equal(t, len(tt.errors), len(response.Items))
+				for i, item := range response.Items {
+					assert.Equal(t, tt.items[i].CorrelationId, item.CorrelationId)
+					assert.Equal(t, tt.errors[i], item.Error != "")
+					assert.Equal(t, tt.errors[i], item.ShortUrl == "")
+				}
 			}
 		})
 	}
 }
+
+func TestShortenerHandler_ShortenerBatch_Existing(t *testing.T) {
+	memoryStorage := storage.NewMemoryStorage()
+	_, _ = memoryStorage.Add(context.Background(), models.URL{ShortURL: "exist1", URL: "https://ya.ru/exist"})
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+
+	s := grpc.NewServer()
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
+
+	md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := contract.NewShortenerHandlerClient(conn)
+
+	response, err := client.ShortenerBatch(ctx, &contract.ShortenerBatchRequest{Items: []*contract.ShortenerBatchRequest_Item{
+		{CorrelationId: "1", OriginalUrl: "https://ya.ru/new"},
+		{CorrelationId: "2", OriginalUrl: "https://ya.ru/exist"},
+		{CorrelationId: "1", OriginalUrl: "https://ya.ru/new"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(response.Items))
+	assert.False(t, response.Items[0].Existing)
+	assert.True(t, response.Items[1].Existing)
+	assert.True(t, strings.HasSuffix(response.Items[1].ShortUrl, "/exist1"))
+	assert.Equal(t, response.Items[0].ShortUrl, response.Items[2].ShortUrl)
+	assert.Equal(t, "1", response.Items[2].CorrelationId)
+
+	userURLs, err := memoryStorage.FindUrlsByUserID(context.Background(), "1111-2222-3333-444")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(*userURLs))
+}
+
+func TestShortenerHandler_Concurrent(t *testing.T) {
+	memoryStorage := storage.NewMemoryStorage()
+	shortURLService := url.NewShortURLService(memoryStorage, memoryStorage)
+
+	s := grpc.NewServer()
+	contract.RegisterShortenerHandlerServer(s, NewShortenerHandler(shortURLService))
+	contract.RegisterRedirectHandlerServer(s, NewRedirectHandler(shortURLService, memoryStorage, nil))
+
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	shortenerClient := contract.NewShortenerHandlerClient(conn)
+	redirectClient := contract.NewRedirectHandlerClient(conn)
+
+	md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+	ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+	const workersCount = 20
+	const requestsPerWorker = 10
+	var wg sync.WaitGroup
+	wg.Add(workersCount)
+	for worker := 0; worker < workersCount; worker++ {
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < requestsPerWorker; i++ {
+				originalURL := fmt.Sprintf("https://ya.ru/concurrent/%d/%d", worker, i)
+				response, err := shortenerClient.ShortenerJSON(ctx, &contract.ShortenerJSONRequest{Url: originalURL})
+				if err != nil {
+					t.Errorf("Не удалось сократить %s: %v", originalURL, err)
+					return
+				}
+				shortURL := response.Result[strings.LastIndex(response.Result, "/")+1:]
+				redirect, err := redirectClient.Redirect(ctx, &contract.RedirectRequest{Id: shortURL})
+				if err != nil {
+					t.Errorf("Не удалось перейти по %s: %v", shortURL, err)
+					return
+				}
+				if redirect.GetUrl() != originalURL {
+					t.Errorf("Короткая ссылка %s ведёт на %s, ожидается %s", shortURL, redirect.GetUrl(), originalURL)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
@@ -13,13 +13,15 @@ import (
 // StatsHandler обработка запросов статистики
 type StatsHandler struct {
 	contract.UnimplementedStatsHandlerServer
-	finderStats handlers.StatsFinder
+	finderStats  handlers.StatsFinder
+	purgeCounter handlers.PurgeCounter
 }
 
-// NewStatsHandler конструктор
-func NewStatsHandler(finderStats handlers.StatsFinder) *StatsHandler {
+// NewStatsHandler конструктор, purgeCounter может быть nil, если очистка не запущена
+func NewStatsHandler(finderStats handlers.StatsFinder, purgeCounter handlers.PurgeCounter) *StatsHandler {
 	instance := &StatsHandler{
-		finderStats: finderStats,
+		finderStats:  finderStats,
+		purgeCounter: purgeCounter,
 	}
 
 	return instance
@@ -30,14 +32,17 @@ func (s *StatsHandler) Stats(ctx context.Context, request *empty.Empty) (*contra
 	var err error
 
 	response := &contract.StatsResponse{}
-	response.Users, err = s.finderStats.GetCountUser()
+	response.Users, err = s.finderStats.GetCountUser(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "error GetCountUser()")
 	}
-	response.Urls, err = s.finderStats.GetCountShortURL()
+	response.Urls, err = s.finderStats.GetCountShortURL(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "error GetCountShortURL()")
 	}
+	if s.purgeCounter != nil {
+		response.PurgedUrls = s.purgeCounter.Purged()
+	}
 
 	return response, nil
 }
@@ -19,12 +19,12 @@ type mockBadUserFinder struct {
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (s *mockBadUserFinder) GetCountShortURL() (int64, error) {
+func (s *mockBadUserFinder) GetCountShortURL(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
 // GetCountUser кол-во пользвателей
-func (s *mockBadUserFinder) GetCountUser() (int64, error) {
+func (s *mockBadUserFinder) GetCountUser(ctx context.Context) (int64, error) {
 	return 0, errors.New("error")
 }
 
@@ -32,12 +32,12 @@ type mockBadURLsFinder struct {
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (s *mockBadURLsFinder) GetCountShortURL() (int64, error) {
+func (s *mockBadURLsFinder) GetCountShortURL(ctx context.Context) (int64, error) {
 	return 0, errors.New("error")
 }
 
 // GetCountUser кол-во пользвателей
-func (s *mockBadURLsFinder) GetCountUser() (int64, error) {
+func (s *mockBadURLsFinder) GetCountUser(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
@@ -45,21 +45,31 @@ type mockFinder struct {
 }
 
 // GetCountShortURL кол-во сокращенных URL
-func (s *mockFinder) GetCountShortURL() (int64, error) {
+func (s *mockFinder) GetCountShortURL(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
 // GetCountUser кол-во пользвателей
-func (s *mockFinder) GetCountUser() (int64, error) {
+func (s *mockFinder) GetCountUser(ctx context.Context) (int64, error) {
 	return 1, nil
 }
 
+type mockPurgeCounter struct {
+}
+
+// Purged количество окончательно удалённых ссылок
+func (m *mockPurgeCounter) Purged() int64 {
+	return 7
+}
+
 func TestStatsHandler_Stats(t *testing.T) {
 
 	tests := []struct {
-		name         string
-		finder       handlers.StatsFinder
-		expectedCode codes.Code
+		name           string
+		finder         handlers.StatsFinder
+		purgeCounter   handlers.PurgeCounter
+		expectedCode   codes.Code
+		expectedPurged int64
 	}{
 		{
 			name:         "error_GetCountUser",
@@ -76,12 +86,19 @@ func TestStatsHandler_Stats(t *testing.T) {
 			finder:       new(mockFinder),
 			expectedCode: codes.OK,
 		},
+		{
+			name:           "ok_с_очисткой",
+			finder:         new(mockFinder),
+			purgeCounter:   new(mockPurgeCounter),
+			expectedCode:   codes.OK,
+			expectedPurged: 7,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := grpc.NewServer()
-			contract.RegisterStatsHandlerServer(s, NewStatsHandler(tt.finder))
+			contract.RegisterStatsHandlerServer(s, NewStatsHandler(tt.finder, tt.purgeCounter))
 			ctx := context.Background()
 
 			dopts := []grpc.DialOption{
@@ -97,7 +114,10 @@ func TestStatsHandler_Stats(t *testing.T) {
 			defer conn.Close()
 			client := contract.NewStatsHandlerClient(conn)
 			r := &empty.Empty{}
-			_, err = client.Stats(ctx, r)
+			response, err := client.Stats(ctx, r)
+			if err == nil && response.GetPurgedUrls() != tt.expectedPurged {
+				t.Error("purged urls: expected", tt.expectedPurged, "received", response.GetPurgedUrls())
+			}
 
 			if er, ok := status.FromError(err); ok {
 				if er.Code() != tt.expectedCode {
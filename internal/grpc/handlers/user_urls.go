@@ -2,14 +2,21 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/northmule/shorturl/config"
 	"github.com/northmule/shorturl/internal/app/handlers"
+	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/policy"
+	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
+	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/northmule/shorturl/internal/grpc/contract"
 	"github.com/northmule/shorturl/internal/grpc/handlers/utils"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -17,46 +24,108 @@ import (
 // UserURLsHandler хэндлер отображения ссылок пользователя.
 type UserURLsHandler struct {
 	contract.UnimplementedUserUrlsHandlerServer
-	finder  handlers.URLFinder
-	session storage.SessionAdapter
-	worker  handlers.Deleter
+	finder   handlers.URLPageFinder
+	session  storage.SessionAdapter
+	worker   handlers.Deleter
+	exporter handlers.URLExporter
+	updater  handlers.URLUpdater
+	restorer handlers.Restorer
 }
 
 // NewUserURLsHandler Конструктор.
-func NewUserURLsHandler(finder handlers.URLFinder, sessionStorage storage.SessionAdapter, worker handlers.Deleter) *UserURLsHandler {
+func NewUserURLsHandler(finder handlers.URLPageFinder, sessionStorage storage.SessionAdapter, worker handlers.Deleter, exporter handlers.URLExporter, updater handlers.URLUpdater, restorer handlers.Restorer) *UserURLsHandler {
 	instance := UserURLsHandler{
-		finder:  finder,
-		session: sessionStorage,
-		worker:  worker,
+		finder:   finder,
+		session:  sessionStorage,
+		worker:   worker,
+		exporter: exporter,
+		updater:  updater,
+		restorer: restorer,
 	}
 	return &instance
 }
 
-// View короткие ссылки пользователя.
-func (u *UserURLsHandler) View(ctx context.Context, request *empty.Empty) (*contract.ViewResponse, error) {
+// View страница коротких ссылок пользователя, следующая страница запрашивается по next_cursor.
+func (u *UserURLsHandler) View(ctx context.Context, request *contract.ViewRequest) (*contract.ViewResponse, error) {
 	userUUID, err := utils.FillUserUUID(ctx)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
 	}
-	userURLs, err := u.finder.FindUrlsByUserID(userUUID)
+	if request.GetLimit() < 0 || request.GetLimit() > models.URLPageMaxLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", models.URLPageMaxLimit)
+	}
+	filter := models.URLFilter{
+		Limit:          int(request.GetLimit()),
+		Cursor:         request.GetCursor(),
+		Host:           request.GetHost(),
+		IncludeDeleted: request.GetIncludeDeleted(),
+		Search:         request.GetSearch(),
+		Sort:           request.GetSort(),
+	}
+	if request.GetCreatedFrom() != nil {
+		filter.CreatedFrom = request.GetCreatedFrom().AsTime()
+	}
+	if request.GetCreatedTo() != nil {
+		filter.CreatedTo = request.GetCreatedTo().AsTime()
+	}
+	page, err := u.finder.FindUserURLs(ctx, userUUID, filter)
+	if errors.Is(err, storage.ErrFilterInvalid) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	if len(*userURLs) == 0 {
+	if len(page.URLs) == 0 && filter.Cursor == "" {
 		return nil, status.Error(codes.NotFound, "url not found")
 	}
 
 	var responseList []*contract.ViewResponse_Item
-	for _, urlItem := range *userURLs {
+	for _, urlItem := range page.URLs {
 		responseList = append(responseList, &contract.ViewResponse_Item{
 			ShortUrl:    fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, urlItem.ShortURL),
 			OriginalUrl: urlItem.URL,
+			CreatedAt:   timeToTimestamp(urlItem.CreatedAt),
+			DeletedAt:   timeToTimestamp(urlItem.DeletedAt),
 		})
 	}
 
 	response := &contract.ViewResponse{}
 	response.Items = responseList
+	response.NextCursor = page.NextCursor
+
+	return response, nil
+}
+
+// Update смена URL короткой ссылки её владельцем, прежний URL сохраняется в истории.
+func (u *UserURLsHandler) Update(ctx context.Context, request *contract.UpdateRequest) (*contract.UpdateResponse, error) {
+	userUUID, err := utils.FillUserUUID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+	if request.GetShortUrl() == "" || request.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "expected short_url and url")
+	}
+	shortURLData, err := u.updater.UpdateURL(ctx, userUUID, request.GetShortUrl(), request.GetUrl())
+	switch {
+	case errors.Is(err, url.ErrURLInvalid):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, policy.ErrDestinationDenied):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, url.ErrShortURLNotFound):
+		return nil, status.Error(codes.NotFound, "url not found")
+	case errors.Is(err, url.ErrShortURLGone):
+		return nil, status.Error(codes.NotFound, "url gone")
+	case errors.Is(err, url.ErrURLExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case err != nil:
+		logger.LogSugar.Errorf("Не удалось сменить URL ссылки %s: %s", request.GetShortUrl(), err)
+		return nil, status.Error(codes.Internal, "error update url")
+	}
+
+	response := &contract.UpdateResponse{}
+	response.ShortUrl = fmt.Sprintf("%s/%s", config.AppConfig.BaseShortURL, shortURLData.ShortURL)
+	response.OriginalUrl = shortURLData.URL
 
 	return response, nil
 }
@@ -75,3 +144,48 @@ func (u *UserURLsHandler) Delete(ctx context.Context, request *contract.DeleteRe
 
 	return response, nil
 }
+
+// Restore восстановление удалённых ссылок текущего пользователя в пределах окна восстановления.
+func (u *UserURLsHandler) Restore(ctx context.Context, request *contract.RestoreRequest) (*contract.RestoreResponse, error) {
+	userUUID, err := utils.FillUserUUID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+	if len(request.GetShortUrls()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected short_urls")
+	}
+	restored, err := u.restorer.RestoreShortURLs(ctx, userUUID, handlers.RestoreDeletedSince(time.Now()), request.GetShortUrls()...)
+	if err != nil {
+		logger.LogSugar.Errorf("Не удалось восстановить ссылки пользователя %s: %s", userUUID, err)
+		return nil, status.Error(codes.Internal, "error restore urls")
+	}
+
+	result := handlers.NewResponseRestore(request.GetShortUrls(), restored)
+	response := &contract.RestoreResponse{}
+	response.Restored = result.Restored
+	response.Skipped = result.Skipped
+
+	return response, nil
+}
+
+// Export выгрузка всех ссылок пользователя, включая удалённые, ссылки отправляются по мере чтения из хранилища.
+func (u *UserURLsHandler) Export(request *contract.ExportRequest, stream grpc.ServerStreamingServer[contract.ExportResponse]) error {
+	userUUID, err := utils.FillUserUUID(stream.Context())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "expected userUUID")
+	}
+	err = u.exporter.ExportUserURLs(stream.Context(), userUUID, func(exportURL models.ExportURL) error {
+		return stream.Send(&contract.ExportResponse{
+			ShortUrl:    exportURL.ShortURL,
+			OriginalUrl: exportURL.URL.URL,
+			CreatedAt:   timeToTimestamp(exportURL.CreatedAt),
+			DeletedAt:   timeToTimestamp(exportURL.DeletedAt),
+			ExpiresAt:   timeToTimestamp(exportURL.ExpiresAt),
+			Clicks:      exportURL.Clicks,
+		})
+	})
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return nil
+}
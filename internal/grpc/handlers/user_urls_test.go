@@ -3,12 +3,14 @@ package handlers
 import (
 	"context"
 	"errors"
+	"io"
 	"log"
 	"testing"
+	"time"
 
-	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/northmule/shorturl/internal/app/handlers"
 	"github.com/northmule/shorturl/internal/app/logger"
+	"github.com/northmule/shorturl/internal/app/services/url"
 	"github.com/northmule/shorturl/internal/app/storage"
 	"github.com/northmule/shorturl/internal/app/storage/models"
 	"github.com/northmule/shorturl/internal/app/workers"
@@ -16,18 +18,20 @@ import (
 	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type MockFinder struct {
 	mock.Mock
 }
 
-func (m *MockFinder) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
+func (m *MockFinder) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
 	args := m.Called(userUUID)
 	return args.Get(0).(*[]models.URL), args.Error(1)
 }
@@ -36,7 +40,16 @@ type MockFinderBad struct {
 	mock.Mock
 }
 
-func (m *MockFinderBad) FindUrlsByUserID(userUUID string) (*[]models.URL, error) {
+func (m *MockFinder) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
+	args := m.Called(userUUID, filter)
+	return args.Get(0).(*models.URLPage), args.Error(1)
+}
+
+func (m *MockFinderBad) FindUrlsByUserID(ctx context.Context, userUUID string) (*[]models.URL, error) {
+	return nil, errors.New("error")
+}
+
+func (m *MockFinderBad) FindUserURLs(ctx context.Context, userUUID string, filter models.URLFilter) (*models.URLPage, error) {
 	return nil, errors.New("error")
 }
 
@@ -54,7 +67,8 @@ func TestUserURLsHandler_View(t *testing.T) {
 	memoryStorage := storage.NewMemoryStorage()
 	tests := []struct {
 		name         string
-		finder       handlers.URLFinder
+		finder       handlers.URLPageFinder
+		request      *contract.ViewRequest
 		expectedCode codes.Code
 		ctx          func() context.Context
 	}{
@@ -86,19 +100,41 @@ func TestUserURLsHandler_View(t *testing.T) {
 				return ctx
 			},
 		},
+		{
+			name:         "неверный_порядок",
+			finder:       memoryStorage,
+			request:      &contract.ViewRequest{Sort: "random"},
+			expectedCode: codes.InvalidArgument,
+			ctx: func() context.Context {
+				md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+				ctx := metadata.NewOutgoingContext(context.Background(), md)
+				return ctx
+			},
+		},
+		{
+			name:         "неверный_limit",
+			finder:       memoryStorage,
+			request:      &contract.ViewRequest{Limit: models.URLPageMaxLimit + 1},
+			expectedCode: codes.InvalidArgument,
+			ctx: func() context.Context {
+				md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+				ctx := metadata.NewOutgoingContext(context.Background(), md)
+				return ctx
+			},
+		},
 		{
 			name:         "ссылки_есть",
 			finder:       memoryStorage,
 			expectedCode: codes.OK,
 			ctx: func() context.Context {
-				_, _ = memoryStorage.CreateUser(models.User{
+				_, _ = memoryStorage.CreateUser(context.Background(), models.User{
 					UUID: "1111-2222-3333-444",
 				})
-				id, _ := memoryStorage.Add(models.URL{
+				id, _ := memoryStorage.Add(context.Background(), models.URL{
 					URL:      "http://ya.ru",
 					ShortURL: "2ljdsf",
 				})
-				memoryStorage.LikeURLToUser(id, "1111-2222-3333-444")
+				memoryStorage.LikeURLToUser(context.Background(), id, "1111-2222-3333-444")
 				md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
 				ctx := metadata.NewOutgoingContext(context.Background(), md)
 				return ctx
@@ -118,7 +154,7 @@ func TestUserURLsHandler_View(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := grpc.NewServer()
-			contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(tt.finder, sessionStorage, worker))
+			contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(tt.finder, sessionStorage, worker, nil, nil, nil))
 			ctx := tt.ctx()
 
 			dopts := []grpc.DialOption{
@@ -132,7 +168,10 @@ func TestUserURLsHandler_View(t *testing.T) {
 			}
 			defer conn.Close()
 			client := contract.NewUserUrlsHandlerClient(conn)
-			r := &empty.Empty{}
+			r := tt.request
+			if r == nil {
+				r = &contract.ViewRequest{}
+			}
 			response, err := client.View(ctx, r)
 
 			if er, ok := status.FromError(err); ok {
@@ -141,19 +180,63 @@ func TestUserURLsHandler_View(t *testing.T) {
 				}
 			}
 			if status.Code(err) == codes.OK {
-				assert.Equal(t, 2, len(response.Items))
+				assert.Equal(t, 1, len(response.Items))
 			}
 		})
 	}
 }
 
+func TestUserURLsHandler_ViewPagination(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "1111-2222-3333-555"
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.ImportURLs(context.Background(), userUUID, []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1", CreatedAt: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{ShortURL: "short2", URL: "https://avito.ru/2", CreatedAt: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{ShortURL: "short3", URL: "https://ozon.ru/3", CreatedAt: time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(memoryStorage, nil, nil, nil, nil, nil))
+	conn, err := grpc.NewClient(":///test.server",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := contract.NewUserUrlsHandlerClient(conn)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{mData.UserUUID: userUUID}))
+
+	var originalURLs []string
+	request := &contract.ViewRequest{Limit: 1, Host: "ozon.ru", Sort: models.SortCreatedDesc}
+	for {
+		response, err := client.View(ctx, request)
+		require.NoError(t, err)
+		for _, item := range response.GetItems() {
+			originalURLs = append(originalURLs, item.GetOriginalUrl())
+			assert.NotNil(t, item.GetCreatedAt())
+		}
+		if response.GetNextCursor() == "" {
+			break
+		}
+		request.Cursor = response.GetNextCursor()
+	}
+	assert.Equal(t, []string{"https://ozon.ru/3", "https://ozon.ru/1"}, originalURLs)
+
+	response, err := client.View(ctx, &contract.ViewRequest{CreatedFrom: timestamppb.New(time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)), Search: "avito"})
+	require.NoError(t, err)
+	require.Len(t, response.GetItems(), 1)
+	assert.Equal(t, "https://avito.ru/2", response.GetItems()[0].GetOriginalUrl())
+}
+
 func TestUserURLsHandler_Delete(t *testing.T) {
 	_ = logger.InitLogger("fatal")
 	memoryStorage := storage.NewMemoryStorage()
 	tests := []struct {
 		name         string
 		ShortUrls    []string
-		finder       handlers.URLFinder
+		finder       handlers.URLPageFinder
 		expectedCode codes.Code
 		ctx          func() context.Context
 	}{
@@ -192,7 +275,7 @@ func TestUserURLsHandler_Delete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := grpc.NewServer()
-			contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(tt.finder, sessionStorage, worker))
+			contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(tt.finder, sessionStorage, worker, nil, nil, nil))
 			ctx := tt.ctx()
 
 			dopts := []grpc.DialOption{
@@ -220,3 +303,132 @@ func TestUserURLsHandler_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserURLsHandler_Export(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.ImportURLs(context.Background(), "1111-2222-3333-444", []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru"},
+		{ShortURL: "short2", URL: "https://avito.ru"},
+	})
+	assert.NoError(t, err)
+	_ = memoryStorage.AddClick(context.Background(), models.Click{ShortURL: "short2"})
+
+	s := grpc.NewServer()
+	contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(memoryStorage, storage.NewSessionStorage(), nil, memoryStorage, nil, nil))
+	dopts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	}
+	conn, err := grpc.NewClient(":///test.server", dopts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	client := contract.NewUserUrlsHandlerClient(conn)
+
+	md := metadata.New(map[string]string{mData.UserUUID: "1111-2222-3333-444"})
+	stream, err := client.Export(metadata.NewOutgoingContext(context.Background(), md), &contract.ExportRequest{})
+	assert.NoError(t, err)
+	var items []*contract.ExportResponse
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+		items = append(items, item)
+	}
+	assert.Len(t, items, 2)
+	assert.Equal(t, "short1", items[0].GetShortUrl())
+	assert.NotNil(t, items[0].GetCreatedAt())
+	assert.Nil(t, items[0].GetDeletedAt())
+	assert.Equal(t, int64(1), items[1].GetClicks())
+
+	stream, err = client.Export(context.Background(), &contract.ExportRequest{})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserURLsHandler_Update(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "1111-2222-3333-444"
+	memoryStorage := storage.NewMemoryStorage()
+	service := url.NewShortURLService(memoryStorage, memoryStorage)
+	_, err := service.DecodeURLWithOptions(context.Background(), userUUID, "https://ozon.ru/old", url.DecodeOptions{Alias: "own-link"})
+	require.NoError(t, err)
+	_, err = service.DecodeURLWithOptions(context.Background(), userUUID, "https://avito.ru/taken", url.DecodeOptions{Alias: "taken-link"})
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(memoryStorage, nil, nil, nil, service, nil))
+	conn, err := grpc.NewClient(":///test.server",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := contract.NewUserUrlsHandlerClient(conn)
+	withUser := func(uuid string) context.Context {
+		return metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{mData.UserUUID: uuid}))
+	}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		request      *contract.UpdateRequest
+		expectedCode codes.Code
+	}{
+		{name: "нет_пользователя", ctx: context.Background(), request: &contract.UpdateRequest{ShortUrl: "own-link", Url: "https://ozon.ru/new"}, expectedCode: codes.InvalidArgument},
+		{name: "нет_ссылки", ctx: withUser(userUUID), request: &contract.UpdateRequest{ShortUrl: "own-link"}, expectedCode: codes.InvalidArgument},
+		{name: "некорректная_ссылка", ctx: withUser(userUUID), request: &contract.UpdateRequest{ShortUrl: "own-link", Url: "ozon"}, expectedCode: codes.InvalidArgument},
+		{name: "чужая_ссылка", ctx: withUser("5555-6666"), request: &contract.UpdateRequest{ShortUrl: "own-link", Url: "https://ozon.ru/new"}, expectedCode: codes.NotFound},
+		{name: "ссылка_уже_сокращена", ctx: withUser(userUUID), request: &contract.UpdateRequest{ShortUrl: "own-link", Url: "https://avito.ru/taken"}, expectedCode: codes.AlreadyExists},
+		{name: "ок", ctx: withUser(userUUID), request: &contract.UpdateRequest{ShortUrl: "own-link", Url: "https://ozon.ru/new"}, expectedCode: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := client.Update(tt.ctx, tt.request)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, "https://ozon.ru/new", response.GetOriginalUrl())
+			}
+		})
+	}
+}
+
+func TestUserURLsHandler_Restore(t *testing.T) {
+	_ = logger.InitLogger("fatal")
+	userUUID := "1111-2222-3333-444"
+	memoryStorage := storage.NewMemoryStorage()
+	_, err := memoryStorage.ImportURLs(context.Background(), userUUID, []models.URL{
+		{ShortURL: "short1", URL: "https://ozon.ru/1"},
+		{ShortURL: "short2", URL: "https://ozon.ru/2"},
+	})
+	require.NoError(t, err)
+	_ = memoryStorage.SoftDeletedShortURL(context.Background(), userUUID, "short1")
+
+	s := grpc.NewServer()
+	contract.RegisterUserUrlsHandlerServer(s, NewUserURLsHandler(memoryStorage, nil, nil, nil, nil, memoryStorage))
+	conn, err := grpc.NewClient(":///test.server",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(registerServer(s)),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := contract.NewUserUrlsHandlerClient(conn)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.New(map[string]string{mData.UserUUID: userUUID}))
+
+	_, err = client.Restore(context.Background(), &contract.RestoreRequest{ShortUrls: []string{"short1"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Restore(ctx, &contract.RestoreRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	response, err := client.Restore(ctx, &contract.RestoreRequest{ShortUrls: []string{"short1", "short2"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"short1"}, response.GetRestored())
+	assert.Equal(t, []string{"short2"}, response.GetSkipped())
+	_, err = memoryStorage.FindByShortURL(context.Background(), "short1")
+	assert.NoError(t, err)
+}
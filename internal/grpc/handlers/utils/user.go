@@ -2,10 +2,14 @@ package utils
 
 import (
 	"context"
+	"net"
+	"strings"
 
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -25,11 +29,15 @@ func FillUserUUID(ctx context.Context) (string, error) {
 }
 
 // GetUserToken получить токен из запроса.
+// Ключ доступа из метаданных x-api-key возвращается в виде "ApiKey <ключ>".
 func GetUserToken(ctx context.Context) string {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return ""
 	}
+	if apiKeys := md.Get(mData.APIKey); len(apiKeys) > 0 && apiKeys[0] != "" {
+		return auntificator.APIKeyScheme + " " + apiKeys[0]
+	}
 	mdValues := md.Get(mData.Authorization)
 
 	if len(mdValues) == 0 {
@@ -59,3 +67,48 @@ func AppendMData(ctx context.Context, key string, value string) context.Context
 	ctx = metadata.NewIncomingContext(ctx, md)
 	return ctx
 }
+
+// GetMDValue вернёт первое непустое значение из метаданных по списку ключей.
+func GetMDValue(ctx context.Context, keys ...string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, key := range keys {
+		if mdValues := md.Get(key); len(mdValues) > 0 && mdValues[0] != "" {
+			return mdValues[0]
+		}
+	}
+	return ""
+}
+
+// RemoteIP адрес клиента. X-Real-IP и x-forwarded-for учитываются только от прокси из доверенной сети,
+// иначе клиент подменил бы метаданными свой адрес, например чтобы обойти ограничение частоты запросов.
+func RemoteIP(ctx context.Context, trustedProxies *auntificator.CheckTrustedSubnet) string {
+	host := peerIP(ctx)
+	if trustedProxies == nil || trustedProxies.GrantAccess(host) != nil {
+		return host
+	}
+	if ip := GetMDValue(ctx, "X-Real-IP"); ip != "" {
+		return ip
+	}
+	if forwarded := GetMDValue(ctx, "x-forwarded-for"); forwarded != "" {
+		// Последний адрес добавил доверенный прокси, предыдущие мог передать сам клиент
+		addrs := strings.Split(forwarded, ",")
+		return strings.TrimSpace(addrs[len(addrs)-1])
+	}
+	return host
+}
+
+// peerIP адрес соединения.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
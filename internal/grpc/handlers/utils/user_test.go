@@ -2,12 +2,16 @@ package utils
 
 import (
 	"context"
+	"net"
 	"testing"
 
+	"github.com/northmule/shorturl/config"
+	"github.com/northmule/shorturl/internal/app/services/auntificator"
 	mData "github.com/northmule/shorturl/internal/grpc/handlers/metadata"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -58,6 +62,13 @@ func TestGetUserToken_AuthorizationPresent(t *testing.T) {
 	assert.Equal(t, expectedToken, token)
 }
 
+func TestGetUserToken_APIKeyPresent(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(mData.Authorization, "test-token", mData.APIKey, "sk_123"))
+	token := GetUserToken(ctx)
+
+	assert.Equal(t, "ApiKey sk_123", token)
+}
+
 func TestAppendMData_MetadataMissing(t *testing.T) {
 	ctx := context.Background()
 	key := "test-key"
@@ -92,6 +103,32 @@ func TestAppendMData_MetadataPresent_KeyPresent(t *testing.T) {
 	assert.Equal(t, []string{newValue}, md.Get(key))
 }
 
+func TestRemoteIP(t *testing.T) {
+	trustedProxies := auntificator.NewTrustedSubnet(&config.Config{TrustedSubnet: "192.168.1.0/24"})
+	peerCtx := func(ip string, pairs ...string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(pairs...))
+	}
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		trustedProxies *auntificator.CheckTrustedSubnet
+		want           string
+	}{
+		{name: "#1_доверенный_прокси_x_real_ip", ctx: peerCtx("192.168.1.10", "X-Real-IP", "10.0.0.1"), trustedProxies: trustedProxies, want: "10.0.0.1"},
+		{name: "#2_доверенный_прокси_x_forwarded_for", ctx: peerCtx("192.168.1.10", "x-forwarded-for", "1.1.1.1, 10.0.0.1"), trustedProxies: trustedProxies, want: "10.0.0.1"},
+		{name: "#3_прокси_без_метаданных", ctx: peerCtx("192.168.1.10"), trustedProxies: trustedProxies, want: "192.168.1.10"},
+		{name: "#4_клиент_не_из_доверенной_сети", ctx: peerCtx("10.0.0.2", "X-Real-IP", "10.0.0.1", "x-forwarded-for", "10.0.0.1"), trustedProxies: trustedProxies, want: "10.0.0.2"},
+		{name: "#5_без_проверки_прокси", ctx: peerCtx("192.168.1.10", "X-Real-IP", "10.0.0.1"), want: "192.168.1.10"},
+		{name: "#6_без_соединения", ctx: context.Background(), trustedProxies: trustedProxies, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RemoteIP(tt.ctx, tt.trustedProxies))
+		})
+	}
+}
+
 func BenchmarkFillUserUUID(b *testing.B) {
 	md := metadata.Pairs(mData.UserUUID, "test-uuid")
 	ctx := metadata.NewIncomingContext(context.Background(), md)
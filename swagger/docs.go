@@ -39,6 +39,76 @@ const docTemplate = `{
                     },
                     "400": {
                         "description": "Bad Request"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    }
+                }
+            }
+        },
+        "/api/internal/api-keys": {
+            "post": {
+                "summary": "Выпуск ключа доступа",
+                "parameters": [
+                    {
+                        "description": "владелец ключа",
+                        "name": "APIKey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RequestMintAPIKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/internal/api-keys/{id}": {
+            "delete": {
+                "summary": "Отзыв ключа доступа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "id ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
                     }
                 }
             }
@@ -66,6 +136,12 @@ const docTemplate = `{
                     },
                     "400": {
                         "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
                     }
                 }
             }
@@ -80,7 +156,113 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.BatchRequest"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.BatchRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "новых ссылок не создано",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.BatchResponse"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "создана хотя бы одна ссылка",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.BatchResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/shorten/import": {
+            "post": {
+                "description": "Ответ отправляется после получения всего потока, ход задания отражает запись оставшихся пачек.\nЗадания хранятся в памяти процесса: после перезапуска сервиса задание не найдётся.",
+                "consumes": [
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "summary": "Массовый импорт ссылок из NDJSON или CSV",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "формат потока: ndjson или csv, по умолчанию определяется по Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "сохранить коды ссылок из прежнего сервиса",
+                        "name": "preserve_codes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseImportJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/api/shorten/import/{id}": {
+            "get": {
+                "description": "Задания хранятся в памяти процесса сутки после завершения и теряются при перезапуске сервиса.",
+                "summary": "Ход массового импорта ссылок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "идентификатор задания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseImportJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            }
+        },
+        "/api/user/login": {
+            "post": {
+                "summary": "Вход пользователя",
+                "parameters": [
+                    {
+                        "description": "логин и пароль",
+                        "name": "Login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RequestLogin"
                         }
                     }
                 ],
@@ -88,11 +270,50 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.BatchResponse"
+                            "$ref": "#/definitions/handlers.ResponseAccount"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/register": {
+            "post": {
+                "summary": "Регистрация пользователя",
+                "parameters": [
+                    {
+                        "description": "логин и пароль",
+                        "name": "Register",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RequestRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
                     }
                 }
             }
@@ -100,13 +321,66 @@ const docTemplate = `{
         "/api/user/urls": {
             "get": {
                 "summary": "Просмотр коротких ссылок пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "размер страницы, от 1 до 1000, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "созданные не раньше, RFC3339",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "созданные раньше, RFC3339",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "домен оригинальной ссылки",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "включать удалённые ссылки",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "подстрока в оригинальной или короткой ссылке",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "порядок: created_asc или created_desc",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ResponseView"
+                            "$ref": "#/definitions/handlers.ResponseViewPage"
                         }
                     },
+                    "204": {
+                        "description": "No Content"
+                    },
                     "400": {
                         "description": "Bad Request"
                     },
@@ -141,6 +415,157 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/user/urls/export": {
+            "get": {
+                "produces": [
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "summary": "Выгрузка ссылок пользователя в CSV, JSON или NDJSON",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "формат выгрузки: csv, json или ndjson, по умолчанию json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/api/user/urls/restore": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Восстановление удалённых ссылок пользователем",
+                "parameters": [
+                    {
+                        "description": "ссылки для восстановления",
+                        "name": "Restore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseRestore"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/urls/{short}": {
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Смена URL короткой ссылки пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "короткая ссылка",
+                        "name": "short",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "новый URL",
+                        "name": "Update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RequestUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseView"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "410": {
+                        "description": "Gone"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/api/user/urls/{short}/stats": {
+            "get": {
+                "summary": "Статистика переходов по короткой ссылке пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "короткая ссылка",
+                        "name": "short",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResponseURLStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
         "/ping": {
             "get": {
                 "summary": "Проверка подключения к БД",
@@ -170,8 +595,14 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "Not Found"
+                    },
                     "410": {
                         "description": "Gone"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
                     }
                 }
             }
@@ -184,8 +615,16 @@ const docTemplate = `{
                 "correlation_id": {
                     "type": "string"
                 },
+                "expires_at": {
+                    "description": "ExpiresAt время, после которого ссылка перестанет работать (необязательный)",
+                    "type": "string"
+                },
                 "original_url": {
                     "type": "string"
+                },
+                "ttl_seconds": {
+                    "description": "TTLSeconds время жизни ссылки в секундах (необязательный)",
+                    "type": "integer"
                 }
             }
         },
@@ -195,6 +634,14 @@ const docTemplate = `{
                 "correlation_id": {
                     "type": "string"
                 },
+                "error": {
+                    "description": "Error ошибка проверки элемента, короткая ссылка для него не создаётся",
+                    "type": "string"
+                },
+                "existing": {
+                    "description": "Existing ссылка была сокращена ранее",
+                    "type": "boolean"
+                },
                 "short_url": {
                     "type": "string"
                 }
@@ -208,9 +655,182 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.RequestLogin": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RequestMintAPIKey": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "user_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RequestRegister": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.RequestUpdate": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ResponseAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "user_uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ResponseAccount": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ResponseImportError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ResponseImportJob": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error причина остановки импорта",
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors отчёт о пропущенных строках и строках с ошибками",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ResponseImportError"
+                    }
+                },
+                "errors_truncated": {
+                    "description": "ErrorsTruncated в отчёт попали не все строки",
+                    "type": "boolean"
+                },
+                "failed": {
+                    "description": "Failed строки с ошибками",
+                    "type": "integer"
+                },
+                "imported": {
+                    "description": "Imported создано ссылок",
+                    "type": "integer"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "received": {
+                    "description": "Received прочитано строк",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Skipped строки со ссылками, которые были сокращены ранее",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ResponseRestore": {
+            "type": "object",
+            "properties": {
+                "restored": {
+                    "description": "Restored восстановленные ссылки",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "skipped": {
+                    "description": "Skipped чужие, не удалённые, удалённые слишком давно или с URL, который уже занят действующей ссылкой",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.ResponseURLStats": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ClickDay"
+                    }
+                },
+                "short_url": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
         "handlers.ResponseView": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "original_url": {
                     "type": "string"
                 },
@@ -219,11 +839,48 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.ResponseViewPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "urls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ResponseView"
+                    }
+                }
+            }
+        },
         "handlers.ShortenerRequest": {
             "type": "object",
             "properties": {
                 "URL": {
                     "type": "string"
+                },
+                "alias": {
+                    "description": "Alias желаемый код короткой ссылки (необязательный)",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "ExpiresAt время, после которого ссылка перестанет работать (необязательный)",
+                    "type": "string"
+                },
+                "ttl_seconds": {
+                    "description": "TTLSeconds время жизни ссылки в секундах (необязательный)",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ClickDay": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
                 }
             }
         }